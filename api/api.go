@@ -17,5 +17,19 @@ func initializePublicRoutes(rootRouter *mux.Router, context *Context) {
 
 	initHealth(apiRouter, context)
 	initUser(apiRouter, context)
+	initMFA(apiRouter, context)
+	initSession(apiRouter, context)
+	initLockout(apiRouter, context)
+	initEmailChange(apiRouter, context)
+	initUserAdmin(apiRouter, context)
+	initInvitation(apiRouter, context)
+	initNotification(apiRouter, context)
+	initPersonalAccessToken(apiRouter, context)
 	initOAuth(apiRouter, context)
+	initGithubAccess(apiRouter, context)
+	initAPIKey(apiRouter, context)
+	initCycle(apiRouter, context)
+	initRole(apiRouter, context)
+	initAudit(apiRouter, context)
+	initDev(apiRouter, context)
 }
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initAPIKey registers API key endpoints on the given router.
+func initAPIKey(apiRouter *mux.Router, context *Context) {
+	apiKeysRouter := apiRouter.PathPrefix("/apikeys").Subrouter()
+	apiKeysRouter.Handle("", newAPIPermissionRequiredHandler(context, handleCreateAPIKey, model.PermissionAPIKeysManage)).Methods("POST")
+	apiKeysRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetAPIKeys, model.PermissionAPIKeysManage)).Methods("GET")
+	apiKeysRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleRevokeAPIKey, model.PermissionAPIKeysManage)).Methods("DELETE")
+}
+
+// handleCreateAPIKey responds to POST /api/v1/apikeys, creating an API key.
+// The key is only returned in this response.
+func handleCreateAPIKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.CreateAPIKeyRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	resp, err := c.App.CreateAPIKey(c.Session.UserID, request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:  model.AuditActionAPIKeyCreate,
+		Details: model.StringMap{"api_key_id": resp.APIKey.ID, "name": resp.APIKey.Name, "scope": resp.APIKey.Scope},
+	})
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleGetAPIKeys responds to GET /api/v1/apikeys, returning the API keys that
+// were not revoked.
+func handleGetAPIKeys(c *Context, w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := c.App.GetAPIKeys()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(apiKeys)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleRevokeAPIKey responds to DELETE /api/v1/apikeys/{id}, revoking an API
+// key.
+func handleRevokeAPIKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	apiKey, err := c.App.GetAPIKey(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if apiKey == nil || apiKey.IsRevoked() {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = c.App.RevokeAPIKey(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	admin := signUpAdmin(t, adminClient, th.SqlStore)
+
+	t.Run("requires the permission to manage API keys", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetAPIKeys()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		signUp(t, client, th.SqlStore)
+		_, err = client.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeRead})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Scope: model.APIKeyScopeRead})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: "junk"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeRead, ExpiresAt: 1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("create, use and revoke", func(t *testing.T) {
+		resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeRead})
+		require.NoError(t, err)
+		require.NotNil(t, resp.APIKey)
+		assert.Len(t, resp.Key, model.APIKeySize)
+		assert.Equal(t, admin.ID, resp.APIKey.CreatorID)
+		assert.Empty(t, resp.APIKey.KeyHash)
+
+		apiKeys, err := adminClient.GetAPIKeys()
+		require.NoError(t, err)
+		found := false
+		for _, apiKey := range apiKeys {
+			if apiKey.ID == resp.APIKey.ID {
+				found = true
+				assert.Zero(t, apiKey.LastUsedAt)
+			}
+		}
+		assert.True(t, found)
+
+		client := model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+		user, err := client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, admin.ID, user.ID)
+
+		apiKey, err := th.SqlStore.APIKey().GetAPIKey(resp.APIKey.ID)
+		require.NoError(t, err)
+		assert.NotZero(t, apiKey.LastUsedAt)
+		assert.NotEmpty(t, apiKey.LastUsedIP)
+
+		// A read-only key can't change anything.
+		_, err = client.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "other", Scope: model.APIKeyScopeAdmin})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		err = adminClient.RevokeAPIKey(resp.APIKey.ID)
+		require.NoError(t, err)
+
+		err = adminClient.RevokeAPIKey(resp.APIKey.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = client.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "admin", Scope: model.APIKeyScopeAdmin})
+		require.NoError(t, err)
+
+		client := model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+		_, err = client.GetAPIKeys()
+		require.NoError(t, err)
+
+		resp, err = adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ingest", Scope: model.APIKeyScopeIngest})
+		require.NoError(t, err)
+
+		client = model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+		_, err = client.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("expired and unknown keys", func(t *testing.T) {
+		resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeRead, ExpiresAt: model.GetMillis() + 1000*60})
+		require.NoError(t, err)
+
+		client := model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+		_, err = client.GetMe()
+		require.NoError(t, err)
+
+		client = model.NewClientWithAPIKey(th.Server.URL, "junk")
+		_, err = client.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+}
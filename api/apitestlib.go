@@ -2,6 +2,9 @@ package api
 
 import (
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -17,24 +20,36 @@ const (
 )
 
 type ApiTestHelper struct {
-	App      *app.App
-	Router   *mux.Router
-	Server   *httptest.Server
-	SqlStore *store.SqlStore
+	App            *app.App
+	Router         *mux.Router
+	Server         *httptest.Server
+	SqlStore       *store.SqlStore
+	EmailDirectory string
 }
 
 func SetupApiTestHelper(t *testing.T) *ApiTestHelper {
+	return setupApiTestHelperWithConfig(t, nil)
+}
+
+// setupApiTestHelperWithConfig sets up the API with the dev configuration
+// altered by the given function.
+func setupApiTestHelperWithConfig(t *testing.T, configure func(config *app.Config)) *ApiTestHelper {
 	logger := testlib.MakeLogger(t)
 	config := app.NewConfig()
+	config.Email.FileDirectory = t.TempDir()
 	app.SetDevConfig(&config)
 	logger.Debug("Using dev configuration")
+	if configure != nil {
+		configure(&config)
+	}
 
 	sqlStore := store.MakeTestStore(t, logger)
 
-	userService := app.NewUserService(logger, sqlStore)
-	appService := app.NewApp(logger, sqlStore, config, userService)
+	userService := app.NewUserService(logger, sqlStore, config)
+	appService, err := app.NewApp(logger, sqlStore, config, userService)
+	require.NoError(t, err)
 
-	err := appService.ReloadHTMLTemplates()
+	err = appService.ReloadHTMLTemplates()
 	if err != nil {
 		logger.WithError(err).Warn("Unable to load HTML templates")
 	}
@@ -49,10 +64,11 @@ func SetupApiTestHelper(t *testing.T) *ApiTestHelper {
 	ts := httptest.NewServer(router)
 
 	return &ApiTestHelper{
-		App:      appService,
-		Router:   router,
-		Server:   ts,
-		SqlStore: sqlStore,
+		App:            appService,
+		Router:         router,
+		Server:         ts,
+		SqlStore:       sqlStore,
+		EmailDirectory: config.Email.FileDirectory,
 	}
 }
 
@@ -61,6 +77,24 @@ func (th *ApiTestHelper) TearDown(t *testing.T) {
 	store.CloseConnection(t, th.SqlStore)
 }
 
+// getSentEmails returns the raw content of the emails written by the file
+// transport for the given recipient.
+func (th *ApiTestHelper) getSentEmails(t *testing.T, to string) []string {
+	files, err := filepath.Glob(filepath.Join(th.EmailDirectory, "*.eml"))
+	require.NoError(t, err)
+
+	var emails []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		if strings.Contains(string(data), "To: "+to+"\r\n") {
+			emails = append(emails, string(data))
+		}
+	}
+
+	return emails
+}
+
 func signUpWithEmail(t *testing.T, email string, client *model.Client, sqlStore *store.SqlStore) *model.User {
 	resp, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword})
 	require.NoError(t, err)
@@ -76,3 +110,16 @@ func signUpWithEmail(t *testing.T, email string, client *model.Client, sqlStore
 func signUp(t *testing.T, client *model.Client, sqlStore *store.SqlStore) *model.User {
 	return signUpWithEmail(t, testlib.GetTestEmail(), client, sqlStore)
 }
+
+func signUpAdmin(t *testing.T, client *model.Client, sqlStore *store.SqlStore) *model.User {
+	user := signUp(t, client, sqlStore)
+
+	role, err := sqlStore.Role().GetRoleByName(model.AdminRoleName)
+	require.NoError(t, err)
+	require.NotNil(t, role)
+
+	err = sqlStore.Role().AddUserRole(user.ID, role.ID)
+	require.NoError(t, err)
+
+	return user
+}
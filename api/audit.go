@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initAudit registers the audit log endpoints on the given router.
+func initAudit(apiRouter *mux.Router, context *Context) {
+	auditLogsRouter := apiRouter.PathPrefix("/audit-logs").Subrouter()
+	auditLogsRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetAuditLogs, model.PermissionAuditLogRead)).Methods("GET")
+}
+
+// recordAudit appends the given entry to the audit log, filling in the actor,
+// IP address and request ID of the request. The actor of the entry is kept
+// if set, e.g. for logins where the request has no session yet. Failing to
+// record the entry is logged rather than failing the request.
+func recordAudit(c *Context, r *http.Request, entry *model.AuditLog) {
+	if entry.ActorID == "" && entry.ActorType == "" && c.Session != nil {
+		switch {
+		case c.Session.APIKey != nil:
+			entry.ActorID = c.Session.APIKey.ID
+			entry.ActorType = model.AuditActorAPIKey
+		case c.Session.UserID != "":
+			entry.ActorID = c.Session.UserID
+			entry.ActorType = model.AuditActorUser
+		}
+	}
+	if entry.ActorID != "" && entry.ActorType == "" {
+		entry.ActorType = model.AuditActorUser
+	}
+	entry.IPAddress = c.App.GetIPAddress(r)
+	entry.RequestID = c.RequestID
+
+	// Failures are logged by the audit service.
+	c.App.Audit().Record(entry)
+}
+
+// parseAuditLogFilter builds an audit log filter from the actor_id,
+// target_id, action, since, until, page and per_page query parameters.
+func parseAuditLogFilter(r *http.Request) (*model.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := &model.AuditLogFilter{
+		ActorID:  query.Get("actor_id"),
+		TargetID: query.Get("target_id"),
+		Action:   query.Get("action"),
+		PerPage:  model.AuditLogsDefaultPerPage,
+	}
+
+	var err error
+	if since := query.Get("since"); since != "" {
+		filter.Since, err = strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid since")
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		filter.Until, err = strconv.ParseInt(until, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid until")
+		}
+	}
+	if page := query.Get("page"); page != "" {
+		filter.Page, err = strconv.Atoi(page)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid page")
+		}
+	}
+	if perPage := query.Get("per_page"); perPage != "" {
+		filter.PerPage, err = strconv.Atoi(perPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid per page")
+		}
+	}
+
+	return filter, filter.IsValid()
+}
+
+// handleGetAuditLogs responds to GET /api/v1/audit-logs, returning a page of
+// the audit log, the most recent entries first.
+func handleGetAuditLogs(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditLogFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	logs, err := c.App.Audit().GetAuditLogs(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(logs)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
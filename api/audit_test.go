@@ -0,0 +1,182 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/utils"
+)
+
+func TestAuditLogs(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	admin := signUpAdmin(t, adminClient, th.SqlStore)
+
+	// targetLogs returns the audit log entries of the given user, the most
+	// recent first.
+	targetLogs := func(t *testing.T, userID string) []*model.AuditLog {
+		logs, err := adminClient.GetAuditLogs(&model.AuditLogFilter{TargetID: userID, PerPage: model.AuditLogsMaxPerPage})
+		require.NoError(t, err)
+		return logs
+	}
+
+	t.Run("requires the permission", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		_, err := client.GetAuditLogs(&model.AuditLogFilter{PerPage: 10})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := adminClient.GetAuditLogs(&model.AuditLogFilter{PerPage: model.AuditLogsMaxPerPage + 1})
+		require.Error(t, err)
+	})
+
+	t.Run("role changes record who made them", func(t *testing.T) {
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+		role, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+		require.NoError(t, err)
+
+		err = adminClient.AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+		// Granting a role the user already has is not recorded.
+		err = adminClient.AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+		err = adminClient.RemoveUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		logs := targetLogs(t, user.ID)
+		require.Len(t, logs, 2)
+		assert.Equal(t, model.AuditActionRoleRemove, logs[0].Action)
+		assert.Equal(t, model.AuditActionRoleAdd, logs[1].Action)
+		for _, entry := range logs {
+			assert.Equal(t, admin.ID, entry.ActorID)
+			assert.Equal(t, model.AuditActorUser, entry.ActorType)
+			assert.Equal(t, model.AdminRoleName, entry.Details["role"])
+			assert.NotEmpty(t, entry.IPAddress)
+			assert.Len(t, entry.RequestID, 26)
+		}
+	})
+
+	t.Run("logins, password changes and logouts", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		_, err := client.Login(&model.LoginRequest{Email: user.Email, Password: "wrong"})
+		require.Error(t, err)
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: testPassword, NewPassword: testPassword + "2"})
+		require.NoError(t, err)
+		err = client.Logout()
+		require.NoError(t, err)
+
+		logs := targetLogs(t, user.ID)
+		require.Len(t, logs, 4)
+		assert.Equal(t, model.AuditActionLogout, logs[0].Action)
+		assert.Equal(t, model.AuditActionPasswordChange, logs[1].Action)
+		assert.Equal(t, model.AuditActionLogin, logs[2].Action)
+		assert.Equal(t, model.SessionSourcePassword, logs[2].Details["source"])
+		assert.Equal(t, model.AuditActionLoginFailed, logs[3].Action)
+		assert.Equal(t, model.AuditActorAnonymous, logs[3].ActorType)
+		for _, entry := range logs[:3] {
+			assert.Equal(t, user.ID, entry.ActorID)
+		}
+	})
+
+	t.Run("locking users", func(t *testing.T) {
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		err := adminClient.LockUser(user.ID)
+		require.NoError(t, err)
+		err = adminClient.UnlockUser(user.ID)
+		require.NoError(t, err)
+
+		logs := targetLogs(t, user.ID)
+		require.Len(t, logs, 2)
+		assert.Equal(t, model.AuditActionUserUnlock, logs[0].Action)
+		assert.Equal(t, model.AuditActionUserLock, logs[1].Action)
+	})
+
+	t.Run("role permission changes", func(t *testing.T) {
+		role, err := adminClient.CreateRole(&model.CreateRoleRequest{Name: "auditors-" + model.NewID()[:8], Permissions: []string{model.PermissionAuditLogRead}})
+		require.NoError(t, err)
+		_, err = adminClient.UpdateRolePermissions(role.ID, &model.UpdateRolePermissionsRequest{Permissions: []string{model.PermissionAuditLogRead, model.PermissionRolesManage}})
+		require.NoError(t, err)
+
+		logs, err := adminClient.GetAuditLogs(&model.AuditLogFilter{ActorID: admin.ID, Action: model.AuditActionRoleCreate, PerPage: model.AuditLogsMaxPerPage})
+		require.NoError(t, err)
+		require.NotEmpty(t, logs)
+		assert.Equal(t, role.ID, logs[0].Details["role_id"])
+		assert.Equal(t, model.PermissionAuditLogRead, logs[0].Details["permissions"])
+
+		logs, err = adminClient.GetAuditLogs(&model.AuditLogFilter{ActorID: admin.ID, Action: model.AuditActionRolePermissionsUpdate, PerPage: model.AuditLogsMaxPerPage})
+		require.NoError(t, err)
+		require.NotEmpty(t, logs)
+		assert.Equal(t, role.ID, logs[0].Details["role_id"])
+		assert.Equal(t, model.PermissionAuditLogRead, logs[0].Details["previous_permissions"])
+		assert.Equal(t, model.PermissionAuditLogRead+","+model.PermissionRolesManage, logs[0].Details["permissions"])
+	})
+
+	t.Run("resetting 2FA and deleting users", func(t *testing.T) {
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		err := adminClient.ResetUserMFA(user.ID)
+		require.NoError(t, err)
+		err = adminClient.DeleteUser(user.ID)
+		require.NoError(t, err)
+
+		logs := targetLogs(t, user.ID)
+		require.Len(t, logs, 2)
+		assert.Equal(t, model.AuditActionUserDelete, logs[0].Action)
+		assert.Equal(t, user.Email, logs[0].Details["email"])
+		assert.Equal(t, model.AuditActionMFAReset, logs[1].Action)
+	})
+
+	t.Run("filter by actor and action", func(t *testing.T) {
+		resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeIngest})
+		require.NoError(t, err)
+
+		logs, err := adminClient.GetAuditLogs(&model.AuditLogFilter{ActorID: admin.ID, Action: model.AuditActionAPIKeyCreate, PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, resp.APIKey.ID, logs[0].Details["api_key_id"])
+	})
+}
+
+func TestTrustedProxies(t *testing.T) {
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		proxies, err := utils.ParseTrustedProxies([]string{"127.0.0.1", "::1"})
+		require.NoError(t, err)
+		config.TrustedProxies = proxies
+	})
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	signUpAdmin(t, adminClient, th.SqlStore)
+
+	user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+	client := model.NewClientWithHeaders(th.Server.URL, map[string]string{model.HeaderForwardedFor: "198.51.100.7"})
+	_, err := client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+	require.NoError(t, err)
+
+	sessions, err := client.GetMySessions()
+	require.NoError(t, err)
+	var ipAddresses []string
+	for _, session := range sessions {
+		ipAddresses = append(ipAddresses, session.IPAddress)
+	}
+	assert.Contains(t, ipAddresses, "198.51.100.7")
+
+	logs, err := adminClient.GetAuditLogs(&model.AuditLogFilter{TargetID: user.ID, Action: model.AuditActionLogin, PerPage: 10})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "198.51.100.7", logs[0].IPAddress)
+}
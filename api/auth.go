@@ -1,41 +1,110 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"golang.org/x/oauth2"
+	"github.com/sirupsen/logrus"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
 )
 
 // initAuth registers OAuth endpoints on the given router.
 func initOAuth(apiRouter *mux.Router, context *Context) {
-	githubOAuthRouter := apiRouter.PathPrefix("/oauth/github").Subrouter()
-	githubOAuthRouter.Handle("/connect", newAPIHandler(context, handleGithubConnect)).Methods(http.MethodGet)
-	githubOAuthRouter.Handle("/complete", newAPIHandler(context, handleGithubComplete)).Methods(http.MethodPost)
+	apiRouter.Handle("/oauth/providers", newAPIHandler(context, handleGetOAuthProviders)).Methods(http.MethodGet)
+
+	oauthRouter := apiRouter.PathPrefix("/oauth/{provider:[a-z]+}").Subrouter()
+	oauthRouter.Handle("/connect", newAPIHandler(context, handleOAuthConnect)).Methods(http.MethodGet)
+	oauthRouter.Handle("/link", newAPISessionRequiredHandler(context, handleOAuthLink, true)).Methods(http.MethodGet)
+	oauthRouter.Handle("/complete", newAPIHandler(context, handleOAuthComplete).withRateLimit("auth", authRateLimit)).Methods(http.MethodPost)
+
+	meOAuthRouter := apiRouter.PathPrefix("/users/me/oauth/{provider:[a-z]+}").Subrouter()
+	meOAuthRouter.Handle("", newAPISessionRequiredHandler(context, handleGetOAuthAccount, true)).Methods(http.MethodGet)
+	meOAuthRouter.Handle("", newAPISessionRequiredHandler(context, handleUnlinkOAuthAccount, true)).Methods(http.MethodDelete)
 }
 
-// handleGithubConnect connects app to Github
-// Responds to GET /api/v1/oauth/github/connect
-func handleGithubConnect(c *Context, w http.ResponseWriter, r *http.Request) {
-	state, err := c.App.CreateOAuthState()
+// handleGetOAuthProviders responds to GET /api/v1/oauth/providers, returning
+// the names of the OAuth providers users can log in with.
+func handleGetOAuthProviders(c *Context, w http.ResponseWriter, r *http.Request) {
+	b, err := json.Marshal(c.App.GetOAuthProviderNames())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// getOAuthProvider returns the provider of the request, writing a not found
+// error if it isn't enabled.
+func getOAuthProvider(c *Context, w http.ResponseWriter, r *http.Request) app.OAuthProvider {
+	name := mux.Vars(r)["provider"]
+	provider := c.App.GetOAuthProvider(name)
+	if provider == nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.writeAndLogError(w, errors.Errorf("OAuth provider %s is not configured", name))
+		return nil
+	}
+
+	return provider
+}
+
+// handleOAuthConnect responds to GET /api/v1/oauth/{provider}/connect,
+// redirecting to the provider to log in.
+func handleOAuthConnect(c *Context, w http.ResponseWriter, r *http.Request) {
+	redirectToOAuthProvider(c, w, r, "")
+}
+
+// handleOAuthLink responds to GET /api/v1/oauth/{provider}/link, starting the
+// OAuth flow to link an account of the provider to the logged in user.
+func handleOAuthLink(c *Context, w http.ResponseWriter, r *http.Request) {
+	redirectToOAuthProvider(c, w, r, c.Session.UserID)
+}
+
+// redirectToOAuthProvider starts an OAuth flow, linking the account to the
+// given user on completion or signing in with it if the user id is empty.
+func redirectToOAuthProvider(c *Context, w http.ResponseWriter, r *http.Request, userID string) {
+	provider := getOAuthProvider(c, w, r)
+	if provider == nil {
+		return
+	}
+
+	state, err := c.App.CreateOAuthState(provider.Name(), userID)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		c.writeAndLogError(w, errors.New("error creating oauth state"))
 		return
 	}
 
-	conf := c.App.GetOAuthConfig()
-	url := conf.AuthCodeURL(state.Token, oauth2.AccessTypeOffline)
+	url, err := provider.AuthCodeURL(r.Context(), state)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		c.writeAndLogError(w, err)
+		return
+	}
 
 	http.Redirect(w, r, url, http.StatusFound)
 }
 
-// handleGithubComplete completes app to Github
-// Responds to POST /api/v1/oauth/github/complete
-func handleGithubComplete(c *Context, w http.ResponseWriter, r *http.Request) {
+// handleOAuthComplete responds to POST /api/v1/oauth/{provider}/complete,
+// completing the OAuth flow with the code and state the provider redirected
+// to.
+//
+// The user is logged in with the account of the provider, or the account is
+// linked to the logged in user if they started the flow from
+// /oauth/{provider}/link. The roles mapped to the groups of the account are
+// synchronized on completion. Logging in may require a second factor, as with
+// /users/login.
+func handleOAuthComplete(c *Context, w http.ResponseWriter, r *http.Request) {
+	provider := getOAuthProvider(c, w, r)
+	if provider == nil {
+		return
+	}
+
 	code := r.URL.Query().Get("code")
 	if len(code) == 0 {
 		w.WriteHeader(http.StatusBadRequest)
@@ -44,43 +113,160 @@ func handleGithubComplete(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	stateToken := r.URL.Query().Get("state")
-	storedOAuthState, err := c.App.GetOAuthState(stateToken)
-	if err != nil {
+	if len(stateToken) == 0 {
 		w.WriteHeader(http.StatusBadRequest)
-		c.writeAndLogError(w, errors.New("invalid state"))
+		c.writeAndLogError(w, errors.New("missing state"))
 		return
 	}
 
-	if storedOAuthState != nil {
+	storedOAuthState, err := c.App.ConsumeOAuthState(stateToken)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	if storedOAuthState == nil {
 		w.WriteHeader(http.StatusBadRequest)
 		c.writeAndLogError(w, errors.New("state expired or not found"))
 		return
 	}
 
-	ctx := context.Background()
-	conf := c.App.GetOAuthConfig()
+	if storedOAuthState.Provider != provider.Name() {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("state was created for another provider"))
+		return
+	}
 
-	tok, err := conf.Exchange(ctx, code)
+	if storedOAuthState.UserID != "" && storedOAuthState.UserID != c.Session.UserID {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.writeAndLogError(w, errors.New("account linking was started by another user"))
+		return
+	}
+
+	userAuthInfo, verifiedEmails, err := provider.GetAccount(r.Context(), code, storedOAuthState)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	var user *model.User
+	if storedOAuthState.UserID != "" {
+		err = c.App.LinkOAuthAccount(storedOAuthState.UserID, userAuthInfo)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			c.writeAndLogError(w, err)
+			return
+		}
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionOAuthLink,
+			TargetID: storedOAuthState.UserID,
+			Details:  model.StringMap{"provider": provider.Name(), "username": userAuthInfo.Username},
+		})
+
+		user, err = c.App.User().Get(storedOAuthState.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+	} else {
+		allowed, err := c.App.IsOAuthAccountAllowed(userAuthInfo)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			c.writeAndLogErrorWithFields(w, errors.New("account is not a member of an allowed organization or team"), logrus.Fields{"provider": provider.Name(), "username": userAuthInfo.Username})
+			return
+		}
+
+		user, err = c.App.LoginWithOAuthAccount(userAuthInfo, verifiedEmails)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			c.writeAndLogError(w, err)
+			return
+		}
+
+		if user.State != model.UserStateActive {
+			w.WriteHeader(http.StatusLocked)
+			c.writeAndLogErrorWithFields(w, errors.New("Attempt to login to locked account"), logrus.Fields{"email": user.Email})
+			return
+		}
+	}
+
+	err = c.App.SyncOAuthRoles(user.ID, userAuthInfo)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	if storedOAuthState.UserID == "" {
+		loginUser(c, w, r, user, model.SessionSourceOAuth)
+		return
+	}
+
+	user.Sanitize()
+	b, err := json.Marshal(user)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		c.writeAndLogError(w, errors.New("failed to exchange oauth code into token"))
+		c.writeAndLogError(w, err)
 		return
 	}
 
-	githubClient, err := c.App.GithubConnectToken(*tok)
+	w.Write(b)
+}
+
+// handleGetOAuthAccount responds to GET /api/v1/users/me/oauth/{provider},
+// returning the account of the provider linked to the logged in user.
+func handleGetOAuthAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	userAuthInfo, err := c.App.GetUserAuthInfo(c.Session.UserID, mux.Vars(r)["provider"])
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		c.writeAndLogError(w, errors.New("failed to authenticate with Github"))
+		c.writeAndLogError(w, err)
+		return
+	}
+	if userAuthInfo == nil {
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	gitUser, _, err := githubClient.Users.Get(ctx, "")
+	b, err := json.Marshal(userAuthInfo)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		c.writeAndLogError(w, errors.New("failed to get authenticated GitHub user"))
+		c.writeAndLogError(w, err)
 		return
 	}
 
-	b, _ := json.Marshal(gitUser)
 	w.Write(b)
 }
+
+// handleUnlinkOAuthAccount responds to DELETE
+// /api/v1/users/me/oauth/{provider}, unlinking the account of the provider
+// from the logged in user.
+func handleUnlinkOAuthAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	oauthProvider := mux.Vars(r)["provider"]
+	userAuthInfo, err := c.App.GetUserAuthInfo(c.Session.UserID, oauthProvider)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if userAuthInfo == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = c.App.UnlinkOAuthAccount(c.Session.UserID, oauthProvider)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
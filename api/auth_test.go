@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/internal/oidc"
+	"github.com/saturninoabril/dashboard-server/internal/oidc/oidctest"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestOAuthProviders(t *testing.T) {
+	t.Run("no provider", func(t *testing.T) {
+		th := SetupApiTestHelper(t)
+		defer th.TearDown(t)
+
+		client := model.NewClient(th.Server.URL)
+		providers, err := client.GetOAuthProviders()
+		require.NoError(t, err)
+		assert.Empty(t, providers)
+
+		_, err = client.CompleteOAuth(model.OAuthProviderGithub, "code", "state")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("configured providers", func(t *testing.T) {
+		th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+			config.GithubOAuth.ClientID = "client"
+			config.GitlabOAuth.ClientID = "client"
+		})
+		defer th.TearDown(t)
+
+		providers, err := model.NewClient(th.Server.URL).GetOAuthProviders()
+		require.NoError(t, err)
+		assert.Equal(t, []string{model.OAuthProviderGithub, model.OAuthProviderGitlab}, providers)
+	})
+}
+
+func TestGithubOAuth(t *testing.T) {
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.GithubOAuth.ClientID = "client"
+		config.GitlabOAuth.ClientID = "client"
+	})
+	defer th.TearDown(t)
+
+	t.Run("complete requires a code and a valid state", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.CompleteOAuth(model.OAuthProviderGithub, "", "state")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing authorization code")
+
+		_, err = client.CompleteOAuth(model.OAuthProviderGithub, "code", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing state")
+
+		_, err = client.CompleteOAuth(model.OAuthProviderGithub, "code", model.NewID())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "state expired or not found")
+	})
+
+	t.Run("state is consumed and bound to the linking user", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		state, err := th.SqlStore.OAuthState().CreateOAuthState(model.OAuthProviderGithub, user.ID)
+		require.NoError(t, err)
+
+		// The state can't be completed with another provider.
+		_, err = client.CompleteOAuth(model.OAuthProviderGitlab, "code", state.Token)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "created for another provider")
+
+		state, err = th.SqlStore.OAuthState().CreateOAuthState(model.OAuthProviderGithub, user.ID)
+		require.NoError(t, err)
+
+		otherClient := model.NewClient(th.Server.URL)
+		signUp(t, otherClient, th.SqlStore)
+
+		_, err = otherClient.CompleteOAuth(model.OAuthProviderGithub, "code", state.Token)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "started by another user")
+
+		_, err = client.CompleteOAuth(model.OAuthProviderGithub, "code", state.Token)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "state expired or not found")
+	})
+
+	t.Run("get and unlink the GitHub account", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		_, err := client.GetOAuthAccount(model.OAuthProviderGithub)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		err = client.UnlinkOAuthAccount(model.OAuthProviderGithub)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = th.SqlStore.UserAuthInfo().CreateUserAuthInfo(&model.UserAuthInfo{
+			UserID:         user.ID,
+			OAuthProvider:  model.OAuthProviderGithub,
+			ProviderUserID: model.NewID(),
+			Token:          oauth2.Token{AccessToken: "secret"},
+			Username:       "octocat",
+		})
+		require.NoError(t, err)
+
+		account, err := client.GetOAuthAccount(model.OAuthProviderGithub)
+		require.NoError(t, err)
+		assert.Equal(t, "octocat", account.Username)
+		assert.Empty(t, account.Token.AccessToken)
+
+		err = client.UnlinkOAuthAccount(model.OAuthProviderGithub)
+		require.NoError(t, err)
+
+		_, err = client.GetOAuthAccount(model.OAuthProviderGithub)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
+
+func TestOIDC(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		th := SetupApiTestHelper(t)
+		defer th.TearDown(t)
+
+		_, err := model.NewClient(th.Server.URL).CompleteOAuth(model.OAuthProviderOIDC, "code", "state")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	issuer := oidctest.NewIssuer(t, "dashboard", "secret")
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.OIDC = app.OIDC{
+			IssuerURL:    issuer.URL(),
+			ClientID:     issuer.ClientID,
+			ClientSecret: issuer.ClientSecret,
+			GroupRoles:   map[string]string{"dashboard-admins": model.AdminRoleName},
+		}
+	})
+	defer th.TearDown(t)
+
+	// connect returns the URL of the provider the webapp redirects to.
+	connect := func(t *testing.T) string {
+		httpClient := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := httpClient.Get(th.Server.URL + "/api/v1/oauth/oidc/connect")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		return resp.Header.Get("Location")
+	}
+
+	t.Run("sign in creates a user with the roles of their groups", func(t *testing.T) {
+		email := testlib.GetTestEmail()
+		subject := model.NewID()
+		code, state := issuer.Authorize(t, connect(t), map[string]interface{}{
+			"sub":                subject,
+			"email":              email,
+			"email_verified":     true,
+			"name":               "Jane Doe",
+			"preferred_username": "jane",
+			"groups":             []string{"dashboard-admins", "other"},
+		})
+
+		client := model.NewClient(th.Server.URL)
+		user, err := client.CompleteOAuth(model.OAuthProviderOIDC, code, state)
+		require.NoError(t, err)
+		assert.Equal(t, email, user.Email)
+		assert.True(t, user.EmailVerified)
+		assert.Equal(t, "Jane", user.FirstName)
+
+		isAdmin, err := th.SqlStore.Role().UserHasRoleByName(user.ID, model.AdminRoleName)
+		require.NoError(t, err)
+		assert.True(t, isAdmin)
+
+		account, err := client.GetOAuthAccount(model.OAuthProviderOIDC)
+		require.NoError(t, err)
+		assert.Equal(t, subject, account.ProviderUserID)
+		assert.Equal(t, "jane", account.Username)
+
+		// The role is removed once the user leaves the group.
+		code, state = issuer.Authorize(t, connect(t), map[string]interface{}{
+			"sub":    subject,
+			"groups": []string{"other"},
+		})
+		user2, err := model.NewClient(th.Server.URL).CompleteOAuth(model.OAuthProviderOIDC, code, state)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, user2.ID)
+
+		isAdmin, err = th.SqlStore.Role().UserHasRoleByName(user.ID, model.AdminRoleName)
+		require.NoError(t, err)
+		assert.False(t, isAdmin)
+
+		err = client.UnlinkOAuthAccount(model.OAuthProviderOIDC)
+		require.NoError(t, err)
+
+		_, err = client.GetOAuthAccount(model.OAuthProviderOIDC)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("unverified email is not used", func(t *testing.T) {
+		code, state := issuer.Authorize(t, connect(t), map[string]interface{}{
+			"sub":            model.NewID(),
+			"email":          testlib.GetTestEmail(),
+			"email_verified": false,
+		})
+
+		_, err := model.NewClient(th.Server.URL).CompleteOAuth(model.OAuthProviderOIDC, code, state)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no verified email")
+	})
+
+	t.Run("link to the logged in user", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		oauthState, err := th.App.CreateOAuthState(model.OAuthProviderOIDC, user.ID)
+		require.NoError(t, err)
+		authURL, err := th.App.GetOAuthProvider(model.OAuthProviderOIDC).AuthCodeURL(context.Background(), oauthState)
+		require.NoError(t, err)
+
+		subject := model.NewID()
+		code, state := issuer.Authorize(t, authURL, map[string]interface{}{"sub": subject})
+
+		linked, err := client.CompleteOAuth(model.OAuthProviderOIDC, code, state)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, linked.ID)
+
+		account, err := client.GetOAuthAccount(model.OAuthProviderOIDC)
+		require.NoError(t, err)
+		assert.Equal(t, subject, account.ProviderUserID)
+	})
+
+	t.Run("code can't be exchanged with the state of another flow", func(t *testing.T) {
+		code, _ := issuer.Authorize(t, connect(t), map[string]interface{}{"sub": model.NewID()})
+
+		otherURL, err := url.Parse(connect(t))
+		require.NoError(t, err)
+
+		_, err = model.NewClient(th.Server.URL).CompleteOAuth(model.OAuthProviderOIDC, code, otherURL.Query().Get("state"))
+		require.Error(t, err)
+	})
+}
+
+func TestGitlabOAuth(t *testing.T) {
+	var codeChallenge string
+	gitlabEmail := testlib.GetTestEmail()
+
+	// gitlab fakes the endpoints of a GitLab instance used to log in.
+	gitlab := http.NewServeMux()
+	gitlab.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "code" || oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "gitlab-token", "token_type": "Bearer", "expires_in": 7200}`))
+	})
+	gitlab.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer gitlab-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id": 42, "username": "jdoe", "name": "Jane Doe", "email": "` + gitlabEmail + `", "confirmed_at": "2021-01-01T00:00:00Z"}`))
+	})
+	gitlab.HandleFunc("/api/v4/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 1, "email": "unconfirmed@example.com", "confirmed_at": null}]`))
+	})
+	gitlabServer := httptest.NewServer(gitlab)
+	defer gitlabServer.Close()
+
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.GitlabOAuth = app.GitlabOAuth{
+			BaseURL:      gitlabServer.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+		}
+	})
+	defer th.TearDown(t)
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := httpClient.Get(th.Server.URL + "/api/v1/oauth/gitlab/connect")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, gitlabServer.URL+"/oauth/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+	codeChallenge = authURL.Query().Get("code_challenge")
+
+	client := model.NewClient(th.Server.URL)
+	user, err := client.CompleteOAuth(model.OAuthProviderGitlab, "code", authURL.Query().Get("state"))
+	require.NoError(t, err)
+	assert.Equal(t, gitlabEmail, user.Email)
+	assert.True(t, user.EmailVerified)
+
+	account, err := client.GetOAuthAccount(model.OAuthProviderGitlab)
+	require.NoError(t, err)
+	assert.Equal(t, "42", account.ProviderUserID)
+	assert.Equal(t, "jdoe", account.Username)
+}
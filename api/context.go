@@ -40,3 +40,12 @@ func (c *Context) writeAndLogErrorWithFields(w http.ResponseWriter, err error, l
 func (c *Context) writeAndLogError(w http.ResponseWriter, err error) {
 	c.writeAndLogErrorWithFields(w, err, nil)
 }
+
+// writeAndLogInvalidPasswordError writes the error along with the reasons the
+// password was rejected.
+func (c *Context) writeAndLogInvalidPasswordError(w http.ResponseWriter, err *model.InvalidPasswordError) {
+	c.Logger.Error(err.Error())
+
+	b, _ := json.Marshal(&model.APIError{Message: err.Error(), Reasons: err.Reasons})
+	w.Write(b)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initCycle registers the cycle endpoints on the given router. CI runners
+// report cycles with API keys of the ingest scope.
+func initCycle(apiRouter *mux.Router, context *Context) {
+	cyclesRouter := apiRouter.PathPrefix("/cycles").Subrouter()
+	cyclesRouter.Handle("", newAPIIngestHandler(context, handleCreateCycle, model.PermissionCyclesWrite)).Methods("POST")
+	cyclesRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetCycles, model.PermissionCyclesRead)).Methods("GET")
+	cyclesRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleGetCycle, model.PermissionCyclesRead)).Methods("GET")
+}
+
+// handleCreateCycle responds to POST /api/v1/cycles, saving the results of a
+// test cycle.
+func handleCreateCycle(c *Context, w http.ResponseWriter, r *http.Request) {
+	cycle, err := model.CycleFromReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	cycle, err = c.App.CreateCycle(c.Session.UserID, cycle)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(cycle)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// parseCycleFilter builds a cycle filter from the repo, branch, page and
+// per_page query parameters.
+func parseCycleFilter(r *http.Request) (*model.CycleFilter, error) {
+	query := r.URL.Query()
+	filter := &model.CycleFilter{
+		Repo:    query.Get("repo"),
+		Branch:  query.Get("branch"),
+		PerPage: model.CyclesDefaultPerPage,
+	}
+
+	var err error
+	if page := query.Get("page"); page != "" {
+		filter.Page, err = strconv.Atoi(page)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid page")
+		}
+	}
+	if perPage := query.Get("per_page"); perPage != "" {
+		filter.PerPage, err = strconv.Atoi(perPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid per page")
+		}
+	}
+
+	return filter, filter.IsValid()
+}
+
+// handleGetCycles responds to GET /api/v1/cycles, returning a page of the
+// cycles of the repos the user can see, the most recent first.
+func handleGetCycles(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCycleFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	cycles, err := c.App.GetCycles(c.Session.UserID, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(cycles)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleGetCycle responds to GET /api/v1/cycles/{id}, returning a cycle if
+// the user can see its repo.
+func handleGetCycle(c *Context, w http.ResponseWriter, r *http.Request) {
+	cycle, err := c.App.GetCycle(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if cycle == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	canAccess, err := c.App.UserCanAccessRepo(c.Session.UserID, cycle.Repo)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if !canAccess {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	b, err := json.Marshal(cycle)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
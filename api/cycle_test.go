@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestCycles(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	admin := signUpAdmin(t, adminClient, th.SqlStore)
+
+	resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeIngest})
+	require.NoError(t, err)
+	ingestClient := model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+
+	repo := "webapp-" + model.NewID()
+
+	t.Run("ingest keys report cycles", func(t *testing.T) {
+		cycle, err := ingestClient.CreateCycle(&model.Cycle{Repo: repo, Branch: "master", Build: "42", Passed: 10, Failed: 2})
+		require.NoError(t, err)
+		assert.Equal(t, admin.ID, cycle.CreatorID)
+		assert.Equal(t, 2, cycle.Failed)
+
+		_, err = ingestClient.CreateCycle(&model.Cycle{Repo: "a/b", Branch: "master"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("ingest keys can't read", func(t *testing.T) {
+		_, err := ingestClient.GetCycles(&model.CycleFilter{Repo: repo, PerPage: 10})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		_, err = ingestClient.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("users read cycles", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		cycles, err := client.GetCycles(&model.CycleFilter{Repo: repo, PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, cycles, 1)
+		assert.Equal(t, "42", cycles[0].Build)
+
+		cycle, err := client.GetCycle(cycles[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, cycles[0], cycle)
+
+		_, err = client.GetCycle(model.NewID())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = client.GetCycles(&model.CycleFilter{PerPage: model.CyclesMaxPerPage + 1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = client.CreateCycle(&model.Cycle{Repo: repo, Branch: "master"})
+		require.Error(t, err, "users can't write cycles by default")
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("read keys can't report cycles", func(t *testing.T) {
+		resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "reader", Scope: model.APIKeyScopeRead})
+		require.NoError(t, err)
+		client := model.NewClientWithAPIKey(th.Server.URL, resp.Key)
+
+		_, err = client.CreateCycle(&model.Cycle{Repo: repo, Branch: "master"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		cycles, err := client.GetCycles(&model.CycleFilter{Repo: repo, PerPage: 10})
+		require.NoError(t, err)
+		assert.Len(t, cycles, 1)
+	})
+}
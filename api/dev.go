@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initDev registers developer endpoints on the given router. The endpoints are
+// only registered when the server runs in dev mode.
+func initDev(apiRouter *mux.Router, context *Context) {
+	if !context.App.Config().Dev {
+		return
+	}
+
+	devRouter := apiRouter.PathPrefix("/dev").Subrouter()
+	devRouter.Handle("/emails", newAPIHandler(context, handleGetDevEmails)).Methods(http.MethodGet)
+}
+
+// handleGetDevEmails responds to GET /api/v1/dev/emails, returning the latest
+// email captured for every recipient. Use the "to" query parameter to filter
+// by recipient.
+func handleGetDevEmails(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.App.Config().Dev {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	emails, err := c.App.GetDevEmails()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	to := strings.ToLower(r.URL.Query().Get("to"))
+	filtered := []*model.DevEmail{}
+	for _, email := range emails {
+		if to == "" || strings.ToLower(email.To) == to {
+			filtered = append(filtered, email)
+		}
+	}
+
+	b, err := json.Marshal(filtered)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
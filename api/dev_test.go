@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevEmails(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("complete email verification with captured token", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		email := testlib.GetTestEmail()
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword})
+		require.NoError(t, err)
+
+		err = client.VerifyEmailStart()
+		require.NoError(t, err)
+
+		emails, err := client.GetDevEmails(email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		assert.Equal(t, email, emails[0].To)
+
+		token := emails[0].Tokens[model.TokenTypeVerifyEmail]
+		require.Len(t, token, model.TokenSizeDigits)
+
+		err = client.VerifyEmailComplete(&model.VerifyEmailRequest{Token: token})
+		require.NoError(t, err)
+
+		user, err := client.GetMe()
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+	})
+
+	t.Run("reset password with captured token", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		err := client.ForgotPassword(&model.ForgotPasswordRequest{Email: user.Email})
+		require.NoError(t, err)
+
+		emails, err := client.GetDevEmails(user.Email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+
+		token := emails[0].Tokens[model.TokenTypeResetPassword]
+		require.Len(t, token, model.TokenSize)
+
+		newPassword := "Test1234"
+		err = client.ResetPassword(&model.ResetPasswordRequest{Password: newPassword, Token: token})
+		require.NoError(t, err)
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: newPassword})
+		require.NoError(t, err)
+	})
+
+	t.Run("not available outside dev mode", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		config := app.NewConfig()
+		config.Email.Transport = email.TransportLog
+		userService := app.NewUserService(logger, th.SqlStore, config)
+		devApp, err := app.NewApp(logger, th.SqlStore, config, userService)
+		require.NoError(t, err)
+		router := mux.NewRouter()
+		Register(router, &Context{
+			App:    devApp,
+			Logger: logger,
+		})
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		client := model.NewClient(ts.URL)
+		_, err = client.GetDevEmails("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
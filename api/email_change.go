@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initEmailChange registers the endpoints confirming and cancelling email
+// changes on the given router. Changes are requested with PUT
+// /api/v1/users/me.
+func initEmailChange(apiRouter *mux.Router, context *Context) {
+	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
+	usersRouter.Handle("/confirm-email-change", newAPIHandler(context, handleConfirmEmailChange).withRateLimit("auth", authRateLimit)).Methods("POST")
+	usersRouter.Handle("/cancel-email-change", newAPIHandler(context, handleCancelEmailChange).withRateLimit("auth", authRateLimit)).Methods("POST")
+}
+
+// handleConfirmEmailChange responds to POST
+// /api/v1/users/confirm-email-change, replacing the email of the user with
+// the pending one the token was sent to.
+func handleConfirmEmailChange(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.ConfirmEmailChangeRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	user, previousEmail, err := c.App.ConfirmEmailChange(request.Token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionEmailChange,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Details:  model.StringMap{"from": previousEmail, "to": user.Email},
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleCancelEmailChange responds to POST /api/v1/users/cancel-email-change,
+// cancelling the pending email change the previous email was notified of.
+func handleCancelEmailChange(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.CancelEmailChangeRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	user, cancelledEmail, err := c.App.CancelEmailChange(request.Token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionEmailChangeCancel,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Details:  model.StringMap{"email": user.Email, "cancelled": cancelledEmail},
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+)
+
+// getEmailChangeTokens returns the token confirming the latest email change
+// requested to the given new email, and the token cancelling it sent to the
+// given current email.
+func getEmailChangeTokens(t *testing.T, client *model.Client, currentEmail, newEmail string) (string, string) {
+	emails, err := client.GetDevEmails(newEmail)
+	require.NoError(t, err)
+	require.NotEmpty(t, emails)
+	confirmEmail := emails[len(emails)-1]
+	assert.Equal(t, "Confirm Your New Email", confirmEmail.Subject)
+
+	emails, err = client.GetDevEmails(currentEmail)
+	require.NoError(t, err)
+	require.NotEmpty(t, emails)
+	cancelEmail := emails[len(emails)-1]
+	assert.Equal(t, "Email Change Requested", cancelEmail.Subject)
+
+	confirmToken := confirmEmail.Tokens[model.TokenTypeConfirmEmailChange]
+	require.NotEmpty(t, confirmToken)
+	cancelToken := cancelEmail.Tokens[model.TokenTypeCancelEmailChange]
+	require.NotEmpty(t, cancelToken)
+
+	return confirmToken, cancelToken
+}
+
+func TestEmailChange(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("invalid requests", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		other := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		user.Email = other.Email
+		_, err := client.UpdateMe(user)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: "junk"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		err = client.CancelEmailChange(&model.CancelEmailChangeRequest{Token: "junk"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		currentEmail := user.Email
+		newEmail := testlib.GetTestEmail()
+
+		user.Email = newEmail
+		user, err := client.UpdateMe(user)
+		require.NoError(t, err)
+		assert.Equal(t, currentEmail, user.Email)
+		assert.Equal(t, newEmail, user.PendingEmail)
+
+		me, err := client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, newEmail, me.PendingEmail)
+
+		confirmToken, cancelToken := getEmailChangeTokens(t, client, currentEmail, newEmail)
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: cancelToken})
+		require.Error(t, err, "the cancel token can't confirm the change")
+		assert.Contains(t, err.Error(), "400")
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: confirmToken})
+		require.NoError(t, err)
+
+		me, err = client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, newEmail, me.Email)
+		assert.Empty(t, me.PendingEmail)
+		assert.True(t, me.EmailVerified)
+
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: user.ID, Action: model.AuditActionEmailChange, PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, currentEmail, logs[0].Details["from"])
+		assert.Equal(t, newEmail, logs[0].Details["to"])
+
+		err = client.CancelEmailChange(&model.CancelEmailChangeRequest{Token: cancelToken})
+		require.Error(t, err, "the change was confirmed")
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		currentEmail := user.Email
+		newEmail := testlib.GetTestEmail()
+
+		user.Email = newEmail
+		_, err := client.UpdateMe(user)
+		require.NoError(t, err)
+		confirmToken, cancelToken := getEmailChangeTokens(t, client, currentEmail, newEmail)
+
+		err = client.CancelEmailChange(&model.CancelEmailChangeRequest{Token: cancelToken})
+		require.NoError(t, err)
+
+		me, err := client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, currentEmail, me.Email)
+		assert.Empty(t, me.PendingEmail)
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: confirmToken})
+		require.Error(t, err, "the change was cancelled")
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("new requests replace previous ones", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		currentEmail := user.Email
+
+		firstEmail := testlib.GetTestEmail()
+		user.Email = firstEmail
+		_, err := client.UpdateMe(user)
+		require.NoError(t, err)
+		firstToken, _ := getEmailChangeTokens(t, client, currentEmail, firstEmail)
+
+		secondEmail := testlib.GetTestEmail()
+		user.Email = secondEmail
+		user, err = client.UpdateMe(user)
+		require.NoError(t, err)
+		assert.Equal(t, secondEmail, user.PendingEmail)
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: firstToken})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("the new email was taken since", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		currentEmail := user.Email
+		newEmail := testlib.GetTestEmail()
+
+		user.Email = newEmail
+		_, err := client.UpdateMe(user)
+		require.NoError(t, err)
+		confirmToken, _ := getEmailChangeTokens(t, client, currentEmail, newEmail)
+
+		signUpWithEmail(t, newEmail, model.NewClient(th.Server.URL), th.SqlStore)
+
+		err = client.ConfirmEmailChange(&model.ConfirmEmailChangeRequest{Token: confirmToken})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		me, err := client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, currentEmail, me.Email)
+		assert.Empty(t, me.PendingEmail)
+	})
+}
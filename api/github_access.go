@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initGithubAccess registers GitHub access endpoints on the given router.
+func initGithubAccess(apiRouter *mux.Router, context *Context) {
+	rulesRouter := apiRouter.PathPrefix("/github/access-rules").Subrouter()
+	rulesRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetGithubAccessRules, model.PermissionSettingsManage)).Methods("GET")
+	rulesRouter.Handle("", newAPIPermissionRequiredHandler(context, handleCreateGithubAccessRule, model.PermissionSettingsManage)).Methods("POST")
+	rulesRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleUpdateGithubAccessRule, model.PermissionSettingsManage)).Methods("PUT")
+	rulesRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleDeleteGithubAccessRule, model.PermissionSettingsManage)).Methods("DELETE")
+
+	apiRouter.Handle("/users/me/oauth/github/membership", newAPISessionRequiredHandler(context, handleGetGithubMembership, true)).Methods("GET")
+}
+
+// handleGetGithubAccessRules responds to GET /api/v1/github/access-rules,
+// returning every GitHub access rule.
+func handleGetGithubAccessRules(c *Context, w http.ResponseWriter, r *http.Request) {
+	rules, err := c.App.GetGithubAccessRules()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleCreateGithubAccessRule responds to POST /api/v1/github/access-rules,
+// creating a GitHub access rule.
+func handleCreateGithubAccessRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	rule, err := model.GithubAccessRuleFromReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	rule, err = c.App.CreateGithubAccessRule(rule)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(rule)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleUpdateGithubAccessRule responds to PUT
+// /api/v1/github/access-rules/{id}, replacing the organization, team, role
+// and repos of a GitHub access rule.
+func handleUpdateGithubAccessRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	rule, err := c.App.GetGithubAccessRule(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if rule == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	update, err := model.GithubAccessRuleFromReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	rule.Org = update.Org
+	rule.Team = update.Team
+	rule.RoleID = update.RoleID
+	rule.Repos = update.Repos
+
+	err = c.App.UpdateGithubAccessRule(rule)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(rule)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleDeleteGithubAccessRule responds to DELETE
+// /api/v1/github/access-rules/{id}, deleting a GitHub access rule.
+func handleDeleteGithubAccessRule(c *Context, w http.ResponseWriter, r *http.Request) {
+	rule, err := c.App.GetGithubAccessRule(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if rule == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = c.App.DeleteGithubAccessRule(rule.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleGetGithubMembership responds to GET
+// /api/v1/users/me/oauth/github/membership, returning the GitHub access of the
+// logged in user as of their last login or sync.
+func handleGetGithubMembership(c *Context, w http.ResponseWriter, r *http.Request) {
+	membership, err := c.App.GetGithubMembership(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if membership == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	b, err := json.Marshal(membership)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+)
+
+// fakeGithub fakes the endpoints of GitHub used to log in, returning the
+// current user with the organizations and teams set on it.
+type fakeGithub struct {
+	*httptest.Server
+	userID int64
+	email  string
+	orgs   []string
+	teams  []string
+}
+
+func newFakeGithub(t *testing.T) *fakeGithub {
+	g := &fakeGithub{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "github-token", "token_type": "bearer"}`))
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer github-token", r.Header.Get("Authorization"))
+		fmt.Fprintf(w, `{"id": %d, "login": "octocat%d"}`, g.userID, g.userID)
+	})
+	mux.HandleFunc("/api/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"email": %q, "primary": true, "verified": true}]`, g.email)
+	})
+	mux.HandleFunc("/api/user/memberships/orgs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "active", r.URL.Query().Get("state"))
+		w.Write([]byte("["))
+		for i, org := range g.orgs {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"state": "active", "organization": {"login": %q}}`, org)
+		}
+		w.Write([]byte("]"))
+	})
+	mux.HandleFunc("/api/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("["))
+		for i, team := range g.teams {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			org, slug := team, team
+			for j := range team {
+				if team[j] == '/' {
+					org, slug = team[:j], team[j+1:]
+					break
+				}
+			}
+			fmt.Fprintf(w, `{"slug": %q, "organization": {"login": %q}}`, slug, org)
+		}
+		w.Write([]byte("]"))
+	})
+	g.Server = httptest.NewServer(mux)
+
+	return g
+}
+
+// signIn signs in as a new GitHub user belonging to the given organizations
+// and teams.
+func (g *fakeGithub) signIn(t *testing.T, serverURL string, orgs, teams []string) (*model.Client, *model.User, error) {
+	g.userID++
+	g.email = testlib.GetTestEmail()
+	g.orgs = orgs
+	g.teams = teams
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := httpClient.Get(serverURL + "/api/v1/oauth/github/connect")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	client := model.NewClient(serverURL)
+	user, err := client.CompleteOAuth(model.OAuthProviderGithub, "code", authURL.Query().Get("state"))
+
+	return client, user, err
+}
+
+func TestGithubAccessRules(t *testing.T) {
+	github := newFakeGithub(t)
+	defer github.Close()
+
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.GithubOAuth.BaseURL = github.URL
+		config.GithubOAuth.APIURL = github.URL + "/api"
+		config.GithubOAuth.ClientID = "client"
+		config.GithubOAuth.ClientSecret = "secret"
+	})
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	signUpAdmin(t, adminClient, th.SqlStore)
+
+	adminRole, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+	require.NoError(t, err)
+
+	t.Run("anyone can sign in without rules", func(t *testing.T) {
+		client, user, err := github.signIn(t, th.Server.URL, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, github.email, user.Email)
+
+		membership, err := client.GetGithubMembership()
+		require.NoError(t, err)
+		assert.True(t, membership.Allowed)
+		assert.Empty(t, membership.Groups)
+	})
+
+	t.Run("only admins manage rules", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		_, err := client.GetGithubAccessRules()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		_, err = client.CreateGithubAccessRule(&model.GithubAccessRule{Org: "acme"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		_, err := adminClient.CreateGithubAccessRule(&model.GithubAccessRule{Org: "-acme"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid organization")
+
+		_, err = adminClient.CreateGithubAccessRule(&model.GithubAccessRule{Org: "acme", RoleID: model.NewID()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "role not found")
+
+		_, err = adminClient.CreateGithubAccessRule(&model.GithubAccessRule{Org: "acme", Repos: []string{"a/b"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid repo")
+	})
+
+	teamRule, err := adminClient.CreateGithubAccessRule(&model.GithubAccessRule{Org: "Acme", Team: "core", RoleID: adminRole.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", teamRule.Org)
+
+	orgRule, err := adminClient.CreateGithubAccessRule(&model.GithubAccessRule{Org: "acme", Repos: []string{"webapp"}})
+	require.NoError(t, err)
+
+	rules, err := adminClient.GetGithubAccessRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, teamRule.ID, rules[0].ID)
+
+	t.Run("members of other organizations can't sign in", func(t *testing.T) {
+		_, _, err := github.signIn(t, th.Server.URL, []string{"other"}, []string{"other/core"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		user, err := th.SqlStore.User().GetUserByEmail(github.email)
+		require.NoError(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("organization members see the repos of their rules", func(t *testing.T) {
+		client, user, err := github.signIn(t, th.Server.URL, []string{"acme"}, nil)
+		require.NoError(t, err)
+
+		membership, err := client.GetGithubMembership()
+		require.NoError(t, err)
+		assert.True(t, membership.Allowed)
+		assert.Equal(t, model.StringList{"acme"}, membership.Groups)
+		assert.Equal(t, model.StringList{"webapp"}, membership.Repos)
+
+		canAccess, err := th.App.UserCanAccessRepo(user.ID, "webapp")
+		require.NoError(t, err)
+		assert.True(t, canAccess)
+		canAccess, err = th.App.UserCanAccessRepo(user.ID, "server")
+		require.NoError(t, err)
+		assert.False(t, canAccess)
+
+		hasRole, err := th.SqlStore.Role().UserHasRole(user.ID, adminRole.ID)
+		require.NoError(t, err)
+		assert.False(t, hasRole)
+	})
+
+	t.Run("team members get the role of their rule", func(t *testing.T) {
+		client, user, err := github.signIn(t, th.Server.URL, []string{"acme"}, []string{"Acme/core"})
+		require.NoError(t, err)
+
+		membership, err := client.GetGithubMembership()
+		require.NoError(t, err)
+		assert.Equal(t, model.StringList{"acme", "acme/core"}, membership.Groups)
+
+		hasRole, err := th.SqlStore.Role().UserHasRole(user.ID, adminRole.ID)
+		require.NoError(t, err)
+		assert.True(t, hasRole)
+
+		t.Run("and only see the cycles of the repos of their rules", func(t *testing.T) {
+			webappCycle, err := th.App.CreateCycle(user.ID, &model.Cycle{Repo: "webapp", Branch: "master"})
+			require.NoError(t, err)
+			serverCycle, err := th.App.CreateCycle(user.ID, &model.Cycle{Repo: "server", Branch: "master"})
+			require.NoError(t, err)
+
+			cycle, err := client.GetCycle(webappCycle.ID)
+			require.NoError(t, err)
+			assert.Equal(t, webappCycle.ID, cycle.ID)
+
+			_, err = client.GetCycle(serverCycle.ID)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "403")
+
+			cycles, err := client.GetCycles(&model.CycleFilter{Repo: "server", PerPage: model.CyclesMaxPerPage})
+			require.NoError(t, err)
+			assert.Empty(t, cycles)
+
+			cycles, err = client.GetCycles(&model.CycleFilter{PerPage: model.CyclesMaxPerPage})
+			require.NoError(t, err)
+			require.NotEmpty(t, cycles)
+			for _, cycle := range cycles {
+				assert.Equal(t, "webapp", cycle.Repo)
+			}
+		})
+
+		t.Run("and lose it once they leave the team", func(t *testing.T) {
+			github.teams = nil
+			err := th.App.SyncGithubMemberships(context.Background())
+			require.NoError(t, err)
+
+			hasRole, err := th.SqlStore.Role().UserHasRole(user.ID, adminRole.ID)
+			require.NoError(t, err)
+			assert.False(t, hasRole)
+
+			membership, err := client.GetGithubMembership()
+			require.NoError(t, err)
+			assert.True(t, membership.Allowed)
+		})
+
+		t.Run("and are logged out once they leave the organization", func(t *testing.T) {
+			github.orgs = nil
+			err := th.App.SyncGithubMemberships(context.Background())
+			require.NoError(t, err)
+
+			_, err = client.GetGithubMembership()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "401")
+		})
+
+		t.Run("and can't sign in with a password either", func(t *testing.T) {
+			password := "Test1234"
+			err := th.App.UpdatePassword(user, password)
+			require.NoError(t, err)
+
+			_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: password})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "403")
+
+			err = client.ForgotPassword(&model.ForgotPasswordRequest{Email: user.Email})
+			require.NoError(t, err)
+			emails, err := client.GetDevEmails(user.Email)
+			require.NoError(t, err)
+			require.NotEmpty(t, emails)
+			token := emails[len(emails)-1].Tokens[model.TokenTypeResetPassword]
+			require.NotEmpty(t, token)
+
+			err = client.ResetPassword(&model.ResetPasswordRequest{Password: "Test12345", Token: token})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "403")
+		})
+	})
+
+	t.Run("update and delete rules", func(t *testing.T) {
+		orgRule.Repos = []string{"webapp", "server"}
+		updated, err := adminClient.UpdateGithubAccessRule(orgRule)
+		require.NoError(t, err)
+		assert.Equal(t, model.StringList{"webapp", "server"}, updated.Repos)
+
+		err = adminClient.DeleteGithubAccessRule(teamRule.ID)
+		require.NoError(t, err)
+		err = adminClient.DeleteGithubAccessRule(teamRule.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		rules, err := adminClient.GetGithubAccessRules()
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, orgRule.ID, rules[0].ID)
+	})
+}
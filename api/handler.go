@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
 	"github.com/saturninoabril/dashboard-server/internal/web"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/utils"
@@ -22,8 +23,14 @@ type contextHandler struct {
 	handler              contextHandlerFunc
 	requiresSession      bool
 	requiresVerification bool
-	requiresAdmin        bool
+	requiredPermission   string
+	allowsIngestAPIKey   bool
 	isStatic             bool
+	// rateLimitName and rateLimit are the budget of the route, the default
+	// one if unset.
+	rateLimitName string
+	rateLimit     ratelimit.Limit
+	skipRateLimit bool
 }
 
 func (h contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -44,15 +51,27 @@ func (h contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		context.Logger.WithFields(responseLogFields).Debug("Received HTTP request")
 	}()
 
-	h.setDefaultHeaders(w, r)
+	h.setDefaultHeaders(w, r, context.RequestID)
 
 	session, err := h.parseTokenAndGetSession(w, r)
+
+	// Requests with an invalid session are limited by their IP address.
+	if !h.checkRateLimit(context, w, r, session) {
+		return
+	}
+
 	if err != nil {
 		context.Logger.WithError(err).Warn("invalid session")
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
 
+	if session != nil && !h.isAllowedByScope(session, r) {
+		context.Logger.WithField("session_id", session.ID).Warn("token scope does not allow the request")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
 	if h.requiresSession && !h.isValidSession(session) {
 		utils.DeleteSessionCookies(w, r)
 		w.WriteHeader(http.StatusUnauthorized)
@@ -65,9 +84,9 @@ func (h contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requiresAdmin && !h.isUserAdmin(session) {
-		context.Logger.WithField("user_id", session.UserID).Warn("user is is not admin")
-		w.WriteHeader(http.StatusUnauthorized)
+	if h.requiredPermission != "" && !h.hasPermission(session, h.requiredPermission) {
+		context.Logger.WithField("user_id", session.UserID).WithField("permission", h.requiredPermission).Warn("user does not have the required permission")
+		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
@@ -88,6 +107,22 @@ func (h contextHandler) isValidSession(session *model.Session) bool {
 	return true
 }
 
+// isAllowedByScope returns true if the scope of the API key or personal access
+// token the session was created from permits the request.
+func (h contextHandler) isAllowedByScope(session *model.Session, r *http.Request) bool {
+	if session.APIKey != nil {
+		if h.allowsIngestAPIKey && session.APIKey.Scope == model.APIKeyScopeIngest {
+			return true
+		}
+		return session.APIKey.AllowsMethod(r.Method)
+	}
+	if session.PersonalAccessToken != nil {
+		return session.PersonalAccessToken.AllowsMethod(r.Method)
+	}
+
+	return true
+}
+
 func (h contextHandler) isUserVerified(session *model.Session) bool {
 	if session == nil || session.UserID == "" {
 		h.context.Logger.
@@ -105,24 +140,39 @@ func (h contextHandler) isUserVerified(session *model.Session) bool {
 	return user.EmailVerified
 }
 
-func (h contextHandler) isUserAdmin(session *model.Session) bool {
+func (h contextHandler) hasPermission(session *model.Session, permission string) bool {
 	if session == nil || session.UserID == "" {
 		h.context.Logger.
 			Error("session or userid in the session is not present")
 		return false
 	}
-	isAdmin, err := h.context.App.User().HasAdminPermission(session.UserID)
+	hasPermission, err := h.context.App.User().HasPermission(session.UserID, permission)
 	if err != nil {
 		h.context.Logger.
 			WithError(err).
 			WithField("user_id", session.UserID).
-			Error("error trying to check if user has admin role")
+			WithField("permission", permission).
+			Error("error trying to check if user has permission")
 		return false
 	}
-	return isAdmin
+	return hasPermission
 }
 
 func (h contextHandler) parseTokenAndGetSession(w http.ResponseWriter, r *http.Request) (*model.Session, error) {
+	// API keys are not subject to CSRF checks since they are never sent by
+	// browsers on their own.
+	if apiKey := r.Header.Get(model.HeaderApiKey); apiKey != "" {
+		session, err := h.context.App.GetSessionForAPIKey(apiKey, h.context.App.GetIPAddress(r))
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, errors.New("invalid API key")
+		}
+
+		return session, nil
+	}
+
 	token := ""
 	tokenFromCookie := false
 
@@ -137,13 +187,27 @@ func (h contextHandler) parseTokenAndGetSession(w http.ResponseWriter, r *http.R
 	// Parse the token from the header
 	if len(authHeader) > 6 && strings.ToUpper(authHeader[0:6]) == model.AuthorizationBearer {
 		token = authHeader[7:]
+
+		// Personal access tokens are not subject to CSRF checks since they
+		// are never sent by browsers on their own.
+		if model.IsPersonalAccessToken(token) {
+			session, err := h.context.App.GetSessionForPersonalAccessToken(token)
+			if err != nil {
+				return nil, err
+			}
+			if session == nil {
+				return nil, errors.New("invalid personal access token")
+			}
+
+			return session, nil
+		}
 	}
 
 	if len(token) == 0 {
 		return nil, nil
 	}
 
-	session, err := h.context.App.User().GetSession(token)
+	session, err := h.context.App.User().GetSessionByToken(token)
 	if err != nil {
 		return nil, err
 	}
@@ -156,11 +220,15 @@ func (h contextHandler) parseTokenAndGetSession(w http.ResponseWriter, r *http.R
 		return nil, err
 	}
 
+	if h.context.App.TouchSession(session) && tokenFromCookie {
+		utils.AttachSessionCookies(w, r, session)
+	}
+
 	return session, nil
 }
 
-func (h contextHandler) setDefaultHeaders(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(model.HeaderRequestID, h.context.RequestID)
+func (h contextHandler) setDefaultHeaders(w http.ResponseWriter, r *http.Request, requestID string) {
+	w.Header().Set(model.HeaderRequestID, requestID)
 
 	if h.isStatic {
 		// Instruct the browser not to display us in an iframe unless is the same origin for anti-clickjacking
@@ -216,12 +284,30 @@ func newAPISessionRequiredHandler(context *Context, handler contextHandlerFunc,
 	}
 }
 
-func newAPISessionAdminRequiredHandler(context *Context, handler contextHandlerFunc) *contextHandler {
+// newAPIPermissionRequiredHandler returns a handler requiring a verified user
+// having a role that grants the given permission.
+func newAPIPermissionRequiredHandler(context *Context, handler contextHandlerFunc, permission string) *contextHandler {
+	return &contextHandler{
+		context:              context,
+		handler:              handler,
+		requiresSession:      true,
+		requiresVerification: true,
+		requiredPermission:   permission,
+	}
+}
+
+// newAPIIngestHandler returns a handler requiring a verified user having a role
+// that grants the given permission, which also accepts API keys of the ingest
+// scope, used by CI runners to report results.
+func newAPIIngestHandler(context *Context, handler contextHandlerFunc, permission string) *contextHandler {
 	return &contextHandler{
 		context:              context,
 		handler:              handler,
 		requiresSession:      true,
-		requiresAdmin:        true,
 		requiresVerification: true,
+		requiredPermission:   permission,
+		allowsIngestAPIKey:   true,
+		rateLimitName:        "ingest",
+		rateLimit:            ingestRateLimit,
 	}
 }
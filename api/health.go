@@ -9,7 +9,7 @@ import (
 // initHealth registers status endpoints on the given router.
 func initHealth(apiRouter *mux.Router, context *Context) {
 	healthRouter := apiRouter.PathPrefix("/health").Subrouter()
-	healthRouter.Handle("", newAPIHandler(context, handleHealthCheck)).Methods("GET")
+	healthRouter.Handle("", newAPIHandler(context, handleHealthCheck).withoutRateLimit()).Methods("GET")
 }
 
 // handleHealthCheck responds to GET /api/v1/health as a health check.
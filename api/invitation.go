@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initInvitation registers invitation endpoints on the given router.
+func initInvitation(apiRouter *mux.Router, context *Context) {
+	invitationsRouter := apiRouter.PathPrefix("/invitations").Subrouter()
+	invitationsRouter.Handle("", newAPIPermissionRequiredHandler(context, handleCreateInvitation, model.PermissionUsersManage)).Methods("POST")
+	invitationsRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetInvitations, model.PermissionUsersManage)).Methods("GET")
+	invitationsRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleRevokeInvitation, model.PermissionUsersManage)).Methods("DELETE")
+}
+
+// handleCreateInvitation responds to POST /api/v1/invitations, emailing an
+// invitation to sign up.
+func handleCreateInvitation(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.CreateInvitationRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	// Granting roles can grant any permission, so it requires managing roles.
+	if len(request.RoleIDs) > 0 {
+		canManageRoles, err := c.App.User().HasPermission(c.Session.UserID, model.PermissionRolesManage)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+		if !canManageRoles {
+			w.WriteHeader(http.StatusForbidden)
+			c.writeAndLogError(w, errors.New("inviting with roles requires managing roles"))
+			return
+		}
+	}
+
+	invitation, _, err := c.App.CreateInvitation(request, c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:  model.AuditActionInvitationCreate,
+		Details: invitation.AuditDetails(),
+	})
+
+	b, err := json.Marshal(invitation)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleGetInvitations responds to GET /api/v1/invitations, returning the
+// invitations that can still be accepted.
+func handleGetInvitations(c *Context, w http.ResponseWriter, r *http.Request) {
+	invitations, err := c.App.GetInvitations()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(invitations)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleRevokeInvitation responds to DELETE /api/v1/invitations/{id},
+// revoking an invitation.
+func handleRevokeInvitation(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	invitation, err := c.App.GetInvitation(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if invitation == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = c.App.RevokeInvitation(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:  model.AuditActionInvitationRevoke,
+		Details: invitation.AuditDetails(),
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+)
+
+// getInvitationToken returns the token of the latest invitation emailed to
+// the given email.
+func getInvitationToken(t *testing.T, client *model.Client, email string) string {
+	emails, err := client.GetDevEmails(email)
+	require.NoError(t, err)
+	require.NotEmpty(t, emails)
+
+	token := emails[len(emails)-1].Tokens[model.TokenTypeInvitation]
+	require.NotEmpty(t, token)
+
+	return token
+}
+
+func TestInvitations(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	admin := signUpAdmin(t, adminClient, th.SqlStore)
+
+	adminRole, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+	require.NoError(t, err)
+
+	t.Run("requires the permission to manage users", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetInvitations()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		signUp(t, client, th.SqlStore)
+		_, err = client.CreateInvitation(&model.CreateInvitationRequest{Email: testlib.GetTestEmail()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("inviting with roles requires the permission to manage roles", func(t *testing.T) {
+		role, err := adminClient.CreateRole(&model.CreateRoleRequest{Name: "user-managers", Permissions: []string{model.PermissionUsersManage}})
+		require.NoError(t, err)
+
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+		require.NoError(t, th.SqlStore.Role().AddUserRole(user.ID, role.ID))
+
+		_, err = client.CreateInvitation(&model.CreateInvitationRequest{Email: testlib.GetTestEmail(), RoleIDs: []string{adminRole.ID}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		_, err = client.CreateInvitation(&model.CreateInvitationRequest{Email: testlib.GetTestEmail()})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: "junk"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: testlib.GetTestEmail(), RoleIDs: []string{model.NewID()}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		// Existing users can't be invited.
+		_, err = adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: admin.Email})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("invite and sign up", func(t *testing.T) {
+		email := testlib.GetTestEmail()
+		invitation, err := adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: email, RoleIDs: []string{adminRole.ID}})
+		require.NoError(t, err)
+		assert.Equal(t, admin.ID, invitation.InviterID)
+		assert.Equal(t, model.StringList{adminRole.ID}, invitation.RoleIDs)
+
+		invitations, err := adminClient.GetInvitations()
+		require.NoError(t, err)
+		assert.Contains(t, invitations, invitation)
+
+		token := getInvitationToken(t, adminClient, email)
+
+		client := model.NewClient(th.Server.URL)
+		_, err = client.SignUp(&model.SignUpRequest{Email: testlib.GetTestEmail(), Password: testPassword, InvitationToken: token})
+		require.Error(t, err, "the invitation is for another email")
+		assert.Contains(t, err.Error(), "403")
+
+		resp, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword, InvitationToken: token})
+		require.NoError(t, err)
+		assert.True(t, resp.User.EmailVerified)
+
+		hasRole, err := th.SqlStore.Role().UserHasRole(resp.User.ID, adminRole.ID)
+		require.NoError(t, err)
+		assert.True(t, hasRole)
+
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: resp.User.ID, Action: model.AuditActionInvitationAccept, PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, invitation.ID, logs[0].Details["invitation_id"])
+
+		invitations, err = adminClient.GetInvitations()
+		require.NoError(t, err)
+		assert.NotContains(t, invitations, invitation)
+
+		_, err = model.NewClient(th.Server.URL).SignUp(&model.SignUpRequest{Email: testlib.GetTestEmail(), Password: testPassword, InvitationToken: token})
+		require.Error(t, err, "the invitation was accepted")
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("new invitations replace previous ones", func(t *testing.T) {
+		email := testlib.GetTestEmail()
+		first, err := adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: email})
+		require.NoError(t, err)
+		firstToken := getInvitationToken(t, adminClient, email)
+
+		second, err := adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: email})
+		require.NoError(t, err)
+
+		invitations, err := adminClient.GetInvitations()
+		require.NoError(t, err)
+		assert.NotContains(t, invitations, first)
+		assert.Contains(t, invitations, second)
+
+		_, err = model.NewClient(th.Server.URL).SignUp(&model.SignUpRequest{Email: email, Password: testPassword, InvitationToken: firstToken})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		email := testlib.GetTestEmail()
+		invitation, err := adminClient.CreateInvitation(&model.CreateInvitationRequest{Email: email})
+		require.NoError(t, err)
+		token := getInvitationToken(t, adminClient, email)
+
+		err = adminClient.RevokeInvitation(invitation.ID)
+		require.NoError(t, err)
+
+		err = adminClient.RevokeInvitation(invitation.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = model.NewClient(th.Server.URL).SignUp(&model.SignUpRequest{Email: email, Password: testPassword, InvitationToken: token})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+}
+
+func TestSignupRestrictions(t *testing.T) {
+	t.Run("invite only", func(t *testing.T) {
+		th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+			config.Signup.Mode = model.SignupModeInvite
+		})
+		defer th.TearDown(t)
+
+		client := model.NewClient(th.Server.URL)
+		email := testlib.GetTestEmail()
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		_, token, err := th.App.CreateInvitation(&model.CreateInvitationRequest{Email: email}, "")
+		require.NoError(t, err)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword, InvitationToken: token.Token})
+		require.NoError(t, err)
+	})
+
+	t.Run("allowed domains", func(t *testing.T) {
+		th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+			config.Signup.AllowedDomains = []string{"example.com"}
+		})
+		defer th.TearDown(t)
+
+		signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		client := model.NewClient(th.Server.URL)
+		email := "user" + model.NewID() + "@example.org"
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		// Invited users are allowed whatever their domain.
+		_, token, err := th.App.CreateInvitation(&model.CreateInvitationRequest{Email: email}, "")
+		require.NoError(t, err)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword, InvitationToken: token.Token})
+		require.NoError(t, err)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+			config.Signup.Mode = model.SignupModeClosed
+		})
+		defer th.TearDown(t)
+
+		_, err := model.NewClient(th.Server.URL).SignUp(&model.SignUpRequest{Email: testlib.GetTestEmail(), Password: testPassword})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		_, _, err = th.App.CreateInvitation(&model.CreateInvitationRequest{Email: testlib.GetTestEmail()}, "")
+		require.Error(t, err)
+	})
+}
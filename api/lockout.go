@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initLockout registers the endpoints unlocking accounts and managing the
+// lockouts of failed login attempts on the given router.
+func initLockout(apiRouter *mux.Router, context *Context) {
+	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
+	usersRouter.Handle("/unlock-account-complete", newAPIHandler(context, handleUnlockAccount).withRateLimit("auth", authRateLimit)).Methods("POST")
+
+	lockoutsRouter := apiRouter.PathPrefix("/lockouts").Subrouter()
+	lockoutsRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetLockouts, model.PermissionUsersManage)).Methods("GET")
+	lockoutsRouter.Handle("/clear", newAPIPermissionRequiredHandler(context, handleClearLockout, model.PermissionUsersManage)).Methods("POST")
+}
+
+// checkLoginAttempts returns true if the given action can be attempted
+// against the account of the given email and from the IP address of the
+// request. Otherwise it writes a 429 response telling when to retry.
+func checkLoginAttempts(c *Context, w http.ResponseWriter, r *http.Request, action, email string) bool {
+	retryAfter, err := c.App.CheckLoginAttempts(action, email, c.App.GetIPAddress(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return false
+	}
+	if retryAfter == 0 {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	c.writeAndLogError(w, errors.New("too many failed attempts, try again later"))
+	return false
+}
+
+// recordLoginFailure counts a failed attempt of the given action against the
+// account of the given email and from the IP address of the request.
+func recordLoginFailure(c *Context, r *http.Request, action, email string) {
+	lockouts, err := c.App.RecordLoginFailure(action, email, c.App.GetIPAddress(r))
+	if err != nil {
+		c.Logger.WithError(err).WithField("action", action).Error("Failed to record failed attempt")
+	}
+
+	for _, lockout := range lockouts {
+		recordAudit(c, r, &model.AuditLog{
+			Action: model.AuditActionAccountLockout,
+			Details: model.StringMap{
+				"action":       lockout.Action,
+				"scope":        lockout.Scope,
+				"subject":      lockout.Subject,
+				"locked_until": strconv.FormatInt(lockout.LockedUntil, 10),
+			},
+		})
+	}
+}
+
+// handleUnlockAccount responds to POST /api/v1/users/unlock-account-complete,
+// lifting the lockout of the account the token was sent to.
+func handleUnlockAccount(c *Context, w http.ResponseWriter, r *http.Request) {
+	uar := &model.UnlockAccountRequest{}
+	err := decodeJSON(uar, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	token, err := c.App.Store().Token().GetToken(uar.Token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if token == nil ||
+		token.Type != model.TokenTypeUnlockAccount ||
+		token.CreateAt < model.GetMillis()-model.TokenDefaultExpiryTime {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
+
+	err = c.App.UnlockAccount(token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	email, _ := token.GetExtraEmail()
+	recordAudit(c, r, &model.AuditLog{
+		Action:  model.AuditActionAccountUnlock,
+		Details: model.StringMap{"scope": model.LoginAttemptScopeAccount, "subject": email},
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleGetLockouts responds to GET /api/v1/lockouts, listing the accounts and
+// IP addresses currently delayed or locked out by failed attempts.
+func handleGetLockouts(c *Context, w http.ResponseWriter, r *http.Request) {
+	lockouts, err := c.App.GetLockouts()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(lockouts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleClearLockout responds to POST /api/v1/lockouts/clear, forgetting the
+// failed attempts against an account or from an IP address.
+func handleClearLockout(c *Context, w http.ResponseWriter, r *http.Request) {
+	clr := &model.ClearLockoutRequest{}
+	err := decodeJSON(clr, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	if clr.Subject == "" ||
+		(clr.Scope != model.LoginAttemptScopeAccount && clr.Scope != model.LoginAttemptScopeIP) {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid scope or subject"))
+		return
+	}
+
+	err = c.App.ClearLockout(clr.Scope, clr.Subject)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	recordAudit(c, r, &model.AuditLog{
+		Action:  model.AuditActionAccountUnlock,
+		Details: model.StringMap{"scope": clr.Scope, "subject": clr.Subject},
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
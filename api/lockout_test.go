@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestLockout(t *testing.T) {
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.Lockout.Account = model.LockoutPolicy{
+			FreeAttempts: 2,
+			Delay:        time.Minute,
+			MaxFailures:  4,
+			Lockout:      time.Hour,
+			Window:       time.Hour,
+		}
+		config.Lockout.IP = model.LockoutPolicy{FreeAttempts: 1000, MaxFailures: 1000, Window: time.Hour}
+	})
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	signUpAdmin(t, adminClient, th.SqlStore)
+
+	// login posts the credentials of the given user, returning the response.
+	login := func(t *testing.T, email, password string) *http.Response {
+		body, err := json.Marshal(&model.LoginRequest{Email: email, Password: password})
+		require.NoError(t, err)
+		resp, err := http.Post(th.Server.URL+"/api/v1/users/login", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("failures delay further attempts", func(t *testing.T) {
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		for i := 0; i < 3; i++ {
+			resp := login(t, user.Email, "wrong")
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		resp := login(t, user.Email, testPassword)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+		lockouts, err := adminClient.GetLockouts()
+		require.NoError(t, err)
+		var lockout *model.LoginAttempts
+		for _, l := range lockouts {
+			if l.Subject == user.Email {
+				lockout = l
+			}
+		}
+		require.NotNil(t, lockout)
+		assert.Equal(t, model.LoginAttemptActionLogin, lockout.Action)
+		assert.Equal(t, model.LoginAttemptScopeAccount, lockout.Scope)
+		assert.Equal(t, 3, lockout.Failures)
+
+		err = adminClient.ClearLockout(&model.ClearLockoutRequest{Scope: model.LoginAttemptScopeAccount, Subject: user.Email})
+		require.NoError(t, err)
+
+		resp = login(t, user.Email, testPassword)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("a successful login forgets the failures", func(t *testing.T) {
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		for i := 0; i < 2; i++ {
+			resp := login(t, user.Email, "wrong")
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+		resp := login(t, user.Email, testPassword)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		attempts, err := th.SqlStore.LoginAttempt().GetLoginAttempts(model.LoginAttemptActionLogin, model.LoginAttemptScopeAccount, user.Email)
+		require.NoError(t, err)
+		assert.Nil(t, attempts)
+	})
+
+	t.Run("lockout is lifted with the emailed link", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		// Pretend earlier failures were spread out enough not to be delayed.
+		for i := 0; i < 3; i++ {
+			_, err := th.SqlStore.LoginAttempt().RecordLoginFailure(model.LoginAttemptActionLogin, model.LoginAttemptScopeAccount, user.Email, model.GetMillis(), time.Hour.Milliseconds())
+			require.NoError(t, err)
+		}
+
+		resp := login(t, user.Email, "wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = login(t, user.Email, testPassword)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "3600", resp.Header.Get("Retry-After"))
+
+		emails, err := client.GetDevEmails(user.Email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		token := emails[0].Tokens[model.TokenTypeUnlockAccount]
+		require.Len(t, token, model.TokenSize)
+
+		err = client.UnlockAccount(&model.UnlockAccountRequest{Token: "junk"})
+		require.Error(t, err)
+
+		err = client.UnlockAccount(&model.UnlockAccountRequest{Token: token})
+		require.NoError(t, err)
+
+		resp = login(t, user.Email, testPassword)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		err = client.UnlockAccount(&model.UnlockAccountRequest{Token: token})
+		require.Error(t, err)
+	})
+
+	t.Run("unknown accounts are locked out the same way", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp := login(t, "nobody@example.com", "wrong")
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		resp := login(t, "NOBODY@example.com", "wrong")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("clearing a lockout requires managing users", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		_, err := client.GetLockouts()
+		require.Error(t, err)
+
+		err = client.ClearLockout(&model.ClearLockoutRequest{Scope: model.LoginAttemptScopeIP, Subject: "127.0.0.1"})
+		require.Error(t, err)
+
+		err = adminClient.ClearLockout(&model.ClearLockoutRequest{Scope: "junk", Subject: "127.0.0.1"})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid two-factor codes delay further attempts", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		enrollment, err := client.StartMFAEnrollment()
+		require.NoError(t, err)
+		codes, err := client.ConfirmMFAEnrollment(totpCode(t, enrollment.Secret, 0))
+		require.NoError(t, err)
+
+		_, err = client.RegenerateMFARecoveryCodes("000000")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+		for i := 0; i < 2; i++ {
+			err = client.DisableMFA("000000")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "400")
+		}
+
+		err = client.DisableMFA(codes.RecoveryCodes[0])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "429")
+
+		_, err = client.RegenerateMFARecoveryCodes(codes.RecoveryCodes[0])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "429")
+	})
+}
+
+func TestLockoutByIP(t *testing.T) {
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.Lockout.IP = model.LockoutPolicy{FreeAttempts: 2, Delay: time.Minute, MaxFailures: 10, Lockout: time.Hour, Window: time.Hour}
+	})
+	defer th.TearDown(t)
+
+	client := model.NewClient(th.Server.URL)
+
+	for i := 0; i < 3; i++ {
+		err := client.ResetPassword(&model.ResetPasswordRequest{Password: "Test1234", Token: model.NewRandomString(model.TokenSize)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid token")
+	}
+
+	err := client.ResetPassword(&model.ResetPasswordRequest{Password: "Test1234", Token: model.NewRandomString(model.TokenSize)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+
+	// Other actions are tracked apart.
+	err = client.VerifyEmailComplete(&model.VerifyEmailRequest{Token: "123456"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid token")
+}
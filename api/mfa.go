@@ -0,0 +1,375 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initMFA registers two-factor authentication endpoints on the given router.
+func initMFA(apiRouter *mux.Router, context *Context) {
+	loginRouter := apiRouter.PathPrefix("/users/login/mfa").Subrouter()
+	loginRouter.Handle("", newAPIHandler(context, handleLoginMFA).withRateLimit("auth", authRateLimit)).Methods("POST")
+	loginRouter.Handle("/enroll", newAPIHandler(context, handleLoginMFAEnroll).withRateLimit("auth", authRateLimit)).Methods("POST")
+	loginRouter.Handle("/enroll/confirm", newAPIHandler(context, handleLoginMFAEnrollConfirm).withRateLimit("auth", authRateLimit)).Methods("POST")
+
+	meRouter := apiRouter.PathPrefix("/users/me/mfa").Subrouter()
+	meRouter.Handle("", newAPISessionRequiredHandler(context, handleGetMFAStatus, false)).Methods("GET")
+	meRouter.Handle("", newAPISessionRequiredHandler(context, handleStartMFAEnrollment, false)).Methods("POST")
+	meRouter.Handle("/confirm", newAPISessionRequiredHandler(context, handleConfirmMFAEnrollment, false)).Methods("POST")
+	meRouter.Handle("/disable", newAPISessionRequiredHandler(context, handleDisableMFA, false).withRateLimit("auth", authRateLimit)).Methods("POST")
+	meRouter.Handle("/recovery-codes", newAPISessionRequiredHandler(context, handleRegenerateMFARecoveryCodes, false).withRateLimit("auth", authRateLimit)).Methods("POST")
+}
+
+// handleLoginMFA responds to POST /api/v1/users/login/mfa, logging in the
+// user of a login challenge with their TOTP or recovery code. The challenge
+// can only be used once, even with an invalid code.
+func handleLoginMFA(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.LoginMFARequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	challenge, err := c.App.CompleteMFAChallenge(request.Token, request.Code)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	user := getActiveUserForLogin(c, w, challenge.UserID)
+	if user == nil {
+		return
+	}
+
+	err = c.App.Login(w, r, user, challenge.Source)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordLoginAudit(c, r, user, challenge.Source)
+
+	user.Sanitize()
+	b, err := json.Marshal(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleLoginMFAEnroll responds to POST /api/v1/users/login/mfa/enroll,
+// starting the enrollment of an authenticator for the user of a login
+// challenge whose role requires two-factor authentication.
+func handleLoginMFAEnroll(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.LoginMFARequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	challenge, err := c.App.GetMFAChallenge(request.Token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if challenge == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.writeAndLogError(w, errors.New("challenge expired or not found"))
+		return
+	}
+
+	user := getActiveUserForLogin(c, w, challenge.UserID)
+	if user == nil {
+		return
+	}
+
+	enrollment, err := c.App.StartMFAEnrollment(user)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(enrollment)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleLoginMFAEnrollConfirm responds to POST
+// /api/v1/users/login/mfa/enroll/confirm, enabling the authenticator enrolled
+// with a login challenge and logging the user in. The recovery codes are
+// returned along with the session header.
+func handleLoginMFAEnrollConfirm(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.LoginMFARequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	challenge, codes, err := c.App.CompleteMFAChallengeEnrollment(request.Token, request.Code)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	user := getActiveUserForLogin(c, w, challenge.UserID)
+	if user == nil {
+		return
+	}
+
+	err = c.App.Login(w, r, user, challenge.Source)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordLoginAudit(c, r, user, challenge.Source)
+
+	b, err := json.Marshal(&model.MFARecoveryCodes{RecoveryCodes: codes})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// getActiveUserForLogin returns the user completing a login challenge. It
+// writes the error response and returns nil if the user can't be found or was
+// locked in the meantime.
+func getActiveUserForLogin(c *Context, w http.ResponseWriter, userID string) *model.User {
+	user, err := c.App.User().Get(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return nil
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		c.writeAndLogError(w, errors.New("no user"))
+		return nil
+	}
+	if user.State != model.UserStateActive {
+		w.WriteHeader(http.StatusLocked)
+		c.writeAndLogErrorWithFields(w, errors.New("Attempt to login to locked account"), logrus.Fields{"email": user.Email})
+		return nil
+	}
+
+	return user
+}
+
+// handleGetMFAStatus responds to GET /api/v1/users/me/mfa, returning the
+// two-factor authentication status of the logged in user.
+func handleGetMFAStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	status, err := c.App.GetMFAStatus(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleStartMFAEnrollment responds to POST /api/v1/users/me/mfa, returning a
+// new TOTP secret for the logged in user to enroll in their authenticator.
+func handleStartMFAEnrollment(c *Context, w http.ResponseWriter, r *http.Request) {
+	user, err := c.App.User().Get(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	enrollment, err := c.App.StartMFAEnrollment(user)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(enrollment)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleConfirmMFAEnrollment responds to POST /api/v1/users/me/mfa/confirm,
+// enabling the authenticator of the logged in user with a code and returning
+// their recovery codes.
+func handleConfirmMFAEnrollment(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.MFACodeRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	codes, err := c.App.ConfirmMFAEnrollment(c.Session.UserID, request.Code)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(&model.MFARecoveryCodes{RecoveryCodes: codes})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// checkMFACode returns true if the given code is one of the logged in user,
+// tracking the failed attempts against their account. Otherwise it writes the
+// error response.
+func checkMFACode(c *Context, w http.ResponseWriter, r *http.Request, code string) bool {
+	user, err := c.App.User().Get(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return false
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		c.writeAndLogError(w, errors.New("user not found"))
+		return false
+	}
+
+	if !checkLoginAttempts(c, w, r, model.LoginAttemptActionMFA, user.Email) {
+		return false
+	}
+
+	valid, err := c.App.VerifyMFACode(user.ID, code)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return false
+	}
+	if !valid {
+		recordLoginFailure(c, r, model.LoginAttemptActionMFA, user.Email)
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid code"))
+		return false
+	}
+
+	err = c.App.ClearLoginFailures(model.LoginAttemptActionMFA, user.Email)
+	if err != nil {
+		c.Logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to clear failed two-factor attempts")
+	}
+
+	return true
+}
+
+// handleDisableMFA responds to POST /api/v1/users/me/mfa/disable, removing
+// the authenticator of the logged in user after checking one of their codes.
+func handleDisableMFA(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.MFACodeRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	// The role is checked first so that the code isn't used up for nothing.
+	required, err := c.App.UserRequiresMFA(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if required {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("two-factor authentication is required by your role"))
+		return
+	}
+
+	if !checkMFACode(c, w, r, request.Code) {
+		return
+	}
+
+	err = c.App.DisableMFA(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleRegenerateMFARecoveryCodes responds to POST
+// /api/v1/users/me/mfa/recovery-codes, replacing the recovery codes of the
+// logged in user after checking one of their codes.
+func handleRegenerateMFARecoveryCodes(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.MFACodeRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	if !checkMFACode(c, w, r, request.Code) {
+		return
+	}
+
+	codes, err := c.App.RegenerateMFARecoveryCodes(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(&model.MFARecoveryCodes{RecoveryCodes: codes})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/internal/totp"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// totpCode returns the code of the given secret, the given number of periods
+// from now.
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	require.NoError(t, err)
+
+	return code
+}
+
+// loginChallenge logs in with the password of the user, expecting a second
+// factor challenge.
+func loginChallenge(t *testing.T, client *model.Client, user *model.User) *model.MFAChallenge {
+	_, err := client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+	require.Error(t, err)
+
+	mfaErr, ok := err.(*model.MFARequiredError)
+	require.True(t, ok, "expected a second factor challenge, got %v", err)
+	require.NotEmpty(t, mfaErr.Challenge.Token)
+
+	return mfaErr.Challenge
+}
+
+func TestMFA(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	client := model.NewClient(th.Server.URL)
+	user := signUp(t, client, th.SqlStore)
+
+	status, err := client.GetMFAStatus()
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+	assert.False(t, status.Required)
+
+	enrollment, err := client.StartMFAEnrollment()
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	t.Run("enrollment is not enabled before confirmation", func(t *testing.T) {
+		_, err := model.NewClient(th.Server.URL).Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+
+		_, err = client.ConfirmMFAEnrollment("000000")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid code")
+	})
+
+	codes, err := client.ConfirmMFAEnrollment(totpCode(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+	require.Len(t, codes.RecoveryCodes, model.MFARecoveryCodeCount)
+
+	status, err = client.GetMFAStatus()
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, model.MFARecoveryCodeCount, status.RecoveryCodesLeft)
+
+	_, err = client.StartMFAEnrollment()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already enabled")
+
+	t.Run("login requires a TOTP code", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		challenge := loginChallenge(t, client, user)
+		assert.False(t, challenge.EnrollmentRequired)
+
+		_, err := client.GetMe()
+		require.Error(t, err)
+
+		code := totpCode(t, enrollment.Secret, 1)
+		loggedIn, err := client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: code})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, loggedIn.ID)
+
+		me, err := client.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, me.ID)
+
+		t.Run("challenges and codes can't be reused", func(t *testing.T) {
+			_, err := client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: code})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "challenge expired or not found")
+
+			challenge := loginChallenge(t, client, user)
+			_, err = client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: code})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid code")
+		})
+	})
+
+	t.Run("an invalid code consumes the challenge", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		challenge := loginChallenge(t, client, user)
+
+		_, err := client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: "000000"})
+		require.Error(t, err)
+
+		_, err = client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: codes.RecoveryCodes[0]})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "challenge expired or not found")
+	})
+
+	t.Run("recovery codes can be used once", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		challenge := loginChallenge(t, client, user)
+
+		_, err := client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: codes.RecoveryCodes[0]})
+		require.NoError(t, err)
+
+		challenge = loginChallenge(t, client, user)
+		_, err = client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: codes.RecoveryCodes[0]})
+		require.Error(t, err)
+
+		status, err := client.GetMFAStatus()
+		require.NoError(t, err)
+		assert.Equal(t, model.MFARecoveryCodeCount-1, status.RecoveryCodesLeft)
+	})
+
+	t.Run("regenerate recovery codes", func(t *testing.T) {
+		_, err := client.RegenerateMFARecoveryCodes(codes.RecoveryCodes[0])
+		require.Error(t, err)
+
+		newCodes, err := client.RegenerateMFARecoveryCodes(codes.RecoveryCodes[1])
+		require.NoError(t, err)
+		require.Len(t, newCodes.RecoveryCodes, model.MFARecoveryCodeCount)
+
+		challenge := loginChallenge(t, client, user)
+		_, err = client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: codes.RecoveryCodes[2]})
+		require.Error(t, err)
+
+		codes = newCodes
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		challenge := loginChallenge(t, client, user)
+		_, err := client.LoginMFA(&model.LoginMFARequest{Token: challenge.Token, Code: codes.RecoveryCodes[0]})
+		require.NoError(t, err)
+
+		err = client.DisableMFA("000000")
+		require.Error(t, err)
+
+		err = client.DisableMFA(codes.RecoveryCodes[1])
+		require.NoError(t, err)
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+	})
+}
+
+func TestMFAAdmin(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	signUpAdmin(t, adminClient, th.SqlStore)
+
+	client := model.NewClient(th.Server.URL)
+	user := signUp(t, client, th.SqlStore)
+
+	enrollment, err := client.StartMFAEnrollment()
+	require.NoError(t, err)
+	_, err = client.ConfirmMFAEnrollment(totpCode(t, enrollment.Secret, 0))
+	require.NoError(t, err)
+
+	t.Run("only admins reset 2FA", func(t *testing.T) {
+		err := client.ResetUserMFA(user.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("reset 2FA", func(t *testing.T) {
+		loginChallenge(t, model.NewClient(th.Server.URL), user)
+
+		err := adminClient.ResetUserMFA(user.ID)
+		require.NoError(t, err)
+
+		_, err = model.NewClient(th.Server.URL).Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+	})
+
+	adminRole, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+	require.NoError(t, err)
+
+	role, err := adminClient.SetRoleRequireMFA(adminRole.ID, true)
+	require.NoError(t, err)
+	assert.True(t, role.RequireMFA)
+
+	t.Run("admins must enroll on login", func(t *testing.T) {
+		admin := signUpAdmin(t, model.NewClient(th.Server.URL), th.SqlStore)
+
+		client := model.NewClient(th.Server.URL)
+		challenge := loginChallenge(t, client, admin)
+		assert.True(t, challenge.EnrollmentRequired)
+
+		enrollment, err := client.StartLoginMFAEnrollment(challenge.Token)
+		require.NoError(t, err)
+
+		codes, err := client.ConfirmLoginMFAEnrollment(&model.LoginMFARequest{
+			Token: challenge.Token,
+			Code:  totpCode(t, enrollment.Secret, 0),
+		})
+		require.NoError(t, err)
+		assert.Len(t, codes.RecoveryCodes, model.MFARecoveryCodeCount)
+
+		status, err := client.GetMFAStatus()
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.True(t, status.Required)
+
+		err = client.DisableMFA(codes.RecoveryCodes[0])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "required by your role")
+
+		challenge = loginChallenge(t, client, admin)
+		assert.False(t, challenge.EnrollmentRequired)
+	})
+
+	t.Run("users without the role are not affected", func(t *testing.T) {
+		_, err := model.NewClient(th.Server.URL).Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+	})
+}
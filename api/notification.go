@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initNotification registers notification endpoints on the given router.
+func initNotification(apiRouter *mux.Router, context *Context) {
+	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
+	usersRouter.Handle("/me/notifications", newAPISessionRequiredHandler(context, handleGetNotificationPreferences, false)).Methods("GET")
+	usersRouter.Handle("/me/notifications", newAPISessionRequiredHandler(context, handleUpdateNotificationPreferences, false)).Methods("PUT")
+	usersRouter.Handle("/unsubscribe", newAPIHandler(context, handleUnsubscribe)).Methods("POST")
+}
+
+// handleGetNotificationPreferences responds to GET /api/v1/users/me/notifications,
+// returning the notification preferences of the logged in user.
+func handleGetNotificationPreferences(c *Context, w http.ResponseWriter, r *http.Request) {
+	preferences, err := c.App.GetNotificationPreferences(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	writeNotificationPreferences(c, w, preferences)
+}
+
+// handleUpdateNotificationPreferences responds to PUT /api/v1/users/me/notifications,
+// updating the notification preferences of the logged in user.
+func handleUpdateNotificationPreferences(c *Context, w http.ResponseWriter, r *http.Request) {
+	preferences, err := model.NotificationPreferencesFromReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	if preferences.UserID != "" && preferences.UserID != c.Session.UserID {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("user id in body did not match session"))
+		return
+	}
+	preferences.UserID = c.Session.UserID
+
+	preferences.PreSave()
+	err = preferences.IsValid()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	preferences, err = c.App.UpdateNotificationPreferences(preferences)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	writeNotificationPreferences(c, w, preferences)
+}
+
+// handleUnsubscribe responds to POST /api/v1/users/unsubscribe, applying the
+// token of a one-click unsubscribe link. It does not require a session.
+func handleUnsubscribe(c *Context, w http.ResponseWriter, r *http.Request) {
+	ur := &model.UnsubscribeRequest{}
+	err := decodeJSON(ur, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	token, err := c.App.Store().Token().GetToken(ur.Token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if token == nil || token.Type != model.TokenTypeUnsubscribe {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
+
+	_, err = c.App.Unsubscribe(token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+func writeNotificationPreferences(c *Context, w http.ResponseWriter, preferences *model.NotificationPreferences) {
+	b, err := json.Marshal(preferences)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
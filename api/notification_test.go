@@ -0,0 +1,144 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("requires a session", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetNotificationPreferences()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		_, err = client.UpdateNotificationPreferences(&model.NotificationPreferences{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("get defaults and update", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		preferences, err := client.GetNotificationPreferences()
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, preferences.UserID)
+		assert.True(t, preferences.Email)
+		assert.True(t, preferences.InApp)
+		assert.ElementsMatch(t, model.NotificationEvents, preferences.Events)
+		assert.Empty(t, preferences.Repos)
+		assert.Empty(t, preferences.Branches)
+
+		preferences.InApp = false
+		preferences.Events = model.StringList{model.NotificationEventCycleFailed}
+		preferences.Repos = model.StringList{"mattermost-server"}
+		preferences.Branches = model.StringList{"master"}
+
+		preferences, err = client.UpdateNotificationPreferences(preferences)
+		require.NoError(t, err)
+		assert.False(t, preferences.InApp)
+
+		preferences, err = client.GetNotificationPreferences()
+		require.NoError(t, err)
+		assert.True(t, preferences.Email)
+		assert.False(t, preferences.InApp)
+		assert.Equal(t, model.StringList{model.NotificationEventCycleFailed}, preferences.Events)
+		assert.Equal(t, model.StringList{"mattermost-server"}, preferences.Repos)
+		assert.Equal(t, model.StringList{"master"}, preferences.Branches)
+	})
+
+	t.Run("invalid preferences", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		_, err := client.UpdateNotificationPreferences(&model.NotificationPreferences{Events: model.StringList{"junk"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = client.UpdateNotificationPreferences(&model.NotificationPreferences{Repos: model.StringList{""}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = client.UpdateNotificationPreferences(&model.NotificationPreferences{UserID: model.NewID()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("notification emails follow preferences", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		_, err := client.UpdateNotificationPreferences(&model.NotificationPreferences{
+			Email:  true,
+			Events: model.StringList{model.NotificationEventCycleFailed},
+			Repos:  model.StringList{"mattermost-server"},
+		})
+		require.NoError(t, err)
+
+		send := func(event, repo string) {
+			bodyPage := th.App.GetHTMLTemplate("test_email_body", user.Locale)
+			err := th.App.SendNotificationEmail(user, event, repo, "master", event, bodyPage)
+			require.NoError(t, err)
+		}
+
+		send(model.NotificationEventCycleCompleted, "mattermost-server")
+		send(model.NotificationEventCycleFailed, "mattermost-webapp")
+		assert.Empty(t, th.getSentEmails(t, user.Email))
+
+		send(model.NotificationEventCycleFailed, "mattermost-server")
+		assert.Len(t, th.getSentEmails(t, user.Email), 1)
+
+		// Security emails are sent whatever the preferences.
+		send(model.NotificationEventSecurity, "")
+		assert.Len(t, th.getSentEmails(t, user.Email), 2)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		bodyPage := th.App.GetHTMLTemplate("test_email_body", user.Locale)
+		err := th.App.SendNotificationEmail(user, model.NotificationEventCycleFailed, "", "", "Cycle failed", bodyPage)
+		require.NoError(t, err)
+
+		emails, err := client.GetDevEmails(user.Email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		token := emails[0].Tokens[model.TokenTypeUnsubscribe]
+		require.NotEmpty(t, token)
+
+		// Every email of the user and event links to the same token.
+		unsubscribeToken, err := th.App.GetUnsubscribeToken(user.ID, model.NotificationEventCycleFailed)
+		require.NoError(t, err)
+		assert.Equal(t, token, unsubscribeToken.Token)
+		unsubscribeToken, err = th.App.GetUnsubscribeToken(user.ID, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, token, unsubscribeToken.Token)
+
+		// The link works without a session.
+		anonymous := model.NewClient(th.Server.URL)
+		err = anonymous.Unsubscribe(&model.UnsubscribeRequest{Token: model.NewID()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		err = anonymous.Unsubscribe(&model.UnsubscribeRequest{Token: token})
+		require.NoError(t, err)
+
+		// Clicking the link again is harmless.
+		err = anonymous.Unsubscribe(&model.UnsubscribeRequest{Token: token})
+		require.NoError(t, err)
+
+		preferences, err := client.GetNotificationPreferences()
+		require.NoError(t, err)
+		assert.True(t, preferences.Email)
+		assert.Equal(t, model.StringList{model.NotificationEventCycleCompleted}, preferences.Events)
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+)
+
+// requirePasswordReasons checks the request was rejected for the given
+// password policy reasons.
+func requirePasswordReasons(t *testing.T, err error, reasons ...string) {
+	t.Helper()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+
+	var invalidPassword *model.InvalidPasswordError
+	require.True(t, errors.As(err, &invalidPassword), err.Error())
+	assert.ElementsMatch(t, reasons, invalidPassword.Reasons)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	const breachedPassword = "Password1"
+	sum := sha1.Sum([]byte(breachedPassword))
+	breachedList := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	err := os.WriteFile(breachedList, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":42\n"), 0600)
+	require.NoError(t, err)
+
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.Passwords.Policy = model.PasswordPolicy{
+			MinLength:        10,
+			RequireLowercase: true,
+			RequireDigit:     true,
+			RequireSymbol:    true,
+			DisallowUserInfo: true,
+			HistorySize:      2,
+		}
+		config.Passwords.BreachedList = breachedList
+	})
+	defer th.TearDown(t)
+
+	const password = "correct-horse-1"
+
+	t.Run("sign up", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		email := testlib.GetTestEmail()
+
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: "short"})
+		requirePasswordReasons(t, err, model.PasswordReasonTooShort, model.PasswordReasonMissingDigit, model.PasswordReasonMissingSymbol)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, Password: breachedPassword})
+		requirePasswordReasons(t, err, model.PasswordReasonTooShort, model.PasswordReasonMissingSymbol, model.PasswordReasonBreached)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, FirstName: "Ferdinand", Password: "ferdinand-1"})
+		requirePasswordReasons(t, err, model.PasswordReasonContainsName)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, Password: "x-" + strings.Split(email, "@")[0] + "-1"})
+		requirePasswordReasons(t, err, model.PasswordReasonContainsEmail)
+
+		_, err = client.SignUp(&model.SignUpRequest{Email: email, Password: password})
+		require.NoError(t, err)
+	})
+
+	t.Run("update password", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		email := testlib.GetTestEmail()
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: password})
+		require.NoError(t, err)
+
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: password, NewPassword: "nosymbol12"})
+		requirePasswordReasons(t, err, model.PasswordReasonMissingSymbol)
+
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: password, NewPassword: password})
+		requirePasswordReasons(t, err, model.PasswordReasonReused)
+
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: password, NewPassword: "second-horse-2"})
+		require.NoError(t, err)
+
+		// The previous password is still in the history of 2 passwords.
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: "second-horse-2", NewPassword: password})
+		requirePasswordReasons(t, err, model.PasswordReasonReused)
+
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: "second-horse-2", NewPassword: "third-horse-3"})
+		require.NoError(t, err)
+		err = client.UpdatePassword(&model.UpdatePasswordRequest{CurrentPassword: "third-horse-3", NewPassword: password})
+		require.NoError(t, err)
+	})
+
+	t.Run("reset password", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		email := testlib.GetTestEmail()
+		_, err := client.SignUp(&model.SignUpRequest{Email: email, Password: password})
+		require.NoError(t, err)
+
+		err = client.ForgotPassword(&model.ForgotPasswordRequest{Email: email})
+		require.NoError(t, err)
+		emails, err := client.GetDevEmails(email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		token := emails[0].Tokens[model.TokenTypeResetPassword]
+
+		err = client.ResetPassword(&model.ResetPasswordRequest{Token: token, Password: password})
+		requirePasswordReasons(t, err, model.PasswordReasonReused)
+
+		// The token is still valid after a rejected password.
+		err = client.ResetPassword(&model.ResetPasswordRequest{Token: token, Password: "fourth-horse-4"})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initPersonalAccessToken registers personal access token endpoints on the
+// given router.
+func initPersonalAccessToken(apiRouter *mux.Router, context *Context) {
+	tokensRouter := apiRouter.PathPrefix("/users/me/tokens").Subrouter()
+	tokensRouter.Handle("", newAPISessionRequiredHandler(context, handleCreatePersonalAccessToken, true)).Methods("POST")
+	tokensRouter.Handle("", newAPISessionRequiredHandler(context, handleGetPersonalAccessTokens, true)).Methods("GET")
+	tokensRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPISessionRequiredHandler(context, handleRevokePersonalAccessToken, true)).Methods("DELETE")
+}
+
+// handleCreatePersonalAccessToken responds to POST /api/v1/users/me/tokens,
+// creating a personal access token for the logged in user. The token is only
+// returned in this response.
+func handleCreatePersonalAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.CreatePersonalAccessTokenRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	resp, err := c.App.CreatePersonalAccessToken(c.Session.UserID, request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleGetPersonalAccessTokens responds to GET /api/v1/users/me/tokens,
+// returning the personal access tokens of the logged in user that were not
+// revoked.
+func handleGetPersonalAccessTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	tokens, err := c.App.GetPersonalAccessTokensForUser(c.Session.UserID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(tokens)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleRevokePersonalAccessToken responds to DELETE /api/v1/users/me/tokens/{id},
+// revoking a personal access token of the logged in user.
+func handleRevokePersonalAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	token, err := c.App.GetPersonalAccessToken(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if token == nil || token.IsRevoked() || token.UserID != c.Session.UserID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	err = c.App.RevokePersonalAccessToken(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
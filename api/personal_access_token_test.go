@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalAccessTokens(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("requires a session", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetPersonalAccessTokens()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		_, err := client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Scope: model.PersonalAccessTokenScopeRead})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		_, err = client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Name: "script", Scope: "junk"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("create, use and revoke", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		resp, err := client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Name: "script", Scope: model.PersonalAccessTokenScopeWrite})
+		require.NoError(t, err)
+		require.NotNil(t, resp.PersonalAccessToken)
+		assert.True(t, model.IsPersonalAccessToken(resp.Token))
+		assert.Equal(t, user.ID, resp.PersonalAccessToken.UserID)
+
+		tokens, err := client.GetPersonalAccessTokens()
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, resp.PersonalAccessToken.ID, tokens[0].ID)
+
+		patClient := model.NewClientWithPersonalAccessToken(th.Server.URL, resp.Token)
+		me, err := patClient.GetMe()
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, me.ID)
+
+		me.FirstName = "Scripted"
+		me, err = patClient.UpdateMe(me)
+		require.NoError(t, err)
+		assert.Equal(t, "Scripted", me.FirstName)
+
+		// Tokens of other users can't be revoked.
+		other := model.NewClient(th.Server.URL)
+		signUp(t, other, th.SqlStore)
+		err = other.RevokePersonalAccessToken(resp.PersonalAccessToken.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		err = client.RevokePersonalAccessToken(resp.PersonalAccessToken.ID)
+		require.NoError(t, err)
+
+		_, err = patClient.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		tokens, err = client.GetPersonalAccessTokens()
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("read scope", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+
+		resp, err := client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Name: "script", Scope: model.PersonalAccessTokenScopeRead})
+		require.NoError(t, err)
+
+		patClient := model.NewClientWithPersonalAccessToken(th.Server.URL, resp.Token)
+		me, err := patClient.GetMe()
+		require.NoError(t, err)
+
+		_, err = patClient.UpdateMe(me)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("skips CSRF checks", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		signUp(t, client, th.SqlStore)
+		sessionToken := strings.Split(client.Headers()[model.HeaderAuthorization], " ")[1]
+
+		resp, err := client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Name: "script", Scope: model.PersonalAccessTokenScopeWrite})
+		require.NoError(t, err)
+
+		doPost := func(authorization string) int {
+			req, err := http.NewRequest(http.MethodPost, th.Server.URL+"/api/v1/users/verify-email", nil)
+			require.NoError(t, err)
+			req.AddCookie(&http.Cookie{Name: model.SessionCookieToken, Value: sessionToken})
+			req.Header.Set(model.HeaderAuthorization, authorization)
+
+			httpResp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer httpResp.Body.Close()
+
+			return httpResp.StatusCode
+		}
+
+		// A session token coming with a cookie requires the CSRF header.
+		assert.Equal(t, http.StatusUnauthorized, doPost(fmt.Sprintf("%s %s", model.AuthorizationBearer, sessionToken)))
+		// The email is already verified, so the request is rejected but
+		// authenticated.
+		assert.Equal(t, http.StatusBadRequest, doPost(fmt.Sprintf("%s %s", model.AuthorizationBearer, resp.Token)))
+	})
+}
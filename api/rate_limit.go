@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// The budgets of the routes that declare their own rate limit. The other
+// routes share the configured default.
+var (
+	// signUpRateLimit is strict since accounts are cheap to create.
+	signUpRateLimit = ratelimit.Limit{Requests: 10, Period: time.Hour}
+	// authRateLimit applies to the routes guessing credentials or tokens,
+	// on top of their failed attempt lockouts.
+	authRateLimit = ratelimit.Limit{Requests: 30, Period: time.Minute}
+	// ingestRateLimit is generous since CI runners report many results.
+	ingestRateLimit = ratelimit.Limit{Requests: 6000, Period: time.Minute}
+)
+
+// withRateLimit gives the route a budget of its own, shared by the routes of
+// the same name.
+func (h *contextHandler) withRateLimit(name string, limit ratelimit.Limit) *contextHandler {
+	h.rateLimitName = name
+	h.rateLimit = limit
+	return h
+}
+
+// withoutRateLimit exempts the route from rate limiting.
+func (h *contextHandler) withoutRateLimit() *contextHandler {
+	h.skipRateLimit = true
+	return h
+}
+
+// rateLimitKey returns the bucket of the request: the one of its API key or
+// user if it has a session, of its IP address otherwise.
+func (h contextHandler) rateLimitKey(c *Context, r *http.Request, session *model.Session) string {
+	name := h.rateLimitName
+	if name == "" {
+		name = "default"
+	}
+
+	switch {
+	case session != nil && session.APIKey != nil:
+		return fmt.Sprintf("%s:apikey:%s", name, session.APIKey.ID)
+	case session != nil && session.UserID != "":
+		return fmt.Sprintf("%s:user:%s", name, session.UserID)
+	}
+
+	return fmt.Sprintf("%s:ip:%s", name, c.App.GetIPAddress(r))
+}
+
+// checkRateLimit returns true if the request is within its rate limit, and
+// writes the RateLimit headers describing it. Otherwise it writes a 429
+// response telling when to retry.
+func (h contextHandler) checkRateLimit(c *Context, w http.ResponseWriter, r *http.Request, session *model.Session) bool {
+	if h.skipRateLimit || h.isStatic {
+		return true
+	}
+
+	result, err := c.App.RateLimitRequest(h.rateLimitKey(c, r, session), h.rateLimit)
+	if err != nil {
+		// Failing open keeps the API available when the limits can't be
+		// checked.
+		c.Logger.WithError(err).Error("Unable to check rate limit")
+		return true
+	}
+	if result == nil {
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit.Requests, ceilSeconds(result.Limit.Period)))
+
+	if result.Allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	w.WriteHeader(http.StatusTooManyRequests)
+	c.writeAndLogError(w, errors.New("rate limit exceeded, try again later"))
+	return false
+}
+
+// ceilSeconds returns the given duration in seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
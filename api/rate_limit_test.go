@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestRateLimit(t *testing.T) {
+	for _, store := range []string{app.RateLimitStoreMemory, app.RateLimitStoreDatabase} {
+		t.Run(store, func(t *testing.T) {
+			th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+				config.RateLimit = app.RateLimit{
+					Enabled: true,
+					Store:   store,
+					Default: ratelimit.Limit{Requests: 5, Period: time.Hour},
+				}
+			})
+			defer th.TearDown(t)
+
+			client := model.NewClient(th.Server.URL)
+			signUp(t, client, th.SqlStore)
+
+			// get requests the given path, returning the response.
+			get := func(t *testing.T, path string, token string) *http.Response {
+				req, err := http.NewRequest(http.MethodGet, th.Server.URL+path, nil)
+				require.NoError(t, err)
+				if token != "" {
+					req.Header.Set(model.HeaderAuthorization, model.AuthorizationBearer+" "+token)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				resp.Body.Close()
+				return resp
+			}
+
+			t.Run("users are limited apart from their IP address", func(t *testing.T) {
+				otherClient := model.NewClient(th.Server.URL)
+				signUp(t, otherClient, th.SqlStore)
+
+				for i := 0; i < 5; i++ {
+					_, err := client.GetMe()
+					require.NoError(t, err)
+				}
+
+				_, err := client.GetMe()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "429")
+
+				_, err = otherClient.GetMe()
+				require.NoError(t, err)
+
+				resp := get(t, "/api/v1/oauth/providers", "")
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			})
+
+			t.Run("responses describe the limit", func(t *testing.T) {
+				resp := get(t, "/api/v1/oauth/providers", "")
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "5", resp.Header.Get("RateLimit-Limit"))
+				assert.Equal(t, "3", resp.Header.Get("RateLimit-Remaining"))
+				assert.Equal(t, "1440", resp.Header.Get("RateLimit-Reset"))
+				assert.Equal(t, "5;w=3600", resp.Header.Get("RateLimit-Policy"))
+
+				for i := 0; i < 3; i++ {
+					resp = get(t, "/api/v1/oauth/providers", "")
+					require.Equal(t, http.StatusOK, resp.StatusCode)
+				}
+
+				resp = get(t, "/api/v1/oauth/providers", "")
+				require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+				assert.Equal(t, "720", resp.Header.Get("Retry-After"))
+				assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+			})
+
+			t.Run("invalid tokens are limited by IP address", func(t *testing.T) {
+				resp := get(t, "/api/v1/users/me", model.NewID())
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			})
+
+			t.Run("routes with their own budget", func(t *testing.T) {
+				resp := get(t, "/api/v1/health", "")
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+
+				// The IP address is out of its default budget, but not of
+				// the login one.
+				_, err := model.NewClient(th.Server.URL).Login(&model.LoginRequest{Email: "nobody@example.com", Password: testPassword})
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "401")
+			})
+
+			t.Run("ingest keys get the ingest budget", func(t *testing.T) {
+				adminClient := model.NewClient(th.Server.URL)
+				signUpAdmin(t, adminClient, th.SqlStore)
+				resp, err := adminClient.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeIngest})
+				require.NoError(t, err)
+
+				repo := "webapp-" + model.NewID()
+				for i := 0; i < 6; i++ {
+					body := strings.NewReader(fmt.Sprintf(`{"repo": %q, "branch": "master", "passed": 1}`, repo))
+					req, err := http.NewRequest(http.MethodPost, th.Server.URL+"/api/v1/cycles", body)
+					require.NoError(t, err)
+					req.Header.Set(model.HeaderApiKey, resp.Key)
+					httpResp, err := http.DefaultClient.Do(req)
+					require.NoError(t, err)
+					httpResp.Body.Close()
+
+					require.Equal(t, http.StatusCreated, httpResp.StatusCode)
+					assert.Equal(t, "6000;w=60", httpResp.Header.Get("RateLimit-Policy"))
+					assert.Equal(t, strconv.Itoa(5999-i), httpResp.Header.Get("RateLimit-Remaining"))
+				}
+			})
+		})
+	}
+}
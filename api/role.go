@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initRole registers role endpoints on the given router.
+func initRole(apiRouter *mux.Router, context *Context) {
+	rolesRouter := apiRouter.PathPrefix("/roles").Subrouter()
+	rolesRouter.Handle("", newAPIPermissionRequiredHandler(context, handleCreateRole, model.PermissionRolesManage)).Methods("POST")
+	rolesRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetRoles, model.PermissionRolesManage)).Methods("GET")
+	rolesRouter.Handle("/{id:[A-Za-z0-9]{26}}/permissions", newAPIPermissionRequiredHandler(context, handleUpdateRolePermissions, model.PermissionRolesManage)).Methods("PUT")
+	rolesRouter.Handle("/{id:[A-Za-z0-9]{26}}/require-mfa", newAPIPermissionRequiredHandler(context, handleSetRoleRequireMFA, model.PermissionRolesManage)).Methods("PUT")
+}
+
+// handleCreateRole responds to POST /api/v1/roles, creating a custom role.
+func handleCreateRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := &model.CreateRoleRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	role, err := c.App.CreateRole(request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action: model.AuditActionRoleCreate,
+		Details: model.StringMap{
+			"role_id":     role.ID,
+			"role":        role.Name,
+			"permissions": strings.Join(role.Permissions, ","),
+		},
+	})
+
+	b, err := json.Marshal(role)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// handleGetRoles responds to GET /api/v1/roles, returning every role along
+// with its permissions.
+func handleGetRoles(c *Context, w http.ResponseWriter, r *http.Request) {
+	roles, err := c.App.GetRoles()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(roles)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleUpdateRolePermissions responds to PUT /api/v1/roles/{id}/permissions,
+// replacing the permissions of a role.
+func handleUpdateRolePermissions(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	request := &model.UpdateRolePermissionsRequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	role, err := c.App.GetRole(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if role == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	previousPermissions := strings.Join(role.Permissions, ",")
+	role, err = c.App.UpdateRolePermissions(role, request.Permissions)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action: model.AuditActionRolePermissionsUpdate,
+		Details: model.StringMap{
+			"role_id":              role.ID,
+			"role":                 role.Name,
+			"previous_permissions": previousPermissions,
+			"permissions":          strings.Join(role.Permissions, ","),
+		},
+	})
+
+	b, err := json.Marshal(role)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleSetRoleRequireMFA responds to PUT /api/v1/roles/{id}/require-mfa,
+// setting whether users with a role must log in with two-factor
+// authentication.
+func handleSetRoleRequireMFA(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	request := &model.RoleRequireMFARequest{}
+	err := decodeJSON(request, r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	role, err := c.App.GetRole(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if role == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	role, err = c.App.SetRoleRequireMFA(role, request.RequireMFA)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(role)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
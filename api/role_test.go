@@ -0,0 +1,92 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	signUpAdmin(t, adminClient, th.SqlStore)
+
+	t.Run("requires the permission to manage roles", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetRoles()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		signUp(t, client, th.SqlStore)
+		_, err = client.CreateRole(&model.CreateRoleRequest{Name: "viewer"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := adminClient.CreateRole(&model.CreateRoleRequest{Name: "Not Valid"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid name")
+
+		_, err = adminClient.CreateRole(&model.CreateRoleRequest{Name: "junk", Permissions: []string{"junk:write"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid permission")
+
+		_, err = adminClient.CreateRole(&model.CreateRoleRequest{Name: model.UserRoleName})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "name exists")
+
+		_, err = adminClient.UpdateRolePermissions(model.NewID(), &model.UpdateRolePermissionsRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("admin role can't be changed", func(t *testing.T) {
+		adminRole, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+		require.NoError(t, err)
+
+		_, err = adminClient.UpdateRolePermissions(adminRole.ID, &model.UpdateRolePermissionsRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "admin role")
+	})
+
+	t.Run("custom role grants its permissions", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		_, err := client.GetRoles()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		name := "managers" + model.NewID()[:8]
+		role, err := adminClient.CreateRole(&model.CreateRoleRequest{
+			Name:        name,
+			Permissions: []string{model.PermissionRolesManage},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, name, role.Name)
+		assert.Equal(t, model.StringList{model.PermissionRolesManage}, role.Permissions)
+
+		err = th.SqlStore.Role().AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		roles, err := client.GetRoles()
+		require.NoError(t, err)
+		assert.NotEmpty(t, roles)
+
+		role, err = adminClient.UpdateRolePermissions(role.ID, &model.UpdateRolePermissionsRequest{
+			Permissions: []string{model.PermissionCyclesRead},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.StringList{model.PermissionCyclesRead}, role.Permissions)
+
+		_, err = client.GetRoles()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// initSession registers the endpoints users manage their sessions with on the
+// given router.
+func initSession(apiRouter *mux.Router, context *Context) {
+	sessionsRouter := apiRouter.PathPrefix("/users/me/sessions").Subrouter()
+	sessionsRouter.Handle("", newAPISessionRequiredHandler(context, handleGetMySessions, false)).Methods("GET")
+	sessionsRouter.Handle("/revoke-others", newAPISessionRequiredHandler(context, handleRevokeMyOtherSessions, false)).Methods("POST")
+	sessionsRouter.Handle("/{id:[A-Za-z0-9]{26}}", newAPISessionRequiredHandler(context, handleRevokeMySession, false)).Methods("DELETE")
+}
+
+// handleGetMySessions responds to GET /api/v1/users/me/sessions, returning the
+// active sessions of the logged in user along with their device.
+func handleGetMySessions(c *Context, w http.ResponseWriter, r *http.Request) {
+	sessions, err := c.App.GetUserSessions(c.Session.UserID, c.Session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(sessions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleRevokeMySession responds to DELETE /api/v1/users/me/sessions/{id},
+// logging the logged in user out of one of their sessions.
+func handleRevokeMySession(c *Context, w http.ResponseWriter, r *http.Request) {
+	revoked, err := c.App.RevokeUserSession(c.Session.UserID, mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if !revoked {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleRevokeMyOtherSessions responds to POST
+// /api/v1/users/me/sessions/revoke-others, logging the logged in user out of
+// every other device.
+func handleRevokeMyOtherSessions(c *Context, w http.ResponseWriter, r *http.Request) {
+	err := c.App.RevokeOtherUserSessions(c.Session.UserID, c.Session.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
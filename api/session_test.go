@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestSessions(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	client := model.NewClientWithHeaders(th.Server.URL, map[string]string{"User-Agent": "laptop"})
+	user := signUp(t, client, th.SqlStore)
+
+	phone := model.NewClientWithHeaders(th.Server.URL, map[string]string{"User-Agent": "phone"})
+	_, err := phone.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+	require.NoError(t, err)
+
+	tablet := model.NewClientWithHeaders(th.Server.URL, map[string]string{"User-Agent": "tablet"})
+	_, err = tablet.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+	require.NoError(t, err)
+
+	sessions, err := client.GetMySessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	var current, phoneSession *model.Session
+	for _, session := range sessions {
+		assert.Empty(t, session.Token)
+		assert.Empty(t, session.CSRFToken)
+		assert.Equal(t, model.SessionSourcePassword, session.Source)
+		assert.NotEmpty(t, session.IPAddress)
+		assert.NotZero(t, session.LastActivityAt)
+		if session.Current {
+			current = session
+		}
+		if session.UserAgent == "phone" {
+			phoneSession = session
+		}
+	}
+	require.NotNil(t, current)
+	assert.Equal(t, "laptop", current.UserAgent)
+	require.NotNil(t, phoneSession)
+	assert.False(t, phoneSession.Current)
+
+	t.Run("cannot revoke the sessions of another user", func(t *testing.T) {
+		otherClient := model.NewClient(th.Server.URL)
+		signUp(t, otherClient, th.SqlStore)
+
+		err := otherClient.RevokeMySession(phoneSession.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = phone.GetMe()
+		require.NoError(t, err)
+	})
+
+	t.Run("revoke a session", func(t *testing.T) {
+		err := client.RevokeMySession(phoneSession.ID)
+		require.NoError(t, err)
+
+		_, err = phone.GetMe()
+		require.Error(t, err)
+
+		sessions, err := client.GetMySessions()
+		require.NoError(t, err)
+		assert.Len(t, sessions, 2)
+	})
+
+	t.Run("revoke all other sessions", func(t *testing.T) {
+		err := client.RevokeMyOtherSessions()
+		require.NoError(t, err)
+
+		_, err = tablet.GetMe()
+		require.Error(t, err)
+
+		_, err = client.GetMe()
+		require.NoError(t, err)
+
+		sessions, err := client.GetMySessions()
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+	})
+}
+
+func TestSessionLifetime(t *testing.T) {
+	th := setupApiTestHelperWithConfig(t, func(config *app.Config) {
+		config.Sessions.User = model.SessionLifetime{MaxAge: 25 * time.Hour, IdleTimeout: 24 * time.Hour}
+		config.Sessions.Admin = model.SessionLifetime{IdleTimeout: 12 * time.Hour}
+	})
+	defer th.TearDown(t)
+
+	// getSession returns the session of the given client.
+	getSession := func(t *testing.T, client *model.Client) *model.Session {
+		sessions, err := client.GetMySessions()
+		require.NoError(t, err)
+		for _, session := range sessions {
+			if session.Current {
+				return session
+			}
+		}
+		require.Fail(t, "no current session")
+		return nil
+	}
+
+	client := model.NewClient(th.Server.URL)
+	user := signUp(t, client, th.SqlStore)
+
+	t.Run("sessions of users get the idle timeout", func(t *testing.T) {
+		session := getSession(t, client)
+		assert.Equal(t, session.CreateAt+(24*time.Hour).Milliseconds(), session.ExpiresAt)
+	})
+
+	t.Run("sessions of admins get the admin idle timeout", func(t *testing.T) {
+		adminClient := model.NewClient(th.Server.URL)
+		admin := signUpAdmin(t, adminClient, th.SqlStore)
+		_, err := adminClient.Login(&model.LoginRequest{Email: admin.Email, Password: testPassword})
+		require.NoError(t, err)
+
+		session := getSession(t, adminClient)
+		assert.Equal(t, session.CreateAt+(12*time.Hour).Milliseconds(), session.ExpiresAt)
+	})
+
+	t.Run("activity slides the expiry up to the max age", func(t *testing.T) {
+		session := getSession(t, client)
+
+		// Pretend the session was last used an hour ago.
+		lastActivityAt := session.LastActivityAt - time.Hour.Milliseconds()
+		err := th.SqlStore.Session().UpdateSessionActivity(session.ID, lastActivityAt, lastActivityAt+(24*time.Hour).Milliseconds())
+		require.NoError(t, err)
+
+		_, err = client.GetMe()
+		require.NoError(t, err)
+
+		extended := getSession(t, client)
+		assert.Greater(t, extended.LastActivityAt, lastActivityAt)
+		assert.Equal(t, session.CreateAt+(25*time.Hour).Milliseconds(), extended.ExpiresAt)
+	})
+
+	t.Run("extending a session attaches its cookies again", func(t *testing.T) {
+		body, err := json.Marshal(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, th.Server.URL+"/api/v1/users/login", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(model.HeaderRequestedWith, model.HeaderRequestedWithXML)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		token := resp.Header.Get(model.SessionHeader)
+		session, err := th.SqlStore.Session().GetSessionByToken(token)
+		require.NoError(t, err)
+		require.NotNil(t, session)
+
+		getMe := func() *http.Response {
+			req, err := http.NewRequest(http.MethodGet, th.Server.URL+"/api/v1/users/me", nil)
+			require.NoError(t, err)
+			req.AddCookie(&http.Cookie{Name: model.SessionCookieToken, Value: token})
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			return resp
+		}
+
+		resp = getMe()
+		assert.Empty(t, resp.Cookies(), "activity is throttled")
+
+		lastActivityAt := session.LastActivityAt - time.Hour.Milliseconds()
+		err = th.SqlStore.Session().UpdateSessionActivity(session.ID, lastActivityAt, lastActivityAt+(24*time.Hour).Milliseconds())
+		require.NoError(t, err)
+
+		resp = getMe()
+		var found bool
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == model.SessionCookieToken {
+				found = true
+				assert.Equal(t, token, cookie.Value)
+				assert.Greater(t, cookie.MaxAge, int((23 * time.Hour).Seconds()))
+			}
+		}
+		assert.True(t, found)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,19 +19,21 @@ func initUser(apiRouter *mux.Router, context *Context) {
 	rand.Seed(time.Now().UnixNano())
 
 	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
-	usersRouter.Handle("/signup", newAPIHandler(context, handleSignUp)).Methods("POST")
-	usersRouter.Handle("/login", newAPIHandler(context, handleLogin)).Methods("POST")
+	usersRouter.Handle("/signup", newAPIHandler(context, handleSignUp).withRateLimit("signup", signUpRateLimit)).Methods("POST")
+	usersRouter.Handle("/login", newAPIHandler(context, handleLogin).withRateLimit("auth", authRateLimit)).Methods("POST")
 	usersRouter.Handle("/logout", newAPIHandler(context, handleLogout)).Methods("POST")
-	usersRouter.Handle("/forgot-password", newAPIHandler(context, handleForgotPassword)).Methods("POST")
-	usersRouter.Handle("/reset-password-complete", newAPIHandler(context, handleResetPassword)).Methods("POST")
+	usersRouter.Handle("/forgot-password", newAPIHandler(context, handleForgotPassword).withRateLimit("auth", authRateLimit)).Methods("POST")
+	usersRouter.Handle("/reset-password-complete", newAPIHandler(context, handleResetPassword).withRateLimit("auth", authRateLimit)).Methods("POST")
 	usersRouter.Handle("/verify-email", newAPISessionRequiredHandler(context, handleVerifyEmailStart, false)).Methods("POST")
-	usersRouter.Handle("/verify-email-complete", newAPIHandler(context, handleVerifyEmailComplete)).Methods("POST")
+	usersRouter.Handle("/verify-email-complete", newAPIHandler(context, handleVerifyEmailComplete).withRateLimit("auth", authRateLimit)).Methods("POST")
 	usersRouter.Handle("/me", newAPISessionRequiredHandler(context, handleGetMe, false)).Methods("GET")
 	usersRouter.Handle("/me", newAPISessionRequiredHandler(context, handleUpdateMe, true)).Methods("PUT")
 	usersRouter.Handle("/me/password", newAPISessionRequiredHandler(context, handleUpdatePassword, true)).Methods("PUT")
 }
 
-// handleSignUp responds to POST /api/v1/users/signup, creating a user.
+// handleSignUp responds to POST /api/v1/users/signup, creating a user. The
+// configured signup mode can require an invitation token, whose invitation
+// is then accepted.
 func handleSignUp(c *Context, w http.ResponseWriter, r *http.Request) {
 	sr := &model.SignUpRequest{}
 	err := decodeJSON(sr, r.Body)
@@ -40,17 +43,43 @@ func handleSignUp(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var invitation *model.Invitation
+	if sr.InvitationToken != "" {
+		invitation, err = c.App.GetInvitationByToken(sr.InvitationToken)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+		if invitation == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			c.writeAndLogError(w, errors.New("invalid invitation"))
+			return
+		}
+	}
+
+	err = c.App.CheckSignup(sr.Email, invitation)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		c.writeAndLogError(w, err)
+		return
+	}
+
 	user := &model.User{
 		FirstName: sr.FirstName,
 		LastName:  sr.LastName,
 		Email:     sr.Email,
 		Password:  sr.Password,
+		Locale:    sr.Locale,
 	}
 
-	err = user.IsValidPassword()
-	if err != nil {
+	if !checkPassword(c, w, user, sr.Password) {
+		return
+	}
+
+	if user.Locale != "" && !c.App.IsSupportedLocale(user.Locale) {
 		w.WriteHeader(http.StatusBadRequest)
-		c.writeAndLogError(w, err)
+		c.writeAndLogError(w, errors.Errorf("unsupported locale %s", user.Locale))
 		return
 	}
 
@@ -61,7 +90,29 @@ func handleSignUp(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.App.User().Login(w, r, user)
+	if invitation != nil {
+		err = c.App.AcceptInvitation(user, invitation)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionInvitationAccept,
+			ActorID:  user.ID,
+			TargetID: user.ID,
+			Details:  invitation.AuditDetails(),
+		})
+
+		user, err = c.App.User().Get(user.ID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+	}
+
+	err = c.App.Login(w, r, user, model.SessionSourcePassword)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, err)
@@ -84,7 +135,26 @@ func handleSignUp(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// checkPassword checks the user can choose the given password, responding
+// with the reasons it was rejected otherwise.
+func checkPassword(c *Context, w http.ResponseWriter, user *model.User, password string) bool {
+	reasons, err := c.App.CheckPassword(user, password)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, errors.Wrap(err, "failed to check password"))
+		return false
+	}
+	if len(reasons) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogInvalidPasswordError(w, &model.InvalidPasswordError{Reasons: reasons})
+		return false
+	}
+
+	return true
+}
+
 // handleLogin responds to POST /api/v1/users/login, logging the user in.
+// Users with two-factor authentication get a challenge instead of a session.
 func handleLogin(c *Context, w http.ResponseWriter, r *http.Request) {
 	lr := &model.LoginRequest{}
 	err := decodeJSON(lr, r.Body)
@@ -94,25 +164,110 @@ func handleLogin(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkLoginAttempts(c, w, r, model.LoginAttemptActionLogin, lr.Email) {
+		return
+	}
+
 	user, err := c.App.User().AuthenticateUserForLogin(lr.Email, lr.Password)
 	if err != nil {
+		recordLoginFailure(c, r, model.LoginAttemptActionLogin, lr.Email)
+		recordFailedLoginAudit(c, r, lr.Email, "invalid credentials")
 		w.WriteHeader(http.StatusUnauthorized)
 		c.writeAndLogErrorWithFields(w, err, logrus.Fields{"email": lr.Email})
 		return
 	}
 
+	err = c.App.ClearLoginFailures(model.LoginAttemptActionLogin, user.Email)
+	if err != nil {
+		c.Logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to clear failed login attempts")
+	}
+
 	if user.State != model.UserStateActive {
+		recordFailedLoginAudit(c, r, user.Email, "account locked")
 		w.WriteHeader(http.StatusLocked)
 		c.writeAndLogErrorWithFields(w, errors.New("Attempt to login to locked account"), logrus.Fields{"email": user.Email})
 		return
 	}
 
-	err = c.App.User().Login(w, r, user)
+	loginUser(c, w, r, user, model.SessionSourcePassword)
+}
+
+// recordFailedLoginAudit records a failed login to the account of the given
+// email in the audit log.
+func recordFailedLoginAudit(c *Context, r *http.Request, email, reason string) {
+	// Nobody is authenticated by a failed login, whatever the session of
+	// the request.
+	entry := &model.AuditLog{
+		Action:    model.AuditActionLoginFailed,
+		ActorType: model.AuditActorAnonymous,
+		Details:   model.StringMap{"email": email, "reason": reason},
+	}
+
+	user, err := c.App.User().GetByEmail(email)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to get the user of a failed login")
+	} else if user != nil {
+		entry.TargetID = user.ID
+	}
+
+	recordAudit(c, r, entry)
+}
+
+// recordLoginAudit records the login of the given user in the audit log.
+func recordLoginAudit(c *Context, r *http.Request, user *model.User, source string) {
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionLogin,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Details:  model.StringMap{"source": source},
+	})
+}
+
+// loginUser logs the given user in and writes them to the response. If the
+// user must provide a second factor first, the challenge to complete through
+// /users/login/mfa is written instead with a 202 status code. Users who lost
+// the access granted by their GitHub membership are rejected whatever the
+// login method.
+func loginUser(c *Context, w http.ResponseWriter, r *http.Request, user *model.User, source string) {
+	allowed, err := c.App.IsGithubAccessAllowed(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if !allowed {
+		recordFailedLoginAudit(c, r, user.Email, "github access revoked")
+		w.WriteHeader(http.StatusForbidden)
+		c.writeAndLogErrorWithFields(w, errors.New("Attempt to login without GitHub access"), logrus.Fields{"email": user.Email})
+		return
+	}
+
+	challenge, err := c.App.CreateMFAChallenge(user.ID, source)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, err)
 		return
 	}
+	if challenge != nil {
+		b, err := json.Marshal(challenge)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(b)
+		return
+	}
+
+	err = c.App.Login(w, r, user, source)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordLoginAudit(c, r, user, source)
 
 	user.Sanitize()
 	b, err := json.Marshal(user)
@@ -128,6 +283,13 @@ func handleLogin(c *Context, w http.ResponseWriter, r *http.Request) {
 // handleLogout responds to POST /api/v1/users/logout, logging the user out.
 func handleLogout(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.App.User().Logout(w, r, c.Session.ID)
+
+	if c.Session.UserID != "" {
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionLogout,
+			TargetID: c.Session.UserID,
+		})
+	}
 }
 
 // handleVerifyEmailStart responds to POST /api/v1/users/verify-email, sending
@@ -158,7 +320,7 @@ func handleVerifyEmailStart(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = c.App.SendVerifyEmailEmail(user.Email, c.App.Config().SiteURL, token)
+	err = c.App.SendVerifyEmailEmail(user.Email, user.Locale, c.App.Config().SiteURL, token)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, err)
@@ -179,6 +341,25 @@ func handleVerifyEmailComplete(c *Context, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Codes are short, so attempts are also tracked against the account of
+	// the logged in user, if any.
+	var accountEmail string
+	if c.Session.UserID != "" {
+		sessionUser, err := c.App.User().Get(c.Session.UserID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			c.writeAndLogError(w, err)
+			return
+		}
+		if sessionUser != nil {
+			accountEmail = sessionUser.Email
+		}
+	}
+
+	if !checkLoginAttempts(c, w, r, model.LoginAttemptActionVerifyEmail, accountEmail) {
+		return
+	}
+
 	token, err := c.App.Store().Token().GetToken(ver.Token)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -188,6 +369,7 @@ func handleVerifyEmailComplete(c *Context, w http.ResponseWriter, r *http.Reques
 	if token == nil ||
 		token.Type != model.TokenTypeVerifyEmail ||
 		token.CreateAt < model.GetMillis()-model.TokenDefaultExpiryTime {
+		recordLoginFailure(c, r, model.LoginAttemptActionVerifyEmail, accountEmail)
 		w.WriteHeader(http.StatusBadRequest)
 		c.writeAndLogError(w, errors.New("invalid token"))
 		return
@@ -206,6 +388,12 @@ func handleVerifyEmailComplete(c *Context, w http.ResponseWriter, r *http.Reques
 		c.writeAndLogError(w, errors.Wrap(err, "failed to get user by email"))
 		return
 	}
+	if user == nil {
+		recordLoginFailure(c, r, model.LoginAttemptActionVerifyEmail, accountEmail)
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
 
 	err = c.App.User().VerifyEmail(user.ID, user.Email)
 	if err != nil {
@@ -214,6 +402,13 @@ func handleVerifyEmailComplete(c *Context, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if accountEmail != "" {
+		err = c.App.ClearLoginFailures(model.LoginAttemptActionVerifyEmail, accountEmail)
+		if err != nil {
+			c.Logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to clear failed verify email attempts")
+		}
+	}
+
 	err = c.App.Store().Token().DeleteToken(token.Token)
 	if err != nil {
 		// The email was verified, but the token used for this could not be
@@ -255,7 +450,7 @@ func handleForgotPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.App.SendPasswordResetEmail(fpr.Email, c.App.Config().SiteURL, token)
+	err = c.App.SendPasswordResetEmail(fpr.Email, user.Locale, c.App.Config().SiteURL, token)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, err)
@@ -276,9 +471,9 @@ func handleResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !model.IsValidPassword(rpr.Password) {
-		w.WriteHeader(http.StatusBadRequest)
-		c.writeAndLogError(w, errors.New("invalid password"))
+	// Reset tokens aren't tied to an account until they are found, so
+	// attempts are only tracked by IP address.
+	if !checkLoginAttempts(c, w, r, model.LoginAttemptActionResetPassword, "") {
 		return
 	}
 
@@ -291,6 +486,7 @@ func handleResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	if token == nil ||
 		token.Type != model.TokenTypeResetPassword ||
 		token.CreateAt < model.GetMillis()-model.TokenDefaultExpiryTime {
+		recordLoginFailure(c, r, model.LoginAttemptActionResetPassword, "")
 		w.WriteHeader(http.StatusBadRequest)
 		c.writeAndLogError(w, errors.New("invalid token"))
 		return
@@ -309,13 +505,45 @@ func handleResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.writeAndLogError(w, errors.Wrap(err, "failed to get user by email"))
 		return
 	}
+	if user == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("invalid token"))
+		return
+	}
 
-	err = c.App.Store().User().UpdatePassword(user.ID, rpr.Password)
+	allowed, err := c.App.IsGithubAccessAllowed(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if !allowed {
+		w.WriteHeader(http.StatusForbidden)
+		c.writeAndLogErrorWithFields(w, errors.New("Attempt to reset the password without GitHub access"), logrus.Fields{"email": user.Email})
+		return
+	}
+
+	if !checkPassword(c, w, user, rpr.Password) {
+		return
+	}
+
+	err = c.App.UpdatePassword(user, rpr.Password)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, errors.Wrap(err, "failed to update user password"))
 		return
 	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionPasswordReset,
+		TargetID: user.ID,
+	})
+
+	// The reset proves the ownership of the account, so it also lifts its
+	// lockouts.
+	err = c.App.ClearLockout(model.LoginAttemptScopeAccount, user.Email)
+	if err != nil {
+		c.Logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to clear account lockout")
+	}
 
 	// Invalidate all the user sessions
 	err = c.App.Store().Session().DeleteSessionsForUser(user.ID)
@@ -353,12 +581,6 @@ func handleUpdatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !model.IsValidPassword(upr.NewPassword) {
-		w.WriteHeader(http.StatusBadRequest)
-		c.writeAndLogError(w, errors.New("invalid password"))
-		return
-	}
-
 	user, err := c.App.User().Get(c.Session.UserID)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
@@ -373,12 +595,20 @@ func handleUpdatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.App.Store().User().UpdatePassword(user.ID, upr.NewPassword)
+	if !checkPassword(c, w, user, upr.NewPassword) {
+		return
+	}
+
+	err = c.App.UpdatePassword(user, upr.NewPassword)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		c.writeAndLogError(w, errors.Wrap(err, "failed to update user password"))
 		return
 	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionPasswordChange,
+		TargetID: user.ID,
+	})
 
 	// Invalidate all the user sessions
 	err = c.App.Store().Session().DeleteSessionsForUser(user.ID)
@@ -389,7 +619,7 @@ func handleUpdatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Re-login the user with the new session
-	err = c.App.User().Login(w, r, user)
+	err = c.App.Login(w, r, user, model.SessionSourcePassword)
 	if err != nil {
 		c.Logger.WithError(err).WithField("userid", user.ID).Warn("error trying to re-login the user after the password change")
 	}
@@ -423,6 +653,7 @@ func handleGetMe(c *Context, w http.ResponseWriter, r *http.Request) {
 }
 
 // handleUpdateMe responds to PUT /api/v1/users/me, updating the logged in user.
+// A new email is only requested, see RequestEmailChange.
 func handleUpdateMe(c *Context, w http.ResponseWriter, r *http.Request) {
 	reqUser, err := model.UserFromReader(r.Body)
 	if err != nil {
@@ -451,28 +682,31 @@ func handleUpdateMe(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// If email change is requested we change the user to an unverified
-	// stat and we send the verification email again
-	if user.Email != reqUser.Email {
-		err = c.App.User().UnverifyEmail(user.ID, user.Email)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			c.writeAndLogError(w, err)
-			return
-		}
-		token, err := c.App.CreateAndStoreVerifyEmailToken(reqUser.Email)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			c.writeAndLogError(w, err)
-			return
-		}
+	if reqUser.Locale == "" {
+		reqUser.Locale = user.Locale
+	} else if !c.App.IsSupportedLocale(reqUser.Locale) {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.Errorf("unsupported locale %s", reqUser.Locale))
+		return
+	}
 
-		err = c.App.SendVerifyEmailEmail(reqUser.Email, c.App.Config().SiteURL, token)
+	// A new email only replaces the current one once confirmed from the new
+	// address, until then it is pending and the current one is notified.
+	newEmail := strings.ToLower(reqUser.Email)
+	reqUser.Email = user.Email
+	if newEmail != user.Email {
+		user.Locale = reqUser.Locale
+		err = c.App.RequestEmailChange(user, newEmail)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusBadRequest)
 			c.writeAndLogError(w, err)
 			return
 		}
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionEmailChangeRequest,
+			TargetID: user.ID,
+			Details:  model.StringMap{"from": user.Email, "to": newEmail},
+		})
 	}
 
 	user, err = c.App.User().Update(reqUser)
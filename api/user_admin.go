@@ -0,0 +1,368 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// initUserAdmin registers the endpoints used to manage other users on the
+// given router.
+func initUserAdmin(apiRouter *mux.Router, context *Context) {
+	usersRouter := apiRouter.PathPrefix("/users").Subrouter()
+	usersRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetUsers, model.PermissionUsersManage)).Methods("GET")
+
+	userRouter := usersRouter.PathPrefix("/{id:[A-Za-z0-9]{26}}").Subrouter()
+	userRouter.Handle("", newAPIPermissionRequiredHandler(context, handleGetUser, model.PermissionUsersManage)).Methods("GET")
+	userRouter.Handle("", newAPIPermissionRequiredHandler(context, handleDeleteUser, model.PermissionUsersManage)).Methods("DELETE")
+	userRouter.Handle("/lock", newAPIPermissionRequiredHandler(context, handleLockUser, model.PermissionUsersManage)).Methods("POST")
+	userRouter.Handle("/unlock", newAPIPermissionRequiredHandler(context, handleUnlockUser, model.PermissionUsersManage)).Methods("POST")
+	userRouter.Handle("/verify-email", newAPIPermissionRequiredHandler(context, handleForceVerifyEmail, model.PermissionUsersManage)).Methods("POST")
+	userRouter.Handle("/sessions", newAPIPermissionRequiredHandler(context, handleRevokeUserSessions, model.PermissionUsersManage)).Methods("DELETE")
+	userRouter.Handle("/mfa", newAPIPermissionRequiredHandler(context, handleResetUserMFA, model.PermissionUsersManage)).Methods("DELETE")
+	// Granting roles can grant any permission, so it requires managing roles.
+	userRouter.Handle("/roles/{role_id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleAddUserRole, model.PermissionRolesManage)).Methods("PUT")
+	userRouter.Handle("/roles/{role_id:[A-Za-z0-9]{26}}", newAPIPermissionRequiredHandler(context, handleRemoveUserRole, model.PermissionRolesManage)).Methods("DELETE")
+}
+
+// getUserFromRequest returns the user matching the id of the request path. It
+// writes the error response and returns nil if the user can't be found.
+func getUserFromRequest(c *Context, w http.ResponseWriter, r *http.Request) *model.User {
+	user, err := c.App.GetUserWithRoles(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return nil
+	}
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+
+	return user
+}
+
+// checkCanManageAdmin checks that the session can lock, delete or reset the
+// 2FA of the given user. Doing so to an admin could lock them out of the
+// permissions users:manage doesn't grant, so it requires managing roles. It
+// writes the error response and returns false otherwise.
+func checkCanManageAdmin(c *Context, w http.ResponseWriter, user *model.User) bool {
+	if !user.HasRole(model.AdminRoleName) {
+		return true
+	}
+
+	canManageRoles, err := c.App.User().HasPermission(c.Session.UserID, model.PermissionRolesManage)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return false
+	}
+	if !canManageRoles {
+		w.WriteHeader(http.StatusForbidden)
+		c.writeAndLogError(w, errors.New("managing an admin requires managing roles"))
+		return false
+	}
+
+	return true
+}
+
+// parseUserFilter builds a user filter from the term, page and per_page query
+// parameters.
+func parseUserFilter(r *http.Request) (*model.UserFilter, error) {
+	query := r.URL.Query()
+	filter := &model.UserFilter{
+		Term:    query.Get("term"),
+		PerPage: model.UsersDefaultPerPage,
+	}
+
+	var err error
+	if page := query.Get("page"); page != "" {
+		filter.Page, err = strconv.Atoi(page)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid page")
+		}
+	}
+	if perPage := query.Get("per_page"); perPage != "" {
+		filter.PerPage, err = strconv.Atoi(perPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid per page")
+		}
+	}
+
+	return filter, filter.IsValid()
+}
+
+// handleGetUsers responds to GET /api/v1/users, returning a page of users
+// optionally filtered by a search term.
+func handleGetUsers(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	users, err := c.App.GetUsers(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	b, err := json.Marshal(users)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleGetUser responds to GET /api/v1/users/{id}, returning a user along with
+// their roles.
+func handleGetUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	b, err := json.Marshal(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write(b)
+}
+
+// handleLockUser responds to POST /api/v1/users/{id}/lock, preventing a user
+// from logging in and revoking their sessions.
+func handleLockUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+	if !checkCanManageAdmin(c, w, user) {
+		return
+	}
+
+	if user.ID == c.Session.UserID {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("you can't lock your own account"))
+		return
+	}
+
+	err := c.App.LockUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionUserLock,
+		TargetID: user.ID,
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleUnlockUser responds to POST /api/v1/users/{id}/unlock, allowing a
+// locked user to log in again.
+func handleUnlockUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	err := c.App.UnlockUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionUserUnlock,
+		TargetID: user.ID,
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleForceVerifyEmail responds to POST /api/v1/users/{id}/verify-email,
+// marking the email of a user as verified.
+func handleForceVerifyEmail(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	err := c.App.ForceVerifyEmail(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleAddUserRole responds to PUT /api/v1/users/{id}/roles/{role_id},
+// granting a role to a user.
+func handleAddUserRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	role, err := c.App.GetRole(mux.Vars(r)["role_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if role == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	added, err := c.App.AddUserRole(user.ID, role.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if added {
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionRoleAdd,
+			TargetID: user.ID,
+			Details:  model.StringMap{"role_id": role.ID, "role": role.Name},
+		})
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleRemoveUserRole responds to DELETE /api/v1/users/{id}/roles/{role_id},
+// removing a role from a user.
+func handleRemoveUserRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	role, err := c.App.GetRole(mux.Vars(r)["role_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if role == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if user.ID == c.Session.UserID && role.Name == model.AdminRoleName {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("you can't remove your own admin role"))
+		return
+	}
+
+	removed, err := c.App.RemoveUserRole(user.ID, role.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	if removed {
+		recordAudit(c, r, &model.AuditLog{
+			Action:   model.AuditActionRoleRemove,
+			TargetID: user.ID,
+			Details:  model.StringMap{"role_id": role.ID, "role": role.Name},
+		})
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleRevokeUserSessions responds to DELETE /api/v1/users/{id}/sessions,
+// logging a user out of every device.
+func handleRevokeUserSessions(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+
+	err := c.App.RevokeUserSessions(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleResetUserMFA responds to DELETE /api/v1/users/{id}/mfa, removing the
+// authenticator and recovery codes of a user who lost access to them.
+func handleResetUserMFA(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+	if !checkCanManageAdmin(c, w, user) {
+		return
+	}
+
+	err := c.App.ResetMFA(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionMFAReset,
+		TargetID: user.ID,
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+// handleDeleteUser responds to DELETE /api/v1/users/{id}, permanently deleting
+// a user.
+func handleDeleteUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	user := getUserFromRequest(c, w, r)
+	if user == nil {
+		return
+	}
+	if !checkCanManageAdmin(c, w, user) {
+		return
+	}
+
+	if user.ID == c.Session.UserID {
+		w.WriteHeader(http.StatusBadRequest)
+		c.writeAndLogError(w, errors.New("you can't delete your own account"))
+		return
+	}
+
+	err := c.App.DeleteUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		c.writeAndLogError(w, err)
+		return
+	}
+	recordAudit(c, r, &model.AuditLog{
+		Action:   model.AuditActionUserDelete,
+		TargetID: user.ID,
+		Details:  model.StringMap{"email": user.Email, "roles": strings.Join(user.Roles, ",")},
+	})
+
+	w.Write([]byte(`{"status": "ok"}`))
+}
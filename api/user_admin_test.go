@@ -0,0 +1,270 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAdmin(t *testing.T) {
+	th := SetupApiTestHelper(t)
+	defer th.TearDown(t)
+
+	adminClient := model.NewClient(th.Server.URL)
+	admin := signUpAdmin(t, adminClient, th.SqlStore)
+
+	t.Run("requires the permission to manage users", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.GetUsers("", 0, 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		user := signUp(t, client, th.SqlStore)
+		_, err = client.GetUsers("", 0, 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		err = client.LockUser(user.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+	})
+
+	t.Run("list and search users", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		users, err := adminClient.GetUsers(user.Email, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, user.ID, users[0].ID)
+		assert.Empty(t, users[0].Password)
+
+		users, err = adminClient.GetUsers("", 0, 1)
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+
+		_, err = adminClient.GetUsers("", 0, model.UsersMaxPerPage+1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+
+	t.Run("get user with roles", func(t *testing.T) {
+		user, err := adminClient.GetUser(admin.ID)
+		require.NoError(t, err)
+		assert.Equal(t, admin.Email, user.Email)
+		assert.ElementsMatch(t, []string{model.AdminRoleName, model.UserRoleName}, user.Roles)
+		assert.True(t, user.IsAdmin)
+		assert.Empty(t, user.Password)
+
+		_, err = adminClient.GetUser(model.NewID())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("lock and unlock user", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		err := adminClient.LockUser(user.ID)
+		require.NoError(t, err)
+
+		_, err = client.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "423")
+
+		err = adminClient.UnlockUser(user.ID)
+		require.NoError(t, err)
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+
+		err = adminClient.LockUser(admin.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "own account")
+	})
+
+	t.Run("locked users can't use their keys and tokens", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUpAdmin(t, client, th.SqlStore)
+
+		tokenResp, err := client.CreatePersonalAccessToken(&model.CreatePersonalAccessTokenRequest{Name: "script", Scope: model.PersonalAccessTokenScopeRead})
+		require.NoError(t, err)
+		keyResp, err := client.CreateAPIKey(&model.CreateAPIKeyRequest{Name: "ci", Scope: model.APIKeyScopeRead})
+		require.NoError(t, err)
+		patClient := model.NewClientWithPersonalAccessToken(th.Server.URL, tokenResp.Token)
+		apiKeyClient := model.NewClientWithAPIKey(th.Server.URL, keyResp.Key)
+
+		err = adminClient.LockUser(user.ID)
+		require.NoError(t, err)
+
+		_, err = patClient.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+		_, err = apiKeyClient.GetUsers("", 0, 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		err = adminClient.UnlockUser(user.ID)
+		require.NoError(t, err)
+
+		_, err = patClient.GetMe()
+		require.NoError(t, err)
+		_, err = apiKeyClient.GetUsers("", 0, 10)
+		require.NoError(t, err)
+	})
+
+	t.Run("managing admins requires managing roles", func(t *testing.T) {
+		role, err := adminClient.CreateRole(&model.CreateRoleRequest{Name: "user-managers-" + model.NewID()[:8], Permissions: []string{model.PermissionUsersManage}})
+		require.NoError(t, err)
+
+		client := model.NewClient(th.Server.URL)
+		manager := signUp(t, client, th.SqlStore)
+		require.NoError(t, th.SqlStore.Role().AddUserRole(manager.ID, role.ID))
+
+		otherAdmin := signUpAdmin(t, model.NewClient(th.Server.URL), th.SqlStore)
+		err = client.LockUser(otherAdmin.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		err = client.ResetUserMFA(otherAdmin.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		err = client.DeleteUser(otherAdmin.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		user := signUp(t, model.NewClient(th.Server.URL), th.SqlStore)
+		err = client.LockUser(user.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("force verify email", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		resp, err := client.SignUp(&model.SignUpRequest{Email: testlib.GetTestEmail(), Password: testPassword})
+		require.NoError(t, err)
+		assert.False(t, resp.User.EmailVerified)
+
+		err = adminClient.ForceVerifyUserEmail(resp.User.ID)
+		require.NoError(t, err)
+
+		user, err := adminClient.GetUser(resp.User.ID)
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+	})
+
+	t.Run("add and remove roles", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		role, err := adminClient.CreateRole(&model.CreateRoleRequest{
+			Name:        "user-managers" + model.NewID()[:8],
+			Permissions: []string{model.PermissionUsersManage},
+		})
+		require.NoError(t, err)
+
+		err = adminClient.AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+		err = adminClient.AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		fetched, err := adminClient.GetUser(user.ID)
+		require.NoError(t, err)
+		assert.Contains(t, fetched.Roles, role.Name)
+
+		_, err = client.GetUsers("", 0, 10)
+		require.NoError(t, err)
+
+		err = adminClient.RemoveUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		_, err = client.GetUsers("", 0, 10)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+
+		err = adminClient.AddUserRole(user.ID, model.NewID())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		adminRole, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+		require.NoError(t, err)
+		err = adminClient.RemoveUserRole(admin.ID, adminRole.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "own admin role")
+	})
+
+	t.Run("revoke sessions", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		_, err := client.GetMe()
+		require.NoError(t, err)
+
+		err = adminClient.RevokeUserSessions(user.ID)
+		require.NoError(t, err)
+
+		_, err = client.GetMe()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		err := adminClient.DeleteUser(user.ID)
+		require.NoError(t, err)
+
+		_, err = adminClient.GetUser(user.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+
+		err = adminClient.DeleteUser(admin.ID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "own account")
+
+		err = adminClient.DeleteUser(model.NewID())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("delete user removes their email tokens", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		err := client.VerifyEmailStart()
+		require.NoError(t, err)
+		emails, err := client.GetDevEmails(user.Email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		verifyToken := emails[0].Tokens[model.TokenTypeVerifyEmail]
+		require.NotEmpty(t, verifyToken)
+
+		err = adminClient.DeleteUser(user.ID)
+		require.NoError(t, err)
+
+		token, err := th.SqlStore.Token().GetToken(verifyToken)
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		// A token left behind for a deleted user is still rejected.
+		extra, err := model.CreateTokenTypeResetPasswordExtra(user.Email)
+		require.NoError(t, err)
+		token, err = th.SqlStore.Token().CreateToken(model.NewToken(model.TokenTypeVerifyEmail, extra))
+		require.NoError(t, err)
+
+		err = model.NewClient(th.Server.URL).VerifyEmailComplete(&model.VerifyEmailRequest{Token: token.Token})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+	})
+}
@@ -4,10 +4,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/saturninoabril/dashboard-server/internal/password"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/testlib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUsers(t *testing.T) {
@@ -41,6 +43,30 @@ func TestUsers(t *testing.T) {
 		assert.Nil(t, user)
 	})
 
+	t.Run("log in upgrades bcrypt password hashes", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+		user := signUp(t, client, th.SqlStore)
+
+		user, err := th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		bcryptHash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+		require.NoError(t, err)
+		err = th.SqlStore.User().UpdatePasswordHash(user.ID, user.Password, string(bcryptHash))
+		require.NoError(t, err)
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+
+		user, err = th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+		hasher := password.NewHasher(th.App.Config().Passwords.PasswordHashParams(), 0)
+		assert.False(t, hasher.NeedsRehash(user.Password))
+
+		_, err = client.Login(&model.LoginRequest{Email: user.Email, Password: testPassword})
+		require.NoError(t, err)
+	})
+
 	t.Run("update password", func(t *testing.T) {
 		client := model.NewClient(th.Server.URL)
 
@@ -72,7 +98,7 @@ func TestUsers(t *testing.T) {
 
 		// Check if session is present for that user
 		sessionHeader := strings.Split(client.Headers()[model.HeaderAuthorization], " ")
-		session, err := th.SqlStore.Session().GetSession(sessionHeader[1])
+		session, err := th.SqlStore.Session().GetSessionByToken(sessionHeader[1])
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -81,7 +107,7 @@ func TestUsers(t *testing.T) {
 
 		// CHeck that session is in place again after the login
 		sessionHeader = strings.Split(client.Headers()[model.HeaderAuthorization], " ")
-		session, err = th.SqlStore.Session().GetSession(sessionHeader[1])
+		session, err = th.SqlStore.Session().GetSessionByToken(sessionHeader[1])
 		require.NoError(t, err)
 		require.NotNil(t, session)
 
@@ -194,6 +220,48 @@ func TestUsers(t *testing.T) {
 
 		err = client.VerifyEmailStart()
 		assert.NoError(t, err)
+
+		emails := th.getSentEmails(t, email)
+		require.Len(t, emails, 1)
+		assert.Contains(t, emails[0], "Subject: Verify Email")
+	})
+
+	t.Run("localized emails", func(t *testing.T) {
+		client := model.NewClient(th.Server.URL)
+
+		_, err := client.SignUp(&model.SignUpRequest{Email: testlib.GetTestEmail(), Password: testPassword, Locale: "xx"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		email := testlib.GetTestEmail()
+		resp, err := client.SignUp(&model.SignUpRequest{Email: email, Password: testPassword, Locale: "es"})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, "es", resp.User.Locale)
+
+		err = client.VerifyEmailStart()
+		require.NoError(t, err)
+
+		emails := th.getSentEmails(t, email)
+		require.Len(t, emails, 1)
+		assert.Contains(t, emails[0], "Subject: Verifica tu correo")
+
+		user := resp.User
+		user.Locale = "xx"
+		_, err = client.UpdateMe(user)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+
+		// An empty locale keeps the current one.
+		user.Locale = ""
+		user, err = client.UpdateMe(user)
+		require.NoError(t, err)
+		assert.Equal(t, "es", user.Locale)
+
+		user.Locale = "pt"
+		user, err = client.UpdateMe(user)
+		require.NoError(t, err)
+		assert.Equal(t, "pt", user.Locale)
 	})
 
 	t.Run("complete email verification", func(t *testing.T) {
@@ -208,15 +276,17 @@ func TestUsers(t *testing.T) {
 		err = client.VerifyEmailStart()
 		assert.NoError(t, err)
 
-		tokens, err := th.SqlStore.Token().GetTokensByEmail(email, model.TokenTypeVerifyEmail)
-		assert.NoError(t, err)
-		require.Len(t, tokens, 1)
+		emails, err := client.GetDevEmails(email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		token := emails[0].Tokens[model.TokenTypeVerifyEmail]
+		require.Len(t, token, model.TokenSizeDigits)
 
 		err = client.VerifyEmailComplete(&model.VerifyEmailRequest{Token: model.NewID()})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "400")
 
-		err = client.VerifyEmailComplete(&model.VerifyEmailRequest{Token: tokens[0].Token})
+		err = client.VerifyEmailComplete(&model.VerifyEmailRequest{Token: token})
 		assert.NoError(t, err)
 	})
 
@@ -233,15 +303,17 @@ func TestUsers(t *testing.T) {
 		err = client.ForgotPassword(&model.ForgotPasswordRequest{Email: user.Email})
 		require.NoError(t, err)
 
-		tokens, err := th.SqlStore.Token().GetTokensByEmail(email, model.TokenTypeResetPassword)
-		assert.NoError(t, err)
-		require.Len(t, tokens, 1)
+		emails, err := client.GetDevEmails(email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+		token := emails[0].Tokens[model.TokenTypeResetPassword]
+		require.Len(t, token, model.TokenSize)
 
 		newPassword := "Test1234"
 
 		err = client.ResetPassword(&model.ResetPasswordRequest{
 			Password: newPassword,
-			Token:    tokens[0].Token,
+			Token:    token,
 		})
 		require.NoError(t, err)
 
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// tokenUsageInterval is how often the usage of API keys, personal access
+// tokens and sessions is recorded, in milliseconds. API key usage from a new
+// IP address is always recorded.
+const tokenUsageInterval = 1000 * 60 // 1 minute
+
+// CreateAPIKey creates a new API key and returns it along with the key value,
+// which can't be retrieved afterwards.
+func (a *App) CreateAPIKey(creatorID string, request *model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	apiKey := &model.APIKey{
+		Name:      request.Name,
+		Scope:     request.Scope,
+		CreatorID: creatorID,
+		ExpiresAt: request.ExpiresAt,
+	}
+	key := apiKey.PreSave()
+
+	apiKey, err := a.store.APIKey().CreateAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("api_key_id", apiKey.ID).WithField("creator_id", creatorID).Info("API key created")
+
+	return &model.CreateAPIKeyResponse{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+// GetAPIKeys returns the API keys that were not revoked.
+func (a *App) GetAPIKeys() ([]*model.APIKey, error) {
+	return a.store.APIKey().GetAPIKeys()
+}
+
+// GetAPIKey returns the API key of the given id.
+func (a *App) GetAPIKey(id string) (*model.APIKey, error) {
+	return a.store.APIKey().GetAPIKey(id)
+}
+
+// RevokeAPIKey revokes the API key of the given id.
+func (a *App) RevokeAPIKey(id string) error {
+	err := a.store.APIKey().DeleteAPIKey(id)
+	if err != nil {
+		return err
+	}
+
+	a.logger.WithField("api_key_id", id).Info("API key revoked")
+
+	return nil
+}
+
+// GetSessionForAPIKey returns a session acting on behalf of the creator of the
+// given API key, or nil if the key is unknown, expired or revoked, or its
+// creator is locked or deleted. The usage of the key is recorded.
+func (a *App) GetSessionForAPIKey(key, ipAddress string) (*model.Session, error) {
+	apiKey, err := a.store.APIKey().GetAPIKeyByHash(model.HashToken(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get API key")
+	}
+	if apiKey == nil || apiKey.IsRevoked() || apiKey.IsExpired() {
+		return nil, nil
+	}
+	if active, err := a.isActiveUser(apiKey.CreatorID); err != nil || !active {
+		return nil, err
+	}
+
+	now := model.GetMillis()
+	if apiKey.LastUsedIP != ipAddress || now-apiKey.LastUsedAt > tokenUsageInterval {
+		err = a.store.APIKey().UpdateAPIKeyUsage(apiKey.ID, now, ipAddress)
+		if err != nil {
+			a.logger.WithError(err).WithField("api_key_id", apiKey.ID).Warn("Unable to record API key usage")
+		} else {
+			apiKey.LastUsedAt = now
+			apiKey.LastUsedIP = ipAddress
+		}
+	}
+
+	return &model.Session{
+		ID:             apiKey.ID,
+		CreateAt:       apiKey.CreateAt,
+		ExpiresAt:      apiKey.ExpiresAt,
+		UserID:         apiKey.CreatorID,
+		Source:         model.SessionSourceAPIKey,
+		LastActivityAt: apiKey.LastUsedAt,
+		APIKey:         apiKey,
+	}, nil
+}
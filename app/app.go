@@ -2,9 +2,13 @@ package app
 
 import (
 	"html/template"
+	"net/http"
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
 	"github.com/saturninoabril/dashboard-server/store"
 	"github.com/saturninoabril/dashboard-server/utils"
 	"github.com/sirupsen/logrus"
@@ -15,28 +19,61 @@ type App struct {
 	config        Config
 	store         store.Store
 	user          UserService
+	audit         AuditService
+	mailTransport email.Transport
+	devMailbox    *email.CaptureTransport
 	htmlTemplates *template.Template
-	logger        logrus.FieldLogger
+	translations  *i18n.Bundle
+	// oauthProviders are the enabled OAuth providers by name.
+	oauthProviders map[string]OAuthProvider
+	// rateLimiter is nil when rate limiting is disabled.
+	rateLimiter *ratelimit.Limiter
+	logger      logrus.FieldLogger
 }
 
-// NewApp creates a new instance of App
-func NewApp(logger logrus.FieldLogger, store store.Store, config Config, userService UserService) *App {
-	return &App{
-		config: config,
-		store:  store,
-		user:   userService,
-		logger: logger,
+// NewApp creates a new instance of App. It fails if the configured email
+// transport can't be created.
+func NewApp(logger logrus.FieldLogger, store store.Store, config Config, userService UserService) (*App, error) {
+	mailTransport, err := email.NewTransport(&config.Email, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create email transport")
+	}
+
+	// In dev mode every outgoing email is also kept in memory so it can be
+	// inspected through the dev API.
+	var devMailbox *email.CaptureTransport
+	if config.Dev {
+		devMailbox = email.NewCaptureTransport(mailTransport)
+		mailTransport = devMailbox
 	}
+
+	return &App{
+		config:         config,
+		store:          store,
+		user:           userService,
+		audit:          NewAuditService(logger, store),
+		mailTransport:  mailTransport,
+		devMailbox:     devMailbox,
+		oauthProviders: newOAuthProviders(config),
+		rateLimiter:    newRateLimiter(config.RateLimit, store),
+		logger:         logger,
+	}, nil
 }
 
 // Clone creates a shallow copy of app
 func (a *App) Clone() *App {
 	return &App{
-		config:        a.Config(),
-		store:         a.Store(),
-		user:          a.User(),
-		htmlTemplates: a.HTMLTemplates(),
-		logger:        a.Logger(),
+		config:         a.Config(),
+		store:          a.Store(),
+		user:           a.User(),
+		audit:          a.Audit(),
+		mailTransport:  a.MailTransport(),
+		devMailbox:     a.devMailbox,
+		htmlTemplates:  a.HTMLTemplates(),
+		translations:   a.Translations(),
+		oauthProviders: a.oauthProviders,
+		rateLimiter:    a.rateLimiter,
+		logger:         a.Logger(),
 	}
 }
 
@@ -54,12 +91,29 @@ func (a *App) Store() store.Store {
 	return a.store
 }
 
+// GetIPAddress returns the IP address of the client of the given request,
+// honouring the proxy headers of the configured trusted proxies.
+func (a *App) GetIPAddress(r *http.Request) string {
+	return utils.GetIPAddress(r, a.config.TrustedProxies)
+}
+
 // User is an accessor for the user service.
 func (a *App) User() UserService {
 	return a.user
 }
 
-// ReloadHTMLTemplates refreshes the in-memory HTML templates.
+// Audit is an accessor for the audit log service.
+func (a *App) Audit() AuditService {
+	return a.audit
+}
+
+// MailTransport is an accessor for the transport used to deliver emails.
+func (a *App) MailTransport() email.Transport {
+	return a.mailTransport
+}
+
+// ReloadHTMLTemplates refreshes the in-memory HTML templates and the
+// translations used to render them.
 func (a *App) ReloadHTMLTemplates() error {
 	templatesDir, ok := utils.GetTemplateDirectory()
 	if !ok {
@@ -71,6 +125,16 @@ func (a *App) ReloadHTMLTemplates() error {
 	}
 	a.htmlTemplates = t
 
+	translationsDir, ok := utils.GetTranslationsDirectory()
+	if !ok {
+		return errors.New("unable to find translations directory")
+	}
+	translations, err := i18n.LoadBundle(translationsDir)
+	if err != nil {
+		return errors.Wrap(err, "unable to reload translations")
+	}
+	a.translations = translations
+
 	return nil
 }
 
@@ -78,3 +142,14 @@ func (a *App) ReloadHTMLTemplates() error {
 func (a *App) HTMLTemplates() *template.Template {
 	return a.htmlTemplates
 }
+
+// Translations is an accessor for the app translations.
+func (a *App) Translations() *i18n.Bundle {
+	return a.translations
+}
+
+// IsSupportedLocale returns true if the emails can be translated to the given
+// locale.
+func (a *App) IsSupportedLocale(locale string) bool {
+	return a.translations != nil && a.translations.IsSupported(locale)
+}
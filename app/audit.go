@@ -0,0 +1,56 @@
+package app
+
+import (
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/store"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditService records security events to the append-only audit log.
+type AuditService interface {
+	Record(entry *model.AuditLog) error
+	GetAuditLogs(filter *model.AuditLogFilter) ([]*model.AuditLog, error)
+}
+
+type auditService struct {
+	logger logrus.FieldLogger
+	store  store.Store
+}
+
+var _ AuditService = &auditService{}
+
+func NewAuditService(logger logrus.FieldLogger, store store.Store) AuditService {
+	logFields := logrus.Fields{"package": "app", "type": "audit"}
+	return &auditService{
+		logger: logger.WithFields(logFields),
+		store:  store,
+	}
+}
+
+// Record appends the given entry to the audit log. Entries are also logged so
+// that they reach the server logs if the database is unavailable.
+func (s *auditService) Record(entry *model.AuditLog) error {
+	_, err := s.store.AuditLog().CreateAuditLog(entry)
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"action":     entry.Action,
+		"actor_id":   entry.ActorID,
+		"actor_type": entry.ActorType,
+		"target_id":  entry.TargetID,
+		"ip_address": entry.IPAddress,
+		"request_id": entry.RequestID,
+	})
+	if err != nil {
+		logger.WithError(err).Error("Unable to record audit log entry")
+		return err
+	}
+	logger.Info("audit")
+
+	return nil
+}
+
+// GetAuditLogs returns a page of the audit log entries matching the given
+// filter, the most recent first.
+func (s *auditService) GetAuditLogs(filter *model.AuditLogFilter) ([]*model.AuditLog, error) {
+	return s.store.AuditLog().GetAuditLogs(filter)
+}
@@ -1,13 +1,193 @@
 package app
 
-import "github.com/saturninoabril/dashboard-server/internal/email"
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/internal/password"
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+const (
+	// RateLimitStoreMemory keeps the rate limits of each server in memory.
+	RateLimitStoreMemory = "memory"
+	// RateLimitStoreDatabase keeps the rate limits in the database, shared by
+	// every server.
+	RateLimitStoreDatabase = "database"
+)
 
 type GithubOAuth struct {
+	// BaseURL is the URL of the GitHub web server, defaults to github.com.
+	BaseURL string
+	// APIURL is the URL of the GitHub API, defaults to api.github.com.
+	APIURL        string
 	ClientID      string
 	ClientSecret  string
 	EncryptionKey string
 }
 
+// GitlabOAuth is the configuration of the GitLab OAuth provider.
+type GitlabOAuth struct {
+	// BaseURL is the URL of the GitLab instance, defaults to gitlab.com.
+	BaseURL      string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL defaults to the GitLab complete page of the webapp.
+	RedirectURL string
+}
+
+// OIDC is the configuration of the OpenID Connect login provider.
+type OIDC struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL defaults to the OIDC complete page of the webapp.
+	RedirectURL string
+	Scopes      []string
+
+	// The claims the email, name and groups of the users are read from.
+	EmailClaim  string
+	NameClaim   string
+	GroupsClaim string
+
+	// GroupRoles maps the groups of the provider to the names of the roles
+	// granted to their members.
+	GroupRoles map[string]string
+}
+
+// Enabled returns true if an OpenID Connect provider is configured.
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != "" && o.ClientID != ""
+}
+
+// Sessions is the configuration of the lifetime of login sessions.
+type Sessions struct {
+	User model.SessionLifetime
+	// Admin applies to the sessions of admins, each unset value falling back
+	// to the one of User.
+	Admin model.SessionLifetime
+}
+
+// Lifetime returns the lifetime of the sessions of admins or regular users.
+func (s Sessions) Lifetime(admin bool) model.SessionLifetime {
+	lifetime := s.User
+	if admin {
+		if s.Admin.MaxAge > 0 {
+			lifetime.MaxAge = s.Admin.MaxAge
+		}
+		if s.Admin.IdleTimeout > 0 {
+			lifetime.IdleTimeout = s.Admin.IdleTimeout
+		}
+	}
+
+	return lifetime
+}
+
+// Lockout is the configuration of how failed login attempts are delayed and
+// locked out. Unset policies use the defaults of the model package.
+type Lockout struct {
+	Account model.LockoutPolicy
+	IP      model.LockoutPolicy
+}
+
+// Policy returns the lockout policy of the given scope.
+func (l Lockout) Policy(scope string) model.LockoutPolicy {
+	if scope == model.LoginAttemptScopeIP {
+		if l.IP.IsZero() {
+			return model.DefaultIPLockoutPolicy
+		}
+		return l.IP
+	}
+
+	if l.Account.IsZero() {
+		return model.DefaultAccountLockoutPolicy
+	}
+	return l.Account
+}
+
+// Passwords is the configuration of the passwords users can choose.
+type Passwords struct {
+	// Policy is model.DefaultPasswordPolicy when unset.
+	Policy model.PasswordPolicy
+	// BreachedList is the path of a local copy of the Pwned Passwords list,
+	// whose passwords are rejected. Empty to not check it.
+	BreachedList string
+	// HashParams are the argon2id parameters of new password hashes,
+	// password.DefaultParams when unset.
+	HashParams password.Params
+	// HashMemoryLimit is the memory, in KiB, that concurrent password hashes
+	// can use together. 0 for no limit.
+	HashMemoryLimit uint64
+}
+
+// PasswordPolicy returns the configured password policy.
+func (p Passwords) PasswordPolicy() model.PasswordPolicy {
+	if p.Policy.IsZero() {
+		return model.DefaultPasswordPolicy
+	}
+	return p.Policy
+}
+
+// PasswordHashParams returns the configured password hash parameters.
+func (p Passwords) PasswordHashParams() password.Params {
+	if p.HashParams == (password.Params{}) {
+		return password.DefaultParams
+	}
+	return p.HashParams
+}
+
+// Signup is the configuration of who can create an account.
+type Signup struct {
+	// Mode is one of the model signup modes, model.SignupModeOpen when unset.
+	Mode string
+	// AllowedDomains restricts signup to the emails of the given domains.
+	// Invited users are allowed whatever their domain.
+	AllowedDomains []string
+}
+
+// SignupMode returns the configured signup mode.
+func (s Signup) SignupMode() string {
+	if s.Mode == "" {
+		return model.SignupModeOpen
+	}
+	return s.Mode
+}
+
+// IsAllowedEmail returns true if the domain of the email is allowed, or if
+// no domain is configured.
+func (s Signup) IsAllowedEmail(email string) bool {
+	if len(s.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range s.AllowedDomains {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RateLimit is the configuration of the rate limits of requests.
+type RateLimit struct {
+	Enabled bool
+	// Store is where the limits are kept, RateLimitStoreMemory or
+	// RateLimitStoreDatabase.
+	Store string
+	// Default is the limit of the routes without their own.
+	Default ratelimit.Limit
+}
+
 // Config is the config used by the dashboard server app.
 type Config struct {
 	// the location to which a user might point their browser
@@ -19,6 +199,31 @@ type Config struct {
 	// Github OAuth configuration
 	GithubOAuth GithubOAuth
 
+	// GitLab OAuth configuration
+	GitlabOAuth GitlabOAuth
+
+	// OpenID Connect configuration
+	OIDC OIDC
+
+	// login session lifetimes
+	Sessions Sessions
+
+	// failed login attempt policies
+	Lockout Lockout
+
+	// request rate limits
+	RateLimit RateLimit
+
+	// password policy, breached passwords and hashing
+	Passwords Passwords
+
+	// signup restrictions
+	Signup Signup
+
+	// the networks of the proxies whose X-Forwarded-For and X-Real-IP headers
+	// are honoured; other clients are identified by their remote address
+	TrustedProxies []*net.IPNet
+
 	// email server related configuration
 	Email email.Config
 
@@ -38,6 +243,12 @@ func SetDevConfig(config *Config) {
 	if len(config.SiteURL) == 0 {
 		config.SiteURL = "http://localhost:3000"
 	}
+	if len(config.Email.Transport) == 0 {
+		config.Email.Transport = email.TransportFile
+	}
+	if len(config.Email.FileDirectory) == 0 {
+		config.Email.FileDirectory = filepath.Join(os.TempDir(), "dashboard-emails")
+	}
 	if len(config.Email.SMTPServer) == 0 {
 		config.Email.SMTPServer = "localhost"
 	}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CreateCycle saves a cycle reported by the given user, or on behalf of the
+// creator of the API key reporting it.
+func (a *App) CreateCycle(creatorID string, cycle *model.Cycle) (*model.Cycle, error) {
+	cycle.ID = ""
+	cycle.CreatorID = creatorID
+	cycle.CreateAt = 0
+
+	cycle, err := a.store.Cycle().CreateCycle(cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("cycle_id", cycle.ID).WithField("repo", cycle.Repo).Debug("Cycle reported")
+
+	return cycle, nil
+}
+
+// GetCycle returns the cycle of the given id.
+func (a *App) GetCycle(id string) (*model.Cycle, error) {
+	return a.store.Cycle().GetCycle(id)
+}
+
+// GetCycles returns a page of the cycles matching the given filter, among the
+// repos the GitHub access rules let the given user see.
+func (a *App) GetCycles(userID string, filter *model.CycleFilter) ([]*model.Cycle, error) {
+	membership, err := a.store.GithubAccess().GetGithubMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership != nil && len(membership.Repos) > 0 {
+		filter.Repos = membership.Repos
+	}
+
+	return a.store.Cycle().GetCycles(filter)
+}
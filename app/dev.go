@@ -0,0 +1,68 @@
+package app
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+var (
+	verifyEmailTokenRegexp = regexp.MustCompile(`>\s*(\d{3}) (\d{3})\s*<`)
+
+	// linkTokenRegexps match the links of the emails carrying a token, by
+	// token type. The token is the first submatch, query escaped.
+	linkTokenRegexps = map[string]*regexp.Regexp{
+		model.TokenTypeResetPassword:      regexp.MustCompile(`/reset-password\?token=([^"&\s]+)`),
+		model.TokenTypeUnsubscribe:        regexp.MustCompile(`/unsubscribe\?token=([^"&\s]+)`),
+		model.TokenTypeUnlockAccount:      regexp.MustCompile(`/unlock-account\?token=([^"&\s]+)`),
+		model.TokenTypeInvitation:         regexp.MustCompile(`/signup\?invitation=([^"&\s]+)`),
+		model.TokenTypeConfirmEmailChange: regexp.MustCompile(`/confirm-email-change\?token=([^"&\s]+)`),
+		model.TokenTypeCancelEmailChange:  regexp.MustCompile(`/cancel-email-change\?token=([^"&\s]+)`),
+	}
+)
+
+// GetDevEmails returns the latest email sent to every recipient along with the
+// tokens found in them. Only available in dev mode.
+func (a *App) GetDevEmails() ([]*model.DevEmail, error) {
+	if !a.Config().Dev || a.devMailbox == nil {
+		return nil, errors.New("captured emails are only available in dev mode")
+	}
+
+	var emails []*model.DevEmail
+	for _, captured := range a.devMailbox.Emails() {
+		emails = append(emails, &model.DevEmail{
+			To:       captured.To,
+			Subject:  captured.Subject,
+			Body:     captured.TextBody,
+			HTMLBody: captured.HTMLBody,
+			SentAt:   captured.SentAt.UnixNano() / 1e6,
+			Tokens:   parseEmailTokens(captured.HTMLBody),
+		})
+	}
+
+	return emails, nil
+}
+
+// parseEmailTokens extracts the token values sent in the body of an email.
+func parseEmailTokens(htmlBody string) map[string]string {
+	tokens := make(map[string]string)
+
+	if matches := verifyEmailTokenRegexp.FindStringSubmatch(htmlBody); matches != nil {
+		tokens[model.TokenTypeVerifyEmail] = matches[1] + matches[2]
+	}
+	for tokenType, tokenRegexp := range linkTokenRegexps {
+		if matches := tokenRegexp.FindStringSubmatch(htmlBody); matches != nil {
+			if token, err := url.QueryUnescape(matches[1]); err == nil {
+				tokens[tokenType] = token
+			}
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return tokens
+}
@@ -7,8 +7,10 @@ import (
 	"io"
 	"net/url"
 
+	"github.com/jaytaylor/html2text"
 	"github.com/pkg/errors"
 	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
 	"github.com/saturninoabril/dashboard-server/model"
 )
 
@@ -19,38 +21,37 @@ const (
 // SendMail sends mail.
 func (a *App) SendMail(to, subject, htmlBody string, sendBcc bool) error {
 	emailConfig := a.Config().Email
-	return email.SendMailUsingConfig(to, subject, htmlBody, sendBcc, nil, &emailConfig)
+	return email.SendMailUsingTransport(a.MailTransport(), to, subject, htmlBody, sendBcc, nil, &emailConfig)
 }
 
 // SendEmailWithAttachments would send the email including the passed attachments
 func (a *App) SendMailWithAttachments(to, subject, htmlBody string, sendBcc bool, attachments []*email.Attachment) error {
 	emailConfig := a.Config().Email
-	return email.SendMailUsingConfig(to, subject, htmlBody, sendBcc, attachments, &emailConfig)
+	return email.SendMailUsingTransport(a.MailTransport(), to, subject, htmlBody, sendBcc, attachments, &emailConfig)
 }
 
-// GetHTMLTemplate returns the HTMLTemplate of a give name.
-func (a *App) GetHTMLTemplate(templateName string) *HTMLTemplate {
+// GetHTMLTemplate returns the HTMLTemplate of a give name, rendering its
+// strings in the given locale.
+func (a *App) GetHTMLTemplate(templateName, locale string) *HTMLTemplate {
 	return &HTMLTemplate{
 		Template:     a.HTMLTemplates(),
 		TemplateName: templateName,
 		Props:        make(map[string]interface{}),
+		Locale:       locale,
+		translations: a.Translations(),
 	}
 }
 
 // SendTestEmail creates and sends a test email.
-func (a *App) SendTestEmail(userEmail, siteURL string) error {
-	bodyPage := a.GetHTMLTemplate("test_email_body")
-
-	bodyPage.Props["Title"] = "Test Email"
-	bodyPage.Props["Info"] = "This is a test email generated by the dashboard server."
-	bodyPage.Props["Organization"] = "Automation Dashboard"
+func (a *App) SendTestEmail(userEmail, locale, siteURL string) error {
+	bodyPage := a.newTestEmailTemplate(locale, siteURL)
 
 	renderedBody, err := bodyPage.Render()
 	if err != nil {
 		return errors.Wrap(err, "unable to render test email")
 	}
 
-	err = a.SendMail(userEmail, "Test Email", renderedBody, false)
+	err = a.SendMail(userEmail, bodyPage.T("email.test.subject"), renderedBody, false)
 	if err != nil {
 		return errors.Wrap(err, "unable to send test email")
 	}
@@ -59,16 +60,8 @@ func (a *App) SendTestEmail(userEmail, siteURL string) error {
 }
 
 // SendVerifyEmailEmail sends a verify-email email.
-func (a *App) SendVerifyEmailEmail(email, siteURL string, token *model.Token) error {
-	subject := "Verify Email"
-
-	bodyPage := a.GetHTMLTemplate("verify_email_body")
-	bodyPage.SetBaseProps()
-	bodyPage.Props["SiteURL"] = siteURL
-	bodyPage.Props["Title"] = "Verify your email address"
-	bodyPage.Props["Info"] = "Enter the code below into the browser window where you began creating your Dashboard account."
-	bodyPage.Props["Footer"] = "This email address was used to create an account with the Dashboard. \nIf it was not you, you can safely ignore this email."
-	bodyPage.Props["Token"] = fmt.Sprintf("%s %s", token.Token[:3], token.Token[3:])
+func (a *App) SendVerifyEmailEmail(email, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newVerifyEmailTemplate(locale, siteURL, token)
 
 	if a.Config().Dev {
 		a.logger.Debugf("Verification code for %s: %s", email, token.Token)
@@ -79,7 +72,7 @@ func (a *App) SendVerifyEmailEmail(email, siteURL string, token *model.Token) er
 		return errors.Wrap(err, "unable to render verify email body")
 	}
 
-	err = a.SendMail(email, subject, renderedBody, false)
+	err = a.SendMail(email, bodyPage.T("email.verify.subject"), renderedBody, false)
 	if err != nil {
 		return errors.Wrap(err, "unable to send verify-email email")
 	}
@@ -88,23 +81,15 @@ func (a *App) SendVerifyEmailEmail(email, siteURL string, token *model.Token) er
 }
 
 // SendPasswordResetEmail sends a password reset email.
-func (a *App) SendPasswordResetEmail(email, siteURL string, token *model.Token) error {
-	subject := "Password Reset"
-
-	bodyPage := a.GetHTMLTemplate("password_reset_body")
-	bodyPage.SetBaseProps()
-	bodyPage.Props["SiteURL"] = siteURL
-	bodyPage.Props["Title"] = "Reset Your Password"
-	bodyPage.Props["Info1"] = "Click the button below to reset your password. If you didn’t request this, you can safely ignore this email."
-	bodyPage.Props["ResetUrl"] = fmt.Sprintf("%s/reset-password?token=%s", siteURL, url.QueryEscape(token.Token))
-	bodyPage.Props["Button"] = "Reset Password"
+func (a *App) SendPasswordResetEmail(email, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newPasswordResetTemplate(locale, siteURL, token)
 
 	renderedBody, err := bodyPage.Render()
 	if err != nil {
 		return errors.Wrap(err, "unable to render reset password email")
 	}
 
-	err = a.SendMail(email, subject, renderedBody, false)
+	err = a.SendMail(email, bodyPage.T("email.password_reset.subject"), renderedBody, false)
 	if err != nil {
 		return errors.Wrap(err, "unable to send reset password email")
 	}
@@ -112,16 +97,222 @@ func (a *App) SendPasswordResetEmail(email, siteURL string, token *model.Token)
 	return nil
 }
 
+// SendUnlockAccountEmail sends the email with the link unlocking an account
+// locked out by failed login attempts.
+func (a *App) SendUnlockAccountEmail(email, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newUnlockAccountTemplate(locale, siteURL, token)
+
+	renderedBody, err := bodyPage.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render unlock account email")
+	}
+
+	err = a.SendMail(email, bodyPage.T("email.unlock_account.subject"), renderedBody, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to send unlock account email")
+	}
+
+	return nil
+}
+
+// SendInvitationEmail sends the email inviting its recipient to sign up.
+func (a *App) SendInvitationEmail(email, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newInvitationTemplate(locale, siteURL, token)
+
+	renderedBody, err := bodyPage.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render invitation email")
+	}
+
+	err = a.SendMail(email, bodyPage.T("email.invitation.subject"), renderedBody, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to send invitation email")
+	}
+
+	return nil
+}
+
+// SendConfirmEmailChangeEmail sends the email confirming the new email of a
+// user to that address.
+func (a *App) SendConfirmEmailChangeEmail(email, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newConfirmEmailChangeTemplate(email, locale, siteURL, token)
+
+	renderedBody, err := bodyPage.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render confirm email change email")
+	}
+
+	err = a.SendMail(email, bodyPage.T("email.confirm_email_change.subject"), renderedBody, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to send confirm email change email")
+	}
+
+	return nil
+}
+
+// SendEmailChangeRequestedEmail notifies the current email of a user that a
+// change to the new email was requested, with the link cancelling it.
+func (a *App) SendEmailChangeRequestedEmail(email, newEmail, locale, siteURL string, token *model.Token) error {
+	bodyPage := a.newEmailChangeRequestedTemplate(newEmail, locale, siteURL, token)
+
+	renderedBody, err := bodyPage.Render()
+	if err != nil {
+		return errors.Wrap(err, "unable to render email change requested email")
+	}
+
+	err = a.SendMail(email, bodyPage.T("email.email_change_requested.subject"), renderedBody, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to send email change requested email")
+	}
+
+	return nil
+}
+
+// InvitationURL returns the signup link of the invitation sent with the
+// given token.
+func InvitationURL(siteURL string, token *model.Token) string {
+	return fmt.Sprintf("%s/signup?invitation=%s", siteURL, url.QueryEscape(token.Token))
+}
+
+func (a *App) newTestEmailTemplate(locale, siteURL string) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("test_email_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.test.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.test.info")
+	bodyPage.Props["Organization"] = bodyPage.T("email.organization")
+
+	return bodyPage
+}
+
+func (a *App) newVerifyEmailTemplate(locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("verify_email_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.verify.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.verify.info")
+	bodyPage.Props["Footer"] = bodyPage.T("email.verify.footer")
+	bodyPage.Props["Token"] = fmt.Sprintf("%s %s", token.Token[:3], token.Token[3:])
+
+	return bodyPage
+}
+
+func (a *App) newPasswordResetTemplate(locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("password_reset_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.password_reset.title")
+	bodyPage.Props["Info1"] = bodyPage.T("email.password_reset.info")
+	bodyPage.Props["ResetUrl"] = fmt.Sprintf("%s/reset-password?token=%s", siteURL, url.QueryEscape(token.Token))
+	bodyPage.Props["Button"] = bodyPage.T("email.password_reset.button")
+
+	return bodyPage
+}
+
+func (a *App) newUnlockAccountTemplate(locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("unlock_account_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.unlock_account.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.unlock_account.info")
+	bodyPage.Props["UnlockUrl"] = fmt.Sprintf("%s/unlock-account?token=%s", siteURL, url.QueryEscape(token.Token))
+	bodyPage.Props["Button"] = bodyPage.T("email.unlock_account.button")
+
+	return bodyPage
+}
+
+func (a *App) newInvitationTemplate(locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("invitation_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.invitation.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.invitation.info")
+	bodyPage.Props["InvitationUrl"] = InvitationURL(siteURL, token)
+	bodyPage.Props["Button"] = bodyPage.T("email.invitation.button")
+
+	return bodyPage
+}
+
+func (a *App) newConfirmEmailChangeTemplate(newEmail, locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("confirm_email_change_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.confirm_email_change.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.confirm_email_change.info", newEmail)
+	bodyPage.Props["ConfirmUrl"] = fmt.Sprintf("%s/confirm-email-change?token=%s", siteURL, url.QueryEscape(token.Token))
+	bodyPage.Props["Button"] = bodyPage.T("email.confirm_email_change.button")
+
+	return bodyPage
+}
+
+func (a *App) newEmailChangeRequestedTemplate(newEmail, locale, siteURL string, token *model.Token) *HTMLTemplate {
+	bodyPage := a.GetHTMLTemplate("email_change_requested_body", locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = bodyPage.T("email.email_change_requested.title")
+	bodyPage.Props["Info"] = bodyPage.T("email.email_change_requested.info", newEmail)
+	bodyPage.Props["CancelUrl"] = fmt.Sprintf("%s/cancel-email-change?token=%s", siteURL, url.QueryEscape(token.Token))
+	bodyPage.Props["Button"] = bodyPage.T("email.email_change_requested.button")
+
+	return bodyPage
+}
+
+// GetEmailPreview returns the given email template filled with sample props in
+// the given locale, the same way it would be filled when sending the actual
+// email.
+func (a *App) GetEmailPreview(templateName, locale string) (*HTMLTemplate, error) {
+	if a.HTMLTemplates() == nil || a.HTMLTemplates().Lookup(templateName) == nil {
+		return nil, errors.Errorf("template %s not found", templateName)
+	}
+
+	siteURL := a.Config().SiteURL
+	switch templateName {
+	case "test_email_body":
+		return a.newTestEmailTemplate(locale, siteURL), nil
+	case "verify_email_body":
+		return a.newVerifyEmailTemplate(locale, siteURL, model.NewToken(model.TokenTypeVerifyEmail, "")), nil
+	case "password_reset_body":
+		return a.newPasswordResetTemplate(locale, siteURL, model.NewToken(model.TokenTypeResetPassword, "")), nil
+	case "unlock_account_body":
+		return a.newUnlockAccountTemplate(locale, siteURL, model.NewToken(model.TokenTypeUnlockAccount, "")), nil
+	case "invitation_body":
+		return a.newInvitationTemplate(locale, siteURL, model.NewToken(model.TokenTypeInvitation, "")), nil
+	case "confirm_email_change_body":
+		return a.newConfirmEmailChangeTemplate("new@example.com", locale, siteURL, model.NewToken(model.TokenTypeConfirmEmailChange, "")), nil
+	case "email_change_requested_body":
+		return a.newEmailChangeRequestedTemplate("new@example.com", locale, siteURL, model.NewToken(model.TokenTypeCancelEmailChange, "")), nil
+	}
+
+	bodyPage := a.GetHTMLTemplate(templateName, locale)
+	bodyPage.SetBaseProps()
+	bodyPage.Props["SiteURL"] = siteURL
+
+	return bodyPage, nil
+}
+
 // HTMLTemplate is a wrapper for specifying and rendering a given HTML template.
 type HTMLTemplate struct {
 	Template     *template.Template
 	TemplateName string
 	Props        map[string]interface{}
+	Locale       string
+
+	translations *i18n.Bundle
 }
 
 // SetBaseProps sets common prop values for most email templates.
 func (t *HTMLTemplate) SetBaseProps() {
-	t.Props["Footer"] = "© 2021 Test Automation Dashboard"
+	t.Props["Footer"] = t.T("email.footer")
+}
+
+// T returns the string of the given id in the template locale, falling back to
+// English. It is also available to the templates as {{.T "id"}}.
+func (t *HTMLTemplate) T(id string, args ...interface{}) string {
+	if t.translations == nil {
+		return id
+	}
+
+	return t.translations.Translate(t.Locale, id, args...)
 }
 
 // Render renders the HTMLTemplate to a string.
@@ -135,6 +326,22 @@ func (t *HTMLTemplate) Render() (string, error) {
 	return text.String(), nil
 }
 
+// RenderText renders the HTMLTemplate to its plaintext version, as used in the
+// text part of the emails.
+func (t *HTMLTemplate) RenderText() (string, error) {
+	html, err := t.Render()
+	if err != nil {
+		return "", err
+	}
+
+	text, err := html2text.FromString(html)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to convert template to text")
+	}
+
+	return text, nil
+}
+
 // RenderToWriter renders the template of the given name to the provided reader.
 func (t *HTMLTemplate) RenderToWriter(w io.Writer) error {
 	if t.Template == nil {
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// RequestEmailChange sets the given email as the pending email of the user,
+// replacing the change they previously requested, and sends the link
+// confirming it to the new address and the link cancelling it to the current
+// one. The email of the user only changes once confirmed.
+func (a *App) RequestEmailChange(user *model.User, email string) error {
+	email = strings.ToLower(email)
+	if !model.IsValidEmail(email) {
+		return errors.New("invalid email")
+	}
+	if email == user.Email {
+		return errors.New("email is already the current one")
+	}
+
+	existing, err := a.store.User().GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("email exists")
+	}
+
+	if user.PendingEmail != "" {
+		if err = a.deleteEmailChangeTokens(user.ID, user.PendingEmail); err != nil {
+			return err
+		}
+	}
+	if err = a.store.User().SetPendingEmail(user.ID, email); err != nil {
+		return err
+	}
+
+	extra, err := model.CreateTokenTypeEmailChangeExtra(user.ID, email)
+	if err != nil {
+		return errors.Wrap(err, "failed to create token extra value")
+	}
+	confirmToken, err := a.store.Token().CreateToken(model.NewToken(model.TokenTypeConfirmEmailChange, extra))
+	if err != nil {
+		return errors.Wrap(err, "unable to save new token")
+	}
+	cancelToken, err := a.store.Token().CreateToken(model.NewToken(model.TokenTypeCancelEmailChange, extra))
+	if err != nil {
+		return errors.Wrap(err, "unable to save new token")
+	}
+
+	siteURL := a.config.SiteURL
+	if err = a.SendConfirmEmailChangeEmail(email, user.Locale, siteURL, confirmToken); err != nil {
+		return err
+	}
+	if err = a.SendEmailChangeRequestedEmail(user.Email, email, user.Locale, siteURL, cancelToken); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", user.ID).Info("Email change requested")
+
+	return nil
+}
+
+// ConfirmEmailChange replaces the email of the user with the pending one the
+// given token confirms. It returns the updated user and their previous email,
+// or a nil user if the token is invalid, the change was cancelled or
+// replaced since, or the email now belongs to another user.
+func (a *App) ConfirmEmailChange(tokenValue string) (*model.User, string, error) {
+	user, extra, err := a.getEmailChange(tokenValue, model.TokenTypeConfirmEmailChange)
+	if err != nil || user == nil {
+		return nil, "", err
+	}
+
+	// Another user may have signed up with the email since it was requested.
+	existing, err := a.store.User().GetUserByEmail(extra.Email)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		if err = a.store.User().SetPendingEmail(user.ID, ""); err != nil {
+			return nil, "", err
+		}
+		return nil, "", a.deleteEmailChangeTokens(user.ID, extra.Email)
+	}
+
+	confirmed, err := a.store.User().ConfirmPendingEmail(user.ID, extra.Email)
+	if err != nil {
+		return nil, "", err
+	}
+	if !confirmed {
+		return nil, "", nil
+	}
+
+	if err = a.deleteEmailChangeTokens(user.ID, extra.Email); err != nil {
+		// The email was changed, the tokens will no longer match the
+		// pending email of the user.
+		a.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to remove claimed email change tokens")
+	}
+
+	a.logger.WithField("user_id", user.ID).Info("Email change confirmed")
+
+	previousEmail := user.Email
+	user, err = a.store.User().GetUser(user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, previousEmail, nil
+}
+
+// CancelEmailChange cancels the pending email change of the user the given
+// token was sent to. It returns the user along with the cancelled email, or
+// a nil user if the token is invalid or the change was already confirmed,
+// cancelled or replaced.
+func (a *App) CancelEmailChange(tokenValue string) (*model.User, string, error) {
+	user, extra, err := a.getEmailChange(tokenValue, model.TokenTypeCancelEmailChange)
+	if err != nil || user == nil {
+		return nil, "", err
+	}
+
+	if err = a.store.User().SetPendingEmail(user.ID, ""); err != nil {
+		return nil, "", err
+	}
+	if err = a.deleteEmailChangeTokens(user.ID, extra.Email); err != nil {
+		return nil, "", err
+	}
+
+	a.logger.WithField("user_id", user.ID).Info("Email change cancelled")
+
+	return user, extra.Email, nil
+}
+
+// getEmailChange returns the user and the email change of the given token,
+// or a nil user if the token is not a valid token of the given type or the
+// email it was sent for is no longer pending.
+func (a *App) getEmailChange(tokenValue, tokenType string) (*model.User, *model.TokenExtraEmailChange, error) {
+	token, err := a.store.Token().GetToken(tokenValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil ||
+		token.Type != tokenType ||
+		token.CreateAt < model.GetMillis()-model.TokenDefaultExpiryTime {
+		return nil, nil, nil
+	}
+
+	extra, err := token.GetExtraEmailChange()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to determine token email change value")
+	}
+
+	user, err := a.store.User().GetUser(extra.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.PendingEmail != extra.Email {
+		return nil, nil, nil
+	}
+
+	return user, extra, nil
+}
+
+// deleteEmailChangeTokens deletes the tokens confirming and cancelling the
+// change of the email of a user to the given one.
+func (a *App) deleteEmailChangeTokens(userID, email string) error {
+	extra, err := model.CreateTokenTypeEmailChangeExtra(userID, email)
+	if err != nil {
+		return errors.Wrap(err, "failed to create token extra value")
+	}
+
+	for _, tokenType := range []string{model.TokenTypeConfirmEmailChange, model.TokenTypeCancelEmailChange} {
+		if err = a.store.Token().DeleteTokensByExtra(extra, tokenType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
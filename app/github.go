@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultGithubBaseURL = "https://github.com/"
+	defaultGithubAPIURL  = "https://api.github.com/"
+)
+
+// githubProvider logs users in with their GitHub account.
+type githubProvider struct {
+	config  GithubOAuth
+	baseURL string
+	apiURL  string
+}
+
+func newGithubProvider(config GithubOAuth) *githubProvider {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = defaultGithubBaseURL
+	}
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = defaultGithubAPIURL
+	}
+
+	return &githubProvider{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/",
+		apiURL:  strings.TrimSuffix(apiURL, "/") + "/",
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return model.OAuthProviderGithub
+}
+
+func (p *githubProvider) oauthConfig() *oauth2.Config {
+	scopes := []string{
+		string(github.ScopeUserEmail),
+		string(github.ScopeReadOrg),
+	}
+
+	authURL, _ := url.Parse(p.baseURL)
+	tokenURL, _ := url.Parse(p.baseURL)
+
+	authURL.Path = path.Join(authURL.Path, "login", "oauth", "authorize")
+	tokenURL.Path = path.Join(tokenURL.Path, "login", "oauth", "access_token")
+
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   authURL.String(),
+			TokenURL:  tokenURL.String(),
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, oauthState *model.OAuthState) (string, error) {
+	return p.oauthConfig().AuthCodeURL(oauthState.Token, oauth2.AccessTypeOffline), nil
+}
+
+// GetAccount returns the GitHub account of the code, along with the
+// organizations and teams of the user as groups.
+func (p *githubProvider) GetAccount(ctx context.Context, code string, oauthState *model.OAuthState) (*model.UserAuthInfo, []string, error) {
+	token, err := p.oauthConfig().Exchange(ctx, code)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to exchange oauth code into token")
+	}
+
+	githubClient, err := p.client(*token)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to authenticate with GitHub")
+	}
+
+	githubUser, _, err := githubClient.Users.Get(ctx, "")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get authenticated GitHub user")
+	}
+
+	githubEmails, _, err := githubClient.Users.ListEmails(ctx, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the emails of the GitHub user")
+	}
+
+	var emails []string
+	for _, githubEmail := range githubEmails {
+		if !githubEmail.GetVerified() {
+			continue
+		}
+		email := strings.ToLower(githubEmail.GetEmail())
+		if githubEmail.GetPrimary() {
+			emails = append([]string{email}, emails...)
+		} else {
+			emails = append(emails, email)
+		}
+	}
+
+	userAuthInfo := &model.UserAuthInfo{
+		OAuthProvider:  model.OAuthProviderGithub,
+		ProviderUserID: strconv.FormatInt(githubUser.GetID(), 10),
+		Token:          *token,
+		Username:       githubUser.GetLogin(),
+		Name:           githubUser.GetName(),
+		AvatarURL:      githubUser.GetAvatarURL(),
+	}
+	if len(emails) > 0 {
+		userAuthInfo.Email = emails[0]
+	}
+
+	userAuthInfo.Groups, err = getGithubGroups(ctx, githubClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userAuthInfo, emails, nil
+}
+
+// GetGroups returns the organizations and teams of the user the given token
+// belongs to.
+func (p *githubProvider) GetGroups(ctx context.Context, token oauth2.Token) ([]string, error) {
+	githubClient, err := p.client(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to authenticate with GitHub")
+	}
+
+	return getGithubGroups(ctx, githubClient)
+}
+
+func (p *githubProvider) client(token oauth2.Token) (*github.Client, error) {
+	githubClient, err := GetGitHubClient(token)
+	if err != nil {
+		return nil, err
+	}
+
+	githubClient.BaseURL, err = url.Parse(p.apiURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub API URL")
+	}
+
+	return githubClient, nil
+}
+
+// getGithubGroups returns the organizations the user is an active member of,
+// and their teams as org/team, all lowercased.
+func getGithubGroups(ctx context.Context, githubClient *github.Client) ([]string, error) {
+	var groups []string
+
+	orgOptions := &github.ListOrgMembershipsOptions{
+		State:       "active",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		memberships, resp, err := githubClient.Organizations.ListOrgMemberships(ctx, orgOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the organizations of the GitHub user")
+		}
+		for _, membership := range memberships {
+			groups = append(groups, strings.ToLower(membership.GetOrganization().GetLogin()))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOptions.Page = resp.NextPage
+	}
+
+	teamOptions := &github.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := githubClient.Teams.ListUserTeams(ctx, teamOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the teams of the GitHub user")
+		}
+		for _, team := range teams {
+			groups = append(groups, strings.ToLower(team.GetOrganization().GetLogin()+"/"+team.GetSlug()))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		teamOptions.Page = resp.NextPage
+	}
+
+	return groups, nil
+}
+
+// GetGitHubClient returns a GitHub client authenticated with the given token.
+func GetGitHubClient(token oauth2.Token) (*github.Client, error) {
+	ts := oauth2.StaticTokenSource(&token)
+	authenticatedClient := oauth2.NewClient(context.Background(), ts)
+
+	return github.NewClient(authenticatedClient), nil
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CreateGithubAccessRule creates a rule granting access to the members of a
+// GitHub organization or team.
+func (a *App) CreateGithubAccessRule(rule *model.GithubAccessRule) (*model.GithubAccessRule, error) {
+	rule.ID = ""
+	rule.CreateAt = 0
+	if err := a.checkGithubAccessRuleRole(rule); err != nil {
+		return nil, err
+	}
+
+	rule, err := a.store.GithubAccess().CreateGithubAccessRule(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("rule_id", rule.ID).WithField("org", rule.Org).WithField("team", rule.Team).Info("GitHub access rule created")
+
+	return rule, nil
+}
+
+// GetGithubAccessRule returns the GitHub access rule of the given id.
+func (a *App) GetGithubAccessRule(id string) (*model.GithubAccessRule, error) {
+	return a.store.GithubAccess().GetGithubAccessRule(id)
+}
+
+// GetGithubAccessRules returns every GitHub access rule.
+func (a *App) GetGithubAccessRules() ([]*model.GithubAccessRule, error) {
+	return a.store.GithubAccess().GetGithubAccessRules()
+}
+
+// UpdateGithubAccessRule updates the given GitHub access rule. The change
+// applies to each user on their next login or membership sync.
+func (a *App) UpdateGithubAccessRule(rule *model.GithubAccessRule) error {
+	if err := a.checkGithubAccessRuleRole(rule); err != nil {
+		return err
+	}
+
+	if err := a.store.GithubAccess().UpdateGithubAccessRule(rule); err != nil {
+		return err
+	}
+
+	a.logger.WithField("rule_id", rule.ID).Info("GitHub access rule updated")
+
+	return nil
+}
+
+// DeleteGithubAccessRule deletes the given GitHub access rule.
+func (a *App) DeleteGithubAccessRule(id string) error {
+	if err := a.store.GithubAccess().DeleteGithubAccessRule(id); err != nil {
+		return err
+	}
+
+	a.logger.WithField("rule_id", id).Info("GitHub access rule deleted")
+
+	return nil
+}
+
+func (a *App) checkGithubAccessRuleRole(rule *model.GithubAccessRule) error {
+	if rule.RoleID == "" {
+		return nil
+	}
+
+	role, err := a.store.Role().GetRole(rule.RoleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
+	return nil
+}
+
+// IsOAuthAccountAllowed returns false if the given account can't be used to
+// sign in, which is the case of GitHub accounts matching none of the access
+// rules once any exists.
+func (a *App) IsOAuthAccountAllowed(userAuthInfo *model.UserAuthInfo) (bool, error) {
+	if userAuthInfo.OAuthProvider != model.OAuthProviderGithub {
+		return true, nil
+	}
+
+	rules, err := a.store.GithubAccess().GetGithubAccessRules()
+	if err != nil {
+		return false, err
+	}
+
+	membership, _, _ := model.EvaluateGithubAccessRules(rules, userAuthInfo.Groups)
+
+	return membership.Allowed, nil
+}
+
+// GetGithubMembership returns the GitHub access of the given user as of their
+// last login or sync, or nil if they never linked a GitHub account.
+func (a *App) GetGithubMembership(userID string) (*model.GithubMembership, error) {
+	return a.store.GithubAccess().GetGithubMembership(userID)
+}
+
+// UserCanAccessRepo returns true if the GitHub access rules don't prevent the
+// given user from seeing the repo.
+func (a *App) UserCanAccessRepo(userID, repo string) (bool, error) {
+	membership, err := a.store.GithubAccess().GetGithubMembership(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return membership.CanAccessRepo(repo), nil
+}
+
+// IsGithubAccessAllowed returns false if the given user linked a GitHub
+// account and no longer matches the access rules as of their last login or
+// sync, in which case they can't sign in at all.
+func (a *App) IsGithubAccessAllowed(userID string) (bool, error) {
+	membership, err := a.store.GithubAccess().GetGithubMembership(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return membership == nil || membership.Allowed, nil
+}
+
+// SyncGithubAccess applies the GitHub access rules to a user belonging to the
+// given organizations and teams: the roles of the matching rules are granted,
+// the other roles managed by the rules are removed and the membership is
+// saved. It returns the saved membership.
+func (a *App) SyncGithubAccess(userID string, groups []string) (*model.GithubMembership, error) {
+	rules, err := a.store.GithubAccess().GetGithubAccessRules()
+	if err != nil {
+		return nil, err
+	}
+
+	membership, grantedRoleIDs, revokedRoleIDs := model.EvaluateGithubAccessRules(rules, groups)
+	membership.UserID = userID
+
+	for _, roleID := range grantedRoleIDs {
+		added, err := a.AddUserRole(userID, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			a.recordSyncedRoleChange(model.AuditActionRoleAdd, userID, roleID, model.OAuthProviderGithub)
+		}
+	}
+	for _, roleID := range revokedRoleIDs {
+		removed, err := a.RemoveUserRole(userID, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			a.recordSyncedRoleChange(model.AuditActionRoleRemove, userID, roleID, model.OAuthProviderGithub)
+		}
+	}
+
+	if err = a.store.GithubAccess().SaveGithubMembership(membership); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// SyncGithubMemberships fetches the organizations and teams of every user who
+// linked a GitHub account and applies the access rules to them. Users who lose
+// access are logged out, and a revoked token counts as no membership at all.
+func (a *App) SyncGithubMemberships(ctx context.Context) error {
+	provider, ok := a.oauthProviders[model.OAuthProviderGithub].(*githubProvider)
+	if !ok {
+		return nil
+	}
+
+	userAuthInfos, err := a.store.UserAuthInfo().GetUserAuthInfosByProvider(model.OAuthProviderGithub)
+	if err != nil {
+		return err
+	}
+
+	for _, userAuthInfo := range userAuthInfos {
+		logger := a.logger.WithField("user_id", userAuthInfo.UserID)
+
+		groups, err := provider.GetGroups(ctx, userAuthInfo.Token)
+		if err != nil {
+			var errorResponse *github.ErrorResponse
+			if !errors.As(err, &errorResponse) || errorResponse.Response.StatusCode != http.StatusUnauthorized {
+				logger.WithError(err).Warn("Failed to get the GitHub membership of the user")
+				continue
+			}
+			groups = nil
+		}
+
+		previous, err := a.store.GithubAccess().GetGithubMembership(userAuthInfo.UserID)
+		if err != nil {
+			return err
+		}
+
+		membership, err := a.SyncGithubAccess(userAuthInfo.UserID, groups)
+		if err != nil {
+			return err
+		}
+
+		if !membership.Allowed && (previous == nil || previous.Allowed) {
+			if err = a.RevokeUserSessions(userAuthInfo.UserID); err != nil {
+				return err
+			}
+			logger.Info("User lost access granted by GitHub membership")
+		}
+	}
+
+	return nil
+}
+
+// RunGithubMembershipSync syncs the GitHub memberships at the given interval
+// until the context is done.
+func (a *App) RunGithubMembershipSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.SyncGithubMemberships(ctx); err != nil {
+				a.logger.WithError(err).Error("Failed to sync GitHub memberships")
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/internal/oidc"
+	"github.com/saturninoabril/dashboard-server/model"
+	"golang.org/x/oauth2"
+)
+
+const defaultGitlabBaseURL = "https://gitlab.com"
+
+// gitlabProvider logs users in with their account on gitlab.com or on a self
+// hosted GitLab instance.
+type gitlabProvider struct {
+	config      GitlabOAuth
+	baseURL     string
+	redirectURL string
+}
+
+type gitlabUser struct {
+	ID          int64   `json:"id"`
+	Username    string  `json:"username"`
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	AvatarURL   string  `json:"avatar_url"`
+	ConfirmedAt *string `json:"confirmed_at"`
+}
+
+type gitlabEmail struct {
+	Email       string  `json:"email"`
+	ConfirmedAt *string `json:"confirmed_at"`
+}
+
+func newGitlabProvider(config GitlabOAuth, siteURL string) *gitlabProvider {
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultGitlabBaseURL
+	}
+	redirectURL := config.RedirectURL
+	if redirectURL == "" {
+		redirectURL = defaultRedirectURL(siteURL, model.OAuthProviderGitlab)
+	}
+
+	return &gitlabProvider{
+		config:      config,
+		baseURL:     baseURL,
+		redirectURL: redirectURL,
+	}
+}
+
+func (p *gitlabProvider) Name() string {
+	return model.OAuthProviderGitlab
+}
+
+func (p *gitlabProvider) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.redirectURL,
+		Scopes:       []string{"read_user"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.baseURL + "/oauth/authorize",
+			TokenURL: p.baseURL + "/oauth/token",
+		},
+	}
+}
+
+func (p *gitlabProvider) AuthCodeURL(ctx context.Context, oauthState *model.OAuthState) (string, error) {
+	return p.oauthConfig().AuthCodeURL(oauthState.Token,
+		oauth2.SetAuthURLParam("code_challenge", oidc.CodeChallenge(oauthState.CodeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// GetAccount returns the GitLab account of the code. Only the emails GitLab
+// confirmed are returned as verified.
+func (p *gitlabProvider) GetAccount(ctx context.Context, code string, oauthState *model.OAuthState) (*model.UserAuthInfo, []string, error) {
+	config := p.oauthConfig()
+	token, err := config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", oauthState.CodeVerifier))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to exchange oauth code into token")
+	}
+
+	client := config.Client(ctx, token)
+
+	var user gitlabUser
+	if err = p.get(ctx, client, "/api/v4/user", &user); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get authenticated GitLab user")
+	}
+
+	var gitlabEmails []gitlabEmail
+	if err = p.get(ctx, client, "/api/v4/user/emails", &gitlabEmails); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get the emails of the GitLab user")
+	}
+
+	var emails []string
+	if user.Email != "" && user.ConfirmedAt != nil {
+		emails = append(emails, strings.ToLower(user.Email))
+	}
+	for _, gitlabEmail := range gitlabEmails {
+		email := strings.ToLower(gitlabEmail.Email)
+		if gitlabEmail.ConfirmedAt == nil || email == "" || (len(emails) > 0 && emails[0] == email) {
+			continue
+		}
+		emails = append(emails, email)
+	}
+
+	userAuthInfo := &model.UserAuthInfo{
+		OAuthProvider:  model.OAuthProviderGitlab,
+		ProviderUserID: strconv.FormatInt(user.ID, 10),
+		Token:          *token,
+		Username:       user.Username,
+		Email:          strings.ToLower(user.Email),
+		Name:           user.Name,
+		AvatarURL:      user.AvatarURL,
+	}
+
+	return userAuthInfo, emails, nil
+}
+
+func (p *gitlabProvider) get(ctx context.Context, client *http.Client, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("GET %s failed with status code %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CheckSignup returns an error if the owner of the given email can't sign up
+// under the configured signup mode, with the given invitation if any.
+func (a *App) CheckSignup(email string, invitation *model.Invitation) error {
+	signup := a.config.Signup
+	if signup.SignupMode() == model.SignupModeClosed {
+		return errors.New("signup is closed")
+	}
+
+	if invitation != nil {
+		if !strings.EqualFold(invitation.Email, email) {
+			return errors.New("the invitation was sent to another email")
+		}
+		return nil
+	}
+
+	if signup.SignupMode() == model.SignupModeInvite {
+		return errors.New("signup requires an invitation")
+	}
+	if !signup.IsAllowedEmail(email) {
+		return errors.New("signup is not allowed for this email domain")
+	}
+
+	return nil
+}
+
+// CreateInvitation invites the owner of an email to sign up, replacing the
+// invitations previously sent to them, and sends them the invitation email.
+// The returned token is the one sent in the email.
+func (a *App) CreateInvitation(request *model.CreateInvitationRequest, inviterID string) (*model.Invitation, *model.Token, error) {
+	if a.config.Signup.SignupMode() == model.SignupModeClosed {
+		return nil, nil, errors.New("signup is closed")
+	}
+
+	invitation := &model.Invitation{
+		Email:     request.Email,
+		RoleIDs:   model.StringList(request.RoleIDs),
+		InviterID: inviterID,
+	}
+	invitation.PreSave()
+	if err := invitation.IsValid(); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid invitation")
+	}
+
+	for _, roleID := range invitation.RoleIDs {
+		role, err := a.store.Role().GetRole(roleID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if role == nil {
+			return nil, nil, errors.Errorf("role %s not found", roleID)
+		}
+	}
+
+	user, err := a.store.User().GetUserByEmail(invitation.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user != nil {
+		return nil, nil, errors.New("a user with this email already exists")
+	}
+
+	previous, err := a.store.Invitation().GetInvitationsByEmail(invitation.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range previous {
+		if err = a.RevokeInvitation(p.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err = a.store.Invitation().CreateInvitation(invitation); err != nil {
+		return nil, nil, err
+	}
+
+	extra, err := model.CreateTokenTypeInvitationExtra(invitation.ID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create token extra value")
+	}
+	token, err := a.store.Token().CreateToken(model.NewToken(model.TokenTypeInvitation, extra))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to save new token")
+	}
+
+	err = a.SendInvitationEmail(invitation.Email, i18n.DefaultLocale, a.config.SiteURL, token)
+	if err != nil {
+		if revokeErr := a.RevokeInvitation(invitation.ID); revokeErr != nil {
+			a.logger.WithError(revokeErr).WithField("invitation_id", invitation.ID).Error("Failed to revoke unsent invitation")
+		}
+		return nil, nil, err
+	}
+
+	a.logger.WithField("invitation_id", invitation.ID).WithField("inviter_id", inviterID).Info("Invitation sent")
+
+	return invitation, token, nil
+}
+
+// GetInvitations returns the invitations that can still be accepted.
+func (a *App) GetInvitations() ([]*model.Invitation, error) {
+	return a.store.Invitation().GetInvitations(model.GetMillis())
+}
+
+// GetInvitation returns the invitation of the given id.
+func (a *App) GetInvitation(id string) (*model.Invitation, error) {
+	return a.store.Invitation().GetInvitation(id)
+}
+
+// GetInvitationByToken returns the invitation sent with the given token, or
+// nil if the token is not a valid invitation token or the invitation expired.
+func (a *App) GetInvitationByToken(tokenValue string) (*model.Invitation, error) {
+	token, err := a.store.Token().GetToken(tokenValue)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Type != model.TokenTypeInvitation {
+		return nil, nil
+	}
+
+	invitationID, err := token.GetExtraInvitation()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to determine token invitation value")
+	}
+
+	invitation, err := a.store.Invitation().GetInvitation(invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.IsExpired() {
+		return nil, nil
+	}
+
+	return invitation, nil
+}
+
+// getPendingInvitation returns the newest invitation sent to the given email
+// that can still be accepted, if any.
+func (a *App) getPendingInvitation(email string) (*model.Invitation, error) {
+	invitations, err := a.store.Invitation().GetInvitationsByEmail(strings.ToLower(email))
+	if err != nil {
+		return nil, err
+	}
+	for _, invitation := range invitations {
+		if !invitation.IsExpired() {
+			return invitation, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// AcceptInvitation grants the roles of the invitation to the user who signed
+// up with it and marks their email as verified, since the invitation proves
+// they own it. The invitation can't be used again.
+func (a *App) AcceptInvitation(user *model.User, invitation *model.Invitation) error {
+	for _, roleID := range invitation.RoleIDs {
+		role, err := a.store.Role().GetRole(roleID)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			// The role was deleted since the invitation was sent.
+			a.logger.WithField("invitation_id", invitation.ID).WithField("role_id", roleID).Warn("Skipping deleted role of invitation")
+			continue
+		}
+
+		if _, err = a.AddUserRole(user.ID, roleID); err != nil {
+			return err
+		}
+	}
+
+	if err := a.store.User().VerifyEmail(user.ID, user.Email); err != nil {
+		return err
+	}
+
+	if err := a.RevokeInvitation(invitation.ID); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", user.ID).WithField("invitation_id", invitation.ID).Info("Invitation accepted")
+
+	return nil
+}
+
+// RevokeInvitation deletes an invitation along with its token.
+func (a *App) RevokeInvitation(id string) error {
+	extra, err := model.CreateTokenTypeInvitationExtra(id)
+	if err != nil {
+		return errors.Wrap(err, "failed to create token extra value")
+	}
+	if err = a.store.Token().DeleteTokensByExtra(extra, model.TokenTypeInvitation); err != nil {
+		return err
+	}
+
+	return a.store.Invitation().DeleteInvitation(id)
+}
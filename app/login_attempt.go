@@ -0,0 +1,163 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// loginAttemptSubjects returns the account and IP address subjects an action
+// is tracked by, skipping the empty ones.
+func loginAttemptSubjects(email, ipAddress string) map[string]string {
+	subjects := make(map[string]string)
+	if email != "" {
+		subjects[model.LoginAttemptScopeAccount] = strings.ToLower(email)
+	}
+	if ipAddress != "" {
+		subjects[model.LoginAttemptScopeIP] = ipAddress
+	}
+
+	return subjects
+}
+
+// CheckLoginAttempts returns how long to wait before the given action can be
+// attempted again against the account of the given email or from the given IP
+// address, zero if it can be attempted now. Either may be empty.
+func (a *App) CheckLoginAttempts(action, email, ipAddress string) (time.Duration, error) {
+	now := model.GetMillis()
+
+	var retryAfter time.Duration
+	for scope, subject := range loginAttemptSubjects(email, ipAddress) {
+		attempts, err := a.store.LoginAttempt().GetLoginAttempts(action, scope, subject)
+		if err != nil {
+			return 0, err
+		}
+		if wait := attempts.RetryAfter(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordLoginFailure counts a failed attempt of the given action against the
+// account of the given email and from the given IP address, delaying or
+// locking out further attempts according to the lockout policies. When a
+// login lockout starts, the owner of the account is sent a link to unlock it.
+// It returns the attempts that got locked out.
+func (a *App) RecordLoginFailure(action, email, ipAddress string) ([]*model.LoginAttempts, error) {
+	now := model.GetMillis()
+
+	var lockouts []*model.LoginAttempts
+	for scope, subject := range loginAttemptSubjects(email, ipAddress) {
+		policy := a.config.Lockout.Policy(scope)
+
+		attempts, err := a.store.LoginAttempt().RecordLoginFailure(action, scope, subject, now, policy.Window.Milliseconds())
+		if err != nil {
+			return nil, err
+		}
+
+		lockedUntil := policy.LockedUntil(attempts.Failures, attempts.LastFailureAt)
+		if lockedUntil == 0 {
+			continue
+		}
+		if err = a.store.LoginAttempt().LockLoginAttempts(action, scope, subject, lockedUntil); err != nil {
+			return nil, err
+		}
+
+		if !policy.IsLockout(attempts.Failures) {
+			continue
+		}
+		attempts.LockedUntil = lockedUntil
+		lockouts = append(lockouts, attempts)
+
+		a.logger.WithFields(logrus.Fields{
+			"action":  action,
+			"scope":   scope,
+			"subject": subject,
+		}).Warn("Locked out after too many failed attempts")
+
+		if action == model.LoginAttemptActionLogin && scope == model.LoginAttemptScopeAccount {
+			if err = a.notifyAccountLockout(subject); err != nil {
+				a.logger.WithError(err).Error("Unable to send unlock account email")
+			}
+		}
+	}
+
+	return lockouts, nil
+}
+
+// ClearLoginFailures forgets the failed attempts of the given action against
+// the account of the given email, once it succeeds. The failures from the IP
+// address are kept so that one valid account can't be used to reset them.
+func (a *App) ClearLoginFailures(action, email string) error {
+	return a.store.LoginAttempt().DeleteLoginAttempts(action, model.LoginAttemptScopeAccount, strings.ToLower(email))
+}
+
+// notifyAccountLockout sends the link unlocking the account of the given
+// email, if there is one.
+func (a *App) notifyAccountLockout(email string) error {
+	user, err := a.store.User().GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.State != model.UserStateActive {
+		return nil
+	}
+
+	token, err := a.CreateAndStoreUnlockAccountToken(user.Email)
+	if err != nil {
+		return err
+	}
+
+	return a.SendUnlockAccountEmail(user.Email, user.Locale, a.config.SiteURL, token)
+}
+
+// UnlockAccount clears the lockouts of the account the given unlock account
+// token was sent to, and consumes the token.
+func (a *App) UnlockAccount(token *model.Token) error {
+	email, err := token.GetExtraEmail()
+	if err != nil {
+		return errors.Wrap(err, "failed to determine token email value")
+	}
+
+	if err = a.ClearLockout(model.LoginAttemptScopeAccount, email); err != nil {
+		return err
+	}
+
+	if err = a.store.Token().DeleteToken(token.Token); err != nil {
+		a.logger.WithError(err).Errorf("Failed to remove claimed %s token", model.TokenTypeUnlockAccount)
+	}
+
+	return nil
+}
+
+// GetLockouts returns the accounts and IP addresses currently delayed or
+// locked out.
+func (a *App) GetLockouts() ([]*model.LoginAttempts, error) {
+	return a.store.LoginAttempt().GetLockedLoginAttempts(model.GetMillis())
+}
+
+// ClearLockout forgets the failed attempts of every action against an account
+// or from an IP address, lifting their lockouts.
+func (a *App) ClearLockout(scope, subject string) error {
+	if scope != model.LoginAttemptScopeAccount && scope != model.LoginAttemptScopeIP {
+		return errors.Errorf("invalid scope %s", scope)
+	}
+	if scope == model.LoginAttemptScopeAccount {
+		subject = strings.ToLower(subject)
+	}
+
+	err := a.store.LoginAttempt().DeleteLoginAttemptsForSubject(scope, subject)
+	if err != nil {
+		return err
+	}
+
+	a.logger.WithField("scope", scope).WithField("subject", subject).Info("Lockout cleared")
+
+	return nil
+}
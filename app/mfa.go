@@ -0,0 +1,322 @@
+package app
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/internal/totp"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// mfaIssuer is the name authenticator apps show the codes under.
+const mfaIssuer = "Dashboard"
+
+// GetMFAStatus returns the two-factor authentication status of the given user.
+func (a *App) GetMFAStatus(userID string) (*model.MFAStatus, error) {
+	mfa, err := a.store.MFA().GetUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := a.UserRequiresMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.MFAStatus{
+		Enabled:  mfa != nil && mfa.Enabled,
+		Required: required,
+	}
+	if status.Enabled {
+		status.RecoveryCodesLeft, err = a.store.MFA().CountMFARecoveryCodes(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// UserRequiresMFA returns true if one of the roles of the given user requires
+// two-factor authentication.
+func (a *App) UserRequiresMFA(userID string) (bool, error) {
+	roles, err := a.store.Role().GetRolesForUser(userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		if role.RequireMFA {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// SetRoleRequireMFA sets whether users with the given role must log in with
+// two-factor authentication. Users without an authenticator enroll one on
+// their next login.
+func (a *App) SetRoleRequireMFA(role *model.Role, requireMFA bool) (*model.Role, error) {
+	if err := a.store.Role().SetRoleRequireMFA(role.ID, requireMFA); err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("role_id", role.ID).WithField("require_mfa", requireMFA).Info("Role MFA requirement updated")
+
+	return a.store.Role().GetRole(role.ID)
+}
+
+// StartMFAEnrollment generates a new TOTP secret for the given user, which is
+// only enabled once confirmed with a code. Starting over replaces the pending
+// secret.
+func (a *App) StartMFAEnrollment(user *model.User) (*model.MFAEnrollment, error) {
+	mfa, err := a.store.MFA().GetUserMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.store.MFA().SaveUserMFA(&model.UserMFA{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment enables the pending authenticator of the given user if
+// the code is valid, and returns a new set of recovery codes.
+func (a *App) ConfirmMFAEnrollment(userID, code string) ([]string, error) {
+	mfa, err := a.store.MFA().GetUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.New("no pending two-factor enrollment")
+	}
+	if mfa.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	step, ok := totp.Validate(mfa.Secret, code, time.Now(), mfa.LastStep)
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+
+	mfa.Enabled = true
+	mfa.LastStep = step
+	if err = a.store.MFA().SaveUserMFA(mfa); err != nil {
+		return nil, err
+	}
+
+	codes, err := a.resetMFARecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("user_id", userID).Info("Two-factor authentication enabled")
+
+	return codes, nil
+}
+
+// VerifyMFACode returns true if the given code is a valid TOTP code of the
+// enabled authenticator of the user, or one of their unused recovery codes.
+// Each code can only be used once.
+func (a *App) VerifyMFACode(userID, code string) (bool, error) {
+	mfa, err := a.store.MFA().GetUserMFA(userID)
+	if err != nil {
+		return false, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return false, nil
+	}
+
+	if model.IsMFARecoveryCode(code) {
+		used, err := a.store.MFA().UseMFARecoveryCode(userID, model.HashMFARecoveryCode(code))
+		if err != nil {
+			return false, err
+		}
+		if used {
+			a.logger.WithField("user_id", userID).Info("Two-factor recovery code used")
+		}
+		return used, nil
+	}
+
+	step, ok := totp.Validate(mfa.Secret, code, time.Now(), mfa.LastStep)
+	if !ok {
+		return false, nil
+	}
+
+	// Concurrent logins may have used the same code in the meantime.
+	return a.store.MFA().UseMFAStep(userID, step)
+}
+
+// DisableMFA removes the authenticator of the given user, whose code was
+// checked by the caller. Users whose role requires two-factor authentication
+// can't disable it.
+func (a *App) DisableMFA(userID string) error {
+	required, err := a.UserRequiresMFA(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required by your role")
+	}
+
+	if err = a.store.MFA().DeleteUserMFA(userID); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", userID).Info("Two-factor authentication disabled")
+
+	return nil
+}
+
+// RegenerateMFARecoveryCodes replaces the recovery codes of the given user,
+// whose code was checked by the caller.
+func (a *App) RegenerateMFARecoveryCodes(userID string) ([]string, error) {
+	return a.resetMFARecoveryCodes(userID)
+}
+
+func (a *App) resetMFARecoveryCodes(userID string) ([]string, error) {
+	codes := model.NewMFARecoveryCodes()
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, model.HashMFARecoveryCode(code))
+	}
+
+	if err := a.store.MFA().SetMFARecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// ResetMFA removes the authenticator of the given user, e.g. when they lost
+// both their authenticator and recovery codes.
+func (a *App) ResetMFA(userID string) error {
+	if err := a.store.MFA().DeleteUserMFA(userID); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", userID).Info("Two-factor authentication reset")
+
+	return nil
+}
+
+// CreateMFAChallenge returns the challenge to complete before logging the
+// given user in, or nil if they can log in without a second factor. The source
+// is the one of the session created on completion.
+func (a *App) CreateMFAChallenge(userID, source string) (*model.MFAChallenge, error) {
+	mfa, err := a.store.MFA().GetUserMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &model.MFAChallenge{}
+	if mfa == nil || !mfa.Enabled {
+		required, err := a.UserRequiresMFA(userID)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+		challenge.EnrollmentRequired = true
+	}
+
+	extra, err := model.CreateTokenTypeMFAChallengeExtra(userID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	token := model.NewToken(model.TokenTypeMFAChallenge, extra)
+	if _, err = a.store.Token().CreateToken(token); err != nil {
+		return nil, err
+	}
+	challenge.Token = token.Token
+
+	return challenge, nil
+}
+
+// GetMFAChallenge returns the user logging in with the given challenge, or
+// nil if it expired or doesn't exist.
+func (a *App) GetMFAChallenge(challengeToken string) (*model.TokenExtraMFAChallenge, error) {
+	token, err := a.store.Token().GetToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Type != model.TokenTypeMFAChallenge ||
+		token.CreateAt < model.GetMillis()-model.MFAChallengeExpiryTime {
+		return nil, nil
+	}
+
+	return token.GetExtraMFAChallenge()
+}
+
+// CompleteMFAChallenge checks the code of the user logging in with the given
+// challenge, returning the user on success. The challenge is consumed even if
+// the code is invalid so that codes can't be guessed with it.
+func (a *App) CompleteMFAChallenge(challengeToken, code string) (*model.TokenExtraMFAChallenge, error) {
+	challenge, err := a.consumeMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := a.VerifyMFACode(challenge.UserID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid code")
+	}
+
+	return challenge, nil
+}
+
+// CompleteMFAChallengeEnrollment confirms the enrollment started by the user
+// logging in with the given challenge, returning the user and their recovery
+// codes on success. The challenge is consumed even if the code is invalid.
+func (a *App) CompleteMFAChallengeEnrollment(challengeToken, code string) (*model.TokenExtraMFAChallenge, []string, error) {
+	challenge, err := a.consumeMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	codes, err := a.ConfirmMFAEnrollment(challenge.UserID, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return challenge, codes, nil
+}
+
+func (a *App) consumeMFAChallenge(challengeToken string) (*model.TokenExtraMFAChallenge, error) {
+	challenge, err := a.GetMFAChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, errors.New("challenge expired or not found")
+	}
+
+	if err = a.store.Token().DeleteToken(challengeToken); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// GetNotificationPreferences returns the notification preferences of a user,
+// or the defaults if the user never changed them.
+func (a *App) GetNotificationPreferences(userID string) (*model.NotificationPreferences, error) {
+	preferences, err := a.store.NotificationPreferences().GetNotificationPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil {
+		return model.NewNotificationPreferences(userID), nil
+	}
+
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences saves the notification preferences of a user.
+func (a *App) UpdateNotificationPreferences(preferences *model.NotificationPreferences) (*model.NotificationPreferences, error) {
+	err := a.store.NotificationPreferences().SaveNotificationPreferences(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// ShouldNotify returns true if the preferences of the user allow notifying them
+// of the given event on the given channel. Account and security events are
+// always notified.
+func (a *App) ShouldNotify(userID, channel, event, repo, branch string) (bool, error) {
+	if model.IsMandatoryNotificationEvent(event) {
+		return true, nil
+	}
+
+	preferences, err := a.GetNotificationPreferences(userID)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get notification preferences")
+	}
+
+	return preferences.Allows(channel, event, repo, branch), nil
+}
+
+// GetUnsubscribeToken returns the token unsubscribing the user from emails
+// of the given event, or from every email if the event is empty, to link to
+// from notification emails. Every email of a user and event links to the same
+// token, which doesn't expire.
+func (a *App) GetUnsubscribeToken(userID, event string) (*model.Token, error) {
+	extra, err := model.CreateTokenTypeUnsubscribeExtra(userID, event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create token extra value")
+	}
+
+	token, err := a.store.Token().CreateDerivedToken(model.TokenTypeUnsubscribe, extra)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to save unsubscribe token")
+	}
+
+	return token, nil
+}
+
+// Unsubscribe applies the given unsubscribe token to the preferences of its
+// user.
+func (a *App) Unsubscribe(token *model.Token) (*model.NotificationPreferences, error) {
+	extra, err := token.GetExtraUnsubscribe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to determine token values")
+	}
+
+	preferences, err := a.GetNotificationPreferences(extra.UserID)
+	if err != nil {
+		return nil, err
+	}
+	preferences.Unsubscribe(extra.Event)
+
+	return a.UpdateNotificationPreferences(preferences)
+}
+
+// SendNotificationEmail sends an email about the given event to the user,
+// unless their notification preferences exclude it. Every notification email
+// goes through it. Emails of events the user can unsubscribe from contain a
+// one-click link doing so.
+func (a *App) SendNotificationEmail(user *model.User, event, repo, branch, subject string, bodyPage *HTMLTemplate) error {
+	notify, err := a.ShouldNotify(user.ID, model.NotificationChannelEmail, event, repo, branch)
+	if err != nil {
+		return err
+	}
+	if !notify {
+		a.logger.WithField("user_id", user.ID).Debugf("Skipping %s email according to the user notification preferences", event)
+		return nil
+	}
+
+	if !model.IsMandatoryNotificationEvent(event) {
+		token, err := a.GetUnsubscribeToken(user.ID, event)
+		if err != nil {
+			return err
+		}
+		bodyPage.Props["UnsubscribeURL"] = fmt.Sprintf("%s/unsubscribe?token=%s", a.Config().SiteURL, url.QueryEscape(token.Token))
+	}
+
+	renderedBody, err := bodyPage.Render()
+	if err != nil {
+		return errors.Wrapf(err, "unable to render %s email", event)
+	}
+
+	err = a.SendMail(user.Email, subject, renderedBody, false)
+	if err != nil {
+		return errors.Wrapf(err, "unable to send %s email", event)
+	}
+
+	return nil
+}
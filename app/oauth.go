@@ -1,60 +1,217 @@
 package app
 
 import (
-	"context"
-	"net/url"
-	"path"
+	"strings"
 
-	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
 	"github.com/saturninoabril/dashboard-server/model"
-	"golang.org/x/oauth2"
 )
 
-func (a *App) CreateOAuthState() (*model.OAuthState, error) {
-	return a.store.OAuthState().CreateOAuthState()
+// CreateOAuthState creates the state of a new OAuth flow with the given
+// provider. The user id is only set when a logged in user is linking their
+// account.
+func (a *App) CreateOAuthState(provider, userID string) (*model.OAuthState, error) {
+	return a.store.OAuthState().CreateOAuthState(provider, userID)
 }
 
 func (a *App) GetOAuthState(idOrToken string) (*model.OAuthState, error) {
 	return a.store.OAuthState().GetOAuthState(idOrToken)
 }
 
-func (a *App) GetOAuthConfig() *oauth2.Config {
-	scopes := []string{
-		string(github.ScopeUserEmail),
-		string(github.ScopeReadOrg),
+// ConsumeOAuthState returns the OAuth state of the given token and deletes it
+// so that it can't be used again. Returns nil if the state is unknown or
+// expired.
+func (a *App) ConsumeOAuthState(token string) (*model.OAuthState, error) {
+	oauthState, err := a.store.OAuthState().GetOAuthState(token)
+	if err != nil || oauthState == nil {
+		return nil, err
+	}
+
+	if err = a.store.OAuthState().DeleteOAuthState(oauthState.ID); err != nil {
+		return nil, err
+	}
+
+	return oauthState, nil
+}
+
+// LoginWithOAuthAccount returns the user to log in with the given account of
+// an OAuth provider. The user linked to the account is returned if any.
+// Otherwise the account is linked to the user matching one of its verified
+// emails, or to a new user created from the primary one.
+func (a *App) LoginWithOAuthAccount(userAuthInfo *model.UserAuthInfo, verifiedEmails []string) (*model.User, error) {
+	existing, err := a.store.UserAuthInfo().GetUserAuthInfoByProviderUserID(userAuthInfo.OAuthProvider, userAuthInfo.ProviderUserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		userAuthInfo.UserID = existing.UserID
+		if err = a.store.UserAuthInfo().UpdateUserAuthInfo(userAuthInfo); err != nil {
+			return nil, err
+		}
+
+		return a.User().Get(existing.UserID)
+	}
+
+	if len(verifiedEmails) == 0 {
+		return nil, errors.Errorf("the %s account has no verified email", userAuthInfo.OAuthProvider)
+	}
+
+	var user *model.User
+	for _, email := range verifiedEmails {
+		user, err = a.User().GetByEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			break
+		}
+	}
+
+	if user != nil {
+		// The account could have been registered by someone else who never
+		// proved they own the email, so it must not be taken over.
+		if !user.EmailVerified {
+			return nil, errors.New("an account with this email exists but is not verified, log in to link it")
+		}
+	} else {
+		user, err = a.signUpWithOAuthAccount(userAuthInfo, verifiedEmails)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	userAuthInfo.UserID = user.ID
+	if _, err = a.store.UserAuthInfo().CreateUserAuthInfo(userAuthInfo); err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("user_id", user.ID).WithField("provider", userAuthInfo.OAuthProvider).Info("OAuth account linked on login")
+
+	return a.User().Get(user.ID)
+}
+
+// signUpWithOAuthAccount creates a user from the given account of an OAuth
+// provider if signup allows it. A pending invitation sent to one of the
+// verified emails of the account is accepted, otherwise the user is created
+// from the primary one.
+func (a *App) signUpWithOAuthAccount(userAuthInfo *model.UserAuthInfo, verifiedEmails []string) (*model.User, error) {
+	email := verifiedEmails[0]
+	var invitation *model.Invitation
+	for _, verifiedEmail := range verifiedEmails {
+		pending, err := a.getPendingInvitation(verifiedEmail)
+		if err != nil {
+			return nil, err
+		}
+		if pending != nil {
+			email, invitation = verifiedEmail, pending
+			break
+		}
+	}
+
+	if err := a.CheckSignup(email, invitation); err != nil {
+		return nil, err
 	}
 
-	baseURL := "https://github.com/"
-	authURL, _ := url.Parse(baseURL)
-	tokenURL, _ := url.Parse(baseURL)
+	user, err := a.createUserFromOAuthAccount(userAuthInfo, email)
+	if err != nil {
+		return nil, err
+	}
 
-	authURL.Path = path.Join(authURL.Path, "login", "oauth", "authorize")
-	tokenURL.Path = path.Join(tokenURL.Path, "login", "oauth", "access_token")
+	if invitation != nil {
+		if err = a.AcceptInvitation(user, invitation); err != nil {
+			return nil, err
+		}
 
-	return &oauth2.Config{
-		ClientID:     a.config.GithubOAuth.ClientID,
-		ClientSecret: a.config.GithubOAuth.ClientSecret,
-		Scopes:       scopes,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:   authURL.String(),
-			TokenURL:  tokenURL.String(),
-			AuthStyle: oauth2.AuthStyleInHeader,
-		},
+		// Failures are logged by the audit service.
+		a.audit.Record(&model.AuditLog{
+			Action:    model.AuditActionInvitationAccept,
+			ActorID:   user.ID,
+			ActorType: model.AuditActorUser,
+			TargetID:  user.ID,
+			Details:   invitation.AuditDetails(),
+		})
 	}
+
+	return user, nil
 }
 
-func (a *App) GithubConnectToken(token oauth2.Token) (*github.Client, error) {
-	client, err := GetGitHubClient(token)
+// createUserFromOAuthAccount creates a user with a verified email and a
+// random password from the given account of an OAuth provider.
+func (a *App) createUserFromOAuthAccount(userAuthInfo *model.UserAuthInfo, email string) (*model.User, error) {
+	firstName, lastName := userAuthInfo.Name, ""
+	if i := strings.LastIndex(userAuthInfo.Name, " "); i > 0 {
+		firstName, lastName = userAuthInfo.Name[:i], userAuthInfo.Name[i+1:]
+	}
+
+	user, err := a.User().Create(&model.User{
+		Email:     email,
+		Password:  model.NewRandomString(32),
+		FirstName: firstName,
+		LastName:  lastName,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	if err = a.User().VerifyEmail(user.ID, user.Email); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// LinkOAuthAccount links the given account of an OAuth provider to a user,
+// replacing the account they previously linked on the same provider.
+func (a *App) LinkOAuthAccount(userID string, userAuthInfo *model.UserAuthInfo) error {
+	existing, err := a.store.UserAuthInfo().GetUserAuthInfoByProviderUserID(userAuthInfo.OAuthProvider, userAuthInfo.ProviderUserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.UserID != userID {
+		return errors.New("account is linked to another user")
+	}
+
+	userAuthInfo.UserID = userID
+	if existing != nil {
+		return a.store.UserAuthInfo().UpdateUserAuthInfo(userAuthInfo)
+	}
+
+	if err = a.store.UserAuthInfo().DeleteUserAuthInfo(userID, userAuthInfo.OAuthProvider); err != nil {
+		return err
+	}
+	if _, err = a.store.UserAuthInfo().CreateUserAuthInfo(userAuthInfo); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", userID).WithField("provider", userAuthInfo.OAuthProvider).Info("OAuth account linked")
+
+	return nil
+}
+
+// GetUserAuthInfo returns the account a user linked on the given OAuth
+// provider.
+func (a *App) GetUserAuthInfo(userID, oauthProvider string) (*model.UserAuthInfo, error) {
+	return a.store.UserAuthInfo().GetUserAuthInfo(userID, oauthProvider)
 }
 
-func GetGitHubClient(token oauth2.Token) (*github.Client, error) {
-	ts := oauth2.StaticTokenSource(&token)
-	authenticatedClient := oauth2.NewClient(context.Background(), ts)
+// UnlinkOAuthAccount unlinks the account a user linked on the given OAuth
+// provider. Unlinking a GitHub account removes the roles granted by the GitHub
+// access rules.
+func (a *App) UnlinkOAuthAccount(userID, oauthProvider string) error {
+	if err := a.store.UserAuthInfo().DeleteUserAuthInfo(userID, oauthProvider); err != nil {
+		return err
+	}
+
+	if oauthProvider == model.OAuthProviderGithub {
+		if _, err := a.SyncGithubAccess(userID, nil); err != nil {
+			return err
+		}
+		if err := a.store.GithubAccess().DeleteGithubMembership(userID); err != nil {
+			return err
+		}
+	}
+
+	a.logger.WithField("user_id", userID).WithField("provider", oauthProvider).Info("OAuth account unlinked")
 
-	return github.NewClient(authenticatedClient), nil
+	return nil
 }
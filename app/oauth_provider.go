@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// OAuthProvider is a provider users can log in with and link their account
+// to.
+type OAuthProvider interface {
+	// Name returns the name of the provider used in routes and stored with
+	// the accounts.
+	Name() string
+
+	// AuthCodeURL returns the URL to redirect the user to in order to start
+	// the flow of the given state.
+	AuthCodeURL(ctx context.Context, oauthState *model.OAuthState) (string, error)
+
+	// GetAccount exchanges the authorization code of a completed flow and
+	// returns the account it grants access to, along with its verified
+	// emails, the primary one first.
+	GetAccount(ctx context.Context, code string, oauthState *model.OAuthState) (*model.UserAuthInfo, []string, error)
+}
+
+// newOAuthProviders returns the providers enabled by the given config by
+// name.
+func newOAuthProviders(config Config) map[string]OAuthProvider {
+	providers := make(map[string]OAuthProvider)
+	if config.GithubOAuth.ClientID != "" {
+		providers[model.OAuthProviderGithub] = newGithubProvider(config.GithubOAuth)
+	}
+	if config.GitlabOAuth.ClientID != "" {
+		providers[model.OAuthProviderGitlab] = newGitlabProvider(config.GitlabOAuth, config.SiteURL)
+	}
+	if config.OIDC.Enabled() {
+		providers[model.OAuthProviderOIDC] = newOIDCProvider(config.OIDC, config.SiteURL)
+	}
+
+	return providers
+}
+
+// GetOAuthProvider returns the enabled OAuth provider of the given name, or
+// nil if there is none.
+func (a *App) GetOAuthProvider(name string) OAuthProvider {
+	return a.oauthProviders[name]
+}
+
+// GetOAuthProviderNames returns the names of the enabled OAuth providers.
+func (a *App) GetOAuthProviderNames() []string {
+	names := make([]string, 0, len(a.oauthProviders))
+	for name := range a.oauthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// defaultRedirectURL returns the page of the webapp completing the flow of
+// the given provider.
+func defaultRedirectURL(siteURL, provider string) string {
+	return strings.TrimSuffix(siteURL, "/") + "/oauth/" + provider + "/complete"
+}
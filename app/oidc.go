@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/internal/oidc"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+const (
+	defaultOIDCEmailClaim  = "email"
+	defaultOIDCNameClaim   = "name"
+	defaultOIDCGroupsClaim = "groups"
+)
+
+// oidcProvider logs users in with the configured OpenID Connect provider,
+// which is discovered lazily so that the server starts even if the provider
+// is unreachable.
+type oidcProvider struct {
+	config      OIDC
+	redirectURL string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func newOIDCProvider(config OIDC, siteURL string) *oidcProvider {
+	redirectURL := config.RedirectURL
+	if redirectURL == "" {
+		redirectURL = defaultRedirectURL(siteURL, model.OAuthProviderOIDC)
+	}
+
+	return &oidcProvider{
+		config:      config,
+		redirectURL: redirectURL,
+	}
+}
+
+func (p *oidcProvider) Name() string {
+	return model.OAuthProviderOIDC
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"email", "profile"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		IssuerURL:    p.config.IssuerURL,
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.provider = provider
+
+	return provider, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, oauthState *model.OAuthState) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return provider.AuthCodeURL(oauthState.Token, oauthState.Nonce, oauthState.CodeVerifier), nil
+}
+
+// GetAccount returns the account described by the ID token, the email is
+// only returned as verified if the provider says so.
+func (p *oidcProvider) GetAccount(ctx context.Context, code string, oauthState *model.OAuthState) (*model.UserAuthInfo, []string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, rawIDToken, err := provider.Exchange(ctx, code, oauthState.CodeVerifier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idToken, err := provider.VerifyIDToken(ctx, rawIDToken, oauthState.Nonce)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to verify the ID token")
+	}
+
+	var claims map[string]interface{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode the ID token claims")
+	}
+
+	emailClaim := p.config.EmailClaim
+	if emailClaim == "" {
+		emailClaim = defaultOIDCEmailClaim
+	}
+	nameClaim := p.config.NameClaim
+	if nameClaim == "" {
+		nameClaim = defaultOIDCNameClaim
+	}
+	groupsClaim := p.config.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultOIDCGroupsClaim
+	}
+
+	email := strings.ToLower(stringClaim(claims, emailClaim))
+	userAuthInfo := &model.UserAuthInfo{
+		OAuthProvider:  model.OAuthProviderOIDC,
+		ProviderUserID: idToken.Subject,
+		Token:          *token,
+		Username:       stringClaim(claims, "preferred_username"),
+		Email:          email,
+		Name:           stringClaim(claims, nameClaim),
+		AvatarURL:      stringClaim(claims, "picture"),
+		Groups:         stringListClaim(claims, groupsClaim),
+	}
+
+	var verifiedEmails []string
+	if email != "" && boolClaim(claims, "email_verified") {
+		verifiedEmails = append(verifiedEmails, email)
+	}
+
+	return userAuthInfo, verifiedEmails, nil
+}
+
+// SyncOAuthRoles synchronizes the roles of a user with the groups of the
+// account they logged in or linked with. Only OpenID Connect and GitHub
+// accounts have groups.
+func (a *App) SyncOAuthRoles(userID string, userAuthInfo *model.UserAuthInfo) error {
+	switch userAuthInfo.OAuthProvider {
+	case model.OAuthProviderOIDC:
+		return a.SyncOIDCRoles(userID, userAuthInfo.Groups)
+	case model.OAuthProviderGithub:
+		_, err := a.SyncGithubAccess(userID, userAuthInfo.Groups)
+		return err
+	}
+
+	return nil
+}
+
+// SyncOIDCRoles grants the roles mapped to the given groups of the OpenID
+// Connect provider to a user, and removes the mapped roles of the groups they
+// are no longer a member of. Roles which are not mapped are left untouched.
+func (a *App) SyncOIDCRoles(userID string, groups []string) error {
+	if len(a.config.OIDC.GroupRoles) == 0 {
+		return nil
+	}
+
+	memberOf := make(map[string]bool)
+	for _, group := range groups {
+		memberOf[group] = true
+	}
+
+	granted := make(map[string]bool)
+	for group, roleName := range a.config.OIDC.GroupRoles {
+		granted[roleName] = granted[roleName] || memberOf[group]
+	}
+
+	for roleName, grant := range granted {
+		role, err := a.store.Role().GetRoleByName(roleName)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			a.logger.WithField("role", roleName).Warn("Unknown role mapped to OpenID Connect groups")
+			continue
+		}
+
+		hasRole, err := a.store.Role().UserHasRole(userID, role.ID)
+		if err != nil {
+			return err
+		}
+
+		if grant && !hasRole {
+			if _, err = a.AddUserRole(userID, role.ID); err != nil {
+				return err
+			}
+			a.recordSyncedRoleChange(model.AuditActionRoleAdd, userID, role.ID, model.OAuthProviderOIDC)
+		} else if !grant && hasRole {
+			if _, err = a.RemoveUserRole(userID, role.ID); err != nil {
+				return err
+			}
+			a.recordSyncedRoleChange(model.AuditActionRoleRemove, userID, role.ID, model.OAuthProviderOIDC)
+		}
+	}
+
+	return nil
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func boolClaim(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		// Some providers send booleans as strings.
+		return value == "true"
+	}
+
+	return false
+}
+
+func stringListClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/internal/hibp"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CheckPassword returns the reasons the user can't choose the given password
+// under the configured password policy, or nil if they can. The ID of the
+// user is unset when signing up, and their Password is the hash of their
+// current password otherwise.
+func (a *App) CheckPassword(user *model.User, password string) ([]string, error) {
+	policy := a.config.Passwords.PasswordPolicy()
+	reasons := policy.Check(password, user)
+
+	if user.ID != "" && policy.HistorySize > 0 {
+		reused, err := a.isPasswordReused(user, password, policy.HistorySize)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			reasons = append(reasons, model.PasswordReasonReused)
+		}
+	}
+
+	if a.config.Passwords.BreachedList != "" {
+		breached, err := isPasswordBreached(a.config.Passwords.BreachedList, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			reasons = append(reasons, model.PasswordReasonBreached)
+		}
+	}
+
+	return reasons, nil
+}
+
+// isPasswordReused returns true if the password is the current one of the
+// user or one of their previous ones within the given history size.
+func (a *App) isPasswordReused(user *model.User, password string, historySize int) (bool, error) {
+	hashes, err := a.store.PasswordHistory().GetPasswordHistory(user.ID, historySize-1)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hash := range append([]string{user.Password}, hashes...) {
+		if a.user.ComparePassword(hash, password) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isPasswordBreached returns true if the password is in the Pwned Passwords
+// list at the given path.
+func isPasswordBreached(path, password string) (bool, error) {
+	list, err := hibp.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer list.Close()
+
+	breached, err := list.Contains(password)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check breached passwords")
+	}
+
+	return breached, nil
+}
+
+// UpdatePassword replaces the password of the user, whose Password is the
+// hash of their current password, and keeps the current one in their
+// password history as long as the policy prevents its reuse.
+func (a *App) UpdatePassword(user *model.User, password string) error {
+	hash, err := a.user.HashPassword(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+	if err = a.store.User().UpdatePassword(user.ID, hash); err != nil {
+		return err
+	}
+
+	keep := a.config.Passwords.PasswordPolicy().HistorySize - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if err := a.store.PasswordHistory().AddPasswordHistory(user.ID, user.Password, keep); err != nil {
+		// The password was changed, only its reuse won't be prevented.
+		a.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record password history")
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CreatePersonalAccessToken creates a new personal access token for the given
+// user and returns it along with the token value, which can't be retrieved
+// afterwards.
+func (a *App) CreatePersonalAccessToken(userID string, request *model.CreatePersonalAccessTokenRequest) (*model.CreatePersonalAccessTokenResponse, error) {
+	token := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      request.Name,
+		Scope:     request.Scope,
+		ExpiresAt: request.ExpiresAt,
+	}
+	value := token.PreSave()
+
+	token, err := a.store.PersonalAccessToken().CreatePersonalAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("personal_access_token_id", token.ID).WithField("user_id", userID).Info("Personal access token created")
+
+	return &model.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: token,
+		Token:               value,
+	}, nil
+}
+
+// GetPersonalAccessToken returns the personal access token of the given id.
+func (a *App) GetPersonalAccessToken(id string) (*model.PersonalAccessToken, error) {
+	return a.store.PersonalAccessToken().GetPersonalAccessToken(id)
+}
+
+// GetPersonalAccessTokensForUser returns the personal access tokens of a user
+// that were not revoked.
+func (a *App) GetPersonalAccessTokensForUser(userID string) ([]*model.PersonalAccessToken, error) {
+	return a.store.PersonalAccessToken().GetPersonalAccessTokensForUser(userID)
+}
+
+// RevokePersonalAccessToken revokes the personal access token of the given id.
+func (a *App) RevokePersonalAccessToken(id string) error {
+	err := a.store.PersonalAccessToken().DeletePersonalAccessToken(id)
+	if err != nil {
+		return err
+	}
+
+	a.logger.WithField("personal_access_token_id", id).Info("Personal access token revoked")
+
+	return nil
+}
+
+// GetSessionForPersonalAccessToken returns a session acting on behalf of the
+// user of the given personal access token, or nil if the token is unknown,
+// expired or revoked, or its user is locked or deleted.
+func (a *App) GetSessionForPersonalAccessToken(value string) (*model.Session, error) {
+	token, err := a.store.PersonalAccessToken().GetPersonalAccessTokenByHash(model.HashToken(value))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get personal access token")
+	}
+	if token == nil || token.IsRevoked() || token.IsExpired() {
+		return nil, nil
+	}
+	if active, err := a.isActiveUser(token.UserID); err != nil || !active {
+		return nil, err
+	}
+
+	now := model.GetMillis()
+	if now-token.LastUsedAt > tokenUsageInterval {
+		err = a.store.PersonalAccessToken().UpdatePersonalAccessTokenLastUsed(token.ID, now)
+		if err != nil {
+			a.logger.WithError(err).WithField("personal_access_token_id", token.ID).Warn("Unable to record personal access token usage")
+		} else {
+			token.LastUsedAt = now
+		}
+	}
+
+	return &model.Session{
+		ID:                  token.ID,
+		CreateAt:            token.CreateAt,
+		ExpiresAt:           token.ExpiresAt,
+		UserID:              token.UserID,
+		Source:              model.SessionSourcePersonalAccessToken,
+		LastActivityAt:      token.LastUsedAt,
+		PersonalAccessToken: token,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/saturninoabril/dashboard-server/internal/ratelimit"
+	"github.com/saturninoabril/dashboard-server/store"
+)
+
+const (
+	// rateLimitCleanupInterval is how often unused rate limits are removed.
+	rateLimitCleanupInterval = time.Hour
+	// rateLimitMaxIdle is how long a rate limit is kept unused. It must be
+	// longer than the periods of the limits.
+	rateLimitMaxIdle = 24 * time.Hour
+)
+
+// defaultRateLimit is the limit of the routes without their own when none is
+// configured.
+var defaultRateLimit = ratelimit.Limit{Requests: 600, Period: time.Minute}
+
+// newRateLimiter creates the rate limiter of the given configuration, or nil
+// if rate limiting is disabled.
+func newRateLimiter(config RateLimit, store store.Store) *ratelimit.Limiter {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Store == RateLimitStoreDatabase {
+		return ratelimit.New(store.RateLimit())
+	}
+
+	return ratelimit.New(ratelimit.NewMemoryStore())
+}
+
+// RateLimitRequest takes a token for a request from the bucket of the given
+// key, using the default limit if the given one is not set. It returns nil if
+// rate limiting is disabled.
+func (a *App) RateLimitRequest(key string, limit ratelimit.Limit) (*ratelimit.Result, error) {
+	if a.rateLimiter == nil {
+		return nil, nil
+	}
+	if limit.IsZero() {
+		limit = a.config.RateLimit.Default
+	}
+	if limit.IsZero() {
+		limit = defaultRateLimit
+	}
+
+	return a.rateLimiter.Allow(key, limit)
+}
+
+// RunRateLimitCleanup removes the unused rate limits periodically until the
+// given context is done.
+func (a *App) RunRateLimitCleanup(ctx context.Context) {
+	if a.rateLimiter == nil {
+		return
+	}
+
+	a.rateLimiter.RunCleanup(ctx, rateLimitCleanupInterval, rateLimitMaxIdle, func(err error) {
+		a.logger.WithError(err).Error("Unable to clean up rate limits")
+	})
+}
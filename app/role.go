@@ -0,0 +1,69 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// CreateRole creates a custom role granting the given permissions.
+func (a *App) CreateRole(request *model.CreateRoleRequest) (*model.Role, error) {
+	role := &model.Role{
+		ID:          model.NewID(),
+		Name:        request.Name,
+		Permissions: model.StringList(request.Permissions),
+	}
+	if role.Permissions == nil {
+		role.Permissions = model.StringList{}
+	}
+	if err := role.IsValid(); err != nil {
+		return nil, err
+	}
+
+	role, err := a.store.Role().CreateRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.store.Role().SetRolePermissions(role.ID, role.Permissions); err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("role_id", role.ID).WithField("role", role.Name).Info("Role created")
+
+	return a.store.Role().GetRole(role.ID)
+}
+
+// GetRole returns the role of the given id along with its permissions.
+func (a *App) GetRole(id string) (*model.Role, error) {
+	return a.store.Role().GetRole(id)
+}
+
+// GetRoles returns every role along with its permissions.
+func (a *App) GetRoles() ([]*model.Role, error) {
+	return a.store.Role().GetRoles()
+}
+
+// UpdateRolePermissions replaces the permissions of the given role. The
+// permissions of the admin role can't be changed so that the server can't be
+// locked out of its own management.
+func (a *App) UpdateRolePermissions(role *model.Role, permissions []string) (*model.Role, error) {
+	if role.Name == model.AdminRoleName {
+		return nil, errors.New("the permissions of the admin role can't be changed")
+	}
+
+	role.Permissions = model.StringList(permissions)
+	if role.Permissions == nil {
+		role.Permissions = model.StringList{}
+	}
+	if err := role.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.store.Role().SetRolePermissions(role.ID, role.Permissions); err != nil {
+		return nil, err
+	}
+
+	a.logger.WithField("role_id", role.ID).WithField("permissions", role.Permissions).Info("Role permissions updated")
+
+	return a.store.Role().GetRole(role.ID)
+}
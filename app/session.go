@@ -0,0 +1,99 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// Login logs the given user in, with the session lifetime of their role.
+func (a *App) Login(w http.ResponseWriter, r *http.Request, user *model.User, source string) error {
+	return a.user.Login(w, r, user, source, a.sessionLifetime(user.ID))
+}
+
+// sessionLifetime returns the lifetime of the sessions of the given user,
+// depending on whether they are an admin.
+func (a *App) sessionLifetime(userID string) model.SessionLifetime {
+	isAdmin, err := a.user.HasAdminPermission(userID)
+	if err != nil {
+		// Fall back to the stricter of the lifetimes.
+		a.logger.WithError(err).WithField("user_id", userID).Warn("Unable to check if user is an admin")
+		isAdmin = true
+	}
+
+	return a.config.Sessions.Lifetime(isAdmin)
+}
+
+// TouchSession records the activity of the given login session and slides its
+// expiry, at most once per tokenUsageInterval so that every request doesn't
+// write to the database. It returns true if the expiry of the session changed,
+// in which case its cookies should be attached again.
+func (a *App) TouchSession(session *model.Session) bool {
+	now := model.GetMillis()
+	if now-session.LastActivityAt <= tokenUsageInterval {
+		return false
+	}
+
+	expiresAt := a.sessionLifetime(session.UserID).ExpiresAt(session.CreateAt, now)
+	err := a.store.Session().UpdateSessionActivity(session.ID, now, expiresAt)
+	if err != nil {
+		a.logger.WithError(err).WithField("session_id", session.ID).Warn("Unable to record session activity")
+		return false
+	}
+	session.LastActivityAt = now
+
+	if expiresAt == session.ExpiresAt {
+		return false
+	}
+	session.ExpiresAt = expiresAt
+
+	return true
+}
+
+// GetUserSessions returns the sanitized sessions of the given user, flagging
+// the current one.
+func (a *App) GetUserSessions(userID, currentSessionID string) ([]*model.Session, error) {
+	sessions, err := a.store.Session().GetSessionsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Sanitize()
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSession logs the given user out of one of their sessions. It
+// returns false if the user has no such session.
+func (a *App) RevokeUserSession(userID, sessionID string) (bool, error) {
+	session, err := a.store.Session().GetSession(sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.ID != sessionID || session.UserID != userID {
+		return false, nil
+	}
+
+	if err = a.store.Session().DeleteSession(session.ID); err != nil {
+		return false, err
+	}
+
+	a.logger.WithField("user_id", userID).WithField("session_id", sessionID).Info("User session revoked")
+
+	return true, nil
+}
+
+// RevokeOtherUserSessions logs the given user out of every device but the one
+// of the given session.
+func (a *App) RevokeOtherUserSessions(userID, currentSessionID string) error {
+	if err := a.store.Session().DeleteOtherSessionsForUser(userID, currentSessionID); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", userID).Info("Other user sessions revoked")
+
+	return nil
+}
@@ -16,6 +16,12 @@ func (a *App) CreateAndStoreResetPasswordToken(email string) (*model.Token, erro
 	return a.createAndStoreEmailToken(model.TokenTypeResetPassword, email)
 }
 
+// CreateAndStoreUnlockAccountToken creates a new unlock account token and
+// stores it.
+func (a *App) CreateAndStoreUnlockAccountToken(email string) (*model.Token, error) {
+	return a.createAndStoreEmailToken(model.TokenTypeUnlockAccount, email)
+}
+
 func (a *App) createAndStoreEmailToken(tokenType, email string) (*model.Token, error) {
 	err := a.store.Token().DeleteTokensByEmail(email, tokenType)
 	if err != nil {
@@ -1,10 +1,12 @@
 package app
 
 import (
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/internal/password"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/store"
 	"github.com/saturninoabril/dashboard-server/utils"
@@ -17,26 +19,35 @@ type UserService interface {
 	GetByEmail(email string) (*model.User, error)
 	Update(user *model.User) (*model.User, error)
 	AuthenticateUserForLogin(email, password string) (*model.User, error)
-	Login(w http.ResponseWriter, r *http.Request, user *model.User) error
+	Login(w http.ResponseWriter, r *http.Request, user *model.User, source string, lifetime model.SessionLifetime) error
 	Logout(w http.ResponseWriter, r *http.Request, sessionID string)
-	GetSession(tokenOrID string) (*model.Session, error)
+	GetSessionByToken(token string) (*model.Session, error)
 	VerifyEmail(id, email string) error
 	UnverifyEmail(id, email string) error
 	HasAdminPermission(id string) (bool, error)
+	HasPermission(id, permission string) (bool, error)
+	HashPassword(password string) (string, error)
+	ComparePassword(hash, password string) bool
 }
 
 type userService struct {
-	logger logrus.FieldLogger
-	store  store.Store
+	logger         logrus.FieldLogger
+	store          store.Store
+	hasher         *password.Hasher
+	trustedProxies []*net.IPNet
 }
 
 var _ UserService = &userService{}
 
-func NewUserService(logger logrus.FieldLogger, store store.Store) UserService {
+// NewUserService creates the user service, hashing passwords and identifying
+// clients as configured.
+func NewUserService(logger logrus.FieldLogger, store store.Store, config Config) UserService {
 	logFields := logrus.Fields{"package": "app", "type": "user"}
 	return &userService{
-		logger: logger.WithFields(logFields),
-		store:  store,
+		logger:         logger.WithFields(logFields),
+		store:          store,
+		hasher:         password.NewHasher(config.Passwords.PasswordHashParams(), config.Passwords.HashMemoryLimit),
+		trustedProxies: config.TrustedProxies,
 	}
 }
 
@@ -46,7 +57,13 @@ func (u *userService) Logger(user *model.User) logrus.FieldLogger {
 
 func (u *userService) Create(user *model.User) (*model.User, error) {
 	user.Email = strings.ToLower(user.Email)
-	user, err := u.store.User().CreateUser(user)
+	hash, err := u.hasher.Hash(user.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+	user.Password = hash
+
+	user, err = u.store.User().CreateUser(user)
 	if err != nil {
 		return nil, err
 	}
@@ -97,10 +114,22 @@ func (u *userService) AuthenticateUserForLogin(email, password string) (*model.U
 		return nil, errors.New("no user")
 	}
 
-	if !model.ComparePassword(user.Password, password) {
+	if !u.hasher.Compare(user.Password, password) {
 		return nil, errors.New("bad password")
 	}
 
+	// Upgrade hashes computed with an earlier scheme or parameters while the
+	// password is known.
+	if u.hasher.NeedsRehash(user.Password) {
+		hash, err := u.hasher.Hash(password)
+		if err == nil {
+			err = u.store.User().UpdatePasswordHash(user.ID, user.Password, hash)
+		}
+		if err != nil {
+			u.Logger(user).WithError(err).Error("Failed to upgrade password hash")
+		}
+	}
+
 	isAdmin, err := u.store.Role().UserHasRoleByName(user.ID, model.AdminRoleName)
 	if err != nil {
 		return nil, errors.New("error getting admin role for user")
@@ -121,8 +150,18 @@ func (u *userService) Update(user *model.User) (*model.User, error) {
 	return u.store.User().GetUser(user.ID)
 }
 
-func (u *userService) Login(w http.ResponseWriter, r *http.Request, user *model.User) error {
-	session := &model.Session{UserID: user.ID}
+// Login creates a session of the given lifetime for the given user, recording
+// how they logged in and the device of the request.
+func (u *userService) Login(w http.ResponseWriter, r *http.Request, user *model.User, source string, lifetime model.SessionLifetime) error {
+	now := model.GetMillis()
+	session := &model.Session{
+		UserID:    user.ID,
+		CreateAt:  now,
+		ExpiresAt: lifetime.ExpiresAt(now, now),
+		Source:    source,
+		UserAgent: r.UserAgent(),
+		IPAddress: utils.GetIPAddress(r, u.trustedProxies),
+	}
 
 	session, err := u.store.Session().CreateSession(session)
 	if err != nil {
@@ -145,8 +184,8 @@ func (u *userService) Logout(w http.ResponseWriter, r *http.Request, sessionID s
 	_ = u.store.Session().DeleteSession(sessionID)
 }
 
-func (u *userService) GetSession(tokenOrID string) (*model.Session, error) {
-	return u.store.Session().GetSession(tokenOrID)
+func (u *userService) GetSessionByToken(token string) (*model.Session, error) {
+	return u.store.Session().GetSessionByToken(token)
 }
 
 func (u *userService) VerifyEmail(id, email string) error {
@@ -165,3 +204,22 @@ func (u *userService) HasAdminPermission(id string) (bool, error) {
 
 	return hasRole, nil
 }
+
+func (u *userService) HasPermission(id, permission string) (bool, error) {
+	hasPermission, err := u.store.Role().UserHasPermission(id, permission)
+	if err != nil {
+		return false, errors.Wrapf(err, "error verifying permission %s for user %s", permission, id)
+	}
+
+	return hasPermission, nil
+}
+
+// HashPassword returns the hash of the given password to store.
+func (u *userService) HashPassword(password string) (string, error) {
+	return u.hasher.Hash(password)
+}
+
+// ComparePassword returns true if the password matches the stored hash.
+func (u *userService) ComparePassword(hash, password string) bool {
+	return u.hasher.Compare(hash, password)
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+// GetUsers returns a sanitized page of users matching the given filter.
+func (a *App) GetUsers(filter *model.UserFilter) ([]*model.User, error) {
+	users, err := a.store.User().GetUsers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		user.Sanitize()
+	}
+
+	return users, nil
+}
+
+// GetUserWithRoles returns the sanitized user of the given id along with the
+// names of their roles.
+func (a *App) GetUserWithRoles(id string) (*model.User, error) {
+	user, err := a.store.User().GetUser(id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	roles, err := a.store.Role().GetRolesForUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Roles = []string{}
+	for _, role := range roles {
+		user.Roles = append(user.Roles, role.Name)
+		if role.Name == model.AdminRoleName {
+			user.IsAdmin = true
+		}
+	}
+	user.Sanitize()
+
+	return user, nil
+}
+
+// LockUser prevents the given user from logging in and revokes all of their
+// sessions. Their API keys and personal access tokens are rejected until they
+// are unlocked.
+func (a *App) LockUser(id string) error {
+	if err := a.store.User().UpdateUserState(id, model.UserStateLocked); err != nil {
+		return err
+	}
+
+	if err := a.store.Session().DeleteSessionsForUser(id); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", id).Info("User locked")
+
+	return nil
+}
+
+// isActiveUser returns true if the given user exists and is not locked.
+func (a *App) isActiveUser(id string) (bool, error) {
+	user, err := a.store.User().GetUser(id)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get user")
+	}
+
+	return user != nil && user.State == model.UserStateActive, nil
+}
+
+// UnlockUser allows the given user to log in again.
+func (a *App) UnlockUser(id string) error {
+	if err := a.store.User().UpdateUserState(id, model.UserStateActive); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", id).Info("User unlocked")
+
+	return nil
+}
+
+// ForceVerifyEmail marks the email of the given user as verified without
+// requiring the verification code.
+func (a *App) ForceVerifyEmail(user *model.User) error {
+	if err := a.store.User().VerifyEmail(user.ID, user.Email); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", user.ID).Info("User email verified by an admin")
+
+	return nil
+}
+
+// AddUserRole grants the given role to a user. It returns false if the user
+// already had the role.
+func (a *App) AddUserRole(userID, roleID string) (bool, error) {
+	hasRole, err := a.store.Role().UserHasRole(userID, roleID)
+	if err != nil {
+		return false, err
+	}
+	if hasRole {
+		return false, nil
+	}
+
+	if err = a.store.Role().AddUserRole(userID, roleID); err != nil {
+		return false, err
+	}
+
+	a.logger.WithField("user_id", userID).WithField("role_id", roleID).Info("Role added to user")
+
+	return true, nil
+}
+
+// RemoveUserRole removes the given role from a user. It returns false if the
+// user did not have the role.
+func (a *App) RemoveUserRole(userID, roleID string) (bool, error) {
+	hasRole, err := a.store.Role().UserHasRole(userID, roleID)
+	if err != nil {
+		return false, err
+	}
+	if !hasRole {
+		return false, nil
+	}
+
+	if err = a.store.Role().DeleteUserRole(userID, roleID); err != nil {
+		return false, err
+	}
+
+	a.logger.WithField("user_id", userID).WithField("role_id", roleID).Info("Role removed from user")
+
+	return true, nil
+}
+
+// recordSyncedRoleChange records a role granted or removed by syncing the
+// memberships of an OAuth provider in the audit log.
+func (a *App) recordSyncedRoleChange(action, userID, roleID, provider string) {
+	// Failures are logged by the audit service.
+	a.audit.Record(&model.AuditLog{
+		Action:    action,
+		ActorType: model.AuditActorSystem,
+		TargetID:  userID,
+		Details:   model.StringMap{"role_id": roleID, "provider": provider},
+	})
+}
+
+// RevokeUserSessions logs the given user out of every device.
+func (a *App) RevokeUserSessions(userID string) error {
+	if err := a.store.Session().DeleteSessionsForUser(userID); err != nil {
+		return err
+	}
+
+	a.logger.WithField("user_id", userID).Info("User sessions revoked")
+
+	return nil
+}
+
+// DeleteUser permanently deletes the given user, along with the email tokens
+// still outstanding for them.
+func (a *App) DeleteUser(id string) error {
+	user, err := a.store.User().GetUser(id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get user %s", id)
+	}
+	if user != nil {
+		for _, tokenType := range []string{
+			model.TokenTypeVerifyEmail,
+			model.TokenTypeResetPassword,
+			model.TokenTypeUnlockAccount,
+		} {
+			err = a.store.Token().DeleteTokensByEmail(user.Email, tokenType)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete %s tokens of user %s", tokenType, id)
+			}
+		}
+	}
+
+	if err := a.store.User().DeleteUser(id); err != nil {
+		return errors.Wrapf(err, "failed to delete user %s", id)
+	}
+
+	a.logger.WithField("user_id", id).Info("User deleted")
+
+	return nil
+}
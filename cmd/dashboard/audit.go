@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/saturninoabril/dashboard-server/store"
+)
+
+func init() {
+	auditCmd.AddCommand(auditExportCmd)
+	auditExportCmd.Flags().String("since", "", "Only export the entries created at or after this RFC 3339 time.")
+	auditExportCmd.Flags().String("until", "", "Only export the entries created at or before this RFC 3339 time. Defaults to now.")
+	auditExportCmd.Flags().String("action", "", "Only export the entries of this action.")
+	auditExportCmd.Flags().String("actor-id", "", "Only export the entries made by this user or API key.")
+	auditExportCmd.Flags().String("target-id", "", "Only export the entries affecting this user.")
+	auditExportCmd.Flags().String("format", "json", "The format of the export. Possible values json, one entry per line, and csv.")
+	auditExportCmd.Flags().String("output", "", "The file to write the export to. Defaults to stdout.")
+}
+
+// recordCLIAudit records a change made by a command in the audit log. The
+// operating system user running the command is kept in the details since
+// commands have no actor.
+func recordCLIAudit(store store.Store, action, targetID string, details model.StringMap) error {
+	if osUser, err := user.Current(); err == nil {
+		details["os_user"] = osUser.Username
+	}
+
+	return app.NewAuditService(logger, store).Record(&model.AuditLog{
+		Action:    action,
+		ActorType: model.AuditActorCLI,
+		TargetID:  targetID,
+		Details:   details,
+	})
+}
+
+// parseAuditTime parses the given RFC 3339 time flag to milliseconds, zero if
+// unset.
+func parseAuditTime(command *cobra.Command, name string) (int64, error) {
+	value, _ := command.Flags().GetString(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", name)
+	}
+
+	return model.GetMillisForTime(t), nil
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the security audit log.",
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Export the audit log",
+	Long:    "Export the entries of the audit log matching the given filters, the most recent first.",
+	Example: "audit export --since 2021-01-01T00:00:00Z --action role_add --format csv --output audit.csv",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		filter := &model.AuditLogFilter{PerPage: model.AuditLogsMaxPerPage}
+		filter.Action, _ = command.Flags().GetString("action")
+		filter.ActorID, _ = command.Flags().GetString("actor-id")
+		filter.TargetID, _ = command.Flags().GetString("target-id")
+
+		var err error
+		if filter.Since, err = parseAuditTime(command, "since"); err != nil {
+			return err
+		}
+		if filter.Until, err = parseAuditTime(command, "until"); err != nil {
+			return err
+		}
+		// Entries recorded during the export would shift the pages.
+		if filter.Until == 0 {
+			filter.Until = model.GetMillis()
+		}
+		if err = filter.IsValid(); err != nil {
+			return err
+		}
+
+		format, _ := command.Flags().GetString("format")
+		var write func(*model.AuditLog) error
+		var flush func() error
+
+		out := command.OutOrStdout()
+		if output, _ := command.Flags().GetString("output"); output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(out)
+			write = func(entry *model.AuditLog) error { return encoder.Encode(entry) }
+			flush = func() error { return nil }
+		case "csv":
+			write, flush, err = newAuditCSVWriter(out)
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("unsupported format %s", format)
+		}
+
+		store, err := cmdStore(command)
+		if err != nil {
+			return err
+		}
+		auditService := app.NewAuditService(logger, store)
+
+		exported := 0
+		for {
+			entries, err := auditService.GetAuditLogs(filter)
+			if err != nil {
+				return errors.Wrapf(err, "failed after exporting %d entries", exported)
+			}
+			for _, entry := range entries {
+				if err = write(entry); err != nil {
+					return err
+				}
+			}
+			exported += len(entries)
+			if len(entries) < filter.PerPage {
+				break
+			}
+			filter.Page++
+		}
+
+		if err = flush(); err != nil {
+			return err
+		}
+		logger.Infof("Exported %d audit log entries", exported)
+
+		return nil
+	},
+}
+
+// newAuditCSVWriter returns functions writing audit log entries as CSV rows
+// to the given writer, after a header row. The details are written as
+// key=value pairs separated by spaces.
+func newAuditCSVWriter(out io.Writer) (func(*model.AuditLog) error, func() error, error) {
+	writer := csv.NewWriter(out)
+	err := writer.Write([]string{"id", "time", "action", "actor_id", "actor_type", "target_id", "ip_address", "request_id", "details"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	write := func(entry *model.AuditLog) error {
+		keys := make([]string, 0, len(entry.Details))
+		for key := range entry.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		details := make([]string, 0, len(keys))
+		for _, key := range keys {
+			details = append(details, key+"="+strconv.Quote(entry.Details[key]))
+		}
+
+		return writer.Write([]string{
+			entry.ID,
+			time.Unix(0, entry.CreateAt*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano),
+			entry.Action,
+			entry.ActorID,
+			entry.ActorType,
+			entry.TargetID,
+			entry.IPAddress,
+			entry.RequestID,
+			strings.Join(details, " "),
+		})
+	}
+	flush := func() error {
+		writer.Flush()
+		return writer.Error()
+	}
+
+	return write, flush, nil
+}
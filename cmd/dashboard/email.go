@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
+)
+
+func init() {
+	addEmailFlags(emailCmd)
+	emailCmd.PersistentFlags().String("siteurl", "", "The site URL used to render links and images in the emails.")
+	emailCmd.PersistentFlags().String("locale", i18n.DefaultLocale, "The locale to render the emails in.")
+
+	emailCmd.AddCommand(emailPreviewCmd)
+	emailPreviewCmd.Flags().String("format", "html", "The format to render the template to. Possible values html and text.")
+	emailPreviewCmd.Flags().String("output", "", "The file to write the rendered template to. Defaults to stdout.")
+
+	emailCmd.AddCommand(emailTestCmd)
+	emailTestCmd.Flags().String("to", "", "Email address to send the test email to.")
+}
+
+// addEmailFlags adds the flags configuring how emails are sent to the given
+// command.
+func addEmailFlags(command *cobra.Command) {
+	command.PersistentFlags().String("email-transport", "", "How emails are delivered: smtp, file or log. Defaults to file in dev mode and smtp otherwise.")
+	command.PersistentFlags().String("email-directory", "", "The directory emails are written to when using the file transport.")
+	command.PersistentFlags().String("smtp-server", "", "The SMTP server used to send emails.")
+	command.PersistentFlags().String("smtp-port", "", "The port of the SMTP server.")
+	command.PersistentFlags().String("smtp-username", "", "The username used to authenticate with the SMTP server.")
+	command.PersistentFlags().String("smtp-password", "", "The password used to authenticate with the SMTP server.")
+	command.PersistentFlags().String("smtp-connection-security", "", "The SMTP connection security. Possible values are empty, TLS and STARTTLS.")
+	command.PersistentFlags().Int("smtp-servertimeout", 10, "The SMTP server timeout in seconds.")
+	command.PersistentFlags().String("email-reply-to", "", "The address emails are sent from.")
+}
+
+// emailConfigFromFlags builds the email config from the flags added by
+// addEmailFlags.
+func emailConfigFromFlags(command *cobra.Command) email.Config {
+	var config email.Config
+	config.Transport, _ = command.Flags().GetString("email-transport")
+	config.FileDirectory, _ = command.Flags().GetString("email-directory")
+	config.SMTPServer, _ = command.Flags().GetString("smtp-server")
+	config.SMTPPort, _ = command.Flags().GetString("smtp-port")
+	config.SMTPUsername, _ = command.Flags().GetString("smtp-username")
+	config.SMTPPassword, _ = command.Flags().GetString("smtp-password")
+	config.SMTPConnectionSecurity, _ = command.Flags().GetString("smtp-connection-security")
+	config.SMTPServerTimeout, _ = command.Flags().GetInt("smtp-servertimeout")
+	config.ReplyToAddress, _ = command.Flags().GetString("email-reply-to")
+
+	return config
+}
+
+// cmdEmailApp creates an app able to render and send emails. It has no store,
+// so it must only be used for emails.
+func cmdEmailApp(command *cobra.Command, config app.Config) (*app.App, error) {
+	config.SiteURL, _ = command.Flags().GetString("siteurl")
+	dev, _ := command.Flags().GetBool("dev")
+	if dev {
+		app.SetDevConfig(&config)
+	}
+
+	emailApp, err := app.NewApp(logger, nil, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = emailApp.ReloadHTMLTemplates(); err != nil {
+		return nil, err
+	}
+
+	return emailApp, nil
+}
+
+var emailCmd = &cobra.Command{
+	Use:   "email",
+	Short: "Preview and test the emails sent by the dashboard.",
+}
+
+var emailPreviewCmd = &cobra.Command{
+	Use:     "preview <template>",
+	Short:   "Render an email template with sample values",
+	Long:    "Render an email template from internal/templates with sample values, to HTML or plaintext.",
+	Example: "email preview verify_email_body --format text",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, _ := command.Flags().GetString("format")
+		output, _ := command.Flags().GetString("output")
+
+		var config app.Config
+		config.Email.Transport = email.TransportLog
+		emailApp, err := cmdEmailApp(command, config)
+		if err != nil {
+			return err
+		}
+
+		locale, _ := command.Flags().GetString("locale")
+		bodyPage, err := emailApp.GetEmailPreview(args[0], locale)
+		if err != nil {
+			return err
+		}
+
+		var rendered string
+		switch format {
+		case "html":
+			rendered, err = bodyPage.Render()
+		case "text":
+			rendered, err = bodyPage.RenderText()
+		default:
+			return errors.Errorf("unsupported format %s", format)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to render template %s", args[0])
+		}
+
+		if output == "" {
+			fmt.Fprintln(command.OutOrStdout(), rendered)
+			return nil
+		}
+
+		return os.WriteFile(output, []byte(rendered), 0644)
+	},
+}
+
+var emailTestCmd = &cobra.Command{
+	Use:     "test",
+	Short:   "Send a test email",
+	Long:    "Test the connection to the configured SMTP server and send a test email through it.",
+	Example: "email test --to test@test.com --smtp-server localhost --smtp-port 12025",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		to, _ := command.Flags().GetString("to")
+		if to == "" {
+			return errors.New("an email address to send the test email to is required")
+		}
+
+		var config app.Config
+		config.Email = emailConfigFromFlags(command)
+		// Always deliver the test email through SMTP, whatever the configured
+		// transport is.
+		config.Email.Transport = email.TransportSMTP
+		emailApp, err := cmdEmailApp(command, config)
+		if err != nil {
+			return err
+		}
+
+		config = emailApp.Config()
+		if err = email.TestConnection(&config.Email); err != nil {
+			fmt.Fprintf(command.OutOrStdout(), "SMTP connection to %s:%s failed: %s\n", config.Email.SMTPServer, config.Email.SMTPPort, err)
+			return err
+		}
+		fmt.Fprintf(command.OutOrStdout(), "SMTP connection to %s:%s succeeded\n", config.Email.SMTPServer, config.Email.SMTPPort)
+
+		locale, _ := command.Flags().GetString("locale")
+		if err = emailApp.SendTestEmail(to, locale, config.SiteURL); err != nil {
+			return err
+		}
+		fmt.Fprintf(command.OutOrStdout(), "Test email sent to %s\n", to)
+
+		return nil
+	},
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
+)
+
+func init() {
+	keysCmd.AddCommand(keysGenerateCmd)
+	keysGenerateCmd.Flags().String("id", "", "The id of the new key.")
+	keysGenerateCmd.MarkFlagRequired("id")
+
+	keysCmd.AddCommand(keysRotateCmd)
+	keysRotateCmd.Flags().String("encryption-keys", os.Getenv("DASHBOARD_ENCRYPTION_KEY"), "The comma separated <id>:<key> encryption keys, the new primary key first. Defaults to DASHBOARD_ENCRYPTION_KEY.")
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys encrypting secrets stored in the database.",
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:     "generate",
+	Short:   "Generate a new encryption key.",
+	Example: "keys generate --id 2",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		id, _ := command.Flags().GetString("id")
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		entry := id + ":" + key
+		if _, err = encryption.ParseKeyring(entry); err != nil {
+			return err
+		}
+
+		fmt.Println(entry)
+
+		return nil
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt the secrets stored in the database with the primary key.",
+	Long: `Re-encrypt the secrets stored in the database, OAuth tokens, TOTP secrets
+and the key tokens are hashed with, with the primary key.
+
+Add the new key first in DASHBOARD_ENCRYPTION_KEY, keeping the previous ones,
+and restart the servers. Then run this command, after which the previous keys
+can be removed.`,
+	Example: "keys rotate --encryption-keys 2:<new key>,1:<old key>",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		value, _ := command.Flags().GetString("encryption-keys")
+		keyring, err := encryption.ParseKeyring(value)
+		if err != nil {
+			return errors.Wrap(err, "invalid encryption keys")
+		}
+
+		// The store re-encrypts the key tokens are hashed with when opened.
+		store, err := cmdStoreWithKeyring(command, keyring)
+		if err != nil {
+			return err
+		}
+
+		rotated, err := store.RotateEncryptionKeys()
+		if err != nil {
+			return errors.Wrapf(err, "failed after re-encrypting %d secrets", rotated)
+		}
+
+		logger.Infof("Re-encrypted %d secrets with key %s", rotated, keyring.PrimaryID())
+
+		return nil
+	},
+}
+
+// devEncryptionKeys is the well-known keyring used in dev mode when none is
+// set, so that dev databases stay readable across restarts.
+const devEncryptionKeys = "dev:ZGFzaGJvYXJkLWRldmVsb3BtZW50LWtleS0wMDAwMDA="
+
+// loadKeyring parses the encryption keys used by the server and the commands
+// accessing the database, which require them. In dev mode a well-known key is
+// used if none is set.
+func loadKeyring(value string, dev bool) (*encryption.Keyring, error) {
+	if value == "" && dev {
+		logger.Warn("No encryption key set, using the insecure development key")
+		value = devEncryptionKeys
+	}
+	if value == "" {
+		return nil, errors.New("DASHBOARD_ENCRYPTION_KEY is required, generate one with `dashboard keys generate`")
+	}
+
+	keyring, err := encryption.ParseKeyring(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid DASHBOARD_ENCRYPTION_KEY")
+	}
+
+	return keyring, nil
+}
@@ -41,6 +41,9 @@ func init() {
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(schemaCmd)
 	rootCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(emailCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(auditCmd)
 }
 
 func main() {
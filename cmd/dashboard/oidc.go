@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/app"
+)
+
+// oidcConfigFromEnv builds the OpenID Connect config from the DASHBOARD_OIDC_*
+// environment variables.
+func oidcConfigFromEnv() (app.OIDC, error) {
+	config := app.OIDC{
+		IssuerURL:    os.Getenv("DASHBOARD_OIDC_ISSUER"),
+		ClientID:     os.Getenv("DASHBOARD_OIDC_CLIENT"),
+		ClientSecret: os.Getenv("DASHBOARD_OIDC_SECRET"),
+		RedirectURL:  os.Getenv("DASHBOARD_OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv("DASHBOARD_OIDC_SCOPES"), ",", " ")),
+		EmailClaim:   os.Getenv("DASHBOARD_OIDC_EMAIL_CLAIM"),
+		NameClaim:    os.Getenv("DASHBOARD_OIDC_NAME_CLAIM"),
+		GroupsClaim:  os.Getenv("DASHBOARD_OIDC_GROUPS_CLAIM"),
+	}
+
+	groupRoles, err := parseGroupRoles(os.Getenv("DASHBOARD_OIDC_GROUP_ROLES"))
+	if err != nil {
+		return app.OIDC{}, err
+	}
+	config.GroupRoles = groupRoles
+
+	return config, nil
+}
+
+// parseGroupRoles parses a comma separated list of <group>=<role> pairs.
+func parseGroupRoles(value string) (map[string]string, error) {
+	groupRoles := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 || i == len(entry)-1 {
+			return nil, errors.Errorf("invalid group role mapping %q, expected <group>=<role>", entry)
+		}
+		groupRoles[entry[:i]] = entry[i+1:]
+	}
+
+	return groupRoles, nil
+}
@@ -9,14 +9,19 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 	logrus "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/saturninoabril/dashboard-server/api"
 	"github.com/saturninoabril/dashboard-server/app"
+	"github.com/saturninoabril/dashboard-server/internal/email"
+	"github.com/saturninoabril/dashboard-server/internal/hibp"
+	"github.com/saturninoabril/dashboard-server/internal/password"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/store"
+	"github.com/saturninoabril/dashboard-server/utils"
 )
 
 var instanceID string
@@ -29,6 +34,33 @@ func init() {
 
 	serverCmd.PersistentFlags().String("listen", ":8085", "The interface and port on which to listen on the API.")
 	serverCmd.PersistentFlags().Bool("debug", false, "Whether to output debug logs.")
+	serverCmd.PersistentFlags().String("siteurl", "", "The URL of the dashboard webapp.")
+	serverCmd.PersistentFlags().String("apiurl", "", "The URL of the API, if different from the site URL.")
+	serverCmd.PersistentFlags().Duration("github-sync-interval", time.Hour, "How often to sync the GitHub organizations and teams of the users, 0 to disable.")
+	serverCmd.PersistentFlags().Duration("session-max-age", model.SessionLengthMilliseconds*time.Millisecond, "How long a login session lasts regardless of activity, 0 for no limit.")
+	serverCmd.PersistentFlags().Duration("session-idle-timeout", 15*24*time.Hour, "How long a login session lasts without activity, 0 for no limit.")
+	serverCmd.PersistentFlags().Duration("admin-session-max-age", 0, "How long the login session of an admin lasts regardless of activity, 0 to use --session-max-age.")
+	serverCmd.PersistentFlags().Duration("admin-session-idle-timeout", 12*time.Hour, "How long the login session of an admin lasts without activity, 0 to use --session-idle-timeout.")
+	serverCmd.PersistentFlags().StringSlice("trusted-proxies", nil, "The IP addresses or CIDR ranges of the proxies whose X-Forwarded-For and X-Real-IP headers identify the clients.")
+	serverCmd.PersistentFlags().Bool("rate-limit", true, "Whether to rate limit requests.")
+	serverCmd.PersistentFlags().String("rate-limit-store", app.RateLimitStoreMemory, "Where to keep the rate limits: memory, or database to share them between servers.")
+	serverCmd.PersistentFlags().Int("rate-limit-requests", 600, "The number of requests allowed per period on the routes without their own limit.")
+	serverCmd.PersistentFlags().Duration("rate-limit-period", time.Minute, "The period of --rate-limit-requests.")
+	serverCmd.PersistentFlags().Int("password-min-length", model.DefaultPasswordPolicy.MinLength, "The minimum length of passwords.")
+	serverCmd.PersistentFlags().Bool("password-require-lowercase", model.DefaultPasswordPolicy.RequireLowercase, "Whether passwords must contain a lowercase letter.")
+	serverCmd.PersistentFlags().Bool("password-require-uppercase", model.DefaultPasswordPolicy.RequireUppercase, "Whether passwords must contain an uppercase letter.")
+	serverCmd.PersistentFlags().Bool("password-require-digit", model.DefaultPasswordPolicy.RequireDigit, "Whether passwords must contain a digit.")
+	serverCmd.PersistentFlags().Bool("password-require-symbol", model.DefaultPasswordPolicy.RequireSymbol, "Whether passwords must contain a symbol.")
+	serverCmd.PersistentFlags().Bool("password-disallow-user-info", model.DefaultPasswordPolicy.DisallowUserInfo, "Whether passwords can't contain the email or the name of their user.")
+	serverCmd.PersistentFlags().Int("password-history", model.DefaultPasswordPolicy.HistorySize, "The number of the most recent passwords of a user, the current one included, that can't be reused. 0 to allow reuse.")
+	serverCmd.PersistentFlags().String("breached-passwords-list", "", "The path of a local copy of the Pwned Passwords list, whose passwords are rejected.")
+	serverCmd.PersistentFlags().Uint32("password-hash-memory", password.DefaultParams.Memory, "The memory used to hash a password with argon2id, in KiB.")
+	serverCmd.PersistentFlags().Uint32("password-hash-iterations", password.DefaultParams.Iterations, "The number of argon2id iterations when hashing a password.")
+	serverCmd.PersistentFlags().Uint8("password-hash-parallelism", password.DefaultParams.Parallelism, "The number of threads used to hash a password with argon2id.")
+	serverCmd.PersistentFlags().Uint64("password-hash-memory-limit", password.DefaultMemoryLimit, "The memory concurrent password hashes can use together, in KiB. Hashes over it wait for others to complete, 0 for no limit.")
+	serverCmd.PersistentFlags().String("signup-mode", model.SignupModeOpen, "Who can sign up: open to anyone, invite to only invited users, or closed.")
+	serverCmd.PersistentFlags().StringSlice("signup-allowed-domains", nil, "The email domains allowed to sign up without an invitation. Empty to allow any domain.")
+	addEmailFlags(serverCmd)
 }
 
 var serverCmd = &cobra.Command{
@@ -51,17 +83,68 @@ var serverCmd = &cobra.Command{
 			config.APIURL = config.SiteURL
 		}
 
+		config.Sessions.User.MaxAge, _ = command.Flags().GetDuration("session-max-age")
+		config.Sessions.User.IdleTimeout, _ = command.Flags().GetDuration("session-idle-timeout")
+		config.Sessions.Admin.MaxAge, _ = command.Flags().GetDuration("admin-session-max-age")
+		config.Sessions.Admin.IdleTimeout, _ = command.Flags().GetDuration("admin-session-idle-timeout")
+
+		config.RateLimit.Enabled, _ = command.Flags().GetBool("rate-limit")
+		config.RateLimit.Store, _ = command.Flags().GetString("rate-limit-store")
+		config.RateLimit.Default.Requests, _ = command.Flags().GetInt("rate-limit-requests")
+		config.RateLimit.Default.Period, _ = command.Flags().GetDuration("rate-limit-period")
+		if config.RateLimit.Store != app.RateLimitStoreMemory && config.RateLimit.Store != app.RateLimitStoreDatabase {
+			return errors.Errorf("invalid rate limit store %s", config.RateLimit.Store)
+		}
+
+		config.Passwords.Policy.MinLength, _ = command.Flags().GetInt("password-min-length")
+		config.Passwords.Policy.RequireLowercase, _ = command.Flags().GetBool("password-require-lowercase")
+		config.Passwords.Policy.RequireUppercase, _ = command.Flags().GetBool("password-require-uppercase")
+		config.Passwords.Policy.RequireDigit, _ = command.Flags().GetBool("password-require-digit")
+		config.Passwords.Policy.RequireSymbol, _ = command.Flags().GetBool("password-require-symbol")
+		config.Passwords.Policy.DisallowUserInfo, _ = command.Flags().GetBool("password-disallow-user-info")
+		config.Passwords.Policy.HistorySize, _ = command.Flags().GetInt("password-history")
+		if err := config.Passwords.Policy.IsValid(); err != nil {
+			return errors.Wrap(err, "invalid password policy")
+		}
+		config.Passwords.BreachedList, _ = command.Flags().GetString("breached-passwords-list")
+		if config.Passwords.BreachedList != "" {
+			list, err := hibp.Open(config.Passwords.BreachedList)
+			if err != nil {
+				return err
+			}
+			list.Close()
+		}
+
+		config.Signup.Mode, _ = command.Flags().GetString("signup-mode")
+		if !model.IsValidSignupMode(config.Signup.Mode) {
+			return errors.Errorf("invalid signup mode %s", config.Signup.Mode)
+		}
+		config.Signup.AllowedDomains, _ = command.Flags().GetStringSlice("signup-allowed-domains")
+
+		config.Passwords.HashParams.Memory, _ = command.Flags().GetUint32("password-hash-memory")
+		config.Passwords.HashParams.Iterations, _ = command.Flags().GetUint32("password-hash-iterations")
+		config.Passwords.HashParams.Parallelism, _ = command.Flags().GetUint8("password-hash-parallelism")
+		if err := config.Passwords.HashParams.IsValid(); err != nil {
+			return errors.Wrap(err, "invalid password hash parameters")
+		}
+		config.Passwords.HashMemoryLimit, _ = command.Flags().GetUint64("password-hash-memory-limit")
+
+		trustedProxies, _ := command.Flags().GetStringSlice("trusted-proxies")
+		proxies, err := utils.ParseTrustedProxies(trustedProxies)
+		if err != nil {
+			return err
+		}
+		config.TrustedProxies = proxies
+
 		// Set email config
-		config.Email.SMTPUsername, _ = command.Flags().GetString("smtp-username")
-		config.Email.SMTPPassword, _ = command.Flags().GetString("smtp-password")
-		config.Email.SMTPServer, _ = command.Flags().GetString("smtp-server")
-		config.Email.SMTPPort, _ = command.Flags().GetString("smtp-port")
-		config.Email.SMTPServerTimeout, _ = command.Flags().GetInt("smtp-servertimeout")
+		config.Email = emailConfigFromFlags(command)
 
 		// Set Github config
 		githubClient := os.Getenv("DASHBOARD_GITHUB_CLIENT")
 		githubSecret := os.Getenv("DASHBOARD_GITHUB_SECRET")
 		encryptionKey := os.Getenv("DASHBOARD_ENCRYPTION_KEY")
+		config.GithubOAuth.BaseURL = os.Getenv("DASHBOARD_GITHUB_URL")
+		config.GithubOAuth.APIURL = os.Getenv("DASHBOARD_GITHUB_API_URL")
 		config.GithubOAuth.ClientID = githubClient
 		config.GithubOAuth.ClientSecret = githubSecret
 		config.GithubOAuth.EncryptionKey = encryptionKey
@@ -69,6 +152,25 @@ var serverCmd = &cobra.Command{
 			logger.Debug("No Github config found")
 		}
 
+		// Set GitLab config
+		config.GitlabOAuth.BaseURL = os.Getenv("DASHBOARD_GITLAB_URL")
+		config.GitlabOAuth.ClientID = os.Getenv("DASHBOARD_GITLAB_CLIENT")
+		config.GitlabOAuth.ClientSecret = os.Getenv("DASHBOARD_GITLAB_SECRET")
+		config.GitlabOAuth.RedirectURL = os.Getenv("DASHBOARD_GITLAB_REDIRECT_URL")
+		if config.GitlabOAuth.ClientID == "" || config.GitlabOAuth.ClientSecret == "" {
+			logger.Debug("No GitLab config found")
+		}
+
+		// Set OpenID Connect config
+		oidcConfig, err := oidcConfigFromEnv()
+		if err != nil {
+			return err
+		}
+		config.OIDC = oidcConfig
+		if !config.OIDC.Enabled() {
+			logger.Debug("No OpenID Connect config found")
+		}
+
 		database, _ := command.Flags().GetString("database")
 		tablePrefix, _ := command.Flags().GetString("table-prefix")
 		dev, _ := command.Flags().GetBool("dev")
@@ -78,18 +180,31 @@ var serverCmd = &cobra.Command{
 			logger.Debugf("Using database: '%s'\n", database)
 			logger.Debugf("Using table prefix: '%s'\n", tablePrefix)
 		}
+		if config.Email.Transport == email.TransportFile {
+			logger.Infof("Writing emails to: '%s'", config.Email.FileDirectory)
+		}
+
+		keyring, err := loadKeyring(config.GithubOAuth.EncryptionKey, dev)
+		if err != nil {
+			return err
+		}
 
-		store, err := store.New(database, tablePrefix, logger)
+		store, err := store.New(database, tablePrefix, keyring, logger)
 		if err != nil {
 			return err
 		}
 
-		userService := app.NewUserService(logger, store)
+		userService := app.NewUserService(logger, store, config)
 
-		app := app.NewApp(logger, store, config, userService)
+		app, err := app.NewApp(logger, store, config, userService)
+		if err != nil {
+			return err
+		}
+		// Without the translations every locale would be rejected, so the
+		// server can't run without them.
 		err = app.ReloadHTMLTemplates()
 		if err != nil {
-			logger.WithError(err).Warn("Unable to load HTML templates")
+			return errors.Wrap(err, "unable to load HTML templates and translations")
 		}
 
 		listen, _ := command.Flags().GetString("listen")
@@ -124,6 +239,14 @@ var serverCmd = &cobra.Command{
 
 		publicServer := startServer(publicRouter, listen)
 
+		syncCtx, stopSync := context.WithCancel(context.Background())
+		defer stopSync()
+		githubSyncInterval, _ := command.Flags().GetDuration("github-sync-interval")
+		if config.GithubOAuth.ClientID != "" && githubSyncInterval > 0 {
+			go app.RunGithubMembershipSync(syncCtx, githubSyncInterval)
+		}
+		go app.RunRateLimitCleanup(syncCtx)
+
 		c := make(chan os.Signal, 1)
 		// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
 		// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
@@ -132,6 +255,7 @@ var serverCmd = &cobra.Command{
 		// Block until we receive our signal.
 		<-c
 		logger.Info("Shutting down")
+		stopSync()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
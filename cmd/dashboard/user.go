@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 	"github.com/saturninoabril/dashboard-server/app"
 	"github.com/saturninoabril/dashboard-server/model"
@@ -14,6 +16,11 @@ func init() {
 	addNewUserCmd.Flags().String("password", "", "Password for the user.")
 	addNewUserCmd.Flags().String("role", "", "Role for the created user. Possible values user and admin")
 	addNewUserCmd.Flags().Bool("email-verified", true, "Is the user email verified.")
+	userCmd.AddCommand(inviteUserCmd)
+	inviteUserCmd.Flags().String("email", "", "Email to send the invitation to.")
+	inviteUserCmd.Flags().StringSlice("role", nil, "Roles granted on top of the user role when the invitation is accepted.")
+	inviteUserCmd.Flags().String("siteurl", "", "The URL of the dashboard webapp, used in the invitation link.")
+	addEmailFlags(inviteUserCmd)
 	userCmd.AddCommand(userRoleCmd)
 
 	// Roles
@@ -50,7 +57,7 @@ var addNewUserCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		userService := app.NewUserService(logger, store)
+		userService := app.NewUserService(logger, store, app.NewConfig())
 		userData := &model.User{
 			Email:         email,
 			EmailVerified: emailVerified,
@@ -73,7 +80,70 @@ var addNewUserCmd = &cobra.Command{
 			if err != nil {
 				return errors.Wrapf(err, "Error creating user for %s", email)
 			}
+			err = recordCLIAudit(store, model.AuditActionRoleAdd, user.ID, model.StringMap{"role_id": roleData.ID, "role": role})
+			if err != nil {
+				return errors.Wrapf(err, "Error recording role %s of user %s", role, email)
+			}
+		}
+		return nil
+	},
+}
+
+var inviteUserCmd = &cobra.Command{
+	Use:     "invite",
+	Short:   "Invite a user to sign up",
+	Long:    "Email an invitation to sign up to Dashboard, granting the given roles when it is accepted. The invitation link is also printed.",
+	Example: "user invite --email test@test.com --role admin --siteurl https://dashboard.example.com",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		email, _ := command.Flags().GetString("email")
+		roles, _ := command.Flags().GetStringSlice("role")
+
+		store, err := cmdStore(command)
+		if err != nil {
+			return err
+		}
+
+		request := &model.CreateInvitationRequest{Email: email}
+		for _, role := range roles {
+			roleData, err := store.Role().GetRoleByName(role)
+			if err != nil {
+				return errors.Wrapf(err, "Error inviting user %s", email)
+			}
+			if roleData == nil {
+				return errors.Errorf("Role %s doesn't exist", role)
+			}
+			request.RoleIDs = append(request.RoleIDs, roleData.ID)
+		}
+
+		var config app.Config
+		config.SiteURL, _ = command.Flags().GetString("siteurl")
+		config.Email = emailConfigFromFlags(command)
+		dev, _ := command.Flags().GetBool("dev")
+		if dev {
+			app.SetDevConfig(&config)
+		}
+
+		inviteApp, err := app.NewApp(logger, store, config, app.NewUserService(logger, store, config))
+		if err != nil {
+			return err
+		}
+		if err = inviteApp.ReloadHTMLTemplates(); err != nil {
+			return err
+		}
+
+		invitation, token, err := inviteApp.CreateInvitation(request, "")
+		if err != nil {
+			return errors.Wrapf(err, "Error inviting user %s", email)
 		}
+		err = recordCLIAudit(store, model.AuditActionInvitationCreate, "", invitation.AuditDetails())
+		if err != nil {
+			return errors.Wrapf(err, "Error recording invitation of user %s", email)
+		}
+
+		fmt.Fprintf(command.OutOrStdout(), "Invitation sent to %s: %s\n", invitation.Email, app.InvitationURL(inviteApp.Config().SiteURL, token))
+
 		return nil
 	},
 }
@@ -91,7 +161,7 @@ var addUserRoleCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		userService := app.NewUserService(logger, store)
+		userService := app.NewUserService(logger, store, app.NewConfig())
 		user, err := userService.GetByEmail(email)
 		if err != nil {
 			return errors.Wrapf(err, "Error add role %s to user %s", role, email)
@@ -107,6 +177,10 @@ var addUserRoleCmd = &cobra.Command{
 		if err != nil {
 			return errors.Wrapf(err, "Error add role %s to user %s", role, email)
 		}
+		err = recordCLIAudit(store, model.AuditActionRoleAdd, user.ID, model.StringMap{"role_id": roleData.ID, "role": role})
+		if err != nil {
+			return errors.Wrapf(err, "Error recording role %s of user %s", role, email)
+		}
 		logger.WithFields(logrus.Fields{
 			"email": email,
 			"role":  role,
@@ -129,7 +203,7 @@ var removeUserRoleCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		userService := app.NewUserService(logger, store)
+		userService := app.NewUserService(logger, store, app.NewConfig())
 		user, err := userService.GetByEmail(email)
 		if err != nil {
 			return errors.Wrapf(err, "Error removing role %s to user %s", role, email)
@@ -145,6 +219,10 @@ var removeUserRoleCmd = &cobra.Command{
 		if err != nil {
 			return errors.Wrapf(err, "Error removing role %s to user %s", role, email)
 		}
+		err = recordCLIAudit(store, model.AuditActionRoleRemove, user.ID, model.StringMap{"role_id": roleData.ID, "role": role})
+		if err != nil {
+			return errors.Wrapf(err, "Error recording role %s of user %s", role, email)
+		}
 		logger.WithFields(logrus.Fields{
 			"email": email,
 			"role":  role,
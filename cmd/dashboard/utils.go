@@ -1,12 +1,27 @@
 package main
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
 	"github.com/saturninoabril/dashboard-server/store"
 )
 
+// cmdStore opens the store with the keyring of DASHBOARD_ENCRYPTION_KEY.
 func cmdStore(command *cobra.Command) (*store.SqlStore, error) {
+	dev, _ := command.Flags().GetBool("dev")
+	keyring, err := loadKeyring(os.Getenv("DASHBOARD_ENCRYPTION_KEY"), dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return cmdStoreWithKeyring(command, keyring)
+}
+
+// cmdStoreWithKeyring opens the store with the given keyring.
+func cmdStoreWithKeyring(command *cobra.Command, keyring *encryption.Keyring) (*store.SqlStore, error) {
 	database, err := command.Flags().GetString("database")
 	if err != nil {
 		return nil, err
@@ -17,7 +32,7 @@ func cmdStore(command *cobra.Command) (*store.SqlStore, error) {
 		return nil, err
 	}
 
-	newStore, err := store.New(database, tablePrefix, logger)
+	newStore, err := store.New(database, tablePrefix, keyring, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -25,6 +25,13 @@ const (
 
 // Config contains SMTP settings for sending emails.
 type Config struct {
+	// Transport selects how emails are delivered: smtp, file or log.
+	// Defaults to smtp when empty.
+	Transport string
+	// FileDirectory is the directory .eml files are written to when using
+	// the file transport.
+	FileDirectory string
+
 	ReplyToName              string
 	ReplyToAddress           string
 	BCCAddresses             []string
@@ -272,6 +279,23 @@ func TestConnection(config *Config) error {
 
 // SendMailWithEmbeddedFilesUsingConfig sends an email with file attachments.
 func SendMailWithEmbeddedFilesUsingConfig(to, subject, htmlBody string, sendBcc bool, embeddedFiles map[string]io.Reader, attachments []*Attachment, config *Config) error {
+	mailData := newMailData(to, subject, htmlBody, sendBcc, embeddedFiles, attachments, config)
+
+	return sendMailUsingConfigAdvanced(mailData, config, sendEmailDefaultRetries)
+}
+
+// SendMailUsingConfig sends an email with the provided config.
+func SendMailUsingConfig(to, subject, htmlBody string, sendBcc bool, attachments []*Attachment, config *Config) error {
+	return SendMailWithEmbeddedFilesUsingConfig(to, subject, htmlBody, sendBcc, nil, attachments, config)
+}
+
+// SendMailUsingTransport composes an email with the provided config and hands
+// it to the given transport for delivery.
+func SendMailUsingTransport(transport Transport, to, subject, htmlBody string, sendBcc bool, attachments []*Attachment, config *Config) error {
+	return transport.Send(newMailData(to, subject, htmlBody, sendBcc, nil, attachments, config))
+}
+
+func newMailData(to, subject, htmlBody string, sendBcc bool, embeddedFiles map[string]io.Reader, attachments []*Attachment, config *Config) mailData {
 	fromMail := mail.Address{Name: config.ReplyToName, Address: config.ReplyToAddress}
 	replyTo := mail.Address{Name: config.ReplyToName, Address: config.ReplyToAddress}
 
@@ -292,12 +316,7 @@ func SendMailWithEmbeddedFilesUsingConfig(to, subject, htmlBody string, sendBcc
 		}
 	}
 
-	return sendMailUsingConfigAdvanced(mailData, config, sendEmailDefaultRetries)
-}
-
-// SendMailUsingConfig sends an email with the provided config.
-func SendMailUsingConfig(to, subject, htmlBody string, sendBcc bool, attachments []*Attachment, config *Config) error {
-	return SendMailWithEmbeddedFilesUsingConfig(to, subject, htmlBody, sendBcc, nil, attachments, config)
+	return mailData
 }
 
 // sendMailUsingConfigAdvanced allows for sending an email with attachments and
@@ -339,11 +358,45 @@ func sendMailUsingConfigAdvanced(mail mailData, config *Config, retries uint64)
 
 // SendMail sends an email.
 func SendMail(c smtpClient, mail mailData, date time.Time) error {
+	m, err := newMessage(mail, date)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Mail(mail.from.Address); err != nil {
+		return errors.Wrapf(err, "failed to add from email address %s", mail.from.Address)
+	}
+	if err = c.Rcpt(mail.smtpTo); err != nil {
+		return errors.Wrapf(err, "failed to add to email address %s", mail.smtpTo)
+	}
+	for _, bcc := range mail.bcc {
+		if err = c.Rcpt(bcc.Address); err != nil {
+			return errors.Wrapf(err, "failed to add bcc address %s", bcc.Address)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "utils.mail.send_mail.msg_data.app_error")
+	}
+
+	if _, err = m.WriteTo(w); err != nil {
+		return errors.Wrap(err, "utils.mail.send_mail.msg.app_error")
+	}
+	if err = w.Close(); err != nil {
+		return errors.Wrap(err, "utils.mail.send_mail.close.app_error")
+	}
+
+	return nil
+}
+
+// newMessage builds the MIME message for the given mail data.
+func newMessage(mail mailData, date time.Time) (*gomail.Message, error) {
 	htmlMessage := "\r\n<html><body>" + mail.htmlBody + "</body></html>"
 
 	txtBody, err := html2text.FromString(mail.htmlBody)
 	if err != nil {
-		return errors.Wrap(err, "failed to convert email body to html text")
+		return nil, errors.Wrap(err, "failed to convert email body to html text")
 	}
 
 	headers := map[string][]string{
@@ -377,29 +430,5 @@ func SendMail(c smtpClient, mail mailData, date time.Time) error {
 		m.AttachReader(attachment.Name, attachment.Data)
 	}
 
-	if err = c.Mail(mail.from.Address); err != nil {
-		return errors.Wrapf(err, "failed to add from email address %s", mail.from.Address)
-	}
-	if err = c.Rcpt(mail.smtpTo); err != nil {
-		return errors.Wrapf(err, "failed to add to email address %s", mail.smtpTo)
-	}
-	for _, bcc := range mail.bcc {
-		if err = c.Rcpt(bcc.Address); err != nil {
-			return errors.Wrapf(err, "failed to add bcc address %s", bcc.Address)
-		}
-	}
-
-	w, err := c.Data()
-	if err != nil {
-		return errors.Wrap(err, "utils.mail.send_mail.msg_data.app_error")
-	}
-
-	if _, err = m.WriteTo(w); err != nil {
-		return errors.Wrap(err, "utils.mail.send_mail.msg.app_error")
-	}
-	if err = w.Close(); err != nil {
-		return errors.Wrap(err, "utils.mail.send_mail.close.app_error")
-	}
-
-	return nil
+	return m, nil
 }
@@ -0,0 +1,195 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaytaylor/html2text"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TransportSMTP delivers emails through the configured SMTP server.
+	TransportSMTP = "smtp"
+	// TransportFile writes every email as an .eml file to a directory.
+	TransportFile = "file"
+	// TransportLog only logs the emails without delivering them.
+	TransportLog = "log"
+)
+
+// Transport delivers a composed email.
+type Transport interface {
+	Send(mail mailData) error
+}
+
+// NewTransport returns the transport selected by the given config.
+func NewTransport(config *Config, logger logrus.FieldLogger) (Transport, error) {
+	switch config.Transport {
+	case "", TransportSMTP:
+		return NewSMTPTransport(config), nil
+	case TransportFile:
+		return NewFileTransport(config.FileDirectory)
+	case TransportLog:
+		return NewLogTransport(logger), nil
+	}
+
+	return nil, errors.Errorf("unsupported email transport %q", config.Transport)
+}
+
+type smtpTransport struct {
+	config *Config
+}
+
+// NewSMTPTransport returns a transport sending emails through the SMTP server
+// of the given config.
+func NewSMTPTransport(config *Config) Transport {
+	return &smtpTransport{config: config}
+}
+
+func (t *smtpTransport) Send(mail mailData) error {
+	return sendMailUsingConfigAdvanced(mail, t.config, sendEmailDefaultRetries)
+}
+
+type fileTransport struct {
+	directory string
+}
+
+// NewFileTransport returns a transport writing every email as an .eml file to
+// the given directory, creating it if needed.
+func NewFileTransport(directory string) (Transport, error) {
+	if len(directory) == 0 {
+		return nil, errors.New("no directory configured for the file email transport")
+	}
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, errors.Wrapf(err, "unable to create email directory %s", directory)
+	}
+
+	return &fileTransport{directory: directory}, nil
+}
+
+func (t *fileTransport) Send(mail mailData) error {
+	now := time.Now()
+	m, err := newMessage(mail, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), sanitizeFileName(mail.smtpTo))
+	f, err := os.OpenFile(filepath.Join(t.directory, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return errors.Wrap(err, "unable to create email file")
+	}
+	defer f.Close()
+
+	if _, err = m.WriteTo(f); err != nil {
+		return errors.Wrap(err, "unable to write email file")
+	}
+
+	return nil
+}
+
+// sanitizeFileName keeps only the characters of an email address that are safe
+// to use in a file name.
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '@', r == '.', r == '-', r == '_', r == '+':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+type logTransport struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogTransport returns a transport that only logs the emails.
+func NewLogTransport(logger logrus.FieldLogger) Transport {
+	return &logTransport{logger: logger.WithField("transport", TransportLog)}
+}
+
+func (t *logTransport) Send(mail mailData) error {
+	txtBody, err := html2text.FromString(mail.htmlBody)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert email body to html text")
+	}
+
+	t.logger.WithFields(logrus.Fields{
+		"to":      mail.mimeTo,
+		"subject": mail.subject,
+	}).Infof("Email not delivered, body:\n%s", txtBody)
+
+	return nil
+}
+
+// CapturedEmail is an email kept in memory by a CaptureTransport.
+type CapturedEmail struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+	SentAt   time.Time
+}
+
+// CaptureTransport keeps the latest email sent to every recipient in memory
+// before handing it to the next transport.
+type CaptureTransport struct {
+	next   Transport
+	mutex  sync.RWMutex
+	emails map[string]*CapturedEmail
+}
+
+// NewCaptureTransport returns a transport capturing emails in memory and
+// forwarding them to the given transport.
+func NewCaptureTransport(next Transport) *CaptureTransport {
+	return &CaptureTransport{
+		next:   next,
+		emails: make(map[string]*CapturedEmail),
+	}
+}
+
+func (t *CaptureTransport) Send(mail mailData) error {
+	txtBody, err := html2text.FromString(mail.htmlBody)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert email body to html text")
+	}
+
+	t.mutex.Lock()
+	t.emails[strings.ToLower(mail.smtpTo)] = &CapturedEmail{
+		To:       mail.smtpTo,
+		Subject:  mail.subject,
+		HTMLBody: mail.htmlBody,
+		TextBody: txtBody,
+		SentAt:   time.Now(),
+	}
+	t.mutex.Unlock()
+
+	return t.next.Send(mail)
+}
+
+// Emails returns the latest captured email of every recipient, sorted by
+// recipient.
+func (t *CaptureTransport) Emails() []*CapturedEmail {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	emails := make([]*CapturedEmail, 0, len(t.emails))
+	for _, captured := range t.emails {
+		copied := *captured
+		emails = append(emails, &copied)
+	}
+	sort.Slice(emails, func(i, j int) bool {
+		return emails[i].To < emails[j].To
+	})
+
+	return emails
+}
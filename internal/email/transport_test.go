@@ -0,0 +1,87 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransports(t *testing.T) {
+	config := &Config{
+		ReplyToName:    "Dashboard",
+		ReplyToAddress: "noreply@example.com",
+	}
+
+	t.Run("unknown transport", func(t *testing.T) {
+		_, err := NewTransport(&Config{Transport: "pigeon"}, testlib.MakeLogger(t))
+		require.Error(t, err)
+	})
+
+	t.Run("file transport requires a directory", func(t *testing.T) {
+		_, err := NewTransport(&Config{Transport: TransportFile}, testlib.MakeLogger(t))
+		require.Error(t, err)
+	})
+
+	t.Run("file transport writes eml files", func(t *testing.T) {
+		directory := filepath.Join(t.TempDir(), "emails")
+		transport, err := NewTransport(&Config{Transport: TransportFile, FileDirectory: directory}, testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		err = SendMailUsingTransport(transport, "user@example.com", "Hello", "<p>Hello there</p>", false, nil, config)
+		require.NoError(t, err)
+
+		files, err := filepath.Glob(filepath.Join(directory, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Contains(t, filepath.Base(files[0]), "user@example.com")
+
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "To: user@example.com")
+		assert.Contains(t, string(data), "Subject: Hello")
+		assert.Contains(t, string(data), "Hello there")
+	})
+
+	t.Run("log transport", func(t *testing.T) {
+		logger, hook := testlib.MakeLoggerWithHooks(t)
+		transport, err := NewTransport(&Config{Transport: TransportLog}, logger)
+		require.NoError(t, err)
+
+		err = SendMailUsingTransport(transport, "user@example.com", "Hello", "<p>Hello there</p>", false, nil, config)
+		require.NoError(t, err)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, "user@example.com", entry.Data["to"])
+		assert.Equal(t, "Hello", entry.Data["subject"])
+		assert.Contains(t, entry.Message, "Hello there")
+	})
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "user+tag@example.com", sanitizeFileName("user+tag@example.com"))
+	assert.Equal(t, ".._etc_passwd", sanitizeFileName("../etc/passwd"))
+}
+
+func TestCaptureTransport(t *testing.T) {
+	config := &Config{ReplyToAddress: "noreply@example.com"}
+	transport := NewCaptureTransport(NewLogTransport(testlib.MakeLogger(t)))
+
+	err := SendMailUsingTransport(transport, "one@example.com", "First", "<p>first</p>", false, nil, config)
+	require.NoError(t, err)
+	err = SendMailUsingTransport(transport, "one@example.com", "Second", "<p>second</p>", false, nil, config)
+	require.NoError(t, err)
+	err = SendMailUsingTransport(transport, "another@example.com", "Other", "<p>other</p>", false, nil, config)
+	require.NoError(t, err)
+
+	emails := transport.Emails()
+	require.Len(t, emails, 2)
+	assert.Equal(t, "another@example.com", emails[0].To)
+	assert.Equal(t, "one@example.com", emails[1].To)
+	assert.Equal(t, "Second", emails[1].Subject)
+	assert.Equal(t, "second", emails[1].TextBody)
+}
@@ -0,0 +1,261 @@
+// Package encryption implements envelope encryption of secrets stored at rest.
+//
+// Every value is encrypted with its own random data key using AES-256-GCM.
+// The data key is in turn encrypted with a key encryption key from a Keyring
+// and stored next to the value along with the id of that key, so that several
+// keys can coexist while values are being re-encrypted under a new one.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// KeySize is the size in bytes of the keys, selecting AES-256.
+	KeySize = 32
+
+	// version prefixes ciphertexts so that their format can evolve.
+	version = "v1"
+)
+
+var keyIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,32}$`)
+
+// Keyring holds the key encryption keys by id. Values are always encrypted
+// with the primary key, while any key of the keyring can decrypt them.
+type Keyring struct {
+	primaryID string
+	keys      map[string][]byte
+}
+
+// NewKeyring creates a keyring using the key of the given id as the primary
+// one.
+func NewKeyring(primaryID string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption key")
+	}
+	for id, key := range keys {
+		if !keyIDRegexp.MatchString(id) {
+			return nil, errors.Errorf("invalid encryption key id %q", id)
+		}
+		if len(key) != KeySize {
+			return nil, errors.Errorf("encryption key %s must be %d bytes", id, KeySize)
+		}
+	}
+	if _, ok := keys[primaryID]; !ok {
+		return nil, errors.Errorf("unknown primary encryption key %s", primaryID)
+	}
+
+	return &Keyring{
+		primaryID: primaryID,
+		keys:      keys,
+	}, nil
+}
+
+// ParseKeyring parses a comma separated list of <id>:<base64 key> pairs. The
+// first key of the list is the primary one.
+func ParseKeyring(value string) (*Keyring, error) {
+	primaryID := ""
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("encryption keys must be formatted as <id>:<base64 key>")
+		}
+		id := parts[0]
+		if _, ok := keys[id]; ok {
+			return nil, errors.Errorf("duplicate encryption key id %s", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode encryption key %s", id)
+		}
+
+		keys[id] = key
+		if primaryID == "" {
+			primaryID = id
+		}
+	}
+
+	return NewKeyring(primaryID, keys)
+}
+
+// GenerateKey returns a new random key encoded in base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", errors.Wrap(err, "failed to generate key")
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// PrimaryID returns the id of the key used to encrypt new values.
+func (k *Keyring) PrimaryID() string {
+	return k.primaryID
+}
+
+// Encrypt encrypts the plaintext under a new data key wrapped with the
+// primary key.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", errors.Wrap(err, "failed to generate data key")
+	}
+
+	sealed, err := seal(dataKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return k.wrap(dataKey, sealed)
+}
+
+// Decrypt decrypts a value returned by Encrypt with any key of the keyring.
+func (k *Keyring) Decrypt(ciphertext string) ([]byte, error) {
+	dataKey, sealed, err := k.unwrap(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataKey, sealed)
+}
+
+// NeedsRotation returns true if the value was not encrypted with the primary
+// key.
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	keyID, err := KeyID(ciphertext)
+	return err != nil || keyID != k.primaryID
+}
+
+// Rotate re-encrypts the data key of the value with the primary key. The
+// value itself is left untouched.
+func (k *Keyring) Rotate(ciphertext string) (string, error) {
+	dataKey, sealed, err := k.unwrap(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return k.wrap(dataKey, sealed)
+}
+
+// IsEncrypted returns true if the value looks like it was returned by
+// Encrypt.
+func IsEncrypted(value string) bool {
+	_, err := KeyID(value)
+	return err == nil
+}
+
+// KeyID returns the id of the key the value was encrypted with.
+func KeyID(ciphertext string) (string, error) {
+	parts := strings.Split(ciphertext, ":")
+	if len(parts) != 4 || parts[0] != version {
+		return "", errors.New("invalid ciphertext")
+	}
+
+	return parts[1], nil
+}
+
+func (k *Keyring) wrap(dataKey, sealed []byte) (string, error) {
+	wrappedKey, err := seal(k.keys[k.primaryID], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		version,
+		k.primaryID,
+		base64.StdEncoding.EncodeToString(wrappedKey),
+		base64.StdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+func (k *Keyring) unwrap(ciphertext string) ([]byte, []byte, error) {
+	keyID, err := KeyID(ciphertext)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, nil, errors.Errorf("unknown encryption key %s", keyID)
+	}
+
+	parts := strings.Split(ciphertext, ":")
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid ciphertext")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid ciphertext")
+	}
+
+	dataKey, err := open(key, wrappedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dataKey, sealed, nil
+}
+
+// seal encrypts the plaintext with AES-GCM, prefixing it with a random nonce.
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a value returned by seal.
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+	nonce, encrypted := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, encrypted, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gcm")
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,137 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T, ids ...string) *Keyring {
+	t.Helper()
+
+	var entries []string
+	for _, id := range ids {
+		key, err := GenerateKey()
+		require.NoError(t, err)
+		entries = append(entries, id+":"+key)
+	}
+
+	keyring, err := ParseKeyring(strings.Join(entries, ","))
+	require.NoError(t, err)
+
+	return keyring
+}
+
+func TestParseKeyring(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	keyring, err := ParseKeyring("new:" + key + ", old:" + key)
+	require.NoError(t, err)
+	assert.Equal(t, "new", keyring.PrimaryID())
+
+	_, err = ParseKeyring("")
+	assert.Error(t, err)
+
+	_, err = ParseKeyring(key)
+	assert.Error(t, err)
+
+	_, err = ParseKeyring("a:" + key + ",a:" + key)
+	assert.Error(t, err)
+
+	_, err = ParseKeyring("a:c2hvcnQ=")
+	assert.Error(t, err)
+
+	_, err = ParseKeyring("not valid:" + key)
+	assert.Error(t, err)
+}
+
+func TestEncryption(t *testing.T) {
+	keyring := newTestKeyring(t, "one")
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		ciphertext, err := keyring.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		assert.NotContains(t, ciphertext, "secret")
+		assert.True(t, IsEncrypted(ciphertext))
+		assert.False(t, keyring.NeedsRotation(ciphertext))
+
+		keyID, err := KeyID(ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "one", keyID)
+
+		other, err := keyring.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		assert.NotEqual(t, ciphertext, other)
+
+		plaintext, err := keyring.Decrypt(ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		ciphertext, err := keyring.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+
+		parts := strings.Split(ciphertext, ":")
+		sealed := []byte(parts[3])
+		if sealed[len(sealed)-5] == 'A' {
+			sealed[len(sealed)-5] = 'B'
+		} else {
+			sealed[len(sealed)-5] = 'A'
+		}
+		parts[3] = string(sealed)
+
+		_, err = keyring.Decrypt(strings.Join(parts, ":"))
+		assert.Error(t, err)
+
+		_, err = keyring.Decrypt("plaintext")
+		assert.Error(t, err)
+		assert.False(t, IsEncrypted("plaintext"))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		ciphertext, err := newTestKeyring(t, "other").Encrypt([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = keyring.Decrypt(ciphertext)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown encryption key other")
+	})
+}
+
+func TestRotate(t *testing.T) {
+	oldKey, err := GenerateKey()
+	require.NoError(t, err)
+	newKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	oldKeyring, err := ParseKeyring("old:" + oldKey)
+	require.NoError(t, err)
+	rotatingKeyring, err := ParseKeyring("new:" + newKey + ",old:" + oldKey)
+	require.NoError(t, err)
+	newKeyring, err := ParseKeyring("new:" + newKey)
+	require.NoError(t, err)
+
+	ciphertext, err := oldKeyring.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+
+	// Values encrypted with the previous key are still readable while rotating.
+	plaintext, err := rotatingKeyring.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+	assert.True(t, rotatingKeyring.NeedsRotation(ciphertext))
+
+	rotated, err := rotatingKeyring.Rotate(ciphertext)
+	require.NoError(t, err)
+	assert.False(t, rotatingKeyring.NeedsRotation(rotated))
+
+	plaintext, err = newKeyring.Decrypt(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = oldKeyring.Decrypt(rotated)
+	assert.Error(t, err)
+}
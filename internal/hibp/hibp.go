@@ -0,0 +1,139 @@
+// Package hibp checks passwords against a local copy of the Pwned Passwords
+// list of Have I Been Pwned, so that no request leaves the server.
+//
+// The list is the one built from the range API, as written by the official
+// downloader: one uppercase SHA-1 hash per line, made of the 5 characters of
+// the range prefix followed by the 35 characters of the suffix, then a colon
+// and the number of times the password was seen, sorted by hash. The list is
+// too large to be loaded in memory, so it is binary searched in place.
+package hibp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	hashLength = 2 * sha1.Size
+	// maxLineLength is larger than any line of the list, counts included.
+	maxLineLength = 256
+)
+
+// List is a local copy of the Pwned Passwords list. It is safe for
+// concurrent use.
+type List struct {
+	file *os.File
+	size int64
+}
+
+// Open opens the list at the given path.
+func Open(path string) (*List, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open breached passwords list")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "failed to stat breached passwords list")
+	}
+
+	return &List{file: file, size: info.Size()}, nil
+}
+
+// Close closes the file of the list.
+func (l *List) Close() error {
+	return l.file.Close()
+}
+
+// Contains returns true if the password is in the list.
+func (l *List) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := make([]byte, hashLength)
+	hex.Encode(target, sum[:])
+	target = bytes.ToUpper(target)
+
+	low, high := int64(0), l.size
+	for low < high {
+		middle := low + (high-low)/2
+		hash, next, err := l.hashAfter(middle)
+		if err != nil {
+			return false, err
+		}
+		if hash == nil {
+			high = middle
+			continue
+		}
+
+		switch bytes.Compare(hash, target) {
+		case 0:
+			return true, nil
+		case -1:
+			low = next
+		default:
+			// The line of the target, if any, starts before the first line
+			// starting at or after the middle.
+			high = middle
+		}
+	}
+
+	return false, nil
+}
+
+// hashAfter returns the hash of the first line starting at or after the given
+// offset and the offset of the line following it, or a nil hash if no line
+// starts after the offset.
+func (l *List) hashAfter(offset int64) ([]byte, int64, error) {
+	start := offset
+	if offset > 0 {
+		// Read from the previous byte to know if a line starts at the offset.
+		start--
+	}
+
+	buffer := make([]byte, 2*maxLineLength)
+	n, err := l.file.ReadAt(buffer, start)
+	if err != nil && err != io.EOF {
+		return nil, 0, errors.Wrap(err, "failed to read breached passwords list")
+	}
+	buffer = buffer[:n]
+
+	if offset > 0 {
+		newline := bytes.IndexByte(buffer, '\n')
+		if newline < 0 {
+			if start+int64(n) < l.size {
+				return nil, 0, errors.New("invalid breached passwords list: line too long")
+			}
+			return nil, 0, nil
+		}
+		buffer = buffer[newline+1:]
+		start += int64(newline) + 1
+	}
+	if len(buffer) == 0 {
+		return nil, 0, nil
+	}
+
+	line := buffer
+	next := start + int64(len(buffer))
+	if newline := bytes.IndexByte(buffer, '\n'); newline >= 0 {
+		line = buffer[:newline]
+		next = start + int64(newline) + 1
+	} else if next < l.size {
+		return nil, 0, errors.New("invalid breached passwords list: line too long")
+	}
+
+	if colon := bytes.IndexByte(line, ':'); colon >= 0 {
+		line = line[:colon]
+	}
+	line = bytes.TrimSpace(line)
+	if len(line) != hashLength {
+		return nil, 0, errors.New("invalid breached passwords list: not a SHA-1 hash")
+	}
+
+	return bytes.ToUpper(line), next, nil
+}
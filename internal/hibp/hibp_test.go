@@ -0,0 +1,123 @@
+package hibp
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeList writes a list of the given passwords, and as many filler hashes,
+// to a temporary file.
+func writeList(t *testing.T, passwords []string, filler int, lineEnding string) string {
+	var hashes []string
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	for i := 0; i < filler; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("filler-%d", i)))
+		hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	sort.Strings(hashes)
+
+	var content strings.Builder
+	for i, hash := range hashes {
+		fmt.Fprintf(&content, "%s:%d%s", hash, i+1, lineEnding)
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0600))
+
+	return path
+}
+
+func TestContains(t *testing.T) {
+	breached := []string{"password", "123456", "Password1", "qwerty"}
+
+	for name, lineEnding := range map[string]string{"unix": "\n", "windows": "\r\n"} {
+		t.Run(name, func(t *testing.T) {
+			list, err := Open(writeList(t, breached, 1000, lineEnding))
+			require.NoError(t, err)
+			defer list.Close()
+
+			for _, password := range breached {
+				found, err := list.Contains(password)
+				require.NoError(t, err)
+				assert.True(t, found, password)
+			}
+			for _, password := range []string{"", "correct horse battery staple", "Password2"} {
+				found, err := list.Contains(password)
+				require.NoError(t, err)
+				assert.False(t, found, password)
+			}
+		})
+	}
+
+	t.Run("first and last lines", func(t *testing.T) {
+		path := writeList(t, nil, 100, "\n")
+		list, err := Open(path)
+		require.NoError(t, err)
+		defer list.Close()
+
+		for i := 0; i < 100; i++ {
+			found, err := list.Contains(fmt.Sprintf("filler-%d", i))
+			require.NoError(t, err)
+			require.True(t, found, i)
+		}
+	})
+
+	t.Run("no trailing newline", func(t *testing.T) {
+		sum := sha1.Sum([]byte("password"))
+		path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+		require.NoError(t, os.WriteFile(path, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":3"), 0600))
+
+		list, err := Open(path)
+		require.NoError(t, err)
+		defer list.Close()
+
+		found, err := list.Contains("password")
+		require.NoError(t, err)
+		assert.True(t, found)
+		found, err = list.Contains("other")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+
+		list, err := Open(path)
+		require.NoError(t, err)
+		defer list.Close()
+
+		found, err := list.Contains("password")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("invalid list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+		require.NoError(t, os.WriteFile(path, []byte("not a hash\n"), 0600))
+
+		list, err := Open(path)
+		require.NoError(t, err)
+		defer list.Close()
+
+		_, err = list.Contains("password")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing list", func(t *testing.T) {
+		_, err := Open(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,132 @@
+// Package i18n loads the translation bundles used to localize the strings
+// sent to users, such as emails.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLocale is the locale of users who did not pick one, also used when a
+// string has no translation for the requested locale.
+const DefaultLocale = "en"
+
+// translation is a single entry of a translation file.
+type translation struct {
+	ID          string `json:"id"`
+	Translation string `json:"translation"`
+}
+
+// Bundle contains the translations of every supported locale.
+type Bundle struct {
+	translations map[string]map[string]string
+}
+
+// LoadBundle reads every <locale>.json file of the given directory. The
+// default locale must be present.
+func LoadBundle(directory string) (*Bundle, error) {
+	files, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list translation files")
+	}
+
+	bundle := &Bundle{translations: make(map[string]map[string]string)}
+	for _, file := range files {
+		locale := strings.TrimSuffix(filepath.Base(file), ".json")
+		translations, err := loadTranslations(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load translations for locale %s", locale)
+		}
+		bundle.translations[NormalizeLocale(locale)] = translations
+	}
+
+	if _, ok := bundle.translations[DefaultLocale]; !ok {
+		return nil, errors.Errorf("no translations found for default locale %s in %s", DefaultLocale, directory)
+	}
+
+	return bundle, nil
+}
+
+func loadTranslations(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []translation
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	translations := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		translations[entry.ID] = entry.Translation
+	}
+
+	return translations, nil
+}
+
+// NormalizeLocale lowercases the given locale and replaces underscores with
+// dashes, so that "pt_BR" and "pt-br" are the same locale.
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+
+// Locales returns the supported locales, sorted.
+func (b *Bundle) Locales() []string {
+	locales := make([]string, 0, len(b.translations))
+	for locale := range b.translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// IsSupported returns true if the bundle has translations for the given
+// locale or for its base language.
+func (b *Bundle) IsSupported(locale string) bool {
+	return b.resolve(locale) != ""
+}
+
+// resolve returns the bundle locale matching the given one, trying the exact
+// locale first and then its base language, or an empty string if none match.
+func (b *Bundle) resolve(locale string) string {
+	locale = NormalizeLocale(locale)
+	if _, ok := b.translations[locale]; ok {
+		return locale
+	}
+
+	if i := strings.Index(locale, "-"); i > 0 {
+		if _, ok := b.translations[locale[:i]]; ok {
+			return locale[:i]
+		}
+	}
+
+	return ""
+}
+
+// Translate returns the string of the given id for the given locale, falling
+// back to the default locale and then to the id itself. Any args are used to
+// format the string.
+func (b *Bundle) Translate(locale, id string, args ...interface{}) string {
+	text, ok := b.translations[b.resolve(locale)][id]
+	if !ok {
+		text, ok = b.translations[DefaultLocale][id]
+	}
+	if !ok {
+		text = id
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+
+	return text
+}
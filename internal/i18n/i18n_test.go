@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTranslations(t *testing.T, directory, locale, content string) {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(directory, locale+".json"), []byte(content), 0600)
+	require.NoError(t, err)
+}
+
+func TestBundle(t *testing.T) {
+	directory := t.TempDir()
+	writeTranslations(t, directory, "en", `[
+		{"id": "hello", "translation": "Hello"},
+		{"id": "welcome", "translation": "Welcome %s"}
+	]`)
+	writeTranslations(t, directory, "es", `[{"id": "hello", "translation": "Hola"}]`)
+	writeTranslations(t, directory, "pt_BR", `[{"id": "hello", "translation": "Olá"}]`)
+
+	bundle, err := LoadBundle(directory)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "es", "pt-br"}, bundle.Locales())
+
+	t.Run("supported locales", func(t *testing.T) {
+		assert.True(t, bundle.IsSupported("en"))
+		assert.True(t, bundle.IsSupported("es-MX"))
+		assert.True(t, bundle.IsSupported("PT_br"))
+		assert.False(t, bundle.IsSupported("pt"))
+		assert.False(t, bundle.IsSupported("fr"))
+		assert.False(t, bundle.IsSupported(""))
+	})
+
+	t.Run("translate", func(t *testing.T) {
+		assert.Equal(t, "Hola", bundle.Translate("es", "hello"))
+		assert.Equal(t, "Hola", bundle.Translate("es-AR", "hello"))
+		assert.Equal(t, "Olá", bundle.Translate("pt-BR", "hello"))
+		assert.Equal(t, "Welcome Ana", bundle.Translate("en", "welcome", "Ana"))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		assert.Equal(t, "Hello", bundle.Translate("fr", "hello"))
+		assert.Equal(t, "Welcome Ana", bundle.Translate("es", "welcome", "Ana"))
+		assert.Equal(t, "missing", bundle.Translate("es", "missing"))
+	})
+}
+
+func TestLoadBundle(t *testing.T) {
+	t.Run("default locale is required", func(t *testing.T) {
+		directory := t.TempDir()
+		writeTranslations(t, directory, "es", `[{"id": "hello", "translation": "Hola"}]`)
+
+		_, err := LoadBundle(directory)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		directory := t.TempDir()
+		writeTranslations(t, directory, "en", `{"hello": "Hello"}`)
+
+		_, err := LoadBundle(directory)
+		require.Error(t, err)
+	})
+
+	t.Run("shipped translations", func(t *testing.T) {
+		bundle, err := LoadBundle("translations")
+		require.NoError(t, err)
+
+		// Every shipped locale must only translate strings that exist in
+		// English.
+		for _, locale := range bundle.Locales() {
+			for id := range bundle.translations[locale] {
+				_, ok := bundle.translations[DefaultLocale][id]
+				assert.True(t, ok, "%s translates unknown string %s", locale, id)
+			}
+		}
+	})
+}
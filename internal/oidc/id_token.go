@@ -0,0 +1,218 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clockSkew is the tolerance applied when checking the validity period of ID
+// tokens.
+const clockSkew = time.Minute
+
+// IDToken is a verified ID token.
+type IDToken struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Nonce    string
+	Expiry   time.Time
+	IssuedAt time.Time
+
+	claims []byte
+}
+
+// Claims decodes the claims of the token into v.
+func (t *IDToken) Claims(v interface{}) error {
+	return json.Unmarshal(t.claims, v)
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+
+	return nil
+}
+
+type idTokenClaims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	Nonce           string   `json:"nonce"`
+	Expiry          int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// VerifyIDToken checks the signature and the claims of a raw ID token. The
+// nonce must be the one sent when the flow was started.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.Wrap(err, "malformed ID token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token signature")
+	}
+
+	key, err := p.keys.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifySignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token payload")
+	}
+	var claims idTokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "malformed ID token payload")
+	}
+
+	if claims.Issuer != p.metadata.Issuer {
+		return nil, errors.Errorf("ID token issued by %q instead of %q", claims.Issuer, p.metadata.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	if !contains(claims.Audience, p.config.ClientID) {
+		return nil, errors.New("ID token was not issued for this client")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, errors.New("ID token was not issued for this client")
+	}
+
+	now := time.Now()
+	expiry := time.Unix(claims.Expiry, 0)
+	if claims.Expiry == 0 || now.After(expiry.Add(clockSkew)) {
+		return nil, errors.New("ID token is expired")
+	}
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if issuedAt.After(now.Add(clockSkew)) {
+		return nil, errors.New("ID token is issued in the future")
+	}
+
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	return &IDToken{
+		Issuer:   claims.Issuer,
+		Subject:  claims.Subject,
+		Audience: claims.Audience,
+		Nonce:    claims.Nonce,
+		Expiry:   expiry,
+		IssuedAt: issuedAt,
+		claims:   payload,
+	}, nil
+}
+
+// verifySignature checks the signature of a JWT. Only asymmetric algorithms
+// are supported, the key type must match the algorithm so that a token can't
+// pick how it is verified.
+func verifySignature(algorithm string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256", "PS256":
+		hash = crypto.SHA256
+	case "RS384", "ES384", "PS384":
+		hash = crypto.SHA384
+	case "RS512", "ES512", "PS512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch algorithm[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Errorf("signing key does not match algorithm %q", algorithm)
+		}
+
+		var err error
+		if algorithm[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, hash, digest, signature, nil)
+		}
+		if err != nil {
+			return errors.New("invalid ID token signature")
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.Errorf("signing key does not match algorithm %q", algorithm)
+		}
+
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ID token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid ID token signature")
+		}
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
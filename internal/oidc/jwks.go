@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minKeyRefreshInterval bounds how often the keys are fetched again when a
+// token is signed with an unknown key, so that forged tokens can't be used to
+// flood the provider.
+const minKeyRefreshInterval = time.Minute
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// keySet caches the signing keys of a provider by key id.
+type keySet struct {
+	uri    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, client *http.Client) *keySet {
+	return &keySet{
+		uri:    uri,
+		client: client,
+	}
+}
+
+// key returns the key of the given id, fetching the keys again if it is
+// unknown, as the provider may have rotated them.
+func (s *keySet) key(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.lookup(keyID); ok {
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < minKeyRefreshInterval {
+		return nil, errors.Errorf("unknown signing key %q", keyID)
+	}
+
+	if err := s.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := s.lookup(keyID); ok {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown signing key %q", keyID)
+}
+
+// lookup returns the key of the given id. Tokens without a key id are only
+// accepted when the provider publishes a single key.
+func (s *keySet) lookup(keyID string) (crypto.PublicKey, bool) {
+	if keyID == "" {
+		if len(s.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[keyID]
+	return key, ok
+}
+
+func (s *keySet) fetch(ctx context.Context) error {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, s.client, s.uri, &jwks); err != nil {
+		return errors.Wrap(err, "failed to fetch the signing keys")
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Keys of unsupported types are ignored rather than failing the
+			// whole set.
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC key")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, errors.Errorf("unsupported key type %q", k.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,154 @@
+// Package oidc implements the relying party side of OpenID Connect: discovery
+// of the provider, the authorization code flow with PKCE and the verification
+// of ID tokens against the keys published by the provider.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// Config is the configuration of a client of an OpenID Connect provider.
+type Config struct {
+	// IssuerURL is the URL the provider is discovered from. It must match the
+	// issuer of the ID tokens.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	// Scopes are requested on top of the openid scope.
+	Scopes []string
+}
+
+// Metadata is the subset of the provider metadata used by the client.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is a discovered OpenID Connect provider.
+type Provider struct {
+	config   Config
+	metadata Metadata
+	client   *http.Client
+	keys     *keySet
+}
+
+// Discover fetches the metadata of the provider of the given configuration.
+func Discover(ctx context.Context, config Config) (*Provider, error) {
+	return DiscoverWithClient(ctx, config, &http.Client{Timeout: 30 * time.Second})
+}
+
+// DiscoverWithClient fetches the metadata of the provider of the given
+// configuration with the given HTTP client, which is also used for any later
+// request to the provider.
+func DiscoverWithClient(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	if config.IssuerURL == "" || config.ClientID == "" {
+		return nil, errors.New("the issuer URL and the client id are required")
+	}
+
+	discoveryURL := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var metadata Metadata
+	if err := getJSON(ctx, client, discoveryURL, &metadata); err != nil {
+		return nil, errors.Wrap(err, "failed to discover the OpenID Connect provider")
+	}
+
+	// The issuer is compared to the configured one, as an attacker able to
+	// alter the discovery document could otherwise issue tokens of their own.
+	if metadata.Issuer != strings.TrimSuffix(config.IssuerURL, "/") && metadata.Issuer != config.IssuerURL {
+		return nil, errors.Errorf("issuer %q does not match the configured issuer %q", metadata.Issuer, config.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("the OpenID Connect provider metadata is incomplete")
+	}
+
+	return &Provider{
+		config:   config,
+		metadata: metadata,
+		client:   client,
+		keys:     newKeySet(metadata.JWKSURI, client),
+	}, nil
+}
+
+// Metadata returns the metadata of the provider.
+func (p *Provider) Metadata() Metadata {
+	return p.metadata
+}
+
+// OAuth2Config returns the OAuth 2 configuration of the client.
+func (p *Provider) OAuth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       append([]string{"openid"}, p.config.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.metadata.AuthorizationEndpoint,
+			TokenURL: p.metadata.TokenEndpoint,
+		},
+	}
+}
+
+// AuthCodeURL returns the URL to redirect the user to in order to log in. The
+// nonce is embedded in the ID token and the code verifier must be presented
+// when exchanging the code.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.OAuth2Config().AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.SetAuthURLParam("code_challenge", CodeChallenge(codeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+}
+
+// Exchange exchanges the authorization code for tokens and returns them along
+// with the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*oauth2.Token, string, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.OAuth2Config().Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to exchange oauth code into token")
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, "", errors.New("the token response has no ID token")
+	}
+
+	return token, rawIDToken, nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of the given code verifier.
+func CodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("GET %s failed with status code %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,160 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/internal/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T, issuer *oidctest.Issuer) *Provider {
+	provider, err := Discover(context.Background(), Config{
+		IssuerURL:    issuer.URL(),
+		ClientID:     issuer.ClientID,
+		ClientSecret: issuer.ClientSecret,
+		RedirectURL:  "http://localhost/oauth/oidc/complete",
+		Scopes:       []string{"email", "profile"},
+	})
+	require.NoError(t, err)
+
+	return provider
+}
+
+func TestDiscover(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "client", "secret")
+
+	provider := newTestProvider(t, issuer)
+	assert.Equal(t, issuer.URL(), provider.Metadata().Issuer)
+	assert.Equal(t, issuer.URL()+"/token", provider.Metadata().TokenEndpoint)
+	assert.Equal(t, []string{"openid", "email", "profile"}, provider.OAuth2Config().Scopes)
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		_, err := Discover(context.Background(), Config{
+			IssuerURL: issuer.URL() + "/other",
+			ClientID:  "client",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("unreachable provider", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		_, err := Discover(context.Background(), Config{
+			IssuerURL: server.URL,
+			ClientID:  "client",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "client", "secret")
+	provider := newTestProvider(t, issuer)
+
+	authURL := provider.AuthCodeURL("state", "nonce", "verifier-verifier-verifier-verifier-verifier")
+	assert.Contains(t, authURL, "code_challenge="+CodeChallenge("verifier-verifier-verifier-verifier-verifier"))
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		code, _ := issuer.Authorize(t, authURL, map[string]interface{}{"sub": "user"})
+
+		_, _, err := provider.Exchange(context.Background(), code, "other-verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("valid flow", func(t *testing.T) {
+		code, state := issuer.Authorize(t, authURL, map[string]interface{}{"sub": "user", "email": "user@example.com"})
+		assert.Equal(t, "state", state)
+
+		token, rawIDToken, err := provider.Exchange(context.Background(), code, "verifier-verifier-verifier-verifier-verifier")
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+
+		idToken, err := provider.VerifyIDToken(context.Background(), rawIDToken, "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "user", idToken.Subject)
+
+		var claims struct {
+			Email string `json:"email"`
+		}
+		require.NoError(t, idToken.Claims(&claims))
+		assert.Equal(t, "user@example.com", claims.Email)
+
+		// The code can't be used twice.
+		_, _, err = provider.Exchange(context.Background(), code, "verifier-verifier-verifier-verifier-verifier")
+		require.Error(t, err)
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "client", "secret")
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	t.Run("valid", func(t *testing.T) {
+		claims := issuer.Claims("user", "nonce")
+		claims["aud"] = []string{"client", "other"}
+		claims["azp"] = "client"
+
+		idToken, err := provider.VerifyIDToken(ctx, issuer.SignIDToken(t, claims), "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "user", idToken.Subject)
+		assert.Equal(t, []string{"client", "other"}, idToken.Audience)
+	})
+
+	tests := map[string]func(claims map[string]interface{}){
+		"wrong issuer":       func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" },
+		"wrong audience":     func(claims map[string]interface{}) { claims["aud"] = "other" },
+		"missing azp":        func(claims map[string]interface{}) { claims["aud"] = []string{"client", "other"} },
+		"expired":            func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"issued in future":   func(claims map[string]interface{}) { claims["iat"] = time.Now().Add(time.Hour).Unix() },
+		"wrong nonce":        func(claims map[string]interface{}) { claims["nonce"] = "other" },
+		"missing subject":    func(claims map[string]interface{}) { delete(claims, "sub") },
+		"missing expiration": func(claims map[string]interface{}) { delete(claims, "exp") },
+	}
+	for name, alter := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := issuer.Claims("user", "nonce")
+			alter(claims)
+
+			_, err := provider.VerifyIDToken(ctx, issuer.SignIDToken(t, claims), "nonce")
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("tampered payload", func(t *testing.T) {
+		parts := strings.Split(issuer.SignIDToken(t, issuer.Claims("user", "nonce")), ".")
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + issuer.URL() + `","sub":"admin","aud":"client","nonce":"nonce","exp":9999999999}`))
+
+		_, err := provider.VerifyIDToken(ctx, strings.Join(parts, "."), "nonce")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid ID token signature")
+	})
+
+	t.Run("unsigned token", func(t *testing.T) {
+		parts := strings.Split(issuer.SignIDToken(t, issuer.Claims("user", "nonce")), ".")
+		parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"test-key"}`))
+
+		_, err := provider.VerifyIDToken(ctx, parts[0]+"."+parts[1]+".", "nonce")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported signing algorithm")
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		parts := strings.Split(issuer.SignIDToken(t, issuer.Claims("user", "nonce")), ".")
+		parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"other"}`))
+
+		_, err := provider.VerifyIDToken(ctx, strings.Join(parts, "."), "nonce")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown signing key")
+	})
+}
@@ -0,0 +1,241 @@
+// Package oidctest provides an in-process OpenID Connect provider to test
+// clients against.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Issuer is a fake OpenID Connect provider serving the discovery document, the
+// signing keys and the token endpoint. Users log in by calling Authorize.
+type Issuer struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+type authorization struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// NewIssuer starts a fake provider with a single registered client. It is
+// closed at the end of the test.
+func NewIssuer(t *testing.T, clientID, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &Issuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		keyID:        "test-key",
+		codes:        make(map[string]*authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.handleDiscovery)
+	mux.HandleFunc("/keys", issuer.handleKeys)
+	mux.HandleFunc("/token", issuer.handleToken)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Server.Close)
+
+	return issuer
+}
+
+// URL returns the issuer URL of the provider.
+func (i *Issuer) URL() string {
+	return i.Server.URL
+}
+
+// Authorize simulates a user logging in at the authorization URL of a client
+// and returns the code and state the provider redirects back with. The claims
+// are added to the ID token issued for the code.
+func (i *Issuer) Authorize(t *testing.T, authURL string, claims map[string]interface{}) (string, string) {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+
+	require.Equal(t, i.URL()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, "code", query.Get("response_type"))
+	require.Equal(t, i.ClientID, query.Get("client_id"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.NotEmpty(t, query.Get("code_challenge"))
+	require.NotEmpty(t, query.Get("nonce"))
+	require.NotEmpty(t, query.Get("state"))
+
+	code := randomString(t)
+	i.mu.Lock()
+	i.codes[code] = &authorization{
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		claims:        claims,
+	}
+	i.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// SignIDToken returns an ID token with the given claims signed by the
+// provider.
+func (i *Issuer) SignIDToken(t *testing.T, claims map[string]interface{}) string {
+	idToken, err := i.signIDToken(claims)
+	require.NoError(t, err)
+
+	return idToken
+}
+
+// Claims returns the standard claims of an ID token issued to the client
+// for the given subject.
+func (i *Issuer) Claims(subject, nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   i.URL(),
+		"sub":   subject,
+		"aud":   i.ClientID,
+		"nonce": nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+}
+
+func (i *Issuer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.URL(),
+		"authorization_endpoint":                i.URL() + "/authorize",
+		"token_endpoint":                        i.URL() + "/token",
+		"jwks_uri":                              i.URL() + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (i *Issuer) handleKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": i.keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != i.ClientID || clientSecret != i.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// Codes can only be used once.
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	authorization := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	if authorization == nil ||
+		authorization.redirectURI != r.PostForm.Get("redirect_uri") ||
+		authorization.codeChallenge != codeChallenge(r.PostForm.Get("code_verifier")) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := i.Claims("", authorization.nonce)
+	for name, value := range authorization.claims {
+		claims[name] = value
+	}
+
+	idToken, err := i.signIDToken(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (i *Issuer) signIDToken(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": i.keyID, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func codeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func randomString(t *testing.T) string {
+	data := make([]byte, 16)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,223 @@
+// Package password hashes passwords with argon2id, encoding the hashes in the
+// PHC string format so that their parameters can be changed over time. The
+// bcrypt hashes of earlier versions still verify so they can be upgraded on
+// login.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+
+	argon2idPrefix = "$argon2id$"
+	// bcryptMaxLength is the number of bytes of a password bcrypt hashes,
+	// ignoring the rest.
+	bcryptMaxLength = 72
+)
+
+// Params are the argon2id parameters of new hashes.
+type Params struct {
+	// Memory is the memory used by a hash, in KiB.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the number of threads used by a hash.
+	Parallelism uint8
+}
+
+// DefaultParams are the parameters recommended by RFC 9106 for environments
+// where 64 MiB of memory per hash is affordable.
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+}
+
+// DefaultMemoryLimit is the memory, in KiB, that concurrent argon2id hashes can
+// use together by default: four hashes with the DefaultParams.
+const DefaultMemoryLimit = 4 * 64 * 1024
+
+// Hasher hashes passwords with its parameters and checks them against their
+// hashes, bounding the memory used by its concurrent argon2id hashes so that
+// a burst of logins queues up instead of exhausting the memory of the server.
+type Hasher struct {
+	params  Params
+	limiter *memoryLimiter
+}
+
+// NewHasher returns a hasher computing new hashes with the given parameters,
+// whose concurrent argon2id hashes use up to memoryLimit KiB together. Hashes
+// over the limit wait for others to complete, and a single hash needing more
+// than the limit runs alone. 0 removes the limit.
+func NewHasher(params Params, memoryLimit uint64) *Hasher {
+	return &Hasher{
+		params:  params,
+		limiter: newMemoryLimiter(memoryLimit),
+	}
+}
+
+// memoryLimiter is a semaphore weighted by the memory of each hash.
+type memoryLimiter struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	limit uint64
+	used  uint64
+}
+
+func newMemoryLimiter(limit uint64) *memoryLimiter {
+	l := &memoryLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mutex)
+
+	return l
+}
+
+// acquire waits until the given memory fits within the limit and reserves it.
+func (l *memoryLimiter) acquire(memory uint64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for l.limit > 0 && l.used > 0 && l.used+memory > l.limit {
+		l.cond.Wait()
+	}
+	l.used += memory
+}
+
+func (l *memoryLimiter) release(memory uint64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.used -= memory
+	l.cond.Broadcast()
+}
+
+// idKey computes an argon2id key once its memory fits within the limit.
+func (h *Hasher) idKey(password string, salt []byte, params Params, keyLen uint32) []byte {
+	h.limiter.acquire(uint64(params.Memory))
+	defer h.limiter.release(uint64(params.Memory))
+
+	return argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, keyLen)
+}
+
+// IsValid checks the parameters are within the bounds of argon2id.
+func (p Params) IsValid() error {
+	if p.Iterations < 1 {
+		return errors.New("iterations must be at least 1")
+	}
+	if p.Parallelism < 1 {
+		return errors.New("parallelism must be at least 1")
+	}
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return errors.New("memory must be at least 8 KiB per thread")
+	}
+
+	return nil
+}
+
+// Hash returns the argon2id hash of the password with the parameters of the
+// hasher and a random salt.
+func (h *Hasher) Hash(password string) (string, error) {
+	if err := h.params.IsValid(); err != nil {
+		return "", errors.Wrap(err, "invalid parameters")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt")
+	}
+	key := h.idKey(password, salt, h.params, keySize)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Compare returns true if the password matches the argon2id or bcrypt hash.
+func (h *Hasher) Compare(hash, password string) bool {
+	if len(hash) == 0 || len(password) == 0 {
+		return false
+	}
+
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		// bcrypt ignores what follows the first 72 bytes, which would let
+		// longer passwords sharing them match.
+		if len(password) > bcryptMaxLength {
+			return false
+		}
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	params, salt, key, err := decode(hash)
+	if err != nil {
+		return false
+	}
+	computed := h.idKey(password, salt, params, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, computed) == 1
+}
+
+// NeedsRehash returns true if the hash was not computed with argon2id and
+// the parameters of the hasher, in which case the password should be hashed
+// again the next time it is known.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	hashParams, _, _, err := decode(hash)
+	if err != nil {
+		return true
+	}
+
+	return hashParams != h.params
+}
+
+// decode parses an argon2id hash in the PHC string format.
+func decode(hash string) (Params, []byte, []byte, error) {
+	var params Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, errors.Wrap(err, "invalid version")
+	}
+	if version != argon2.Version {
+		return params, nil, nil, errors.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errors.Wrap(err, "invalid parameters")
+	}
+	if err := params.IsValid(); err != nil {
+		return params, nil, nil, errors.Wrap(err, "invalid parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.Wrap(err, "invalid salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid key")
+	}
+
+	return params, salt, key, nil
+}
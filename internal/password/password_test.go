@@ -0,0 +1,122 @@
+package password
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast.
+var testParams = Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+var testHasher = NewHasher(testParams, DefaultMemoryLimit)
+
+func TestHash(t *testing.T) {
+	hash, err := testHasher.Hash("Password1")
+	require.NoError(t, err)
+	assert.Equal(t, "$argon2id$v=19$m=64,t=1,p=1$", hash[:len("$argon2id$v=19$m=64,t=1,p=1$")])
+
+	t.Run("salted", func(t *testing.T) {
+		other, err := testHasher.Hash("Password1")
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		assert.True(t, testHasher.Compare(hash, "Password1"))
+		assert.False(t, testHasher.Compare(hash, "Password2"))
+		assert.False(t, testHasher.Compare(hash, ""))
+		assert.False(t, testHasher.Compare("", "Password1"))
+		assert.False(t, testHasher.Compare("$argon2id$v=19$m=64,t=1,p=1$junk", "Password1"))
+	})
+
+	t.Run("long passwords", func(t *testing.T) {
+		password := strings.Repeat("a", 100)
+		hash, err := testHasher.Hash(password)
+		require.NoError(t, err)
+		assert.True(t, testHasher.Compare(hash, password))
+		assert.False(t, testHasher.Compare(hash, password[:99]))
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		_, err := NewHasher(Params{Memory: 64, Iterations: 0, Parallelism: 1}, 0).Hash("Password1")
+		assert.Error(t, err)
+		_, err = NewHasher(Params{Memory: 4, Iterations: 1, Parallelism: 1}, 0).Hash("Password1")
+		assert.Error(t, err)
+	})
+}
+
+func TestBcrypt(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("Password1"), bcrypt.MinCost)
+	require.NoError(t, err)
+	hash := string(hashed)
+
+	assert.True(t, testHasher.Compare(hash, "Password1"))
+	assert.False(t, testHasher.Compare(hash, "Password2"))
+	assert.True(t, testHasher.NeedsRehash(hash))
+
+	t.Run("passwords longer than bcrypt supports", func(t *testing.T) {
+		password := strings.Repeat("a", bcryptMaxLength)
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		assert.True(t, testHasher.Compare(string(hashed), password))
+		assert.False(t, testHasher.Compare(string(hashed), password+"b"))
+	})
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := testHasher.Hash("Password1")
+	require.NoError(t, err)
+
+	assert.False(t, testHasher.NeedsRehash(hash))
+	assert.True(t, NewHasher(Params{Memory: 128, Iterations: 1, Parallelism: 1}, 0).NeedsRehash(hash))
+	assert.True(t, NewHasher(DefaultParams, 0).NeedsRehash(hash))
+	assert.True(t, testHasher.NeedsRehash("junk"))
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := newMemoryLimiter(100)
+
+	limiter.acquire(60)
+	limiter.acquire(40)
+
+	acquired := make(chan struct{})
+	go func() {
+		limiter.acquire(30)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired memory over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.release(40)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("memory not acquired once released")
+	}
+
+	t.Run("a hash over the limit runs alone", func(t *testing.T) {
+		limiter.release(60)
+		limiter.release(30)
+
+		limiter.acquire(500)
+		assert.Equal(t, uint64(500), limiter.used)
+		limiter.release(500)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		limiter := newMemoryLimiter(0)
+		limiter.acquire(500)
+		limiter.acquire(500)
+		assert.Equal(t, uint64(1000), limiter.used)
+	})
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+)
+
+// bucket is the state of the bucket of a key.
+type bucket struct {
+	tokens    float64
+	updatedAt int64
+}
+
+// MemoryStore keeps buckets in memory, limiting the requests of a single
+// server.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// TakeRateLimitToken implements Store.
+func (s *MemoryStore) TakeRateLimitToken(key string, capacity, refillPerMilli float64, now int64) (float64, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now - b.updatedAt; elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)*refillPerMilli)
+	}
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+
+	return b.tokens, true, nil
+}
+
+// DeleteRateLimitsBefore implements Store.
+func (s *MemoryStore) DeleteRateLimitsBefore(before int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updatedAt < before {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package ratelimit implements token bucket rate limiting. Each key has a
+// bucket holding up to the requests of its limit, refilled continuously over
+// the period of the limit, and every request takes a token from it. Buckets
+// are kept in a Store, in memory for a single server or in the database so
+// that every replica shares them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Limit allows a number of requests per period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// IsZero returns true if the limit is not set.
+func (l Limit) IsZero() bool {
+	return l.Requests == 0 || l.Period == 0
+}
+
+// refillPerMilli returns the number of tokens added to a bucket every
+// millisecond.
+func (l Limit) refillPerMilli() float64 {
+	return float64(l.Requests) / float64(l.Period.Milliseconds())
+}
+
+// Result describes the state of a bucket after a request.
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of requests left in the bucket.
+	Remaining int
+	// RetryAfter is how long to wait before a request is allowed again, zero
+	// if it was allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Store keeps the buckets of the keys.
+type Store interface {
+	// TakeRateLimitToken refills the bucket of the given key as of the given
+	// time in milliseconds and takes a token from it if it holds at least
+	// one. It returns the tokens left and whether one was taken. It must be
+	// atomic, since concurrent requests share buckets.
+	TakeRateLimitToken(key string, capacity, refillPerMilli float64, now int64) (float64, bool, error)
+	// DeleteRateLimitsBefore removes the buckets last used before the given
+	// time in milliseconds.
+	DeleteRateLimitsBefore(before int64) error
+}
+
+// Limiter checks requests against their limits.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a limiter keeping its buckets in the given store.
+func New(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow takes a token for a request from the bucket of the given key.
+func (l *Limiter) Allow(key string, limit Limit) (*Result, error) {
+	if limit.IsZero() {
+		return nil, errors.New("rate limit is not set")
+	}
+
+	refill := limit.refillPerMilli()
+	capacity := float64(limit.Requests)
+	tokens, allowed, err := l.store.TakeRateLimitToken(key, capacity, refill, l.now().UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to take rate limit token")
+	}
+
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: millis((capacity - tokens) / refill),
+	}
+	if !allowed {
+		result.RetryAfter = millis((1 - tokens) / refill)
+	}
+
+	return result, nil
+}
+
+// Cleanup removes the buckets unused for longer than the given duration,
+// which should be at least the longest period of the limits since their
+// buckets are full again by then.
+func (l *Limiter) Cleanup(maxIdle time.Duration) error {
+	return l.store.DeleteRateLimitsBefore(l.now().Add(-maxIdle).UnixNano() / int64(time.Millisecond))
+}
+
+// RunCleanup cleans up the buckets unused for longer than the given duration
+// every interval until the given context is done.
+func (l *Limiter) RunCleanup(ctx context.Context, interval, maxIdle time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Cleanup(maxIdle); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// millis converts a number of milliseconds to a duration, rounding up.
+func millis(ms float64) time.Duration {
+	if ms <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Ceil(ms)) * time.Millisecond
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns an in-memory limiter whose clock is moved by the
+// returned function.
+func newTestLimiter() (*Limiter, *MemoryStore, func(time.Duration)) {
+	store := NewMemoryStore()
+	limiter := New(store)
+
+	now := time.Unix(1600000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	return limiter, store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLimiter(t *testing.T) {
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	t.Run("allows a burst up to the limit", func(t *testing.T) {
+		limiter, _, _ := newTestLimiter()
+
+		for i := 2; i >= 0; i-- {
+			result, err := limiter.Allow("key", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, i, result.Remaining)
+			assert.Zero(t, result.RetryAfter)
+			assert.Equal(t, time.Duration(3-i)*time.Second, result.ResetAfter)
+		}
+
+		result, err := limiter.Allow("key", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 3*time.Second, result.ResetAfter)
+	})
+
+	t.Run("refills over the period", func(t *testing.T) {
+		limiter, _, advance := newTestLimiter()
+
+		for i := 0; i < 3; i++ {
+			result, err := limiter.Allow("key", limit)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+		}
+
+		advance(500 * time.Millisecond)
+		result, err := limiter.Allow("key", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+		advance(500 * time.Millisecond)
+		result, err = limiter.Allow("key", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		advance(time.Hour)
+		result, err = limiter.Allow("key", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Remaining)
+	})
+
+	t.Run("keys have their own buckets", func(t *testing.T) {
+		limiter, _, _ := newTestLimiter()
+
+		for i := 0; i < 3; i++ {
+			_, err := limiter.Allow("key", limit)
+			require.NoError(t, err)
+		}
+
+		result, err := limiter.Allow("other", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("limit is required", func(t *testing.T) {
+		limiter, _, _ := newTestLimiter()
+
+		_, err := limiter.Allow("key", Limit{})
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent requests share the bucket", func(t *testing.T) {
+		limiter, _, _ := newTestLimiter()
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		allowed := 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := limiter.Allow("key", Limit{Requests: 10, Period: time.Hour})
+				require.NoError(t, err)
+				if result.Allowed {
+					mutex.Lock()
+					allowed++
+					mutex.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 10, allowed)
+	})
+
+	t.Run("cleanup removes idle buckets", func(t *testing.T) {
+		limiter, store, advance := newTestLimiter()
+
+		_, err := limiter.Allow("idle", limit)
+		require.NoError(t, err)
+		advance(time.Minute)
+		_, err = limiter.Allow("active", limit)
+		require.NoError(t, err)
+
+		err = limiter.Cleanup(30 * time.Second)
+		require.NoError(t, err)
+
+		assert.NotContains(t, store.buckets, "idle")
+		assert.Contains(t, store.buckets, "active")
+	})
+}
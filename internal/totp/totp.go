@@ -0,0 +1,115 @@
+// Package totp implements the time-based one-time passwords of RFC 6238 used
+// by authenticator apps, with their default parameters: HMAC-SHA1, 6 digits
+// and a 30 seconds period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Digits is the number of digits of a code.
+	Digits = 6
+	// Period is how long a code is valid for.
+	Period = 30 * time.Second
+	// Skew is the number of periods before and after the current one whose
+	// codes are accepted, to allow for clock drift.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, base32 encoded as expected by
+// authenticator apps.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "failed to generate secret")
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth URI authenticator apps enroll the secret with,
+// usually shown as a QR code.
+func URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step returns the time step of the given time.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code returns the code of the given secret at the given time step.
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid secret")
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo), nil
+}
+
+// Validate checks the code against the secret at the given time, allowing for
+// the clock skew. Codes of steps up to lastStep are rejected so that a code
+// can't be used twice. It returns the step of the code when it is valid.
+func Validate(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for step := current - Skew; step <= current+Skew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		expected, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
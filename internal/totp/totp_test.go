@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// The RFC vectors have 8 digits, the codes are their last 6.
+	for unix, expected := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	current := Step(now)
+
+	code, err := Code(secret, current)
+	require.NoError(t, err)
+
+	t.Run("current code", func(t *testing.T) {
+		step, ok := Validate(secret, code, now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+	})
+
+	t.Run("codes can't be reused", func(t *testing.T) {
+		_, ok := Validate(secret, code, now, current)
+		assert.False(t, ok)
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		previous, err := Code(secret, current-1)
+		require.NoError(t, err)
+		next, err := Code(secret, current+1)
+		require.NoError(t, err)
+		old, err := Code(secret, current-2)
+		require.NoError(t, err)
+
+		_, ok := Validate(secret, previous, now, 0)
+		assert.True(t, ok)
+		_, ok = Validate(secret, next, now, 0)
+		assert.True(t, ok)
+		_, ok = Validate(secret, old, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid codes", func(t *testing.T) {
+		for _, invalid := range []string{"", "12345", "1234567", "abcdef"} {
+			_, ok := Validate(secret, invalid, now, 0)
+			assert.False(t, ok, invalid)
+		}
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Dashboard", "jane@example.com", "SECRET"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Dashboard:jane@example.com", uri.Path)
+	assert.Equal(t, "SECRET", uri.Query().Get("secret"))
+	assert.Equal(t, "Dashboard", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}
@@ -8,6 +8,8 @@ import (
 // APIError is the error struct returned by REST API endpoints.
 type APIError struct {
 	Message string `json:"message"`
+	// Reasons lists the PasswordReason values of a rejected password.
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 // APIErrorFromReader decodes a json-encoded APIError from the given io.Reader.
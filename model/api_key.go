@@ -0,0 +1,145 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// APIKeyScopeIngest allows an API key to only report test results.
+	APIKeyScopeIngest = "ingest"
+	// APIKeyScopeRead allows an API key to only read data.
+	APIKeyScopeRead = "read"
+	// APIKeyScopeAdmin allows an API key to do anything its creator can do.
+	APIKeyScopeAdmin = "admin"
+	// APIKeySize is the size of the random value of an API key.
+	APIKeySize = 48
+
+	apiKeyNameMaxRuneLength = 64
+)
+
+// APIKey authenticates non-interactive clients, such as CI runners, through
+// the X-CTRL-Api-Key header. Only the hash of the key is stored.
+type APIKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Scope      string `json:"scope"`
+	KeyHash    string `json:"-" db:"key_hash"`
+	CreatorID  string `json:"creator_id" db:"creator_id"`
+	CreateAt   int64  `json:"create_at" db:"create_at"`
+	ExpiresAt  int64  `json:"expires_at" db:"expires_at"`
+	LastUsedAt int64  `json:"last_used_at" db:"last_used_at"`
+	LastUsedIP string `json:"last_used_ip" db:"last_used_ip"`
+	DeleteAt   int64  `json:"delete_at" db:"delete_at"`
+}
+
+// IsValidAPIKeyScope returns true if the given scope exists.
+func IsValidAPIKeyScope(scope string) bool {
+	return scope == APIKeyScopeIngest || scope == APIKeyScopeRead || scope == APIKeyScopeAdmin
+}
+
+// PreSave generates the key of a new API key, sets its hash and returns the
+// key. The key is never stored and can't be retrieved later.
+func (k *APIKey) PreSave() string {
+	if k.ID == "" {
+		k.ID = NewID()
+	}
+
+	key := NewRandomString(APIKeySize)
+	k.KeyHash = HashToken(key)
+	k.CreateAt = GetMillis()
+	k.LastUsedAt = 0
+	k.LastUsedIP = ""
+	k.DeleteAt = 0
+
+	return key
+}
+
+// IsValid will determine if the API key fields are all valid.
+func (k *APIKey) IsValid() error {
+	if len(k.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if len(k.Name) == 0 || utf8.RuneCountInString(k.Name) > apiKeyNameMaxRuneLength {
+		return errors.New("invalid name")
+	}
+	if !IsValidAPIKeyScope(k.Scope) {
+		return errors.Errorf("invalid scope %s", k.Scope)
+	}
+	if len(k.CreatorID) != 26 {
+		return errors.New("invalid creator id")
+	}
+	if k.ExpiresAt < 0 || (k.ExpiresAt > 0 && k.ExpiresAt <= k.CreateAt) {
+		return errors.New("invalid expiry")
+	}
+
+	return nil
+}
+
+// IsExpired returns true if the API key has an expiry in the past.
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt > 0 && GetMillis() > k.ExpiresAt
+}
+
+// IsRevoked returns true if the API key was revoked.
+func (k *APIKey) IsRevoked() bool {
+	return k.DeleteAt > 0
+}
+
+// AllowsMethod returns true if the scope of the API key permits requests of
+// the given HTTP method. Ingest endpoints are allowed separately by the API.
+func (k *APIKey) AllowsMethod(method string) bool {
+	switch k.Scope {
+	case APIKeyScopeAdmin:
+		return true
+	case APIKeyScopeRead:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+
+	return false
+}
+
+// CreateAPIKeyRequest specifies the parameters of a new API key.
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// ExpiresAt is the optional expiry of the key in milliseconds.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse contains a new API key along with its value, which is
+// only ever returned once.
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
+
+// CreateAPIKeyResponseFromReader decodes a json-encoded create API key response
+// from the given io.Reader.
+func CreateAPIKeyResponseFromReader(reader io.Reader) (*CreateAPIKeyResponse, error) {
+	response := CreateAPIKeyResponse{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&response)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// APIKeysFromReader decodes a json-encoded list of API keys from the given
+// io.Reader.
+func APIKeysFromReader(reader io.Reader) ([]*APIKey, error) {
+	apiKeys := []*APIKey{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&apiKeys)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
@@ -0,0 +1,225 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AuditActionLogin is recorded when a user logs in.
+	AuditActionLogin = "login"
+	// AuditActionLoginFailed is recorded when a login fails, by a wrong
+	// password or second factor.
+	AuditActionLoginFailed = "login_failed"
+	// AuditActionLogout is recorded when a user logs out.
+	AuditActionLogout = "logout"
+	// AuditActionPasswordChange is recorded when a user changes their
+	// password.
+	AuditActionPasswordChange = "password_change"
+	// AuditActionPasswordReset is recorded when a password is reset with a
+	// token.
+	AuditActionPasswordReset = "password_reset"
+	// AuditActionEmailChange is recorded when a user confirms the change of
+	// their email from the new address.
+	AuditActionEmailChange = "email_change"
+	// AuditActionEmailChangeRequest is recorded when a user asks to change
+	// their email.
+	AuditActionEmailChangeRequest = "email_change_request"
+	// AuditActionEmailChangeCancel is recorded when an email change is
+	// cancelled from the previous address.
+	AuditActionEmailChangeCancel = "email_change_cancel"
+	// AuditActionRoleAdd is recorded when a role is granted to a user.
+	AuditActionRoleAdd = "role_add"
+	// AuditActionRoleRemove is recorded when a role is removed from a user.
+	AuditActionRoleRemove = "role_remove"
+	// AuditActionRoleCreate is recorded when a custom role is created.
+	AuditActionRoleCreate = "role_create"
+	// AuditActionRolePermissionsUpdate is recorded when the permissions of a
+	// role are replaced.
+	AuditActionRolePermissionsUpdate = "role_permissions_update"
+	// AuditActionUserLock is recorded when an admin locks a user.
+	AuditActionUserLock = "user_lock"
+	// AuditActionUserUnlock is recorded when an admin unlocks a user.
+	AuditActionUserUnlock = "user_unlock"
+	// AuditActionUserDelete is recorded when an admin deletes a user.
+	AuditActionUserDelete = "user_delete"
+	// AuditActionMFAReset is recorded when an admin resets the two-factor
+	// authentication of a user.
+	AuditActionMFAReset = "mfa_reset"
+	// AuditActionAccountLockout is recorded when failed attempts lock an
+	// account or IP address out.
+	AuditActionAccountLockout = "account_lockout"
+	// AuditActionAccountUnlock is recorded when a lockout is lifted, through
+	// the emailed link or by an admin.
+	AuditActionAccountUnlock = "account_unlock"
+	// AuditActionAPIKeyCreate is recorded when an API key is created.
+	AuditActionAPIKeyCreate = "api_key_create"
+	// AuditActionOAuthLink is recorded when a user links an OAuth account.
+	AuditActionOAuthLink = "oauth_link"
+	// AuditActionInvitationCreate is recorded when a user is invited to sign
+	// up.
+	AuditActionInvitationCreate = "invitation_create"
+	// AuditActionInvitationRevoke is recorded when an invitation is revoked.
+	AuditActionInvitationRevoke = "invitation_revoke"
+	// AuditActionInvitationAccept is recorded when a user signs up with an
+	// invitation.
+	AuditActionInvitationAccept = "invitation_accept"
+
+	// AuditActorUser is the actor type of entries made by a logged in user.
+	AuditActorUser = "user"
+	// AuditActorAPIKey is the actor type of entries made with an API key.
+	AuditActorAPIKey = "api_key"
+	// AuditActorCLI is the actor type of entries made by the command line
+	// tools, which have no actor id.
+	AuditActorCLI = "cli"
+	// AuditActorSystem is the actor type of entries made by the server on
+	// its own, e.g. when syncing roles from an OAuth provider.
+	AuditActorSystem = "system"
+	// AuditActorAnonymous is the actor type of entries made by requests
+	// without a session.
+	AuditActorAnonymous = "anonymous"
+
+	// AuditLogsDefaultPerPage is the number of audit log entries returned per
+	// page when the request does not specify it.
+	AuditLogsDefaultPerPage = 50
+	// AuditLogsMaxPerPage is the maximum number of audit log entries returned
+	// per page.
+	AuditLogsMaxPerPage = 200
+
+	auditActionMaxLength = 32
+)
+
+// AuditLog is an entry of the security audit log. Entries are never updated
+// nor deleted.
+type AuditLog struct {
+	ID       string `json:"id"`
+	CreateAt int64  `json:"create_at" db:"create_at"`
+	Action   string `json:"action"`
+	// ActorID is the id of the user or API key who made the change, empty for
+	// the command line tools, the server and anonymous requests.
+	ActorID   string `json:"actor_id" db:"actor_id"`
+	ActorType string `json:"actor_type" db:"actor_type"`
+	// TargetID is the id of the user affected by the change, if any.
+	TargetID  string `json:"target_id" db:"target_id"`
+	IPAddress string `json:"ip_address" db:"ip_address"`
+	RequestID string `json:"request_id" db:"request_id"`
+	// Details describe the change, e.g. the role granted.
+	Details StringMap `json:"details"`
+}
+
+// PreSave will set the ID and CreateAt for the entry.
+func (l *AuditLog) PreSave() {
+	if l.ID == "" {
+		l.ID = NewID()
+	}
+	if l.CreateAt == 0 {
+		l.CreateAt = GetMillis()
+	}
+	if l.ActorType == "" {
+		l.ActorType = AuditActorAnonymous
+	}
+	if l.Details == nil {
+		l.Details = StringMap{}
+	}
+	l.IPAddress = truncateString(l.IPAddress, sessionIPAddressMaxLength)
+}
+
+// IsValid will determine if the entry is valid.
+func (l *AuditLog) IsValid() error {
+	if len(l.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if l.Action == "" || len(l.Action) > auditActionMaxLength {
+		return errors.New("invalid action")
+	}
+	switch l.ActorType {
+	case AuditActorUser, AuditActorAPIKey, AuditActorCLI, AuditActorSystem, AuditActorAnonymous:
+	default:
+		return errors.Errorf("invalid actor type %s", l.ActorType)
+	}
+
+	return nil
+}
+
+// AuditLogFilter describes the parameters used to search and page through the
+// audit log. Empty fields match every entry.
+type AuditLogFilter struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	// Since and Until bound the creation time of the entries, in
+	// milliseconds.
+	Since   int64
+	Until   int64
+	Page    int
+	PerPage int
+}
+
+// IsValid will determine if the filter is valid.
+func (f *AuditLogFilter) IsValid() error {
+	if len(f.Action) > auditActionMaxLength {
+		return errors.New("invalid action")
+	}
+	if f.Since < 0 || f.Until < 0 || (f.Until > 0 && f.Until < f.Since) {
+		return errors.New("invalid time range")
+	}
+	if f.Page < 0 {
+		return errors.New("invalid page")
+	}
+	if f.PerPage < 1 || f.PerPage > AuditLogsMaxPerPage {
+		return errors.New("invalid per page")
+	}
+
+	return nil
+}
+
+// AuditLogsFromReader decodes a json-encoded list of audit log entries from
+// the given io.Reader.
+func AuditLogsFromReader(reader io.Reader) ([]*AuditLog, error) {
+	logs := []*AuditLog{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&logs)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// StringMap is a map of strings stored as a JSON object.
+type StringMap map[string]string
+
+// Value implements driver.Valuer.
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (m *StringMap) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = StringMap{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("unsupported type %T for a string map", value)
+	}
+
+	return json.Unmarshal(data, m)
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -30,6 +32,19 @@ func NewClient(address string) *Client {
 	}
 }
 
+// NewClientWithAPIKey creates a client authenticating with the given API key.
+func NewClientWithAPIKey(address, apiKey string) *Client {
+	return NewClientWithHeaders(address, map[string]string{HeaderApiKey: apiKey})
+}
+
+// NewClientWithPersonalAccessToken creates a client authenticating with the
+// given personal access token.
+func NewClientWithPersonalAccessToken(address, token string) *Client {
+	return NewClientWithHeaders(address, map[string]string{
+		HeaderAuthorization: fmt.Sprintf("%s %s", AuthorizationBearer, token),
+	})
+}
+
 // NewClientWithHeaders creates a client to the server at the given
 // address and uses the provided headers.
 func NewClientWithHeaders(address string, headers map[string]string) *Client {
@@ -53,10 +68,33 @@ func readAPIError(resp *http.Response) error {
 	if err != nil || apiErr == nil {
 		return errors.Errorf("failed with status code %d for url %s", resp.StatusCode, resp.Request.URL)
 	}
+	if len(apiErr.Reasons) > 0 {
+		return errors.Wrapf(&InvalidPasswordError{Reasons: apiErr.Reasons}, "failed with status code %d", resp.StatusCode)
+	}
 
 	return errors.Wrapf(errors.New(apiErr.Message), "failed with status code %d", resp.StatusCode)
 }
 
+// MFARequiredError is returned when logging in requires a second factor. The
+// login is completed with LoginMFA, or ConfirmLoginMFAEnrollment when the user
+// must enroll an authenticator first.
+type MFARequiredError struct {
+	Challenge *MFAChallenge
+}
+
+func (e *MFARequiredError) Error() string {
+	return "second factor required"
+}
+
+func readMFARequiredError(resp *http.Response) error {
+	challenge, err := MFAChallengeFromReader(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the second factor challenge")
+	}
+
+	return &MFARequiredError{Challenge: challenge}
+}
+
 // Headers will return a copy of the HTTP headers that the client sends with all requests.
 func (c *Client) Headers() map[string]string {
 	returnHeaders := map[string]string{}
@@ -222,6 +260,120 @@ func (c *Client) VerifyEmailComplete(request *VerifyEmailRequest) error {
 	return readAPIError(resp)
 }
 
+// ConfirmEmailChange replaces the email of a user with the pending one the
+// token was emailed to.
+func (c *Client) ConfirmEmailChange(request *ConfirmEmailChangeRequest) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/confirm-email-change"), request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// CancelEmailChange cancels the pending email change of a user with the token
+// emailed to their current email.
+func (c *Client) CancelEmailChange(request *CancelEmailChangeRequest) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/cancel-email-change"), request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// UnlockAccount lifts the lockout of an account with the token emailed to it.
+func (c *Client) UnlockAccount(request *UnlockAccountRequest) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/unlock-account-complete"), request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetLockouts gets the accounts and IP addresses locked out by failed
+// attempts.
+func (c *Client) GetLockouts() ([]*LoginAttempts, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/lockouts"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return LoginAttemptsListFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// ClearLockout forgets the failed attempts against an account or from an IP
+// address.
+func (c *Client) ClearLockout(request *ClearLockoutRequest) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/lockouts/clear"), request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetAuditLogs gets a page of the audit log entries matching the given
+// filter, the most recent first.
+func (c *Client) GetAuditLogs(filter *AuditLogFilter) ([]*AuditLog, error) {
+	query := url.Values{}
+	if filter.ActorID != "" {
+		query.Set("actor_id", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query.Set("target_id", filter.TargetID)
+	}
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+	if filter.Since > 0 {
+		query.Set("since", strconv.FormatInt(filter.Since, 10))
+	}
+	if filter.Until > 0 {
+		query.Set("until", strconv.FormatInt(filter.Until, 10))
+	}
+	query.Set("page", strconv.Itoa(filter.Page))
+	query.Set("per_page", strconv.Itoa(filter.PerPage))
+
+	resp, err := c.doGet(c.BuildURL("/api/v1/audit-logs?%s", query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return AuditLogsFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
 // Login will log a user in.
 func (c *Client) Login(request *LoginRequest) (*User, error) {
 	resp, err := c.doPost(c.BuildURL("/api/v1/users/login"), request)
@@ -234,6 +386,181 @@ func (c *Client) Login(request *LoginRequest) (*User, error) {
 	case http.StatusOK:
 		c.headers[HeaderAuthorization] = fmt.Sprintf("%s %s", AuthorizationBearer, resp.Header.Get(SessionHeader))
 		return UserFromReader(resp.Body)
+	case http.StatusAccepted:
+		return nil, readMFARequiredError(resp)
+	}
+	return nil, readAPIError(resp)
+}
+
+// LoginMFA completes a login with the TOTP or recovery code of the user.
+func (c *Client) LoginMFA(request *LoginMFARequest) (*User, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/login/mfa"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		c.headers[HeaderAuthorization] = fmt.Sprintf("%s %s", AuthorizationBearer, resp.Header.Get(SessionHeader))
+		return UserFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// StartLoginMFAEnrollment starts the enrollment of an authenticator required
+// to complete the login of the given challenge.
+func (c *Client) StartLoginMFAEnrollment(challengeToken string) (*MFAEnrollment, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/login/mfa/enroll"), &LoginMFARequest{Token: challengeToken})
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return MFAEnrollmentFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// ConfirmLoginMFAEnrollment enables the authenticator enrolled during a login
+// and completes the login, returning the recovery codes of the user.
+func (c *Client) ConfirmLoginMFAEnrollment(request *LoginMFARequest) (*MFARecoveryCodes, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/login/mfa/enroll/confirm"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		c.headers[HeaderAuthorization] = fmt.Sprintf("%s %s", AuthorizationBearer, resp.Header.Get(SessionHeader))
+		return MFARecoveryCodesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetMySessions gets the active sessions of the logged in user.
+func (c *Client) GetMySessions() ([]*Session, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/sessions"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return SessionsFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// RevokeMySession logs the logged in user out of one of their sessions.
+func (c *Client) RevokeMySession(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/me/sessions/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// RevokeMyOtherSessions logs the logged in user out of every other device.
+func (c *Client) RevokeMyOtherSessions() error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/sessions/revoke-others"), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetMFAStatus gets the two-factor authentication status of the logged in
+// user.
+func (c *Client) GetMFAStatus() (*MFAStatus, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/mfa"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return MFAStatusFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// StartMFAEnrollment starts the enrollment of an authenticator for the logged
+// in user.
+func (c *Client) StartMFAEnrollment() (*MFAEnrollment, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/mfa"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return MFAEnrollmentFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// ConfirmMFAEnrollment enables the authenticator of the logged in user,
+// returning their recovery codes.
+func (c *Client) ConfirmMFAEnrollment(code string) (*MFARecoveryCodes, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/mfa/confirm"), &MFACodeRequest{Code: code})
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return MFARecoveryCodesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// DisableMFA removes the authenticator of the logged in user.
+func (c *Client) DisableMFA(code string) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/mfa/disable"), &MFACodeRequest{Code: code})
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// RegenerateMFARecoveryCodes replaces the recovery codes of the logged in
+// user.
+func (c *Client) RegenerateMFARecoveryCodes(code string) (*MFARecoveryCodes, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/mfa/recovery-codes"), &MFACodeRequest{Code: code})
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return MFARecoveryCodesFromReader(resp.Body)
 	}
 	return nil, readAPIError(resp)
 }
@@ -283,3 +610,633 @@ func (c *Client) UpdateMe(user *User) (*User, error) {
 	}
 	return nil, readAPIError(resp)
 }
+
+// GetUsers gets a page of users whose email or name contains the given term.
+func (c *Client) GetUsers(term string, page, perPage int) ([]*User, error) {
+	query := url.Values{}
+	query.Set("term", term)
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	resp, err := c.doGet(c.BuildURL("/api/v1/users?%s", query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return UsersFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetUser gets a user along with their roles.
+func (c *Client) GetUser(id string) (*User, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/%s", id))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return UserFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// LockUser prevents a user from logging in and revokes their sessions.
+func (c *Client) LockUser(id string) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/%s/lock", id), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// UnlockUser allows a locked user to log in again.
+func (c *Client) UnlockUser(id string) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/%s/unlock", id), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// ForceVerifyUserEmail marks the email of a user as verified.
+func (c *Client) ForceVerifyUserEmail(id string) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/%s/verify-email", id), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// AddUserRole grants a role to a user.
+func (c *Client) AddUserRole(userID, roleID string) error {
+	resp, err := c.doPut(c.BuildURL("/api/v1/users/%s/roles/%s", userID, roleID), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// RemoveUserRole removes a role from a user.
+func (c *Client) RemoveUserRole(userID, roleID string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/%s/roles/%s", userID, roleID))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// RevokeUserSessions logs a user out of every device.
+func (c *Client) RevokeUserSessions(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/%s/sessions", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// ResetUserMFA removes the authenticator and recovery codes of a user.
+func (c *Client) ResetUserMFA(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/%s/mfa", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// DeleteUser permanently deletes a user.
+func (c *Client) DeleteUser(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetOAuthProviders gets the names of the OAuth providers users can log in
+// with.
+func (c *Client) GetOAuthProviders() ([]string, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/oauth/providers"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var providers []string
+		err = json.NewDecoder(resp.Body).Decode(&providers)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode response")
+		}
+		return providers, nil
+	}
+	return nil, readAPIError(resp)
+}
+
+// CompleteOAuth completes the OAuth flow of the given provider with the code
+// and state the provider redirected to, logging in or linking the account.
+func (c *Client) CompleteOAuth(provider, code, state string) (*User, error) {
+	query := url.Values{}
+	query.Set("code", code)
+	query.Set("state", state)
+
+	resp, err := c.doPost(c.BuildURL("/api/v1/oauth/%s/complete?%s", provider, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// A session is only created when logging in, not when linking.
+		if token := resp.Header.Get(SessionHeader); token != "" {
+			c.headers[HeaderAuthorization] = fmt.Sprintf("%s %s", AuthorizationBearer, token)
+		}
+		return UserFromReader(resp.Body)
+	case http.StatusAccepted:
+		return nil, readMFARequiredError(resp)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetOAuthAccount gets the account of the given provider linked to the logged
+// in user.
+func (c *Client) GetOAuthAccount(provider string) (*UserAuthInfo, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/oauth/%s", provider))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return UserAuthInfoFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// UnlinkOAuthAccount unlinks the account of the given provider from the
+// logged in user.
+func (c *Client) UnlinkOAuthAccount(provider string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/me/oauth/%s", provider))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetGithubMembership gets the GitHub access of the logged in user as of their
+// last login or sync.
+func (c *Client) GetGithubMembership() (*GithubMembership, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/oauth/github/membership"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return GithubMembershipFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetGithubAccessRules gets every GitHub access rule.
+func (c *Client) GetGithubAccessRules() ([]*GithubAccessRule, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/github/access-rules"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return GithubAccessRulesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// CreateGithubAccessRule creates a GitHub access rule.
+func (c *Client) CreateGithubAccessRule(rule *GithubAccessRule) (*GithubAccessRule, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/github/access-rules"), rule)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return GithubAccessRuleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// UpdateGithubAccessRule replaces the organization, team, role and repos of a
+// GitHub access rule.
+func (c *Client) UpdateGithubAccessRule(rule *GithubAccessRule) (*GithubAccessRule, error) {
+	resp, err := c.doPut(c.BuildURL("/api/v1/github/access-rules/%s", rule.ID), rule)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return GithubAccessRuleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// DeleteGithubAccessRule deletes a GitHub access rule.
+func (c *Client) DeleteGithubAccessRule(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/github/access-rules/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetNotificationPreferences gets the notification preferences of the logged
+// in user.
+func (c *Client) GetNotificationPreferences() (*NotificationPreferences, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/notifications"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return NotificationPreferencesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// UpdateNotificationPreferences updates the notification preferences of the
+// logged in user.
+func (c *Client) UpdateNotificationPreferences(preferences *NotificationPreferences) (*NotificationPreferences, error) {
+	resp, err := c.doPut(c.BuildURL("/api/v1/users/me/notifications"), preferences)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return NotificationPreferencesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// Unsubscribe unsubscribes a user from emails using the token of an
+// unsubscribe link.
+func (c *Client) Unsubscribe(request *UnsubscribeRequest) error {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/unsubscribe"), request)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// CreateAPIKey creates an API key. The returned key can't be retrieved again.
+func (c *Client) CreateAPIKey(request *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/apikeys"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return CreateAPIKeyResponseFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetAPIKeys gets the API keys that were not revoked.
+func (c *Client) GetAPIKeys() ([]*APIKey, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/apikeys"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return APIKeysFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// RevokeAPIKey revokes an API key.
+func (c *Client) RevokeAPIKey(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/apikeys/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// CreateInvitation emails an invitation to sign up.
+func (c *Client) CreateInvitation(request *CreateInvitationRequest) (*Invitation, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/invitations"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return InvitationFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetInvitations gets the invitations that can still be accepted.
+func (c *Client) GetInvitations() ([]*Invitation, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/invitations"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return InvitationsFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// RevokeInvitation revokes an invitation.
+func (c *Client) RevokeInvitation(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/invitations/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// CreateRole creates a custom role.
+func (c *Client) CreateRole(request *CreateRoleRequest) (*Role, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/roles"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return RoleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetRoles gets every role along with its permissions.
+func (c *Client) GetRoles() ([]*Role, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/roles"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return RolesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// UpdateRolePermissions replaces the permissions of a role.
+func (c *Client) UpdateRolePermissions(id string, request *UpdateRolePermissionsRequest) (*Role, error) {
+	resp, err := c.doPut(c.BuildURL("/api/v1/roles/%s/permissions", id), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return RoleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// SetRoleRequireMFA sets whether users with a role must log in with
+// two-factor authentication.
+func (c *Client) SetRoleRequireMFA(id string, requireMFA bool) (*Role, error) {
+	resp, err := c.doPut(c.BuildURL("/api/v1/roles/%s/require-mfa", id), &RoleRequireMFARequest{RequireMFA: requireMFA})
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return RoleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// CreatePersonalAccessToken creates a personal access token for the logged in
+// user. The returned token can't be retrieved again.
+func (c *Client) CreatePersonalAccessToken(request *CreatePersonalAccessTokenRequest) (*CreatePersonalAccessTokenResponse, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/users/me/tokens"), request)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return CreatePersonalAccessTokenResponseFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetPersonalAccessTokens gets the personal access tokens of the logged in
+// user that were not revoked.
+func (c *Client) GetPersonalAccessTokens() ([]*PersonalAccessToken, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/users/me/tokens"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return PersonalAccessTokensFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// RevokePersonalAccessToken revokes a personal access token of the logged in
+// user.
+func (c *Client) RevokePersonalAccessToken(id string) error {
+	resp, err := c.doDelete(c.BuildURL("/api/v1/users/me/tokens/%s", id))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	}
+	return readAPIError(resp)
+}
+
+// GetDevEmails gets the latest email captured for every recipient. Only
+// available when the server runs in dev mode.
+func (c *Client) GetDevEmails(to string) ([]*DevEmail, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/dev/emails?to=%s", url.QueryEscape(to)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return DevEmailsFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// CreateCycle reports the results of a test cycle.
+func (c *Client) CreateCycle(cycle *Cycle) (*Cycle, error) {
+	resp, err := c.doPost(c.BuildURL("/api/v1/cycles"), cycle)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return CycleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetCycle gets the cycle of the given id.
+func (c *Client) GetCycle(id string) (*Cycle, error) {
+	resp, err := c.doGet(c.BuildURL("/api/v1/cycles/%s", id))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return CycleFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
+
+// GetCycles gets a page of the cycles matching the given filter, the most
+// recent first.
+func (c *Client) GetCycles(filter *CycleFilter) ([]*Cycle, error) {
+	query := url.Values{}
+	if filter.Repo != "" {
+		query.Set("repo", filter.Repo)
+	}
+	if filter.Branch != "" {
+		query.Set("branch", filter.Branch)
+	}
+	query.Set("page", strconv.Itoa(filter.Page))
+	query.Set("per_page", strconv.Itoa(filter.PerPage))
+
+	resp, err := c.doGet(c.BuildURL("/api/v1/cycles?%s", query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return CyclesFromReader(resp.Body)
+	}
+	return nil, readAPIError(resp)
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CyclesDefaultPerPage is the number of cycles returned per page when the
+	// request does not specify it.
+	CyclesDefaultPerPage = 50
+	// CyclesMaxPerPage is the maximum number of cycles returned per page.
+	CyclesMaxPerPage = 200
+
+	cycleBranchMaxRunes = 255
+	cycleBuildMaxRunes  = 128
+)
+
+// Cycle is a run of the tests of a repo and branch, as reported by a CI
+// runner, along with the count of its results.
+type Cycle struct {
+	ID     string `json:"id"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	// Build identifies the CI build that ran the tests, if any.
+	Build   string `json:"build"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped"`
+	// CreatorID is the id of the user who reported the cycle, or who created
+	// the API key that did.
+	CreatorID string `json:"creator_id" db:"creator_id"`
+	CreateAt  int64  `json:"create_at" db:"create_at"`
+}
+
+// PreSave will set the ID and CreateAt for the cycle.
+func (c *Cycle) PreSave() {
+	if c.ID == "" {
+		c.ID = NewID()
+	}
+	if c.CreateAt == 0 {
+		c.CreateAt = GetMillis()
+	}
+}
+
+// IsValid will determine if the cycle fields are all valid.
+func (c *Cycle) IsValid() error {
+	if len(c.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if !githubRepoRegexp.MatchString(c.Repo) {
+		return errors.New("invalid repo")
+	}
+	if len(c.Branch) == 0 || utf8.RuneCountInString(c.Branch) > cycleBranchMaxRunes {
+		return errors.New("invalid branch")
+	}
+	if utf8.RuneCountInString(c.Build) > cycleBuildMaxRunes {
+		return errors.New("invalid build")
+	}
+	if c.Passed < 0 || c.Failed < 0 || c.Skipped < 0 {
+		return errors.New("invalid result count")
+	}
+	if len(c.CreatorID) != 26 {
+		return errors.New("invalid creator id")
+	}
+
+	return nil
+}
+
+// CycleFilter describes the parameters used to search and page through the
+// cycles. Empty fields match every cycle.
+type CycleFilter struct {
+	Repo   string
+	Branch string
+	// Repos restricts the cycles to the given repos when not nil, e.g. to the
+	// repos a user can see.
+	Repos   []string
+	Page    int
+	PerPage int
+}
+
+// IsValid will determine if the filter is valid.
+func (f *CycleFilter) IsValid() error {
+	if f.Page < 0 {
+		return errors.New("invalid page")
+	}
+	if f.PerPage < 1 || f.PerPage > CyclesMaxPerPage {
+		return errors.New("invalid per page")
+	}
+
+	return nil
+}
+
+// CycleFromReader decodes a json-encoded cycle from the given io.Reader.
+func CycleFromReader(reader io.Reader) (*Cycle, error) {
+	cycle := Cycle{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&cycle)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &cycle, nil
+}
+
+// CyclesFromReader decodes a json-encoded list of cycles from the given
+// io.Reader.
+func CyclesFromReader(reader io.Reader) ([]*Cycle, error) {
+	cycles := []*Cycle{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&cycles)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return cycles, nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DevEmail is an email captured by the server while running in dev mode.
+type DevEmail struct {
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	HTMLBody string `json:"html_body"`
+	SentAt   int64  `json:"sent_at"`
+	// Tokens holds the token values found in the email, keyed by token type.
+	Tokens map[string]string `json:"tokens,omitempty"`
+}
+
+// DevEmailsFromReader decodes a json-encoded list of dev emails from the given io.Reader.
+func DevEmailsFromReader(reader io.Reader) ([]*DevEmail, error) {
+	emails := []*DevEmail{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&emails)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return emails, nil
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const githubAccessRuleMaxRepos = 100
+
+var (
+	githubOrgRegexp  = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9\-]{0,38})$`)
+	githubTeamRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]{0,99}$`)
+	githubRepoRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,64}$`)
+)
+
+// GithubAccessRule grants access to the members of a GitHub organization, or
+// of one of its teams when Team is set.
+//
+// Once a rule exists, only the users matching at least one rule can sign in
+// with GitHub. The roles of the rules are managed by them: a role is granted
+// to the users matching a rule with the role and removed from the others. When
+// one of the rules matching a user lists repos, the user can only see the
+// repos listed by their matching rules.
+type GithubAccessRule struct {
+	ID       string     `json:"id"`
+	Org      string     `json:"org"`
+	Team     string     `json:"team"`
+	RoleID   string     `json:"role_id" db:"role_id"`
+	Repos    StringList `json:"repos"`
+	CreateAt int64      `json:"create_at" db:"create_at"`
+	UpdateAt int64      `json:"update_at" db:"update_at"`
+}
+
+// GithubMembership is the GitHub access of a user as of their last login or
+// sync.
+type GithubMembership struct {
+	UserID string `json:"user_id" db:"user_id"`
+	// Groups are the organizations of the user and their teams as org/team.
+	Groups StringList `json:"groups" db:"member_of"`
+	// Allowed is false if the user doesn't match any access rule.
+	Allowed bool `json:"allowed"`
+	// Repos are the only repos the user can see. Empty means all of them.
+	Repos  StringList `json:"repos"`
+	SyncAt int64      `json:"sync_at" db:"sync_at"`
+}
+
+// PreSave will set the ID, CreateAt and UpdateAt for the rule.
+func (r *GithubAccessRule) PreSave() {
+	if r.ID == "" {
+		r.ID = NewID()
+	}
+	r.Org = strings.ToLower(r.Org)
+	r.Team = strings.ToLower(r.Team)
+	if r.Repos == nil {
+		r.Repos = StringList{}
+	}
+
+	now := GetMillis()
+	if r.CreateAt == 0 {
+		r.CreateAt = now
+	}
+	r.UpdateAt = now
+}
+
+// IsValid will determine if the rule fields are all valid.
+func (r *GithubAccessRule) IsValid() error {
+	if !githubOrgRegexp.MatchString(r.Org) {
+		return errors.New("invalid organization")
+	}
+	if r.Team != "" && !githubTeamRegexp.MatchString(r.Team) {
+		return errors.New("invalid team")
+	}
+	if r.RoleID != "" && len(r.RoleID) != 26 {
+		return errors.New("invalid role id")
+	}
+	if len(r.Repos) > githubAccessRuleMaxRepos {
+		return errors.Errorf("more than %d repos", githubAccessRuleMaxRepos)
+	}
+	for _, repo := range r.Repos {
+		if !githubRepoRegexp.MatchString(repo) {
+			return errors.Errorf("invalid repo %q", repo)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the given groups, as in GithubMembership, include
+// the organization or the team of the rule.
+func (r *GithubAccessRule) Matches(groups []string) bool {
+	group := strings.ToLower(r.Org)
+	if r.Team != "" {
+		group += "/" + strings.ToLower(r.Team)
+	}
+
+	for _, g := range groups {
+		if strings.ToLower(g) == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EvaluateGithubAccessRules returns the GitHub access of a user belonging to
+// the given groups, along with the roles to grant them and the roles managed
+// by the rules which they must not have.
+func EvaluateGithubAccessRules(rules []*GithubAccessRule, groups []string) (*GithubMembership, []string, []string) {
+	membership := &GithubMembership{
+		Groups:  StringList(groups),
+		Allowed: len(rules) == 0,
+		Repos:   StringList{},
+	}
+	if membership.Groups == nil {
+		membership.Groups = StringList{}
+	}
+
+	granted := make(map[string]bool)
+	for _, rule := range rules {
+		if _, ok := granted[rule.RoleID]; rule.RoleID != "" && !ok {
+			granted[rule.RoleID] = false
+		}
+		if !rule.Matches(groups) {
+			continue
+		}
+
+		membership.Allowed = true
+		if rule.RoleID != "" {
+			granted[rule.RoleID] = true
+		}
+		for _, repo := range rule.Repos {
+			if !containsString(membership.Repos, repo) {
+				membership.Repos = append(membership.Repos, repo)
+			}
+		}
+	}
+
+	var grantedRoleIDs, revokedRoleIDs []string
+	for roleID, grant := range granted {
+		if grant {
+			grantedRoleIDs = append(grantedRoleIDs, roleID)
+		} else {
+			revokedRoleIDs = append(revokedRoleIDs, roleID)
+		}
+	}
+
+	return membership, grantedRoleIDs, revokedRoleIDs
+}
+
+// CanAccessRepo returns true if the membership doesn't restrict the user from
+// seeing the given repo.
+func (m *GithubMembership) CanAccessRepo(repo string) bool {
+	if m == nil || len(m.Repos) == 0 {
+		return true
+	}
+
+	return containsString(m.Repos, repo)
+}
+
+// GithubAccessRuleFromReader decodes a json-encoded GitHub access rule from
+// the given io.Reader.
+func GithubAccessRuleFromReader(reader io.Reader) (*GithubAccessRule, error) {
+	rule := GithubAccessRule{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&rule)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// GithubAccessRulesFromReader decodes a json-encoded list of GitHub access
+// rules from the given io.Reader.
+func GithubAccessRulesFromReader(reader io.Reader) ([]*GithubAccessRule, error) {
+	rules := []*GithubAccessRule{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&rules)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GithubMembershipFromReader decodes a json-encoded GitHub membership from the
+// given io.Reader.
+func GithubMembershipFromReader(reader io.Reader) (*GithubMembership, error) {
+	membership := GithubMembership{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&membership)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &membership, nil
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignupModeOpen lets anyone sign up, within the allowed email domains
+	// if any.
+	SignupModeOpen = "open"
+	// SignupModeInvite only lets invited users sign up.
+	SignupModeInvite = "invite"
+	// SignupModeClosed prevents anyone from signing up, only the command line
+	// tools create users.
+	SignupModeClosed = "closed"
+
+	// InvitationExpiryTime is the time invitations can be accepted for.
+	InvitationExpiryTime = 1000 * 60 * 60 * 24 * 7 // 7 days
+)
+
+// IsValidSignupMode returns true if the given signup mode exists.
+func IsValidSignupMode(mode string) bool {
+	return mode == SignupModeOpen || mode == SignupModeInvite || mode == SignupModeClosed
+}
+
+// Invitation lets the owner of an email sign up, granting them roles on top
+// of the default one. It is sent as a token of type TokenTypeInvitation.
+type Invitation struct {
+	ID        string     `json:"id"`
+	Email     string     `json:"email"`
+	RoleIDs   StringList `json:"role_ids" db:"role_ids"`
+	InviterID string     `json:"inviter_id" db:"inviter_id"`
+	CreateAt  int64      `json:"create_at" db:"create_at"`
+	ExpiresAt int64      `json:"expires_at" db:"expires_at"`
+}
+
+// PreSave will set the ID, CreateAt and ExpiresAt for the invitation.
+func (i *Invitation) PreSave() {
+	if i.ID == "" {
+		i.ID = NewID()
+	}
+	i.Email = strings.ToLower(i.Email)
+	if i.RoleIDs == nil {
+		i.RoleIDs = StringList{}
+	}
+
+	i.CreateAt = GetMillis()
+	i.ExpiresAt = i.CreateAt + InvitationExpiryTime
+}
+
+// IsValid will determine if the invitation fields are all valid.
+func (i *Invitation) IsValid() error {
+	if len(i.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if !IsValidEmail(i.Email) {
+		return errors.New("invalid email")
+	}
+	for _, roleID := range i.RoleIDs {
+		if len(roleID) != 26 {
+			return errors.Errorf("invalid role id %s", roleID)
+		}
+	}
+	if i.ExpiresAt <= i.CreateAt {
+		return errors.New("invalid expiry")
+	}
+
+	return nil
+}
+
+// IsExpired returns true if the invitation can no longer be accepted.
+func (i *Invitation) IsExpired() bool {
+	return GetMillis() > i.ExpiresAt
+}
+
+// AuditDetails returns the details of the audit log entries of the
+// invitation.
+func (i *Invitation) AuditDetails() StringMap {
+	return StringMap{
+		"invitation_id": i.ID,
+		"email":         i.Email,
+		"inviter_id":    i.InviterID,
+		"role_ids":      strings.Join(i.RoleIDs, ","),
+	}
+}
+
+// TokenExtraInvitation is a token extra field identifying an invitation.
+type TokenExtraInvitation struct {
+	InvitationID string `json:"invitation_id"`
+}
+
+// CreateTokenTypeInvitationExtra returns the correct extra values for a token
+// of type TokenTypeInvitation.
+func CreateTokenTypeInvitationExtra(invitationID string) (string, error) {
+	b, err := json.Marshal(TokenExtraInvitation{InvitationID: invitationID})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal extra field")
+	}
+
+	return string(b), nil
+}
+
+// GetExtraInvitation returns the id of the invitation of a token of type
+// TokenTypeInvitation.
+func (t *Token) GetExtraInvitation() (string, error) {
+	var extra TokenExtraInvitation
+	err := json.Unmarshal([]byte(t.Extra), &extra)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to ummarshal extra field")
+	}
+	if len(extra.InvitationID) == 0 {
+		return "", errors.New("invitation id value is empty")
+	}
+
+	return extra.InvitationID, nil
+}
+
+// CreateInvitationRequest specifies the parameters of a new invitation.
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	// RoleIDs are the roles granted when the invitation is accepted, on top
+	// of the default one.
+	RoleIDs []string `json:"role_ids"`
+}
+
+// InvitationFromReader decodes a json-encoded invitation from the given
+// io.Reader.
+func InvitationFromReader(reader io.Reader) (*Invitation, error) {
+	invitation := Invitation{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&invitation)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// InvitationsFromReader decodes a json-encoded list of invitations from the
+// given io.Reader.
+func InvitationsFromReader(reader io.Reader) ([]*Invitation, error) {
+	invitations := []*Invitation{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&invitations)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	// LoginAttemptActionLogin is the action of logging in with a password.
+	LoginAttemptActionLogin = "login"
+	// LoginAttemptActionResetPassword is the action of completing a password
+	// reset with a token.
+	LoginAttemptActionResetPassword = "reset_password"
+	// LoginAttemptActionVerifyEmail is the action of completing an email
+	// verification with a code.
+	LoginAttemptActionVerifyEmail = "verify_email"
+	// LoginAttemptActionMFA is the action of checking a two-factor code of the
+	// logged in user before changing their two-factor settings.
+	LoginAttemptActionMFA = "mfa"
+
+	// LoginAttemptScopeAccount tracks the attempts made against an account,
+	// identified by its email.
+	LoginAttemptScopeAccount = "account"
+	// LoginAttemptScopeIP tracks the attempts made from an IP address.
+	LoginAttemptScopeIP = "ip"
+
+	// maxLockout caps how long attempts are locked out.
+	maxLockout = 24 * time.Hour
+)
+
+// DefaultAccountLockoutPolicy is the lockout policy of accounts when none is
+// configured.
+var DefaultAccountLockoutPolicy = LockoutPolicy{
+	FreeAttempts: 3,
+	Delay:        time.Second,
+	MaxFailures:  10,
+	Lockout:      15 * time.Minute,
+	Window:       24 * time.Hour,
+}
+
+// DefaultIPLockoutPolicy is the lockout policy of IP addresses when none is
+// configured. It is looser than the one of accounts since users can share
+// an address.
+var DefaultIPLockoutPolicy = LockoutPolicy{
+	FreeAttempts: 20,
+	Delay:        time.Second,
+	MaxFailures:  100,
+	Lockout:      15 * time.Minute,
+	Window:       24 * time.Hour,
+}
+
+// LoginAttempts are the failed attempts of an action made against an account
+// or from an IP address.
+type LoginAttempts struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	// Subject is the email of the account or the IP address.
+	Subject       string `json:"subject"`
+	Failures      int    `json:"failures"`
+	LastFailureAt int64  `json:"last_failure_at" db:"last_failure_at"`
+	// LockedUntil is when the action can be attempted again.
+	LockedUntil int64 `json:"locked_until" db:"locked_until"`
+}
+
+// RetryAfter returns how long to wait at the given time before attempting the
+// action again, zero if it is not locked.
+func (a *LoginAttempts) RetryAfter(now int64) time.Duration {
+	if a == nil || a.LockedUntil <= now {
+		return 0
+	}
+
+	return time.Duration(a.LockedUntil-now) * time.Millisecond
+}
+
+// LockoutPolicy describes how failed attempts delay and lock out further
+// attempts.
+type LockoutPolicy struct {
+	// FreeAttempts is the number of failures allowed without delay.
+	FreeAttempts int
+	// Delay is how long to wait after the first failure past FreeAttempts,
+	// doubling with each further failure.
+	Delay time.Duration
+	// MaxFailures is the number of failures after which attempts are locked
+	// out.
+	MaxFailures int
+	// Lockout is how long attempts are locked out, doubling with each further
+	// failure.
+	Lockout time.Duration
+	// Window is how long failures are remembered after the last one.
+	Window time.Duration
+}
+
+// IsZero returns true if the policy is not configured.
+func (p LockoutPolicy) IsZero() bool {
+	return p == LockoutPolicy{}
+}
+
+// LockedUntil returns until when the given number of failures, the last one
+// at the given time, delays or locks out further attempts. It returns zero if
+// attempts are not delayed.
+func (p LockoutPolicy) LockedUntil(failures int, lastFailureAt int64) int64 {
+	switch {
+	case p.MaxFailures > 0 && failures >= p.MaxFailures:
+		return lastFailureAt + backoff(p.Lockout, failures-p.MaxFailures, maxLockout).Milliseconds()
+	case failures > p.FreeAttempts && p.Delay > 0:
+		return lastFailureAt + backoff(p.Delay, failures-p.FreeAttempts-1, p.Lockout).Milliseconds()
+	}
+
+	return 0
+}
+
+// IsLockout returns true if the given number of failures is the one that
+// starts locking out attempts.
+func (p LockoutPolicy) IsLockout(failures int) bool {
+	return p.MaxFailures > 0 && failures == p.MaxFailures
+}
+
+// backoff doubles the given duration n times, up to the given limit.
+func backoff(d time.Duration, n int, limit time.Duration) time.Duration {
+	for i := 0; i < n && d < limit; i++ {
+		d *= 2
+	}
+	if limit > 0 && d > limit {
+		return limit
+	}
+
+	return d
+}
+
+// LoginAttemptsListFromReader decodes a json-encoded list of login attempts
+// from the given io.Reader.
+func LoginAttemptsListFromReader(reader io.Reader) ([]*LoginAttempts, error) {
+	attempts := []*LoginAttempts{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&attempts)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	// MFARecoveryCodeCount is the number of recovery codes generated on
+	// enrollment.
+	MFARecoveryCodeCount = 10
+	// MFAChallengeExpiryTime is the time a user has to provide their second
+	// factor after their password.
+	MFAChallengeExpiryTime = 1000 * 60 * 5 // 5 minutes
+
+	mfaRecoveryCodeLength = 10
+)
+
+// UserMFA is the TOTP authenticator enrolled by a user.
+type UserMFA struct {
+	UserID string `json:"user_id" db:"user_id"`
+	// Secret is the base32 TOTP secret shared with the authenticator.
+	Secret string `json:"-"`
+	// Enabled is false until the user confirms the enrollment with a code.
+	Enabled bool `json:"enabled"`
+	// LastStep is the time step of the last code used, codes of earlier
+	// steps are rejected.
+	LastStep int64 `json:"-" db:"last_step"`
+	CreateAt int64 `json:"create_at" db:"create_at"`
+	UpdateAt int64 `json:"update_at" db:"update_at"`
+}
+
+// PreSave will set the CreateAt and UpdateAt for the enrollment.
+func (m *UserMFA) PreSave() {
+	now := GetMillis()
+	if m.CreateAt == 0 {
+		m.CreateAt = now
+	}
+	m.UpdateAt = now
+}
+
+// MFAStatus describes the two-factor authentication of a user.
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is true if one of the roles of the user requires two-factor
+	// authentication.
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// MFAEnrollment is the secret an authenticator is enrolled with.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth URI of the secret, usually shown as a QR code.
+	URI string `json:"uri"`
+}
+
+// MFARecoveryCodes are the one-time codes a user can log in with when they
+// lose their authenticator. They are only returned once.
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFACodeRequest is the payload carrying a TOTP or recovery code.
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// MFAChallenge is returned instead of a session when logging in requires a
+// second factor.
+type MFAChallenge struct {
+	// Token identifies the login attempt when providing the second factor.
+	Token string `json:"token"`
+	// EnrollmentRequired is true if the user must enroll an authenticator
+	// before logging in, as one of their roles requires it.
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
+
+// LoginMFARequest is the payload completing a login with a second factor.
+type LoginMFARequest struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+// RoleRequireMFARequest is the payload setting whether a role requires
+// two-factor authentication.
+type RoleRequireMFARequest struct {
+	RequireMFA bool `json:"require_mfa"`
+}
+
+// NewMFARecoveryCodes returns a new set of recovery codes.
+func NewMFARecoveryCodes() []string {
+	codes := make([]string, 0, MFARecoveryCodeCount)
+	for i := 0; i < MFARecoveryCodeCount; i++ {
+		code := NewRandomString(mfaRecoveryCodeLength)
+		codes = append(codes, code[:mfaRecoveryCodeLength/2]+"-"+code[mfaRecoveryCodeLength/2:])
+	}
+
+	return codes
+}
+
+// HashMFARecoveryCode returns the hash a recovery code is stored with,
+// ignoring the case and separators of the given code.
+func HashMFARecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return HashToken(code)
+}
+
+// IsMFARecoveryCode returns true if the given code looks like a recovery code
+// rather than a TOTP code.
+func IsMFARecoveryCode(code string) bool {
+	return len(strings.NewReplacer("-", "", " ", "").Replace(code)) == mfaRecoveryCodeLength
+}
+
+// MFAStatusFromReader decodes a json-encoded MFA status from the given
+// io.Reader.
+func MFAStatusFromReader(reader io.Reader) (*MFAStatus, error) {
+	status := MFAStatus{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&status)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// MFAEnrollmentFromReader decodes a json-encoded MFA enrollment from the
+// given io.Reader.
+func MFAEnrollmentFromReader(reader io.Reader) (*MFAEnrollment, error) {
+	enrollment := MFAEnrollment{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&enrollment)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// MFARecoveryCodesFromReader decodes json-encoded MFA recovery codes from the
+// given io.Reader.
+func MFARecoveryCodesFromReader(reader io.Reader) (*MFARecoveryCodes, error) {
+	codes := MFARecoveryCodes{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&codes)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &codes, nil
+}
+
+// MFAChallengeFromReader decodes a json-encoded MFA challenge from the given
+// io.Reader.
+func MFAChallengeFromReader(reader io.Reader) (*MFAChallenge, error) {
+	challenge := MFAChallenge{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&challenge)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
@@ -0,0 +1,244 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// NotificationChannelEmail delivers notifications by email.
+	NotificationChannelEmail = "email"
+	// NotificationChannelInApp delivers notifications inside the dashboard.
+	NotificationChannelInApp = "in_app"
+)
+
+const (
+	// NotificationEventAccount covers the account emails, such as email
+	// verification and password reset. They are always sent and can't be
+	// unsubscribed from.
+	NotificationEventAccount = "account"
+	// NotificationEventSecurity covers the emails about the security of an
+	// account, such as lockouts and email changes. Like account emails, they
+	// are always sent and can't be unsubscribed from.
+	NotificationEventSecurity = "security"
+	// NotificationEventCycleCompleted is sent when a test cycle completes.
+	NotificationEventCycleCompleted = "cycle_completed"
+	// NotificationEventCycleFailed is sent when a test cycle completes with
+	// failures.
+	NotificationEventCycleFailed = "cycle_failed"
+)
+
+const (
+	notificationFilterMaxLength = 100
+	notificationFilterMaxRunes  = 64
+)
+
+// NotificationEvents are the events users can subscribe to.
+var NotificationEvents = []string{
+	NotificationEventCycleCompleted,
+	NotificationEventCycleFailed,
+}
+
+// IsMandatoryNotificationEvent returns true if the emails of the given event
+// are sent whatever the notification preferences of the user.
+func IsMandatoryNotificationEvent(event string) bool {
+	return event == NotificationEventAccount || event == NotificationEventSecurity
+}
+
+// IsValidNotificationEvent returns true if users can subscribe to the given
+// event.
+func IsValidNotificationEvent(event string) bool {
+	return containsString(NotificationEvents, event)
+}
+
+// NotificationPreferences are the notification settings of a user.
+type NotificationPreferences struct {
+	UserID string `json:"user_id" db:"user_id"`
+	// Email and InApp enable the respective channels.
+	Email bool `json:"email"`
+	InApp bool `json:"in_app" db:"in_app"`
+	// Events are the events the user is subscribed to.
+	Events StringList `json:"events"`
+	// Repos and Branches limit the notifications to the given repos and
+	// branches. Empty means all of them.
+	Repos    StringList `json:"repos"`
+	Branches StringList `json:"branches"`
+	UpdateAt int64      `json:"update_at" db:"update_at"`
+}
+
+// NewNotificationPreferences returns the preferences of a user who never
+// changed them: every channel and event is enabled, for every repo and branch.
+func NewNotificationPreferences(userID string) *NotificationPreferences {
+	events := make(StringList, len(NotificationEvents))
+	copy(events, NotificationEvents)
+
+	return &NotificationPreferences{
+		UserID:   userID,
+		Email:    true,
+		InApp:    true,
+		Events:   events,
+		Repos:    StringList{},
+		Branches: StringList{},
+	}
+}
+
+// PreSave will set the correct values for preferences that are about to be
+// saved.
+func (p *NotificationPreferences) PreSave() {
+	if p.Events == nil {
+		p.Events = StringList{}
+	}
+	if p.Repos == nil {
+		p.Repos = StringList{}
+	}
+	if p.Branches == nil {
+		p.Branches = StringList{}
+	}
+
+	p.UpdateAt = GetMillis()
+}
+
+// IsValid will determine if the preferences fields are all valid.
+func (p *NotificationPreferences) IsValid() error {
+	if len(p.UserID) != 26 {
+		return errors.New("invalid user id")
+	}
+	for _, event := range p.Events {
+		if !IsValidNotificationEvent(event) {
+			return errors.Errorf("invalid event %s", event)
+		}
+	}
+	if err := isValidNotificationFilter(p.Repos); err != nil {
+		return errors.Wrap(err, "invalid repos")
+	}
+	if err := isValidNotificationFilter(p.Branches); err != nil {
+		return errors.Wrap(err, "invalid branches")
+	}
+
+	return nil
+}
+
+func isValidNotificationFilter(filter []string) error {
+	if len(filter) > notificationFilterMaxLength {
+		return errors.Errorf("more than %d values", notificationFilterMaxLength)
+	}
+	for _, value := range filter {
+		if len(value) == 0 || utf8.RuneCountInString(value) > notificationFilterMaxRunes {
+			return errors.Errorf("invalid value %q", value)
+		}
+	}
+
+	return nil
+}
+
+// Allows returns true if the user wants to be notified of the given event on
+// the given channel. Empty repo or branch values are not filtered.
+func (p *NotificationPreferences) Allows(channel, event, repo, branch string) bool {
+	if event == NotificationEventAccount {
+		return true
+	}
+
+	switch channel {
+	case NotificationChannelEmail:
+		if !p.Email {
+			return false
+		}
+	case NotificationChannelInApp:
+		if !p.InApp {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if !containsString(p.Events, event) {
+		return false
+	}
+	if repo != "" && len(p.Repos) > 0 && !containsString(p.Repos, repo) {
+		return false
+	}
+	if branch != "" && len(p.Branches) > 0 && !containsString(p.Branches, branch) {
+		return false
+	}
+
+	return true
+}
+
+// Unsubscribe disables the given event by email, or every email if the event
+// is empty.
+func (p *NotificationPreferences) Unsubscribe(event string) {
+	if event == "" {
+		p.Email = false
+		return
+	}
+
+	events := StringList{}
+	for _, subscribed := range p.Events {
+		if subscribed != event {
+			events = append(events, subscribed)
+		}
+	}
+	p.Events = events
+}
+
+// NotificationPreferencesFromReader decodes json-encoded notification
+// preferences from the given io.Reader.
+func NotificationPreferencesFromReader(reader io.Reader) (*NotificationPreferences, error) {
+	preferences := NotificationPreferences{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&preferences)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &preferences, nil
+}
+
+// StringList is a list of strings stored as a json array.
+type StringList []string
+
+// Value implements driver.Valuer.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (l *StringList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("unsupported type %T for a string list", value)
+	}
+
+	return json.Unmarshal(data, l)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
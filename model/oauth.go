@@ -2,17 +2,26 @@ package model
 
 const (
 	// DefaultTokenTTL is the OAuth state expiry duration in milliseconds
-	DefaultTokenTTL = 10 * 60
+	DefaultTokenTTL = 10 * 60 * 1000
 )
 
 type OAuthState struct {
-	ID        string `json:"id"`
-	Token     string `json:"token"`
-	CreateAt  int64  `json:"create_at" db:"create_at"`
-	ExpiresAt int64  `json:"expires_at" db:"expires_at"`
+	ID    string `json:"id"`
+	Token string `json:"token"`
+	// Provider is the OAuth provider the flow was started with.
+	Provider string `json:"provider"`
+	// UserID is set when a logged in user is linking their account.
+	UserID string `json:"user_id" db:"user_id"`
+	// Nonce and CodeVerifier bind the ID token and the authorization code of
+	// an OpenID Connect flow to the state.
+	Nonce        string `json:"-"`
+	CodeVerifier string `json:"-" db:"code_verifier"`
+	CreateAt     int64  `json:"create_at" db:"create_at"`
+	ExpiresAt    int64  `json:"expires_at" db:"expires_at"`
 }
 
-// PreSave will set the ID, Token, ExpiresAt and CreateAt for the OAuth state.
+// PreSave will set the ID, Token, Nonce, CodeVerifier, ExpiresAt and CreateAt
+// for the OAuth state.
 func (o *OAuthState) PreSave() {
 	if o.ID == "" {
 		o.ID = NewID()
@@ -20,6 +29,12 @@ func (o *OAuthState) PreSave() {
 	if o.Token == "" {
 		o.Token = NewID()
 	}
+	if o.Nonce == "" {
+		o.Nonce = NewRandomString(32)
+	}
+	if o.CodeVerifier == "" {
+		o.CodeVerifier = NewRandomString(64)
+	}
 	o.CreateAt = GetMillis()
 	o.ExpiresAt = o.CreateAt + DefaultTokenTTL
 }
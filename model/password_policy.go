@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// The reasons a password is rejected.
+const (
+	PasswordReasonTooShort         = "too_short"
+	PasswordReasonTooLong          = "too_long"
+	PasswordReasonMissingLowercase = "missing_lowercase"
+	PasswordReasonMissingUppercase = "missing_uppercase"
+	PasswordReasonMissingDigit     = "missing_digit"
+	PasswordReasonMissingSymbol    = "missing_symbol"
+	PasswordReasonContainsEmail    = "contains_email"
+	PasswordReasonContainsName     = "contains_name"
+	PasswordReasonReused           = "reused"
+	PasswordReasonBreached         = "breached"
+
+	// passwordUserInfoMinLength is the length from which parts of the email
+	// or name of a user are not allowed in their password, so that short
+	// names don't reject common words.
+	passwordUserInfoMinLength = 3
+)
+
+// PasswordPolicy is the set of rules the passwords chosen by users must
+// follow.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// DisallowUserInfo rejects passwords containing the email or the name of
+	// their user.
+	DisallowUserInfo bool
+	// HistorySize is the number of the most recent passwords of a user,
+	// the current one included, that can't be chosen again.
+	HistorySize int
+}
+
+// DefaultPasswordPolicy is the policy used when none is configured.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:        userPasswordMinLength,
+	RequireLowercase: true,
+	RequireUppercase: true,
+	RequireDigit:     true,
+	DisallowUserInfo: true,
+	HistorySize:      3,
+}
+
+// IsZero returns true if no rule of the policy is set.
+func (p PasswordPolicy) IsZero() bool {
+	return p == PasswordPolicy{}
+}
+
+// IsValid checks the rules of the policy can be followed.
+func (p PasswordPolicy) IsValid() error {
+	if p.MinLength < 1 || p.MinLength > userPasswordMaxLength {
+		return errors.Errorf("min length must be between 1 and %d", userPasswordMaxLength)
+	}
+	if p.HistorySize < 0 {
+		return errors.New("history size must not be negative")
+	}
+
+	return nil
+}
+
+// Check returns the reasons the password breaks the rules of the policy, or
+// nil if it doesn't. The user choosing the password is used to reject
+// passwords containing their email or name. Passwords reuse is checked
+// separately since it needs the password history.
+func (p PasswordPolicy) Check(password string, user *User) []string {
+	var reasons []string
+
+	if len(password) < p.MinLength {
+		reasons = append(reasons, PasswordReasonTooShort)
+	}
+	if len(password) > userPasswordMaxLength {
+		reasons = append(reasons, PasswordReasonTooLong)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireLowercase && !lower {
+		reasons = append(reasons, PasswordReasonMissingLowercase)
+	}
+	if p.RequireUppercase && !upper {
+		reasons = append(reasons, PasswordReasonMissingUppercase)
+	}
+	if p.RequireDigit && !digit {
+		reasons = append(reasons, PasswordReasonMissingDigit)
+	}
+	if p.RequireSymbol && !symbol {
+		reasons = append(reasons, PasswordReasonMissingSymbol)
+	}
+
+	if p.DisallowUserInfo && user != nil {
+		lowerPassword := strings.ToLower(password)
+		contains := func(value string) bool {
+			value = strings.ToLower(strings.TrimSpace(value))
+			return len(value) >= passwordUserInfoMinLength && strings.Contains(lowerPassword, value)
+		}
+
+		localPart := user.Email
+		if at := strings.LastIndex(localPart, "@"); at >= 0 {
+			localPart = localPart[:at]
+		}
+		if contains(localPart) {
+			reasons = append(reasons, PasswordReasonContainsEmail)
+		}
+		if contains(user.FirstName) || contains(user.LastName) {
+			reasons = append(reasons, PasswordReasonContainsName)
+		}
+	}
+
+	return reasons
+}
+
+// InvalidPasswordError is returned when a password is rejected by the
+// password policy.
+type InvalidPasswordError struct {
+	// Reasons are the PasswordReason values of the rules the password
+	// breaks.
+	Reasons []string
+}
+
+func (e *InvalidPasswordError) Error() string {
+	return fmt.Sprintf("invalid password: %s", strings.Join(e.Reasons, ", "))
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PersonalAccessTokenScopeRead allows a personal access token to only read
+	// data.
+	PersonalAccessTokenScopeRead = "read"
+	// PersonalAccessTokenScopeWrite allows a personal access token to do
+	// anything its user can do.
+	PersonalAccessTokenScopeWrite = "write"
+	// PersonalAccessTokenPrefix starts every personal access token, telling
+	// them apart from session tokens.
+	PersonalAccessTokenPrefix = "dpat_"
+	// PersonalAccessTokenSize is the size of the random value of a personal
+	// access token.
+	PersonalAccessTokenSize = 40
+
+	personalAccessTokenNameMaxRuneLength = 64
+)
+
+// PersonalAccessToken lets a user script against the API with their own
+// identity, through the Authorization header. Only the hash of the token is
+// stored.
+type PersonalAccessToken struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id" db:"user_id"`
+	Name       string `json:"name"`
+	Scope      string `json:"scope"`
+	TokenHash  string `json:"-" db:"token_hash"`
+	CreateAt   int64  `json:"create_at" db:"create_at"`
+	ExpiresAt  int64  `json:"expires_at" db:"expires_at"`
+	LastUsedAt int64  `json:"last_used_at" db:"last_used_at"`
+	DeleteAt   int64  `json:"delete_at" db:"delete_at"`
+}
+
+// IsPersonalAccessToken returns true if the given bearer token is a personal
+// access token rather than a session token.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// PreSave generates the token of a new personal access token, sets its hash
+// and returns the token. The token is never stored and can't be retrieved
+// later.
+func (t *PersonalAccessToken) PreSave() string {
+	if t.ID == "" {
+		t.ID = NewID()
+	}
+
+	token := PersonalAccessTokenPrefix + NewRandomString(PersonalAccessTokenSize)
+	t.TokenHash = HashToken(token)
+	t.CreateAt = GetMillis()
+	t.LastUsedAt = 0
+	t.DeleteAt = 0
+
+	return token
+}
+
+// IsValid will determine if the personal access token fields are all valid.
+func (t *PersonalAccessToken) IsValid() error {
+	if len(t.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if len(t.UserID) != 26 {
+		return errors.New("invalid user id")
+	}
+	if len(t.Name) == 0 || utf8.RuneCountInString(t.Name) > personalAccessTokenNameMaxRuneLength {
+		return errors.New("invalid name")
+	}
+	if t.Scope != PersonalAccessTokenScopeRead && t.Scope != PersonalAccessTokenScopeWrite {
+		return errors.Errorf("invalid scope %s", t.Scope)
+	}
+	if t.ExpiresAt < 0 || (t.ExpiresAt > 0 && t.ExpiresAt <= t.CreateAt) {
+		return errors.New("invalid expiry")
+	}
+
+	return nil
+}
+
+// IsExpired returns true if the token has an expiry in the past.
+func (t *PersonalAccessToken) IsExpired() bool {
+	return t.ExpiresAt > 0 && GetMillis() > t.ExpiresAt
+}
+
+// IsRevoked returns true if the token was revoked.
+func (t *PersonalAccessToken) IsRevoked() bool {
+	return t.DeleteAt > 0
+}
+
+// AllowsMethod returns true if the scope of the token permits requests of the
+// given HTTP method.
+func (t *PersonalAccessToken) AllowsMethod(method string) bool {
+	switch t.Scope {
+	case PersonalAccessTokenScopeWrite:
+		return true
+	case PersonalAccessTokenScopeRead:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+
+	return false
+}
+
+// CreatePersonalAccessTokenRequest specifies the parameters of a new personal
+// access token.
+type CreatePersonalAccessTokenRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// ExpiresAt is the optional expiry of the token in milliseconds.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// CreatePersonalAccessTokenResponse contains a new personal access token along
+// with its value, which is only ever returned once.
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessToken *PersonalAccessToken `json:"personal_access_token"`
+	Token               string               `json:"token"`
+}
+
+// CreatePersonalAccessTokenResponseFromReader decodes a json-encoded create
+// personal access token response from the given io.Reader.
+func CreatePersonalAccessTokenResponseFromReader(reader io.Reader) (*CreatePersonalAccessTokenResponse, error) {
+	response := CreatePersonalAccessTokenResponse{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&response)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// PersonalAccessTokensFromReader decodes a json-encoded list of personal access
+// tokens from the given io.Reader.
+func PersonalAccessTokensFromReader(reader io.Reader) ([]*PersonalAccessToken, error) {
+	tokens := []*PersonalAccessToken{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&tokens)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return tokens, nil
+}
@@ -11,6 +11,10 @@ type SignUpRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Password  string `json:"password"`
+	Locale    string `json:"locale,omitempty"`
+	// InvitationToken is the token of the invitation email, required when
+	// signup is restricted to invited users.
+	InvitationToken string `json:"invitation_token,omitempty"`
 }
 
 // SignUpResponse contains the user.
@@ -57,3 +61,30 @@ type UpdatePasswordRequest struct {
 type VerifyEmailRequest struct {
 	Token string `json:"token"`
 }
+
+// ConfirmEmailChangeRequest specifies the confirm-email-change parameters.
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// CancelEmailChangeRequest specifies the cancel-email-change parameters.
+type CancelEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// UnlockAccountRequest specifies the unlock-account parameters.
+type UnlockAccountRequest struct {
+	Token string `json:"token"`
+}
+
+// ClearLockoutRequest specifies the account or IP address to clear the failed
+// attempts of.
+type ClearLockoutRequest struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+// UnsubscribeRequest specifies the unsubscribe parameters.
+type UnsubscribeRequest struct {
+	Token string `json:"token"`
+}
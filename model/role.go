@@ -1,15 +1,64 @@
 package model
 
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
 const (
 	UserRoleName  = "user"
 	AdminRoleName = "admin"
 )
 
+const (
+	// PermissionCyclesRead allows reading cycles and their results.
+	PermissionCyclesRead = "cycles:read"
+	// PermissionCyclesWrite allows creating and updating cycles.
+	PermissionCyclesWrite = "cycles:write"
+	// PermissionUsersManage allows managing other users.
+	PermissionUsersManage = "users:manage"
+	// PermissionSettingsManage allows changing server settings.
+	PermissionSettingsManage = "settings:manage"
+	// PermissionAPIKeysManage allows creating and revoking API keys.
+	PermissionAPIKeysManage = "apikeys:manage"
+	// PermissionRolesManage allows creating roles and changing their
+	// permissions.
+	PermissionRolesManage = "roles:manage"
+	// PermissionAuditLogRead allows reading the security audit log.
+	PermissionAuditLogRead = "auditlog:read"
+)
+
+// Permissions lists every permission that can be granted to a role.
+var Permissions = []string{
+	PermissionCyclesRead,
+	PermissionCyclesWrite,
+	PermissionUsersManage,
+	PermissionSettingsManage,
+	PermissionAPIKeysManage,
+	PermissionRolesManage,
+	PermissionAuditLogRead,
+}
+
+// DefaultUserRolePermissions are the permissions the user role is seeded
+// with. The admin role is always granted every permission.
+var DefaultUserRolePermissions = []string{
+	PermissionCyclesRead,
+}
+
+var roleNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]{0,63}$`)
+
 type Role struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	CreateAt int64  `json:"create_at" db:"create_at"`
 	UpdateAt int64  `json:"update_at" db:"update_at"`
+	// RequireMFA is true if users with the role must log in with two-factor
+	// authentication.
+	RequireMFA  bool       `json:"require_mfa" db:"require_mfa"`
+	Permissions StringList `json:"permissions" db:"-"`
 }
 
 type UserRole struct {
@@ -19,6 +68,35 @@ type UserRole struct {
 	UpdateAt int64  `json:"update_at" db:"update_at"`
 }
 
+// RolePermission grants a permission to every user having the role.
+type RolePermission struct {
+	RoleID     string `json:"role_id" db:"role_id"`
+	Permission string `json:"permission"`
+	CreateAt   int64  `json:"create_at" db:"create_at"`
+}
+
+// CreateRoleRequest is the request to create a custom role.
+type CreateRoleRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRolePermissionsRequest is the request to replace the permissions of
+// a role.
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// IsValidPermission returns true if the given permission exists.
+func IsValidPermission(permission string) bool {
+	return containsString(Permissions, permission)
+}
+
+// IsBuiltInRole returns true if the role is created by the server itself.
+func IsBuiltInRole(name string) bool {
+	return name == AdminRoleName || name == UserRoleName
+}
+
 // CreatePreSave will set the correct values for a new role that is about to be
 // saved.
 func (r *Role) CreatePreSave() {
@@ -30,3 +108,45 @@ func (r *Role) CreatePreSave() {
 	r.CreateAt = now
 	r.UpdateAt = now
 }
+
+// IsValid will determine if the role fields are all valid.
+func (r *Role) IsValid() error {
+	if len(r.ID) != 26 {
+		return errors.New("invalid id")
+	}
+	if !roleNameRegexp.MatchString(r.Name) {
+		return errors.New("invalid name")
+	}
+	for _, permission := range r.Permissions {
+		if !IsValidPermission(permission) {
+			return errors.Errorf("invalid permission %s", permission)
+		}
+	}
+
+	return nil
+}
+
+// RoleFromReader decodes a json-encoded role from the given io.Reader.
+func RoleFromReader(reader io.Reader) (*Role, error) {
+	role := Role{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&role)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// RolesFromReader decodes a json-encoded list of roles from the given
+// io.Reader.
+func RolesFromReader(reader io.Reader) ([]*Role, error) {
+	roles := []*Role{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&roles)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return roles, nil
+}
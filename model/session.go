@@ -1,5 +1,12 @@
 package model
 
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
 const (
 	// SessionCookieToken is the cookie key for the authorization token.
 	SessionCookieToken = "DASHBOARDAUTHTOKEN"
@@ -9,7 +16,8 @@ const (
 	SessionCookieUser = "DASHBOARDUSERID"
 	// SessionHeader is the header key for a session.
 	SessionHeader = "Token"
-	// SessionLengthMilliseconds is the session length in milliseconds.
+	// SessionLengthMilliseconds is the session length in milliseconds when no
+	// SessionLifetime is configured.
 	SessionLengthMilliseconds = 1000 * 60 * 60 * 24 * 15 // 15 days
 	// HeaderRequestedWith is the HTTP header X-Requested-With.
 	HeaderRequestedWith = "X-Requested-With"
@@ -17,6 +25,10 @@ const (
 	HeaderRequestedWithXML = "XMLHttpRequest"
 	// HeaderForwardedProto is the HTTP header X-Forwarded-Proto.
 	HeaderForwardedProto = "X-Forwarded-Proto"
+	// HeaderForwardedFor is the HTTP header X-Forwarded-For.
+	HeaderForwardedFor = "X-Forwarded-For"
+	// HeaderRealIP is the HTTP header X-Real-IP.
+	HeaderRealIP = "X-Real-IP"
 	// HeaderRequestID is the custom header to track request ID.
 	HeaderRequestID = "X-Request-ID"
 	// HeaderAuthorization is the HTTP header Authorization.
@@ -29,6 +41,25 @@ const (
 	AuthorizationBearer = "BEARER"
 )
 
+const (
+	// SessionSourcePassword is the source of sessions created by logging in
+	// or signing up with a password.
+	SessionSourcePassword = "password"
+	// SessionSourceOAuth is the source of sessions created by logging in
+	// with an OAuth provider.
+	SessionSourceOAuth = "oauth"
+	// SessionSourcePersonalAccessToken is the source of the sessions of
+	// requests authenticated with a personal access token. They are not
+	// stored.
+	SessionSourcePersonalAccessToken = "personal_access_token"
+	// SessionSourceAPIKey is the source of the sessions of requests
+	// authenticated with an API key. They are not stored.
+	SessionSourceAPIKey = "api_key"
+
+	sessionUserAgentMaxLength = 255
+	sessionIPAddressMaxLength = 64
+)
+
 // Session is an authentication session for a user.
 type Session struct {
 	ID        string `json:"id"`
@@ -37,6 +68,21 @@ type Session struct {
 	ExpiresAt int64  `json:"expires_at" db:"expires_at"`
 	UserID    string `json:"user_id" db:"user_id"`
 	CSRFToken string `json:"csrf_token" db:"csrf_token"`
+	// Source is how the session was created, one of the SessionSource
+	// constants.
+	Source         string `json:"source" db:"source"`
+	UserAgent      string `json:"user_agent" db:"user_agent"`
+	IPAddress      string `json:"ip_address" db:"ip_address"`
+	LastActivityAt int64  `json:"last_activity_at" db:"last_activity_at"`
+	// Current is set when listing sessions to flag the session of the
+	// request.
+	Current bool `json:"current" db:"-"`
+	// APIKey is set when the session was created from an API key rather than
+	// a login.
+	APIKey *APIKey `json:"-" db:"-"`
+	// PersonalAccessToken is set when the session was created from a personal
+	// access token rather than a login.
+	PersonalAccessToken *PersonalAccessToken `json:"-" db:"-"`
 }
 
 // PreSave will set the ID, Token, CSRFToken, ExpiresAt and CreateAt for the session.
@@ -50,8 +96,52 @@ func (s *Session) PreSave() {
 	if s.CSRFToken == "" {
 		s.CSRFToken = NewID()
 	}
-	s.CreateAt = GetMillis()
-	s.ExpiresAt = s.CreateAt + SessionLengthMilliseconds
+	if s.CreateAt == 0 {
+		s.CreateAt = GetMillis()
+	}
+	if s.ExpiresAt == 0 {
+		s.ExpiresAt = s.CreateAt + SessionLengthMilliseconds
+	}
+	s.LastActivityAt = s.CreateAt
+	s.UserAgent = truncateString(s.UserAgent, sessionUserAgentMaxLength)
+	s.IPAddress = truncateString(s.IPAddress, sessionIPAddressMaxLength)
+}
+
+// SessionLifetime is how long login sessions last.
+type SessionLifetime struct {
+	// MaxAge is how long a session lasts after it was created, regardless of
+	// its activity. Zero means no limit.
+	MaxAge time.Duration
+	// IdleTimeout is how long a session lasts without activity. Zero means
+	// activity does not extend the session.
+	IdleTimeout time.Duration
+}
+
+// ExpiresAt returns when a session created and last used at the given times
+// expires. Without any limit, sessions last SessionLengthMilliseconds.
+func (l SessionLifetime) ExpiresAt(createAt, lastActivityAt int64) int64 {
+	maxAge := l.MaxAge.Milliseconds()
+	idleTimeout := l.IdleTimeout.Milliseconds()
+
+	switch {
+	case maxAge > 0 && idleTimeout > 0:
+		if createAt+maxAge < lastActivityAt+idleTimeout {
+			return createAt + maxAge
+		}
+		return lastActivityAt + idleTimeout
+	case maxAge > 0:
+		return createAt + maxAge
+	case idleTimeout > 0:
+		return lastActivityAt + idleTimeout
+	}
+
+	return createAt + SessionLengthMilliseconds
+}
+
+// Sanitize clears the secrets of the session so that it can be listed.
+func (s *Session) Sanitize() {
+	s.Token = ""
+	s.CSRFToken = ""
 }
 
 // IsExpired returns true if the session is expired.
@@ -66,3 +156,31 @@ func (s *Session) IsExpired() bool {
 
 	return false
 }
+
+// SessionsFromReader decodes a json-encoded list of sessions from the given
+// io.Reader.
+func SessionsFromReader(reader io.Reader) ([]*Session, error) {
+	sessions := []*Session{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&sessions)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// truncateString returns the longest prefix of the given string of at most
+// max bytes which is still valid UTF-8.
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
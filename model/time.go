@@ -6,3 +6,8 @@ import "time"
 func GetMillis() int64 {
 	return time.Now().UnixNano() / int64(time.Millisecond)
 }
+
+// GetMillisForTime returns the milliseconds since epoch of the given time.
+func GetMillisForTime(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
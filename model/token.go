@@ -15,6 +15,24 @@ const (
 	TokenTypeVerifyEmail = "verify_email"
 	// TokenTypeResetPassword the token type used for resetting user passwords.
 	TokenTypeResetPassword = "reset_password"
+	// TokenTypeUnsubscribe is the token type used in the one-click unsubscribe
+	// links of notification emails. It does not expire.
+	TokenTypeUnsubscribe = "unsubscribe"
+	// TokenTypeMFAChallenge is the token type identifying a login waiting for
+	// its second factor.
+	TokenTypeMFAChallenge = "mfa_challenge"
+	// TokenTypeUnlockAccount is the token type used in the emails sent when an
+	// account gets locked out by failed login attempts.
+	TokenTypeUnlockAccount = "unlock_account"
+	// TokenTypeInvitation is the token type used in invitation emails. It
+	// expires along with its invitation.
+	TokenTypeInvitation = "invitation"
+	// TokenTypeConfirmEmailChange is the token type used to confirm the new
+	// email of a user from that address.
+	TokenTypeConfirmEmailChange = "confirm_email_change"
+	// TokenTypeCancelEmailChange is the token type used to cancel an email
+	// change from the previous address.
+	TokenTypeCancelEmailChange = "cancel_email_change"
 	// TokenDefaultExpiryTime is the default time for tokens to expire.
 	TokenDefaultExpiryTime = 1000 * 60 * 60 * 24 // 24 hour
 )
@@ -46,10 +64,13 @@ func NewToken(tokentype, extra string) *Token {
 
 // IsValid checks a token for valid configuration
 func (t *Token) IsValid() error {
-	if t.Type != TokenTypeResetPassword && t.Type != TokenTypeVerifyEmail {
+	switch t.Type {
+	case TokenTypeResetPassword, TokenTypeVerifyEmail, TokenTypeUnsubscribe, TokenTypeMFAChallenge,
+		TokenTypeUnlockAccount, TokenTypeInvitation, TokenTypeConfirmEmailChange, TokenTypeCancelEmailChange:
+	default:
 		return errors.Errorf("unsupported token type: (%s)", t.Type)
 	}
-	if t.Type == TokenTypeResetPassword && len(t.Token) != TokenSize {
+	if t.Type != TokenTypeVerifyEmail && len(t.Token) != TokenSize {
 		return errors.Errorf("token length (%d) was expected to be %d", len(t.Token), TokenSize)
 	}
 	if t.Type == TokenTypeVerifyEmail && len(t.Token) != TokenSizeDigits {
@@ -58,9 +79,6 @@ func (t *Token) IsValid() error {
 	if t.CreateAt == 0 {
 		return errors.New("token CreateAt value is not set")
 	}
-	if t.Type != TokenTypeVerifyEmail && t.Type != TokenTypeResetPassword {
-		return errors.Errorf("token type %s is invalid", t.Type)
-	}
 
 	return nil
 }
@@ -95,3 +113,102 @@ func CreateTokenTypeResetPasswordExtra(email string) (string, error) {
 
 	return string(b), nil
 }
+
+// TokenExtraUnsubscribe is a token extra field identifying what to unsubscribe
+// from.
+type TokenExtraUnsubscribe struct {
+	UserID string `json:"user_id"`
+	Event  string `json:"event"`
+}
+
+// CreateTokenTypeUnsubscribeExtra returns the correct extra values for a token
+// of type TokenTypeUnsubscribe. An empty event unsubscribes from every email.
+func CreateTokenTypeUnsubscribeExtra(userID, event string) (string, error) {
+	b, err := json.Marshal(TokenExtraUnsubscribe{UserID: userID, Event: event})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal extra field")
+	}
+
+	return string(b), nil
+}
+
+// GetExtraUnsubscribe returns the extra values of a token of type
+// TokenTypeUnsubscribe.
+func (t *Token) GetExtraUnsubscribe() (*TokenExtraUnsubscribe, error) {
+	var extra TokenExtraUnsubscribe
+	err := json.Unmarshal([]byte(t.Extra), &extra)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to ummarshal extra field")
+	}
+	if len(extra.UserID) == 0 {
+		return nil, errors.New("user id value is empty")
+	}
+
+	return &extra, nil
+}
+
+// TokenExtraMFAChallenge is a token extra field identifying the user of a
+// login waiting for its second factor, and how they logged in.
+type TokenExtraMFAChallenge struct {
+	UserID string `json:"user_id"`
+	Source string `json:"source"`
+}
+
+// CreateTokenTypeMFAChallengeExtra returns the correct extra values for a
+// token of type TokenTypeMFAChallenge.
+func CreateTokenTypeMFAChallengeExtra(userID, source string) (string, error) {
+	b, err := json.Marshal(TokenExtraMFAChallenge{UserID: userID, Source: source})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal extra field")
+	}
+
+	return string(b), nil
+}
+
+// GetExtraMFAChallenge returns the extra values of a token of type
+// TokenTypeMFAChallenge.
+func (t *Token) GetExtraMFAChallenge() (*TokenExtraMFAChallenge, error) {
+	var extra TokenExtraMFAChallenge
+	err := json.Unmarshal([]byte(t.Extra), &extra)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to ummarshal extra field")
+	}
+	if len(extra.UserID) == 0 {
+		return nil, errors.New("user id value is empty")
+	}
+
+	return &extra, nil
+}
+
+// TokenExtraEmailChange is a token extra field identifying the email change
+// of a user.
+type TokenExtraEmailChange struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// CreateTokenTypeEmailChangeExtra returns the correct extra values for a
+// token of type TokenTypeConfirmEmailChange or TokenTypeCancelEmailChange.
+func CreateTokenTypeEmailChangeExtra(userID, email string) (string, error) {
+	b, err := json.Marshal(TokenExtraEmailChange{UserID: userID, Email: email})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal extra field")
+	}
+
+	return string(b), nil
+}
+
+// GetExtraEmailChange returns the extra values of a token of type
+// TokenTypeConfirmEmailChange or TokenTypeCancelEmailChange.
+func (t *Token) GetExtraEmailChange() (*TokenExtraEmailChange, error) {
+	var extra TokenExtraEmailChange
+	err := json.Unmarshal([]byte(t.Extra), &extra)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to ummarshal extra field")
+	}
+	if len(extra.UserID) == 0 || len(extra.Email) == 0 {
+		return nil, errors.New("user id or email value is empty")
+	}
+
+	return &extra, nil
+}
@@ -5,18 +5,19 @@ import (
 	"errors"
 	"io"
 	"net/mail"
-	"regexp"
 	"strings"
 	"unicode/utf8"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
 )
 
 const (
 	userNameMaxRuneLength          = 64
-	userPasswordMaxLength          = 72
+	userPasswordMaxLength          = 256
 	userPasswordMinLength          = 8
 	userEmailMaxLength             = 128
+	userLocaleMaxLength            = 32
+	userSearchTermMaxLength        = 64
 	EventTypeSendAdminWelcomeEmail = "send-admin-welcome-email"
 )
 
@@ -27,6 +28,14 @@ const (
 	UserStateLocked = "locked"
 )
 
+const (
+	// UsersDefaultPerPage is the number of users returned per page when the
+	// request does not specify it.
+	UsersDefaultPerPage = 50
+	// UsersMaxPerPage is the maximum number of users returned per page.
+	UsersMaxPerPage = 200
+)
+
 // TODO: cleanup
 // User model represents a user on the system.
 type User struct {
@@ -40,6 +49,36 @@ type User struct {
 	LastName      string `json:"last_name,omitempty" db:"last_name"`
 	State         string `json:"state"`
 	IsAdmin       bool   `json:"is_admin" db:"is_admin"`
+	Locale        string `json:"locale"`
+	// PendingEmail is the email the user asked to change theirs to, until
+	// they confirm it from the new address.
+	PendingEmail string `json:"pending_email,omitempty" db:"pending_email"`
+	// Roles holds the names of the roles of the user. It is only set when
+	// the user is fetched through the admin API.
+	Roles []string `json:"roles,omitempty" db:"-"`
+}
+
+// UserFilter describes the parameters used to search and page through users.
+type UserFilter struct {
+	// Term matches users whose email, first name or last name contains it.
+	Term    string
+	Page    int
+	PerPage int
+}
+
+// IsValid will determine if the filter is valid.
+func (f *UserFilter) IsValid() error {
+	if len(f.Term) > userSearchTermMaxLength {
+		return errors.New("invalid term")
+	}
+	if f.Page < 0 {
+		return errors.New("invalid page")
+	}
+	if f.PerPage < 1 || f.PerPage > UsersMaxPerPage {
+		return errors.New("invalid per page")
+	}
+
+	return nil
 }
 
 // IsValid will determine if the user fields are all valid.
@@ -56,15 +95,9 @@ func (u *User) IsValid() error {
 	if utf8.RuneCountInString(u.LastName) > userNameMaxRuneLength {
 		return errors.New("invalid last name")
 	}
-	return nil
-}
-
-// IsValidPassword returns an error if the user's password is not valid.
-func (u *User) IsValidPassword() error {
-	if !IsValidPassword(u.Password) {
-		return errors.New("invalid password")
+	if len(u.Locale) > userLocaleMaxLength {
+		return errors.New("invalid locale")
 	}
-
 	return nil
 }
 
@@ -81,7 +114,15 @@ func (u *User) CreatePreSave() {
 
 	u.EmailVerified = false
 
-	u.Password = HashPassword(u.Password)
+	if u.Locale == "" {
+		u.Locale = i18n.DefaultLocale
+	}
+}
+
+// HasRole returns true if the user has the role of the given name. The roles
+// of the user must have been loaded.
+func (u *User) HasRole(name string) bool {
+	return containsString(u.Roles, name)
 }
 
 // Sanitize clears any sensitive data from the user.
@@ -94,26 +135,6 @@ func (u *User) GetUserEmailDomain() string {
 	return u.Email[idx+1:]
 }
 
-// HashPassword generates a hash using the bcrypt.GenerateFromPassword
-func HashPassword(password string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
-	if err != nil {
-		panic(err)
-	}
-
-	return string(hash)
-}
-
-// ComparePassword compares the hash to the password.
-func ComparePassword(hash string, password string) bool {
-	if len(password) == 0 || len(hash) == 0 {
-		return false
-	}
-
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
-
 // IsLower will check if a string is lowercased
 func IsLower(s string) bool {
 	return strings.ToLower(s) == s
@@ -135,28 +156,6 @@ func IsValidEmail(email string) bool {
 	return true
 }
 
-// IsValidPassword will perform some minimal validations on whether the provided
-// password meets our requirements.
-func IsValidPassword(password string) bool {
-	if len(password) == 0 || len(password) > userPasswordMaxLength || len(password) < userPasswordMinLength {
-		return false
-	}
-
-	if ok, _ := regexp.MatchString(".*[a-z].*", password); !ok {
-		return false
-	}
-
-	if ok, _ := regexp.MatchString(".*[A-Z].*", password); !ok {
-		return false
-	}
-
-	if ok, _ := regexp.MatchString(".*[0-9].*", password); !ok {
-		return false
-	}
-
-	return true
-}
-
 // UserFromReader decodes a json-encoded user from the given io.Reader.
 func UserFromReader(reader io.Reader) (*User, error) {
 	user := User{}
@@ -168,3 +167,16 @@ func UserFromReader(reader io.Reader) (*User, error) {
 
 	return &user, nil
 }
+
+// UsersFromReader decodes a json-encoded list of users from the given
+// io.Reader.
+func UsersFromReader(reader io.Reader) ([]*User, error) {
+	users := []*User{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&users)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -1,18 +1,41 @@
 package model
 
-import "golang.org/x/oauth2"
+import (
+	"encoding/json"
+	"io"
 
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OAuthProviderGithub is the provider of GitHub accounts.
+	OAuthProviderGithub = "github"
+
+	// OAuthProviderGitlab is the provider of accounts of the configured GitLab
+	// instance.
+	OAuthProviderGitlab = "gitlab"
+
+	// OAuthProviderOIDC is the provider of accounts of the configured OpenID
+	// Connect provider.
+	OAuthProviderOIDC = "oidc"
+)
+
+// UserAuthInfo links a user to their account on an OAuth provider.
 type UserAuthInfo struct {
-	UserID        string       `json:"user_id"`
-	OAuthProvider string       `json:"oauth_provider"`
-	Token         oauth2.Token `json:"token"`
-	Username      string       `json:"username"`
-	Email         string       `json:"email,omitempty"`
-	Name          string       `json:"name,omitempty"`
-	AvatarURL     string       `json:"avatar_url"`
-	CreateAt      int64        `json:"create_at" db:"create_at"`
-	UpdateAt      int64        `json:"update_at" db:"update_at"`
-	DeleteAt      int64        `json:"update_at" db:"delete_at"`
+	UserID         string       `json:"user_id" db:"user_id"`
+	OAuthProvider  string       `json:"oauth_provider" db:"oauth_provider"`
+	ProviderUserID string       `json:"provider_user_id" db:"provider_user_id"`
+	Token          oauth2.Token `json:"-" db:"-"`
+	Username       string       `json:"username"`
+	Email          string       `json:"email,omitempty"`
+	Name           string       `json:"name,omitempty"`
+	AvatarURL      string       `json:"avatar_url" db:"avatar_url"`
+	// Groups are the groups of the account reported by the provider on login.
+	// They are not stored.
+	Groups   []string `json:"groups,omitempty" db:"-"`
+	CreateAt int64    `json:"create_at" db:"create_at"`
+	UpdateAt int64    `json:"update_at" db:"update_at"`
+	DeleteAt int64    `json:"delete_at" db:"delete_at"`
 }
 
 // PreSave will set the ID and CreateAt for the UserAuthInfo.
@@ -22,3 +45,16 @@ func (u *UserAuthInfo) PreSave() {
 	u.UpdateAt = now
 	u.DeleteAt = 0
 }
+
+// UserAuthInfoFromReader decodes a json-encoded user auth info from the given
+// io.Reader.
+func UserAuthInfoFromReader(reader io.Reader) (*UserAuthInfo, error) {
+	userAuthInfo := UserAuthInfo{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&userAuthInfo)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &userAuthInfo, nil
+}
@@ -3,9 +3,10 @@ package model
 import (
 	"bytes"
 	cryptoRand "crypto/rand"
+	"crypto/sha256"
 	"encoding/base32"
-	mathRand "math/rand"
-	"strconv"
+	"encoding/hex"
+	"math/big"
 
 	"github.com/pborman/uuid"
 )
@@ -32,11 +33,19 @@ func NewRandomString(length int) string {
 	return encoding.EncodeToString(data)[:length]
 }
 
+// HashToken returns the hash a random token, such as an API key, is stored and
+// looked up with. The tokens are random enough for a fast hash to be safe.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 // NewRandomNumber returns a random string of the given length, built from digits
 func NewRandomNumber(length int) string {
-	s := ""
-	for i := 0; i < length; i++ {
-		s += strconv.Itoa(mathRand.Intn(10))
+	digits := make([]byte, length)
+	for i := range digits {
+		digit, _ := cryptoRand.Int(cryptoRand.Reader, big.NewInt(10))
+		digits[i] = byte('0' + digit.Int64())
 	}
-	return s
+	return string(digits)
 }
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlAPIKeyStore struct {
+	*SqlStore
+}
+
+func newSqlAPIKeyStore(sqlStore *SqlStore) APIKeyStore {
+	s := &SqlAPIKeyStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) APIKey() APIKeyStore {
+	return s.stores.apiKey
+}
+
+var apiKeySelect sq.SelectBuilder
+
+func init() {
+	apiKeySelect = sq.
+		Select(
+			"id",
+			"name",
+			"scope",
+			"key_hash",
+			"creator_id",
+			"create_at",
+			"expires_at",
+			"last_used_at",
+			"last_used_ip",
+			"delete_at",
+		)
+}
+
+func (s *SqlAPIKeyStore) getAPIKeyTable() string {
+	return s.tablePrefix + "api_key"
+}
+
+// CreateAPIKey inserts a new API key. Its PreSave must have been called to
+// generate the key.
+func (s *SqlAPIKeyStore) CreateAPIKey(apiKey *model.APIKey) (*model.APIKey, error) {
+	if err := apiKey.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getAPIKeyTable()).
+		SetMap(map[string]interface{}{
+			"id":           apiKey.ID,
+			"name":         apiKey.Name,
+			"scope":        apiKey.Scope,
+			"key_hash":     apiKey.KeyHash,
+			"creator_id":   apiKey.CreatorID,
+			"create_at":    apiKey.CreateAt,
+			"expires_at":   apiKey.ExpiresAt,
+			"last_used_at": apiKey.LastUsedAt,
+			"last_used_ip": apiKey.LastUsedIP,
+			"delete_at":    apiKey.DeleteAt,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create API key")
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKey fetches the given API key by id.
+func (s *SqlAPIKeyStore) GetAPIKey(id string) (*model.APIKey, error) {
+	return s.getAPIKey(sq.Eq{"id": id})
+}
+
+// GetAPIKeyByHash fetches the API key with the given hash.
+func (s *SqlAPIKeyStore) GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	return s.getAPIKey(sq.Eq{"key_hash": keyHash})
+}
+
+func (s *SqlAPIKeyStore) getAPIKey(where sq.Eq) (*model.APIKey, error) {
+	var apiKey model.APIKey
+	err := s.getBuilder(
+		s.db,
+		&apiKey,
+		apiKeySelect.From(s.getAPIKeyTable()).Where(where),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get API key")
+	}
+
+	return &apiKey, nil
+}
+
+// GetAPIKeys fetches the API keys that were not revoked, newest first.
+func (s *SqlAPIKeyStore) GetAPIKeys() ([]*model.APIKey, error) {
+	apiKeys := []*model.APIKey{}
+	err := s.selectBuilder(
+		s.db,
+		&apiKeys,
+		apiKeySelect.From(s.getAPIKeyTable()).
+			Where("delete_at = 0").
+			OrderBy("create_at DESC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get API keys")
+	}
+
+	return apiKeys, nil
+}
+
+// UpdateAPIKeyUsage records when and from where an API key was last used.
+func (s *SqlAPIKeyStore) UpdateAPIKeyUsage(id string, lastUsedAt int64, lastUsedIP string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getAPIKeyTable()).
+			Where("id = ?", id).
+			Set("last_used_at", lastUsedAt).
+			Set("last_used_ip", lastUsedIP),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update API key usage")
+	}
+
+	return nil
+}
+
+// DeleteAPIKey revokes an API key. Revoked keys are kept for auditing.
+func (s *SqlAPIKeyStore) DeleteAPIKey(id string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getAPIKeyTable()).
+			Where("id = ?", id).
+			Where("delete_at = 0").
+			Set("delete_at", model.GetMillis()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete API key")
+	}
+
+	return nil
+}
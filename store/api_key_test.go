@@ -0,0 +1,71 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown API key", func(t *testing.T) {
+		apiKey, err := th.SqlStore.APIKey().GetAPIKey(model.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, apiKey)
+
+		apiKey, err = th.SqlStore.APIKey().GetAPIKeyByHash(model.HashToken("junk"))
+		assert.NoError(t, err)
+		assert.Nil(t, apiKey)
+	})
+
+	t.Run("create invalid API key", func(t *testing.T) {
+		apiKey := &model.APIKey{Name: "ci", Scope: "junk", CreatorID: model.NewID()}
+		apiKey.PreSave()
+
+		_, err := th.SqlStore.APIKey().CreateAPIKey(apiKey)
+		require.Error(t, err)
+	})
+
+	t.Run("create, use and delete API key", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+
+		apiKey := &model.APIKey{Name: "ci", Scope: model.APIKeyScopeIngest, CreatorID: user.ID}
+		key := apiKey.PreSave()
+
+		_, err := th.SqlStore.APIKey().CreateAPIKey(apiKey)
+		require.NoError(t, err)
+
+		saved, err := th.SqlStore.APIKey().GetAPIKeyByHash(model.HashToken(key))
+		require.NoError(t, err)
+		assert.Equal(t, apiKey, saved)
+
+		err = th.SqlStore.APIKey().UpdateAPIKeyUsage(apiKey.ID, 42, "10.0.0.1")
+		require.NoError(t, err)
+
+		saved, err = th.SqlStore.APIKey().GetAPIKey(apiKey.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), saved.LastUsedAt)
+		assert.Equal(t, "10.0.0.1", saved.LastUsedIP)
+
+		apiKeys, err := th.SqlStore.APIKey().GetAPIKeys()
+		require.NoError(t, err)
+		assert.Contains(t, apiKeys, saved)
+
+		err = th.SqlStore.APIKey().DeleteAPIKey(apiKey.ID)
+		require.NoError(t, err)
+
+		saved, err = th.SqlStore.APIKey().GetAPIKey(apiKey.ID)
+		require.NoError(t, err)
+		assert.True(t, saved.IsRevoked())
+
+		apiKeys, err = th.SqlStore.APIKey().GetAPIKeys()
+		require.NoError(t, err)
+		for _, other := range apiKeys {
+			assert.NotEqual(t, apiKey.ID, other.ID)
+		}
+	})
+}
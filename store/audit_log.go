@@ -0,0 +1,109 @@
+package store
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlAuditLogStore struct {
+	*SqlStore
+}
+
+func newSqlAuditLogStore(sqlStore *SqlStore) AuditLogStore {
+	s := &SqlAuditLogStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) AuditLog() AuditLogStore {
+	return s.stores.auditLog
+}
+
+var auditLogSelect sq.SelectBuilder
+
+func init() {
+	auditLogSelect = sq.
+		Select(
+			"id",
+			"create_at",
+			"action",
+			"actor_id",
+			"actor_type",
+			"target_id",
+			"ip_address",
+			"request_id",
+			"details",
+		)
+}
+
+func (s *SqlAuditLogStore) getAuditLogTable() string {
+	return s.tablePrefix + "audit_log"
+}
+
+// CreateAuditLog appends an entry to the audit log.
+func (s *SqlAuditLogStore) CreateAuditLog(entry *model.AuditLog) (*model.AuditLog, error) {
+	entry.PreSave()
+	if err := entry.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getAuditLogTable()).
+		SetMap(map[string]interface{}{
+			"id":         entry.ID,
+			"create_at":  entry.CreateAt,
+			"action":     entry.Action,
+			"actor_id":   entry.ActorID,
+			"actor_type": entry.ActorType,
+			"target_id":  entry.TargetID,
+			"ip_address": entry.IPAddress,
+			"request_id": entry.RequestID,
+			"details":    entry.Details,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create audit log entry")
+	}
+
+	return entry, nil
+}
+
+// GetAuditLogs fetches a page of the audit log entries matching the given
+// filter, the most recent first.
+func (s *SqlAuditLogStore) GetAuditLogs(filter *model.AuditLogFilter) ([]*model.AuditLog, error) {
+	if err := filter.IsValid(); err != nil {
+		return nil, err
+	}
+
+	query := auditLogSelect.From(s.getAuditLogTable()).
+		OrderBy("create_at DESC", "id DESC").
+		Limit(uint64(filter.PerPage)).
+		Offset(uint64(filter.Page * filter.PerPage))
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Since > 0 {
+		query = query.Where("create_at >= ?", filter.Since)
+	}
+	if filter.Until > 0 {
+		query = query.Where("create_at <= ?", filter.Until)
+	}
+
+	logs := []*model.AuditLog{}
+	err := s.selectBuilder(s.db, &logs, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get audit logs")
+	}
+
+	return logs, nil
+}
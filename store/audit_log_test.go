@@ -0,0 +1,100 @@
+package store
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestAuditLogs(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	actorID := model.NewID()
+	targetID := model.NewID()
+
+	first, err := th.SqlStore.AuditLog().CreateAuditLog(&model.AuditLog{
+		CreateAt:  1000,
+		Action:    model.AuditActionRoleAdd,
+		ActorID:   actorID,
+		ActorType: model.AuditActorUser,
+		TargetID:  targetID,
+		IPAddress: "127.0.0.1",
+		RequestID: model.NewID(),
+		Details:   model.StringMap{"role": model.AdminRoleName},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.ID)
+
+	second, err := th.SqlStore.AuditLog().CreateAuditLog(&model.AuditLog{
+		CreateAt: 2000,
+		Action:   model.AuditActionRoleRemove,
+		TargetID: targetID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.AuditActorAnonymous, second.ActorType)
+
+	t.Run("invalid entry", func(t *testing.T) {
+		_, err := th.SqlStore.AuditLog().CreateAuditLog(&model.AuditLog{})
+		require.Error(t, err)
+	})
+
+	t.Run("filter by target, most recent first", func(t *testing.T) {
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{second, first}, logs)
+	})
+
+	t.Run("filter by actor and action", func(t *testing.T) {
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{ActorID: actorID, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{first}, logs)
+
+		logs, err = th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, Action: model.AuditActionRoleRemove, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{second}, logs)
+	})
+
+	t.Run("filter by time and page", func(t *testing.T) {
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, Since: 1500, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{second}, logs)
+
+		logs, err = th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, Until: 1500, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{first}, logs)
+
+		logs, err = th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, Page: 1, PerPage: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{first}, logs)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{Since: 2000, Until: 1000, PerPage: 10})
+		require.Error(t, err)
+	})
+
+	t.Run("entries can't be changed", func(t *testing.T) {
+		auditLogStore := th.SqlStore.AuditLog().(*SqlAuditLogStore)
+
+		_, err := auditLogStore.execBuilder(auditLogStore.db, sq.
+			Update(auditLogStore.getAuditLogTable()).
+			Set("action", model.AuditActionLogin).
+			Where("id = ?", first.ID),
+		)
+		require.NoError(t, err)
+		_, err = auditLogStore.execBuilder(auditLogStore.db, sq.
+			Delete(auditLogStore.getAuditLogTable()).
+			Where("id = ?", second.ID),
+		)
+		require.NoError(t, err)
+
+		logs, err := th.SqlStore.AuditLog().GetAuditLogs(&model.AuditLogFilter{TargetID: targetID, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.AuditLog{second, first}, logs)
+	})
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlCycleStore struct {
+	*SqlStore
+}
+
+func newSqlCycleStore(sqlStore *SqlStore) CycleStore {
+	s := &SqlCycleStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) Cycle() CycleStore {
+	return s.stores.cycle
+}
+
+var cycleSelect sq.SelectBuilder
+
+func init() {
+	cycleSelect = sq.
+		Select(
+			"id",
+			"repo",
+			"branch",
+			"build",
+			"passed",
+			"failed",
+			"skipped",
+			"creator_id",
+			"create_at",
+		)
+}
+
+func (s *SqlCycleStore) getCycleTable() string {
+	return s.tablePrefix + "cycle"
+}
+
+// CreateCycle inserts a new cycle.
+func (s *SqlCycleStore) CreateCycle(cycle *model.Cycle) (*model.Cycle, error) {
+	cycle.PreSave()
+	if err := cycle.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getCycleTable()).
+		SetMap(map[string]interface{}{
+			"id":         cycle.ID,
+			"repo":       cycle.Repo,
+			"branch":     cycle.Branch,
+			"build":      cycle.Build,
+			"passed":     cycle.Passed,
+			"failed":     cycle.Failed,
+			"skipped":    cycle.Skipped,
+			"creator_id": cycle.CreatorID,
+			"create_at":  cycle.CreateAt,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cycle")
+	}
+
+	return cycle, nil
+}
+
+// GetCycle fetches the cycle of the given id, or nil if it doesn't exist.
+func (s *SqlCycleStore) GetCycle(id string) (*model.Cycle, error) {
+	var cycle model.Cycle
+	err := s.getBuilder(s.db, &cycle,
+		cycleSelect.From(s.getCycleTable()).Where(sq.Eq{"id": id}),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get cycle by id")
+	}
+
+	return &cycle, nil
+}
+
+// GetCycles fetches a page of the cycles matching the given filter, the most
+// recent first.
+func (s *SqlCycleStore) GetCycles(filter *model.CycleFilter) ([]*model.Cycle, error) {
+	if err := filter.IsValid(); err != nil {
+		return nil, err
+	}
+
+	query := cycleSelect.From(s.getCycleTable()).
+		OrderBy("create_at DESC", "id DESC").
+		Limit(uint64(filter.PerPage)).
+		Offset(uint64(filter.Page * filter.PerPage))
+	if filter.Repo != "" {
+		query = query.Where("repo = ?", filter.Repo)
+	}
+	if filter.Branch != "" {
+		query = query.Where("branch = ?", filter.Branch)
+	}
+	if filter.Repos != nil {
+		query = query.Where(sq.Eq{"repo": filter.Repos})
+	}
+
+	cycles := []*model.Cycle{}
+	err := s.selectBuilder(s.db, &cycles, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cycles")
+	}
+
+	return cycles, nil
+}
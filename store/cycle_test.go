@@ -0,0 +1,72 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestCycles(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown cycle", func(t *testing.T) {
+		cycle, err := th.SqlStore.Cycle().GetCycle(model.NewID())
+		require.NoError(t, err)
+		assert.Nil(t, cycle)
+	})
+
+	t.Run("create invalid cycle", func(t *testing.T) {
+		_, err := th.SqlStore.Cycle().CreateCycle(&model.Cycle{Repo: "a/b", Branch: "master", CreatorID: model.NewID()})
+		require.Error(t, err)
+
+		_, err = th.SqlStore.Cycle().CreateCycle(&model.Cycle{Repo: "webapp", Branch: "master", Failed: -1, CreatorID: model.NewID()})
+		require.Error(t, err)
+	})
+
+	// The repos are unique to each run since the test database is kept.
+	webapp := "webapp-" + model.NewID()
+	server := "server-" + model.NewID()
+	creatorID := model.NewID()
+
+	first, err := th.SqlStore.Cycle().CreateCycle(&model.Cycle{Repo: webapp, Branch: "master", Passed: 10, Failed: 1, CreatorID: creatorID, CreateAt: 1000})
+	require.NoError(t, err)
+	second, err := th.SqlStore.Cycle().CreateCycle(&model.Cycle{Repo: webapp, Branch: "release-1.0", Build: "42", Passed: 11, CreatorID: creatorID, CreateAt: 2000})
+	require.NoError(t, err)
+	third, err := th.SqlStore.Cycle().CreateCycle(&model.Cycle{Repo: server, Branch: "master", Skipped: 3, CreatorID: creatorID})
+	require.NoError(t, err)
+
+	t.Run("get cycle", func(t *testing.T) {
+		cycle, err := th.SqlStore.Cycle().GetCycle(second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, second, cycle)
+	})
+
+	t.Run("get cycles", func(t *testing.T) {
+		cycles, err := th.SqlStore.Cycle().GetCycles(&model.CycleFilter{Repo: webapp, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Cycle{second, first}, cycles)
+
+		cycles, err = th.SqlStore.Cycle().GetCycles(&model.CycleFilter{Repo: webapp, Branch: "master", PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Cycle{first}, cycles)
+
+		cycles, err = th.SqlStore.Cycle().GetCycles(&model.CycleFilter{Repo: webapp, Page: 1, PerPage: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Cycle{first}, cycles)
+
+		cycles, err = th.SqlStore.Cycle().GetCycles(&model.CycleFilter{Repos: []string{server}, PerPage: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Cycle{third}, cycles)
+
+		cycles, err = th.SqlStore.Cycle().GetCycles(&model.CycleFilter{Repo: webapp, Repos: []string{}, PerPage: 10})
+		require.NoError(t, err)
+		assert.Empty(t, cycles)
+
+		_, err = th.SqlStore.Cycle().GetCycles(&model.CycleFilter{PerPage: model.CyclesMaxPerPage + 1})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
+)
+
+// encryptedColumn describes a column holding secrets encrypted at rest.
+type encryptedColumn struct {
+	table      string
+	keyColumns []string
+	column     string
+}
+
+func (s *SqlStore) getEncryptedColumns() []encryptedColumn {
+	return []encryptedColumn{
+		{
+			table:      s.tablePrefix + "user_auth_info",
+			keyColumns: []string{"user_id", "oauth_provider"},
+			column:     "token",
+		},
+		{
+			table:      s.tablePrefix + "user_mfa",
+			keyColumns: []string{"user_id"},
+			column:     "secret",
+		},
+	}
+}
+
+// SetKeyring sets the keys used to encrypt secrets stored at rest.
+func (s *SqlStore) SetKeyring(keyring *encryption.Keyring) {
+	s.keyring = keyring
+}
+
+// encryptSecret encrypts a secret with the primary key of the keyring.
+func (s *SqlStore) encryptSecret(plaintext []byte) (string, error) {
+	if s.keyring == nil {
+		return "", errors.New("no encryption key configured")
+	}
+
+	return s.keyring.Encrypt(plaintext)
+}
+
+// decryptSecret decrypts a secret stored at rest. Secrets stored before
+// encryption was introduced are returned as is.
+func (s *SqlStore) decryptSecret(value string) ([]byte, error) {
+	if !encryption.IsEncrypted(value) {
+		return []byte(value), nil
+	}
+	if s.keyring == nil {
+		return nil, errors.New("no encryption key configured")
+	}
+
+	return s.keyring.Decrypt(value)
+}
+
+// RotateEncryptionKeys re-encrypts every secret stored at rest that was not
+// encrypted with the primary key of the keyring, returning how many were
+// re-encrypted. Rows are updated one by one and only if they did not change
+// in the meantime, so it is safe to run while servers are using the store.
+func (s *SqlStore) RotateEncryptionKeys() (int, error) {
+	if s.keyring == nil {
+		return 0, errors.New("no encryption key configured")
+	}
+
+	rotated := 0
+	for _, encrypted := range s.getEncryptedColumns() {
+		count, err := s.rotateEncryptedColumn(encrypted)
+		rotated += count
+		if err != nil {
+			return rotated, err
+		}
+	}
+
+	return rotated, nil
+}
+
+func (s *SqlStore) rotateEncryptedColumn(encrypted encryptedColumn) (int, error) {
+	columns := append(append([]string{}, encrypted.keyColumns...), encrypted.column)
+	query, args, err := sq.Select(columns...).From(encrypted.table).ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build sql")
+	}
+
+	rows, err := s.db.Queryx(s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get secrets of %s", encrypted.table)
+	}
+
+	var pending []map[string]interface{}
+	for rows.Next() {
+		row := make(map[string]interface{})
+		if err = rows.MapScan(row); err != nil {
+			rows.Close()
+			return 0, errors.Wrapf(err, "failed to read secret of %s", encrypted.table)
+		}
+		pending = append(pending, row)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, errors.Wrapf(err, "failed to get secrets of %s", encrypted.table)
+	}
+
+	rotated := 0
+	for _, row := range pending {
+		value := toString(row[encrypted.column])
+
+		var newValue string
+		if encryption.IsEncrypted(value) {
+			if !s.keyring.NeedsRotation(value) {
+				continue
+			}
+			newValue, err = s.keyring.Rotate(value)
+		} else {
+			newValue, err = s.keyring.Encrypt([]byte(value))
+		}
+		if err != nil {
+			return rotated, errors.Wrapf(err, "failed to re-encrypt secret of %s", encrypted.table)
+		}
+
+		where := sq.Eq{encrypted.column: value}
+		for _, keyColumn := range encrypted.keyColumns {
+			where[keyColumn] = toString(row[keyColumn])
+		}
+
+		result, err := s.execBuilder(s.db, sq.
+			Update(encrypted.table).
+			Set(encrypted.column, newValue).
+			Where(where),
+		)
+		if err != nil {
+			return rotated, errors.Wrapf(err, "failed to update secret of %s", encrypted.table)
+		}
+
+		// The row is left alone if it was updated or deleted in the meantime.
+		if count, err := result.RowsAffected(); err == nil && count > 0 {
+			rotated++
+		}
+	}
+
+	return rotated, nil
+}
+
+// toString converts a value scanned from the database into a string.
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	testKeyring := th.SqlStore.keyring
+	newKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+
+	// The secrets of the other tests are encrypted with the test key, which
+	// is rotated away and then back.
+	rotatingKeyring, err := encryption.ParseKeyring("new:" + newKey + "," + testEncryptionKeys)
+	require.NoError(t, err)
+	newKeyring, err := encryption.ParseKeyring("new:" + newKey)
+	require.NoError(t, err)
+	restoringKeyring, err := encryption.ParseKeyring(testEncryptionKeys + ",new:" + newKey)
+	require.NoError(t, err)
+	defer func() {
+		th.SqlStore.SetKeyring(restoringKeyring)
+		_, err := th.SqlStore.RotateEncryptionKeys()
+		require.NoError(t, err)
+		th.SqlStore.SetKeyring(testKeyring)
+	}()
+
+	user := createTestUser(t, th.SqlStore)
+	_, err = th.SqlStore.UserAuthInfo().CreateUserAuthInfo(&model.UserAuthInfo{
+		UserID:         user.ID,
+		OAuthProvider:  model.OAuthProviderGithub,
+		ProviderUserID: model.NewID(),
+		Token:          oauth2.Token{AccessToken: "secret-access-token"},
+	})
+	require.NoError(t, err)
+	err = th.SqlStore.MFA().SaveUserMFA(&model.UserMFA{UserID: user.ID, Secret: "SECRET"})
+	require.NoError(t, err)
+
+	var stored string
+	err = th.SqlStore.get(th.SqlStore.db, &stored, "SELECT token FROM "+th.SqlStore.tablePrefix+"user_auth_info WHERE user_id = ?", user.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored, "secret-access-token")
+	keyID, err := encryption.KeyID(stored)
+	require.NoError(t, err)
+	assert.Equal(t, "test", keyID)
+
+	th.SqlStore.SetKeyring(newKeyring)
+	_, err = th.SqlStore.UserAuthInfo().GetUserAuthInfo(user.ID, model.OAuthProviderGithub)
+	require.Error(t, err)
+	_, err = th.SqlStore.MFA().GetUserMFA(user.ID)
+	require.Error(t, err)
+
+	th.SqlStore.SetKeyring(rotatingKeyring)
+	rotated, err := th.SqlStore.RotateEncryptionKeys()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rotated, 2)
+
+	rotated, err = th.SqlStore.RotateEncryptionKeys()
+	require.NoError(t, err)
+	assert.Equal(t, 0, rotated)
+
+	th.SqlStore.SetKeyring(newKeyring)
+	userAuthInfo, err := th.SqlStore.UserAuthInfo().GetUserAuthInfo(user.ID, model.OAuthProviderGithub)
+	require.NoError(t, err)
+	require.NotNil(t, userAuthInfo)
+	assert.Equal(t, "secret-access-token", userAuthInfo.Token.AccessToken)
+
+	mfa, err := th.SqlStore.MFA().GetUserMFA(user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, mfa)
+	assert.Equal(t, "SECRET", mfa.Secret)
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlGithubAccessStore struct {
+	*SqlStore
+}
+
+func newSqlGithubAccessStore(sqlStore *SqlStore) GithubAccessStore {
+	s := &SqlGithubAccessStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) GithubAccess() GithubAccessStore {
+	return s.stores.githubAccess
+}
+
+var (
+	githubAccessRuleSelect sq.SelectBuilder
+	githubMembershipSelect sq.SelectBuilder
+)
+
+func init() {
+	githubAccessRuleSelect = sq.
+		Select(
+			"id",
+			"org",
+			"team",
+			"role_id",
+			"repos",
+			"create_at",
+			"update_at",
+		)
+	githubMembershipSelect = sq.
+		Select(
+			"user_id",
+			"member_of",
+			"allowed",
+			"repos",
+			"sync_at",
+		)
+}
+
+func (s *SqlGithubAccessStore) getGithubAccessRuleTable() string {
+	return s.tablePrefix + "github_access_rule"
+}
+
+func (s *SqlGithubAccessStore) getGithubMembershipTable() string {
+	return s.tablePrefix + "github_membership"
+}
+
+// CreateGithubAccessRule inserts a new GitHub access rule.
+func (s *SqlGithubAccessStore) CreateGithubAccessRule(rule *model.GithubAccessRule) (*model.GithubAccessRule, error) {
+	rule.PreSave()
+	if err := rule.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getGithubAccessRuleTable()).
+		SetMap(map[string]interface{}{
+			"id":        rule.ID,
+			"org":       rule.Org,
+			"team":      rule.Team,
+			"role_id":   rule.RoleID,
+			"repos":     rule.Repos,
+			"create_at": rule.CreateAt,
+			"update_at": rule.UpdateAt,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create github access rule")
+	}
+
+	return rule, nil
+}
+
+// GetGithubAccessRule fetches the given GitHub access rule by id.
+func (s *SqlGithubAccessStore) GetGithubAccessRule(id string) (*model.GithubAccessRule, error) {
+	var rule model.GithubAccessRule
+	err := s.getBuilder(s.db, &rule,
+		githubAccessRuleSelect.From(s.getGithubAccessRuleTable()).Where(sq.Eq{"id": id}),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get github access rule by id")
+	}
+
+	return &rule, nil
+}
+
+// GetGithubAccessRules fetches every GitHub access rule, oldest first.
+func (s *SqlGithubAccessStore) GetGithubAccessRules() ([]*model.GithubAccessRule, error) {
+	rules := []*model.GithubAccessRule{}
+	err := s.selectBuilder(s.db, &rules,
+		githubAccessRuleSelect.From(s.getGithubAccessRuleTable()).OrderBy("create_at ASC", "id ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get github access rules")
+	}
+
+	return rules, nil
+}
+
+// UpdateGithubAccessRule updates the given GitHub access rule.
+func (s *SqlGithubAccessStore) UpdateGithubAccessRule(rule *model.GithubAccessRule) error {
+	rule.PreSave()
+	if err := rule.IsValid(); err != nil {
+		return err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Update(s.getGithubAccessRuleTable()).
+		SetMap(map[string]interface{}{
+			"org":       rule.Org,
+			"team":      rule.Team,
+			"role_id":   rule.RoleID,
+			"repos":     rule.Repos,
+			"update_at": rule.UpdateAt,
+		}).
+		Where(sq.Eq{"id": rule.ID}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update github access rule")
+	}
+
+	return nil
+}
+
+// DeleteGithubAccessRule deletes the given GitHub access rule.
+func (s *SqlGithubAccessStore) DeleteGithubAccessRule(id string) error {
+	_, err := s.execBuilder(s.db, sq.
+		Delete(s.getGithubAccessRuleTable()).
+		Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete github access rule")
+	}
+
+	return nil
+}
+
+// GetGithubMembership fetches the GitHub membership of the given user, or nil
+// if it was never synced.
+func (s *SqlGithubAccessStore) GetGithubMembership(userID string) (*model.GithubMembership, error) {
+	var membership model.GithubMembership
+	err := s.getBuilder(s.db, &membership,
+		githubMembershipSelect.From(s.getGithubMembershipTable()).Where(sq.Eq{"user_id": userID}),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get github membership")
+	}
+
+	return &membership, nil
+}
+
+// SaveGithubMembership inserts or updates the GitHub membership of a user.
+func (s *SqlGithubAccessStore) SaveGithubMembership(membership *model.GithubMembership) error {
+	if membership.Groups == nil {
+		membership.Groups = model.StringList{}
+	}
+	if membership.Repos == nil {
+		membership.Repos = model.StringList{}
+	}
+	membership.SyncAt = model.GetMillis()
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getGithubMembershipTable()).
+		SetMap(map[string]interface{}{
+			"user_id":   membership.UserID,
+			"member_of": membership.Groups,
+			"allowed":   membership.Allowed,
+			"repos":     membership.Repos,
+			"sync_at":   membership.SyncAt,
+		}).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			member_of = EXCLUDED.member_of,
+			allowed = EXCLUDED.allowed,
+			repos = EXCLUDED.repos,
+			sync_at = EXCLUDED.sync_at`),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to save github membership")
+	}
+
+	return nil
+}
+
+// DeleteGithubMembership deletes the GitHub membership of the given user.
+func (s *SqlGithubAccessStore) DeleteGithubMembership(userID string) error {
+	_, err := s.execBuilder(s.db, sq.
+		Delete(s.getGithubMembershipTable()).
+		Where(sq.Eq{"user_id": userID}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete github membership")
+	}
+
+	return nil
+}
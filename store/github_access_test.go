@@ -0,0 +1,109 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGithubAccessRules(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown rule", func(t *testing.T) {
+		rule, err := th.SqlStore.GithubAccess().GetGithubAccessRule(model.NewID())
+		require.NoError(t, err)
+		assert.Nil(t, rule)
+	})
+
+	rule, err := th.SqlStore.GithubAccess().CreateGithubAccessRule(&model.GithubAccessRule{
+		Org:   "Acme",
+		Team:  "Core",
+		Repos: model.StringList{"webapp"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", rule.Org)
+	assert.Equal(t, "core", rule.Team)
+
+	t.Run("get rules", func(t *testing.T) {
+		stored, err := th.SqlStore.GithubAccess().GetGithubAccessRule(rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rule, stored)
+
+		rules, err := th.SqlStore.GithubAccess().GetGithubAccessRules()
+		require.NoError(t, err)
+		assert.Equal(t, []*model.GithubAccessRule{rule}, rules)
+	})
+
+	t.Run("update rule", func(t *testing.T) {
+		rule.Team = ""
+		rule.Repos = model.StringList{}
+		err := th.SqlStore.GithubAccess().UpdateGithubAccessRule(rule)
+		require.NoError(t, err)
+
+		stored, err := th.SqlStore.GithubAccess().GetGithubAccessRule(rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rule, stored)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		_, err := th.SqlStore.GithubAccess().CreateGithubAccessRule(&model.GithubAccessRule{Org: ""})
+		require.Error(t, err)
+	})
+
+	t.Run("delete rule", func(t *testing.T) {
+		err := th.SqlStore.GithubAccess().DeleteGithubAccessRule(rule.ID)
+		require.NoError(t, err)
+
+		stored, err := th.SqlStore.GithubAccess().GetGithubAccessRule(rule.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+}
+
+func TestGithubMemberships(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	userID := model.NewID()
+
+	membership, err := th.SqlStore.GithubAccess().GetGithubMembership(userID)
+	require.NoError(t, err)
+	assert.Nil(t, membership)
+
+	err = th.SqlStore.GithubAccess().SaveGithubMembership(&model.GithubMembership{
+		UserID:  userID,
+		Groups:  model.StringList{"acme", "acme/core"},
+		Allowed: true,
+	})
+	require.NoError(t, err)
+
+	membership, err = th.SqlStore.GithubAccess().GetGithubMembership(userID)
+	require.NoError(t, err)
+	require.NotNil(t, membership)
+	assert.Equal(t, model.StringList{"acme", "acme/core"}, membership.Groups)
+	assert.True(t, membership.Allowed)
+	assert.Empty(t, membership.Repos)
+	assert.NotZero(t, membership.SyncAt)
+
+	err = th.SqlStore.GithubAccess().SaveGithubMembership(&model.GithubMembership{
+		UserID: userID,
+		Repos:  model.StringList{"webapp"},
+	})
+	require.NoError(t, err)
+
+	membership, err = th.SqlStore.GithubAccess().GetGithubMembership(userID)
+	require.NoError(t, err)
+	assert.Empty(t, membership.Groups)
+	assert.False(t, membership.Allowed)
+	assert.Equal(t, model.StringList{"webapp"}, membership.Repos)
+
+	err = th.SqlStore.GithubAccess().DeleteGithubMembership(userID)
+	require.NoError(t, err)
+
+	membership, err = th.SqlStore.GithubAccess().GetGithubMembership(userID)
+	require.NoError(t, err)
+	assert.Nil(t, membership)
+}
@@ -41,3 +41,9 @@ func isUniqueConstraintError(err error, indexName []string) bool {
 
 	return unique && field
 }
+
+// escapeLikeTerm escapes the characters having a special meaning in LIKE
+// patterns.
+func escapeLikeTerm(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
@@ -6,6 +6,10 @@ import (
 
 // Initialize imports initial values
 func (s *SqlStore) Initialize() error {
+	if err := s.initializeTokenHashKey(); err != nil {
+		return err
+	}
+
 	roleNames := []string{model.AdminRoleName, model.UserRoleName}
 	for _, name := range roleNames {
 		if err := s.createRoleIfNotExists(name); err != nil {
@@ -13,7 +17,7 @@ func (s *SqlStore) Initialize() error {
 		}
 	}
 
-	return nil
+	return s.initializeRolePermissions()
 }
 
 func (s *SqlStore) createRoleIfNotExists(name string) error {
@@ -34,3 +38,32 @@ func (s *SqlStore) createRoleIfNotExists(name string) error {
 
 	return nil
 }
+
+// initializeRolePermissions grants every permission to the admin role and
+// seeds the user role with its defaults the first time it is initialized, so
+// permissions removed from it by an admin are not granted back on restart.
+func (s *SqlStore) initializeRolePermissions() error {
+	adminRole, err := s.Role().GetRoleByName(model.AdminRoleName)
+	if err != nil {
+		return err
+	}
+	for _, permission := range model.Permissions {
+		if err = s.Role().AddRolePermission(adminRole.ID, permission); err != nil {
+			return err
+		}
+	}
+
+	userRole, err := s.Role().GetRoleByName(model.UserRoleName)
+	if err != nil {
+		return err
+	}
+	if len(userRole.Permissions) == 0 {
+		for _, permission := range model.DefaultUserRolePermissions {
+			if err = s.Role().AddRolePermission(userRole.ID, permission); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
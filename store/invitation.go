@@ -0,0 +1,135 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlInvitationStore struct {
+	*SqlStore
+}
+
+func newSqlInvitationStore(sqlStore *SqlStore) InvitationStore {
+	s := &SqlInvitationStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) Invitation() InvitationStore {
+	return s.stores.invitation
+}
+
+var invitationSelect sq.SelectBuilder
+
+func init() {
+	invitationSelect = sq.
+		Select(
+			"id",
+			"email",
+			"role_ids",
+			"inviter_id",
+			"create_at",
+			"expires_at",
+		)
+}
+
+func (s *SqlInvitationStore) getInvitationTable() string {
+	return s.tablePrefix + "invitation"
+}
+
+// CreateInvitation inserts a new invitation. Its PreSave must have been
+// called.
+func (s *SqlInvitationStore) CreateInvitation(invitation *model.Invitation) (*model.Invitation, error) {
+	if err := invitation.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getInvitationTable()).
+		SetMap(map[string]interface{}{
+			"id":         invitation.ID,
+			"email":      invitation.Email,
+			"role_ids":   invitation.RoleIDs,
+			"inviter_id": invitation.InviterID,
+			"create_at":  invitation.CreateAt,
+			"expires_at": invitation.ExpiresAt,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create invitation")
+	}
+
+	return invitation, nil
+}
+
+// GetInvitation fetches the given invitation by id.
+func (s *SqlInvitationStore) GetInvitation(id string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := s.getBuilder(
+		s.db,
+		&invitation,
+		invitationSelect.From(s.getInvitationTable()).Where("id = ?", id),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get invitation")
+	}
+
+	return &invitation, nil
+}
+
+// GetInvitationsByEmail fetches the invitations sent to the given email,
+// newest first, expired ones included.
+func (s *SqlInvitationStore) GetInvitationsByEmail(email string) ([]*model.Invitation, error) {
+	invitations := []*model.Invitation{}
+	err := s.selectBuilder(
+		s.db,
+		&invitations,
+		invitationSelect.From(s.getInvitationTable()).
+			Where("email = ?", email).
+			OrderBy("create_at DESC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get invitations by email")
+	}
+
+	return invitations, nil
+}
+
+// GetInvitations fetches the invitations that didn't expire at the given
+// time, newest first.
+func (s *SqlInvitationStore) GetInvitations(now int64) ([]*model.Invitation, error) {
+	invitations := []*model.Invitation{}
+	err := s.selectBuilder(
+		s.db,
+		&invitations,
+		invitationSelect.From(s.getInvitationTable()).
+			Where("expires_at >= ?", now).
+			OrderBy("create_at DESC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get invitations")
+	}
+
+	return invitations, nil
+}
+
+// DeleteInvitation deletes an invitation.
+func (s *SqlInvitationStore) DeleteInvitation(id string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Delete("").From(s.getInvitationTable()).Where("id = ?", id),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete invitation")
+	}
+
+	return nil
+}
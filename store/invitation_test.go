@@ -0,0 +1,64 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestInvitations(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown invitation", func(t *testing.T) {
+		invitation, err := th.SqlStore.Invitation().GetInvitation(model.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, invitation)
+	})
+
+	t.Run("create invalid invitation", func(t *testing.T) {
+		invitation := &model.Invitation{Email: "junk"}
+		invitation.PreSave()
+
+		_, err := th.SqlStore.Invitation().CreateInvitation(invitation)
+		require.Error(t, err)
+	})
+
+	t.Run("create, get and delete invitation", func(t *testing.T) {
+		invitation := &model.Invitation{
+			Email:     "Invited" + model.NewID() + "@example.com",
+			RoleIDs:   model.StringList{model.NewID()},
+			InviterID: model.NewID(),
+		}
+		invitation.PreSave()
+
+		_, err := th.SqlStore.Invitation().CreateInvitation(invitation)
+		require.NoError(t, err)
+
+		saved, err := th.SqlStore.Invitation().GetInvitation(invitation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, invitation, saved)
+
+		invitations, err := th.SqlStore.Invitation().GetInvitationsByEmail(invitation.Email)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Invitation{invitation}, invitations)
+
+		invitations, err = th.SqlStore.Invitation().GetInvitations(model.GetMillis())
+		require.NoError(t, err)
+		assert.Contains(t, invitations, invitation)
+
+		invitations, err = th.SqlStore.Invitation().GetInvitations(invitation.ExpiresAt + 1)
+		require.NoError(t, err)
+		assert.NotContains(t, invitations, invitation)
+
+		err = th.SqlStore.Invitation().DeleteInvitation(invitation.ID)
+		require.NoError(t, err)
+
+		saved, err = th.SqlStore.Invitation().GetInvitation(invitation.ID)
+		require.NoError(t, err)
+		assert.Nil(t, saved)
+	})
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlLoginAttemptStore struct {
+	*SqlStore
+}
+
+func newSqlLoginAttemptStore(sqlStore *SqlStore) LoginAttemptStore {
+	s := &SqlLoginAttemptStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) LoginAttempt() LoginAttemptStore {
+	return s.stores.loginAttempt
+}
+
+var loginAttemptSelect sq.SelectBuilder
+
+func init() {
+	loginAttemptSelect = sq.
+		Select(
+			"action",
+			"scope",
+			"subject",
+			"failures",
+			"last_failure_at",
+			"locked_until",
+		)
+}
+
+func (s *SqlLoginAttemptStore) getLoginAttemptTable() string {
+	return s.tablePrefix + "login_attempt"
+}
+
+// GetLoginAttempts fetches the failed attempts of an action against an
+// account or from an IP address, or nil if there are none.
+func (s *SqlLoginAttemptStore) GetLoginAttempts(action, scope, subject string) (*model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	err := s.getBuilder(s.db, &attempts,
+		loginAttemptSelect.From(s.getLoginAttemptTable()).
+			Where(sq.Eq{"action": action, "scope": scope, "subject": subject}),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get login attempts")
+	}
+
+	return &attempts, nil
+}
+
+// RecordLoginFailure counts a failed attempt of an action at the given time
+// and returns the updated attempts. Failures older than the given window are
+// forgotten. The count is updated in a single statement so that concurrent
+// servers don't lose failures.
+func (s *SqlLoginAttemptStore) RecordLoginFailure(action, scope, subject string, now, window int64) (*model.LoginAttempts, error) {
+	table := s.getLoginAttemptTable()
+
+	var attempts model.LoginAttempts
+	err := s.getBuilder(s.db, &attempts, sq.
+		Insert(table).
+		SetMap(map[string]interface{}{
+			"action":          action,
+			"scope":           scope,
+			"subject":         subject,
+			"failures":        1,
+			"last_failure_at": now,
+			"locked_until":    0,
+		}).
+		Suffix(fmt.Sprintf(`ON CONFLICT (action, scope, subject) DO UPDATE SET
+			failures = CASE WHEN %[1]s.last_failure_at < ? THEN 1 ELSE %[1]s.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+			RETURNING action, scope, subject, failures, last_failure_at, locked_until`, table), now-window),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to record login failure")
+	}
+
+	return &attempts, nil
+}
+
+// LockLoginAttempts locks out an action until the given time, unless it is
+// already locked out for longer.
+func (s *SqlLoginAttemptStore) LockLoginAttempts(action, scope, subject string, lockedUntil int64) error {
+	_, err := s.execBuilder(s.db, sq.
+		Update(s.getLoginAttemptTable()).
+		Set("locked_until", sq.Expr("GREATEST(locked_until, ?)", lockedUntil)).
+		Where(sq.Eq{"action": action, "scope": scope, "subject": subject}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock login attempts")
+	}
+
+	return nil
+}
+
+// GetLockedLoginAttempts fetches the attempts that are locked out at the
+// given time, the latest first.
+func (s *SqlLoginAttemptStore) GetLockedLoginAttempts(now int64) ([]*model.LoginAttempts, error) {
+	attempts := []*model.LoginAttempts{}
+	err := s.selectBuilder(s.db, &attempts,
+		loginAttemptSelect.From(s.getLoginAttemptTable()).
+			Where(sq.Gt{"locked_until": now}).
+			OrderBy("last_failure_at DESC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get locked login attempts")
+	}
+
+	return attempts, nil
+}
+
+// DeleteLoginAttempts forgets the failed attempts of an action against an
+// account or from an IP address.
+func (s *SqlLoginAttemptStore) DeleteLoginAttempts(action, scope, subject string) error {
+	_, err := s.execBuilder(s.db, sq.
+		Delete(s.getLoginAttemptTable()).
+		Where(sq.Eq{"action": action, "scope": scope, "subject": subject}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete login attempts")
+	}
+
+	return nil
+}
+
+// DeleteLoginAttemptsForSubject forgets the failed attempts of every action
+// against an account or from an IP address.
+func (s *SqlLoginAttemptStore) DeleteLoginAttemptsForSubject(scope, subject string) error {
+	_, err := s.execBuilder(s.db, sq.
+		Delete(s.getLoginAttemptTable()).
+		Where(sq.Eq{"scope": scope, "subject": subject}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete login attempts")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestLoginAttempts(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	action := model.LoginAttemptActionLogin
+	scope := model.LoginAttemptScopeAccount
+
+	t.Run("get unknown attempts", func(t *testing.T) {
+		attempts, err := th.SqlStore.LoginAttempt().GetLoginAttempts(action, scope, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, attempts)
+	})
+
+	t.Run("record failures within the window", func(t *testing.T) {
+		subject := model.NewID()
+
+		attempts, err := th.SqlStore.LoginAttempt().RecordLoginFailure(action, scope, subject, 1000, 500)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+		assert.Equal(t, int64(1000), attempts.LastFailureAt)
+
+		attempts, err = th.SqlStore.LoginAttempt().RecordLoginFailure(action, scope, subject, 1200, 500)
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts.Failures)
+		assert.Equal(t, int64(1200), attempts.LastFailureAt)
+
+		// Failures of other actions and scopes are counted apart.
+		attempts, err = th.SqlStore.LoginAttempt().RecordLoginFailure(model.LoginAttemptActionVerifyEmail, scope, subject, 1200, 500)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+		attempts, err = th.SqlStore.LoginAttempt().RecordLoginFailure(action, model.LoginAttemptScopeIP, subject, 1200, 500)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+
+		// Failures past the window are forgotten.
+		attempts, err = th.SqlStore.LoginAttempt().RecordLoginFailure(action, scope, subject, 2000, 500)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+
+		attempts, err = th.SqlStore.LoginAttempt().GetLoginAttempts(action, scope, subject)
+		require.NoError(t, err)
+		require.NotNil(t, attempts)
+		assert.Equal(t, 1, attempts.Failures)
+		assert.Equal(t, int64(2000), attempts.LastFailureAt)
+	})
+
+	t.Run("lock, list and delete attempts", func(t *testing.T) {
+		subject := model.NewID()
+		now := model.GetMillis()
+
+		_, err := th.SqlStore.LoginAttempt().RecordLoginFailure(action, scope, subject, now, 1000)
+		require.NoError(t, err)
+		_, err = th.SqlStore.LoginAttempt().RecordLoginFailure(model.LoginAttemptActionResetPassword, scope, subject, now, 1000)
+		require.NoError(t, err)
+
+		err = th.SqlStore.LoginAttempt().LockLoginAttempts(action, scope, subject, now+1000)
+		require.NoError(t, err)
+		// A shorter lock doesn't shorten the current one.
+		err = th.SqlStore.LoginAttempt().LockLoginAttempts(action, scope, subject, now+500)
+		require.NoError(t, err)
+
+		attempts, err := th.SqlStore.LoginAttempt().GetLoginAttempts(action, scope, subject)
+		require.NoError(t, err)
+		assert.Equal(t, now+1000, attempts.LockedUntil)
+
+		locked, err := th.SqlStore.LoginAttempt().GetLockedLoginAttempts(now)
+		require.NoError(t, err)
+		found := false
+		for _, l := range locked {
+			assert.Greater(t, l.LockedUntil, now)
+			if l.Subject == subject {
+				found = true
+				assert.Equal(t, action, l.Action)
+			}
+		}
+		assert.True(t, found)
+
+		err = th.SqlStore.LoginAttempt().DeleteLoginAttempts(action, scope, subject)
+		require.NoError(t, err)
+		attempts, err = th.SqlStore.LoginAttempt().GetLoginAttempts(action, scope, subject)
+		require.NoError(t, err)
+		assert.Nil(t, attempts)
+		attempts, err = th.SqlStore.LoginAttempt().GetLoginAttempts(model.LoginAttemptActionResetPassword, scope, subject)
+		require.NoError(t, err)
+		assert.NotNil(t, attempts)
+
+		err = th.SqlStore.LoginAttempt().DeleteLoginAttemptsForSubject(scope, subject)
+		require.NoError(t, err)
+		attempts, err = th.SqlStore.LoginAttempt().GetLoginAttempts(model.LoginAttemptActionResetPassword, scope, subject)
+		require.NoError(t, err)
+		assert.Nil(t, attempts)
+	})
+}
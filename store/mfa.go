@@ -0,0 +1,219 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlMFAStore struct {
+	*SqlStore
+}
+
+func newSqlMFAStore(sqlStore *SqlStore) MFAStore {
+	s := &SqlMFAStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) MFA() MFAStore {
+	return s.stores.mfa
+}
+
+var userMFASelect sq.SelectBuilder
+
+func init() {
+	userMFASelect = sq.
+		Select(
+			"user_id",
+			"secret",
+			"enabled",
+			"last_step",
+			"create_at",
+			"update_at",
+		)
+}
+
+func (s *SqlMFAStore) getUserMFATable() string {
+	return s.tablePrefix + "user_mfa"
+}
+
+func (s *SqlMFAStore) getMFARecoveryCodeTable() string {
+	return s.tablePrefix + "mfa_recovery_code"
+}
+
+// GetUserMFA fetches the authenticator enrolled by the given user, or nil if
+// there is none. The secret is decrypted.
+func (s *SqlMFAStore) GetUserMFA(userID string) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	err := s.getBuilder(s.db, &mfa,
+		userMFASelect.From(s.getUserMFATable()).Where(sq.Eq{"user_id": userID}),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get user mfa")
+	}
+
+	secret, err := s.decryptSecret(mfa.Secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt mfa secret")
+	}
+	mfa.Secret = string(secret)
+
+	return &mfa, nil
+}
+
+// SaveUserMFA inserts or updates the authenticator of a user, encrypting its
+// secret.
+func (s *SqlMFAStore) SaveUserMFA(mfa *model.UserMFA) error {
+	mfa.PreSave()
+
+	secret, err := s.encryptSecret([]byte(mfa.Secret))
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt mfa secret")
+	}
+
+	_, err = s.execBuilder(s.db, sq.
+		Insert(s.getUserMFATable()).
+		SetMap(map[string]interface{}{
+			"user_id":   mfa.UserID,
+			"secret":    secret,
+			"enabled":   mfa.Enabled,
+			"last_step": mfa.LastStep,
+			"create_at": mfa.CreateAt,
+			"update_at": mfa.UpdateAt,
+		}).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			enabled = EXCLUDED.enabled,
+			last_step = EXCLUDED.last_step,
+			update_at = EXCLUDED.update_at`),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to save user mfa")
+	}
+
+	return nil
+}
+
+// UseMFAStep records that the code of the given time step was used, returning
+// false if a code of the same or a later step was already used.
+func (s *SqlMFAStore) UseMFAStep(userID string, step int64) (bool, error) {
+	result, err := s.execBuilder(s.db, sq.
+		Update(s.getUserMFATable()).
+		Set("last_step", step).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Lt{"last_step": step}),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to use mfa step")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to use mfa step")
+	}
+
+	return rows == 1, nil
+}
+
+// DeleteUserMFA deletes the authenticator and the recovery codes of the given
+// user.
+func (s *SqlMFAStore) DeleteUserMFA(userID string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{s.getUserMFATable(), s.getMFARecoveryCodeTable()} {
+		_, err = s.execBuilder(tx, sq.
+			Delete(table).
+			Where(sq.Eq{"user_id": userID}),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete user mfa")
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// SetMFARecoveryCodes replaces the recovery codes of the given user with the
+// given hashes.
+func (s *SqlMFAStore) SetMFARecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = s.execBuilder(tx, sq.
+		Delete(s.getMFARecoveryCodeTable()).
+		Where(sq.Eq{"user_id": userID}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete mfa recovery codes")
+	}
+
+	if len(codeHashes) > 0 {
+		insert := sq.Insert(s.getMFARecoveryCodeTable()).Columns("user_id", "code_hash")
+		for _, codeHash := range codeHashes {
+			insert = insert.Values(userID, codeHash)
+		}
+		if _, err = s.execBuilder(tx, insert); err != nil {
+			return errors.Wrap(err, "failed to create mfa recovery codes")
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// UseMFARecoveryCode deletes the recovery code of the given hash, returning
+// false if the user has no such code.
+func (s *SqlMFAStore) UseMFARecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := s.execBuilder(s.db, sq.
+		Delete(s.getMFARecoveryCodeTable()).
+		Where(sq.Eq{"user_id": userID, "code_hash": codeHash}),
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to use mfa recovery code")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to use mfa recovery code")
+	}
+
+	return rows == 1, nil
+}
+
+// CountMFARecoveryCodes returns the number of unused recovery codes of the
+// given user.
+func (s *SqlMFAStore) CountMFARecoveryCodes(userID string) (int, error) {
+	var count int
+	err := s.getBuilder(s.db, &count, sq.
+		Select("COUNT(*)").
+		From(s.getMFARecoveryCodeTable()).
+		Where(sq.Eq{"user_id": userID}),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count mfa recovery codes")
+	}
+
+	return count, nil
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserMFA(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	userID := model.NewID()
+
+	mfa, err := th.SqlStore.MFA().GetUserMFA(userID)
+	require.NoError(t, err)
+	assert.Nil(t, mfa)
+
+	err = th.SqlStore.MFA().SaveUserMFA(&model.UserMFA{UserID: userID, Secret: "SECRET"})
+	require.NoError(t, err)
+
+	t.Run("secret is encrypted at rest", func(t *testing.T) {
+		var secret string
+		err := th.SqlStore.get(th.SqlStore.db, &secret, "SELECT secret FROM "+th.SqlStore.tablePrefix+"user_mfa WHERE user_id = ?", userID)
+		require.NoError(t, err)
+		assert.NotContains(t, secret, "SECRET")
+
+		mfa, err := th.SqlStore.MFA().GetUserMFA(userID)
+		require.NoError(t, err)
+		assert.Equal(t, "SECRET", mfa.Secret)
+		assert.False(t, mfa.Enabled)
+	})
+
+	t.Run("steps can only be used once", func(t *testing.T) {
+		used, err := th.SqlStore.MFA().UseMFAStep(userID, 10)
+		require.NoError(t, err)
+		assert.True(t, used)
+
+		used, err = th.SqlStore.MFA().UseMFAStep(userID, 10)
+		require.NoError(t, err)
+		assert.False(t, used)
+
+		used, err = th.SqlStore.MFA().UseMFAStep(userID, 9)
+		require.NoError(t, err)
+		assert.False(t, used)
+
+		mfa, err := th.SqlStore.MFA().GetUserMFA(userID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 10, mfa.LastStep)
+	})
+
+	t.Run("recovery codes", func(t *testing.T) {
+		err := th.SqlStore.MFA().SetMFARecoveryCodes(userID, []string{model.HashMFARecoveryCode("a"), model.HashMFARecoveryCode("b")})
+		require.NoError(t, err)
+
+		count, err := th.SqlStore.MFA().CountMFARecoveryCodes(userID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		used, err := th.SqlStore.MFA().UseMFARecoveryCode(userID, model.HashMFARecoveryCode("a"))
+		require.NoError(t, err)
+		assert.True(t, used)
+
+		used, err = th.SqlStore.MFA().UseMFARecoveryCode(userID, model.HashMFARecoveryCode("a"))
+		require.NoError(t, err)
+		assert.False(t, used)
+
+		count, err = th.SqlStore.MFA().CountMFARecoveryCodes(userID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := th.SqlStore.MFA().DeleteUserMFA(userID)
+		require.NoError(t, err)
+
+		mfa, err := th.SqlStore.MFA().GetUserMFA(userID)
+		require.NoError(t, err)
+		assert.Nil(t, mfa)
+
+		count, err := th.SqlStore.MFA().CountMFARecoveryCodes(userID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
@@ -61,6 +61,348 @@ func _000002_cycle_up_sql() ([]byte, error) {
 	)
 }
 
+var __000003_user_locale_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3a\x00\xc5\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x73\x65\x72\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6c\x6f\x63\x61\x6c\x65\x3b\x0a\x03\x00\x3a\xfd\x58\xe6\x3a\x00\x00\x00")
+
+func _000003_user_locale_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000003_user_locale_down_sql,
+		"000003_user_locale.down.sql",
+	)
+}
+
+var __000003_user_locale_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5f\x00\xa0\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x73\x65\x72\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x6c\x6f\x63\x61\x6c\x65\x20\x56\x41\x52\x43\x48\x41\x52\x28\x33\x32\x29\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x65\x6e\x27\x3b\x0a\x03\x00\x50\xcd\xe0\xa7\x5f\x00\x00\x00")
+
+func _000003_user_locale_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000003_user_locale_up_sql,
+		"000003_user_locale.up.sql",
+	)
+}
+
+var __000004_notification_preferences_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3a\x00\xc5\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x6e\x6f\x74\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x5f\x70\x72\x65\x66\x65\x72\x65\x6e\x63\x65\x73\x3b\x0a\x03\x00\xc5\xfd\x99\xfd\x3a\x00\x00\x00")
+
+func _000004_notification_preferences_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000004_notification_preferences_down_sql,
+		"000004_notification_preferences.down.sql",
+	)
+}
+
+var __000004_notification_preferences_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xcf\xc1\x4a\x03\x31\x10\xc6\xf1\xfb\x3e\xc5\x77\x6b\x0b\xe2\xc1\x83\x17\x4f\x69\x9d\x6a\x30\xee\x4a\x9a\x85\x16\x91\x25\x6e\xa7\x18\xd0\x6c\x48\x52\x11\x4a\xdf\x5d\xd2\x3d\x78\xf0\xb2\xb7\x3f\xc3\x8f\x19\x66\xa5\x49\x18\x82\x11\x4b\x45\x90\x6b\xd4\x8d\x01\x6d\xe5\xc6\x6c\x70\x3a\x5d\x87\xc8\x07\xf7\x73\x3e\xfb\x21\xbb\x83\xeb\x6d\x76\x83\xef\xca\x90\x23\xfb\x9e\x13\xe6\x15\x00\x1c\x13\xc7\xce\xed\x4b\x62\xf5\x28\xf4\xfc\xe6\x76\x81\x17\x2d\x9f\x85\xde\xe1\x89\x76\x57\x17\xc5\x5f\xd6\x7d\x96\x00\xb0\x6c\x1a\x45\xa2\xbe\x9c\xab\x5b\xa5\x70\x4f\x6b\xd1\x2a\x03\xa3\x5b\x1a\xb9\xf3\x9d\x0d\x61\x32\xe7\x6f\xf6\x39\x8d\xdc\xd0\xd6\xfc\xb7\xb3\xd7\xb7\xd9\xb8\x3a\x72\x18\x12\xa6\xd9\xf7\x68\x7d\xff\xc1\x69\x8a\x3d\x86\xbd\xcd\xdc\xd9\x5c\x3e\x94\x0f\xb2\xfe\xd3\xd5\xe2\xae\xfa\x1d\x00\x19\x57\x6a\x59\x6c\x01\x00\x00")
+
+func _000004_notification_preferences_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000004_notification_preferences_up_sql,
+		"000004_notification_preferences.up.sql",
+	)
+}
+
+var __000005_api_key_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x29\x00\xd6\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x61\x70\x69\x5f\x6b\x65\x79\x3b\x0a\x03\x00\xce\x1e\xa6\x76\x29\x00\x00\x00")
+
+func _000005_api_key_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000005_api_key_down_sql,
+		"000005_api_key.down.sql",
+	)
+}
+
+var __000005_api_key_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xd0\x4f\x4f\x02\x31\x10\x05\xf0\xfb\x7e\x8a\x77\x83\x4d\x8c\x31\x68\xb8\x78\x2a\x58\xb4\x71\x5d\xb5\x74\x8d\x9c\x9a\x86\x1d\x43\x03\x4a\xb3\x5d\x13\x08\xe1\xbb\x1b\x5b\xc0\x50\xff\xc4\xf6\xfa\x9b\xf7\x26\x33\x94\x9c\x29\x0e\xc5\x06\x05\x87\x18\xa1\xbc\x57\xe0\xcf\x62\xac\xc6\xd8\x6c\x4e\x5d\x43\x2f\x76\xb5\xdd\x1a\x67\xf5\x9c\xd6\xe8\x66\x00\x60\x6b\x1c\xbd\xe1\x0d\x93\xdd\x5e\x3f\xc7\x83\x14\x77\x4c\x4e\x70\xcb\x27\x27\x41\xbe\x99\x57\xda\xab\xcf\xff\xc4\x64\xc0\xfd\x8b\x3c\x34\x95\x55\x51\x44\xe9\xa7\x4b\x47\x3f\xc8\xf3\x5e\x2a\xe7\xb4\xd6\x33\xe3\x67\x7b\xf9\x2d\x10\x55\x29\x1e\x2b\x1e\xf5\xb4\x21\xd3\x2e\x1b\x6d\xeb\x64\xd7\xe3\xd0\xc0\x48\x9b\x76\x17\x3a\x10\xd7\xa2\x54\x49\x33\xad\x9c\x6d\xc8\x1f\x54\x82\x70\xc5\x47\xac\x2a\x14\xce\x62\xe6\xc2\xf8\x56\xbf\x7b\xaa\x77\x03\xff\xe6\xd6\xfd\x76\xab\xc3\x4c\xa7\x13\x3b\x6a\x5a\xd0\x1f\x7b\x7f\x75\x64\xf9\x65\xf6\x31\x00\xfc\xd4\x47\x3a\xec\x01\x00\x00")
+
+func _000005_api_key_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000005_api_key_up_sql,
+		"000005_api_key.up.sql",
+	)
+}
+
+var __000006_personal_access_token_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x37\x00\xc8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x70\x65\x72\x73\x6f\x6e\x61\x6c\x5f\x61\x63\x63\x65\x73\x73\x5f\x74\x6f\x6b\x65\x6e\x3b\x0a\x03\x00\x4b\x64\xe9\xc7\x37\x00\x00\x00")
+
+func _000006_personal_access_token_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000006_personal_access_token_down_sql,
+		"000006_personal_access_token.down.sql",
+	)
+}
+
+var __000006_personal_access_token_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xd1\xcf\x4b\xc3\x30\x14\x07\xf0\x7b\xff\x8a\x77\x5c\x41\x44\xa6\xec\xb2\x53\xb6\x65\x1a\xac\x99\x76\xa9\x6c\xa7\x10\x9a\x27\x0b\xd6\xb6\xe4\xb5\x50\x18\xfb\xdf\x65\x6d\xd5\x59\xc5\x1f\xc9\xf5\xf3\xbe\xef\xc1\x77\x1e\x73\xa6\x38\x28\x36\x8b\x38\x88\x25\xc8\x95\x02\xbe\x11\x6b\xb5\x86\xfd\xfe\xbc\xf4\xf8\xe4\x9a\xc3\xa1\x44\x4f\x45\x6e\x32\x6d\xd2\x14\x89\x74\x55\x3c\x63\x0e\xa3\x00\x00\xc0\x59\xf8\xf4\xe6\x37\x2c\x1e\x8d\x27\x21\xdc\xc7\xe2\x8e\xc5\x5b\xb8\xe5\xdb\xb3\x56\xd6\x84\x5e\x3b\xfb\x55\x1e\x97\xca\x24\x8a\x3a\x96\x9b\x17\x7c\x23\xc7\xff\xc8\xe2\x36\x73\x72\x35\x94\x94\x16\x25\x7e\x23\x2f\xc7\x43\xd9\x1e\xac\x77\x86\x76\x27\xab\x4f\x03\x21\x91\xe2\x21\xe1\x9d\x4e\x3d\x9a\x0a\xb5\xa9\xfa\xdc\x99\xb8\x16\x52\x0d\x22\xb1\x29\x9d\x47\x7a\x57\x03\x04\x0b\xbe\x64\x49\xa4\xe0\xa2\xcb\xcc\x0c\x55\xba\x26\xb4\xfd\xc0\x2f\xdc\x62\x86\x3f\x9c\xf0\xc1\x83\x70\x1a\x04\x7d\x8b\x42\x2e\xf8\xe6\x9f\x2d\xea\xbe\x15\xed\x6c\x03\x2b\xf9\x87\xd6\x6b\x42\xaf\x9d\x0d\xa7\xc1\xeb\x00\x40\x66\xa9\xc9\x3d\x02\x00\x00")
+
+func _000006_personal_access_token_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000006_personal_access_token_up_sql,
+		"000006_personal_access_token.up.sql",
+	)
+}
+
+var __000007_role_permission_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x31\x00\xce\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x72\x6f\x6c\x65\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x3b\x0a\x03\x00\xe8\xa6\xb9\xb9\x31\x00\x00\x00")
+
+func _000007_role_permission_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000007_role_permission_down_sql,
+		"000007_role_permission.down.sql",
+	)
+}
+
+var __000007_role_permission_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\xce\x41\x4b\xc4\x30\x10\x05\xe0\x7b\x7e\xc5\x3b\x26\x52\xa4\x8a\xec\xc5\x53\x76\xcd\x6a\x30\x56\x49\x53\xb1\xa7\x52\x6a\x8a\x01\xdb\x94\x34\x60\xa1\xf4\xbf\x8b\x41\xa4\x78\x1b\x86\x6f\xe6\xbd\x93\x16\xdc\x08\x18\x7e\x54\x02\xf2\x8c\xe2\xd9\x40\xbc\xc9\xd2\x94\x58\xd7\xcb\x29\xd8\xde\x2d\xdb\x16\xfc\xa7\x6d\x26\x1b\x06\x37\xcf\xce\x8f\xa0\x04\x00\xd2\xd6\xbd\xff\x8c\x38\x3d\x70\x4d\xaf\x0f\x2c\x3d\x28\x2a\xa5\xb2\x44\x76\x37\x78\xe5\x3a\xa9\xc3\xcd\x7f\xd5\x05\xdb\x46\xdb\xb4\x11\xc0\x51\xde\xcb\xc2\xfc\x01\xdc\x89\x33\xaf\x94\x01\xb5\x4b\x0c\x6d\x17\xa9\x9d\x7c\xf7\x81\x3e\xf8\x01\xa3\xff\xa2\x8c\xe1\x02\x57\x79\x9e\xb3\x8c\xa4\xc8\x17\x2d\x9f\xb8\xae\xf1\x28\x6a\xd0\xdf\x8a\xd9\xae\x08\x23\xec\x96\x7c\x0f\x00\xd6\x63\x2e\xa5\xf7\x00\x00\x00")
+
+func _000007_role_permission_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000007_role_permission_up_sql,
+		"000007_role_permission.up.sql",
+	)
+}
+
+var __000008_user_auth_info_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x72\x00\x8d\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x6f\x61\x75\x74\x68\x73\x74\x61\x74\x65\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x73\x65\x72\x5f\x61\x75\x74\x68\x5f\x69\x6e\x66\x6f\x3b\x0a\x03\x00\x90\x76\x40\x1e\x72\x00\x00\x00")
+
+func _000008_user_auth_info_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000008_user_auth_info_down_sql,
+		"000008_user_auth_info.down.sql",
+	)
+}
+
+var __000008_user_auth_info_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\x51\x6b\xfa\x30\x14\xc5\xdf\xf3\x29\xee\x9b\x2d\x94\x3f\xff\xb9\x21\x03\x9f\xa2\xc6\x2d\x2c\xd6\x2d\xa6\x43\x9f\x4a\x58\x23\x2b\xd3\xb6\xc4\x54\x06\xe2\x77\x1f\xc6\x66\xeb\x52\xc6\x5c\x1e\xd3\xdf\x39\x27\xbd\xf7\x8c\x39\xc1\x82\x80\xc0\x23\x46\x80\x4e\x21\x9e\x0b\x20\x4b\xba\x10\x0b\x38\x1c\xfe\x55\x5a\xad\xf3\xf7\xe3\xb1\xde\x29\x9d\xca\xda\xbc\xa6\x79\xb1\x2e\x21\x40\x00\x00\xf6\x32\xcf\xa0\x7d\xc6\xf7\x98\x07\xfd\x41\x68\x7d\xe2\x84\xb1\xc8\xa2\xa5\xd5\x56\xba\xdc\xe7\x99\xd2\xa7\x1b\x80\x67\xcc\x2d\x7d\xdd\xf7\x69\xc7\xa5\xad\x04\x47\x0f\x6e\x7c\xda\x94\x6f\xaa\x70\xf9\xcd\x11\x64\x29\x3c\xec\xe4\x55\xc8\xad\x72\x48\xdb\xf4\xaa\x7f\xfb\xe5\x0a\x13\x32\xc5\x09\x13\xd0\xeb\x9d\x03\xd4\x56\xe6\x1b\x27\xf9\x93\xd2\xcf\xbb\x5c\x29\xf7\xd2\x48\x9d\xd6\x7a\xf3\xc3\x4f\x75\x14\x2f\x5a\x49\xa3\x52\x69\x1c\x0e\x00\x23\x7a\x47\xe3\xce\x20\xaa\xec\x32\x30\x53\x1b\xf5\x0b\xf8\xf9\x8a\xff\x11\xb2\xaf\x78\xe4\x74\x86\xf9\x0a\x1e\xc8\x0a\x82\x66\x7d\x91\xb7\xfe\xf0\x6c\x9f\xc4\xf4\x29\x21\x10\x7c\xff\x18\x75\xb6\x1f\xa2\x70\x88\x10\x66\x82\xf0\xa6\xa6\xad\x62\x5a\xf1\xce\x48\xa3\x00\x4f\x26\x30\x9e\xb3\x64\x16\x7b\x3d\x6e\x7c\xba\xdd\x6c\xcd\x70\x88\x3e\x06\x00\x0b\xcc\xf6\xb2\x09\x03\x00\x00")
+
+func _000008_user_auth_info_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000008_user_auth_info_up_sql,
+		"000008_user_auth_info.up.sql",
+	)
+}
+
+var __000009_oidc_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xa8\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\x2d\x2d\x4e\x2d\x8a\x4f\x2c\x2d\xc9\x88\xcf\xcc\x4b\xcb\x57\x80\xa8\x72\xf6\xf7\x09\xf5\xf5\x53\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x8a\x07\x2b\xca\x4c\x51\x08\x89\x0c\x70\x55\x08\x73\x0c\x72\xf6\x70\x0c\xd2\x30\x33\xd1\xb4\xe6\xe2\xc2\x61\x6c\x3e\xc8\xc8\xe2\x92\xc4\x92\x54\x05\x97\x20\xff\x00\x98\x89\x9e\x6e\x0a\xae\x11\x9e\xc1\x21\xc1\x0a\xc9\xf9\x29\xa9\xf1\x65\xa9\x45\x99\x69\x99\xa9\x45\xd6\x64\x1b\x93\x97\x9f\x97\x9c\x6a\xcd\x05\x18\x00\xab\x06\x6c\xb9\xdd\x00\x00\x00")
+
+func _000009_oidc_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000009_oidc_down_sql,
+		"000009_oidc.down.sql",
+	)
+}
+
+var __000009_oidc_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\xcd\x3f\x0b\x82\x40\x18\x80\xf1\xdd\x4f\xf1\x6e\xe6\x12\x24\x19\x81\xd3\xa5\x27\x09\x97\x86\x9e\x51\xd3\x21\xfa\x4a\xb7\x78\x72\xfe\x21\x10\xbf\x7b\x64\xe1\xe6\xd4\xfc\x3c\xf0\x23\x8c\xd3\x04\x38\x39\x31\x0a\xe3\xb8\x6d\x34\x56\xf2\x35\x4d\x2a\xef\xbb\x67\xdb\xe5\x1d\x02\xf1\x7d\xf0\x62\x96\x5d\x22\x08\x03\x88\x62\x0e\xf4\x1e\xa6\x3c\x85\x5a\xd5\x05\xc2\x8d\x24\xde\x99\x24\x9b\xc3\xde\x9a\x63\x94\x31\x06\x3e\x0d\x48\xc6\x38\x98\xa6\x6b\xfc\x21\x14\xaa\x44\x31\xa0\x96\x95\x44\xbd\x48\x3b\xfb\xb8\x42\xad\x59\x7d\x8b\x5a\x7c\x3c\x21\xeb\x4a\xc1\xf7\xfa\x89\x8d\x56\x83\x2c\x51\x8b\x79\x92\x25\xf0\xc7\x95\x2e\x96\xed\x38\x96\x6b\xbc\x07\x00\x26\xf8\xd8\x10\x25\x01\x00\x00")
+
+func _000009_oidc_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000009_oidc_up_sql,
+		"000009_oidc.up.sql",
+	)
+}
+
+var __000010_oauthstate_provider_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x42\x00\xbd\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x6f\x61\x75\x74\x68\x73\x74\x61\x74\x65\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x3b\x0a\x03\x00\x17\xfa\xaf\x6b\x42\x00\x00\x00")
+
+func _000010_oauthstate_provider_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000010_oauthstate_provider_down_sql,
+		"000010_oauthstate_provider.down.sql",
+	)
+}
+
+var __000010_oauthstate_provider_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x65\x00\x9a\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x6f\x61\x75\x74\x68\x73\x74\x61\x74\x65\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x20\x56\x41\x52\x43\x48\x41\x52\x28\x33\x32\x29\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x27\x3b\x0a\x03\x00\x44\xab\x03\xb9\x65\x00\x00\x00")
+
+func _000010_oauthstate_provider_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000010_oauthstate_provider_up_sql,
+		"000010_oauthstate_provider.up.sql",
+	)
+}
+
+var __000011_github_access_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x67\x00\x98\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x67\x69\x74\x68\x75\x62\x5f\x6d\x65\x6d\x62\x65\x72\x73\x68\x69\x70\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x67\x69\x74\x68\x75\x62\x5f\x61\x63\x63\x65\x73\x73\x5f\x72\x75\x6c\x65\x3b\x0a\x03\x00\xe4\x46\xc6\x04\x67\x00\x00\x00")
+
+func _000011_github_access_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000011_github_access_down_sql,
+		"000011_github_access.down.sql",
+	)
+}
+
+var __000011_github_access_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xd0\x51\x4b\xc3\x30\x10\xc0\xf1\xf7\x7e\x8a\x7b\xdb\x0a\x22\x58\xa4\x08\x3e\xa5\x33\xd3\x62\x6d\x25\x4b\x65\x43\xa4\x74\xed\xd9\x15\x5a\x13\x92\x06\x95\xb1\xef\x2e\x34\x9d\x43\xdd\x64\xcb\xf3\xef\x72\xdc\x7f\xc2\x28\xe1\x14\x38\x09\x22\x0a\xe1\x14\xe2\x84\x03\x9d\x87\x33\x3e\x83\xf5\xfa\x5c\x2a\x7c\xad\x3f\x36\x9b\xaa\xee\x56\x66\x99\xe5\x45\x81\x5a\x67\xca\x34\x08\x63\x07\x00\xa0\x2e\xe1\xfb\x4d\xee\x08\x1b\x7b\xbe\x0b\x8f\x2c\x7c\x20\x6c\x01\xf7\x74\x71\xd6\x2b\xa1\xaa\x2d\x82\x27\xc2\x7a\xe8\x5f\xba\xfd\xb2\x38\x8d\x22\xab\x3a\xcc\xdb\xdf\xea\xc2\xbb\xda\x31\xb8\xa1\x53\x92\x46\x1c\x46\x23\x3b\xa1\x44\x83\x59\x5d\xfe\x98\xf0\xfc\xff\x06\x50\x0a\x3d\xac\xe0\x74\xce\xf7\xc8\xe7\x97\xc1\x16\x0a\xf3\x0e\xb3\xbc\x03\x80\x20\xbc\x0d\xe3\x9d\xb6\xc0\xc8\xf2\x10\x70\xdc\x6b\xc7\x39\x29\x6d\x8b\xed\x12\x95\x5e\xd5\x72\x28\x6b\x34\xaa\xed\x6d\x87\xcb\x56\x4a\x18\xa9\x8f\x3b\x28\x6f\x1a\xf1\x8e\xf6\xc7\x20\x49\x22\x4a\xe2\xbf\x9c\xb3\x94\x9e\xde\x4a\x7f\xbe\x15\xb6\xd4\xde\x14\x5f\x03\x00\x45\x6a\x7e\x38\x65\x02\x00\x00")
+
+func _000011_github_access_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000011_github_access_up_sql,
+		"000011_github_access.up.sql",
+	)
+}
+
+var __000012_mfa_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xa8\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\x2d\xca\xcf\x49\x55\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\x2c\xcd\x2c\x4a\x8d\xcf\x4d\x4b\xb4\xe6\xe2\x02\xab\x81\x68\x47\x28\x41\x32\x28\x37\x2d\x31\xbe\x28\x35\x39\xbf\x2c\xb5\xa8\x32\x3e\x39\x3f\x25\x95\x08\x3d\xa5\xc5\xa9\x45\xf1\xb9\x69\x89\xd6\x5c\x80\x01\x00\xf2\x6f\x00\x54\x9e\x00\x00\x00")
+
+func _000012_mfa_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000012_mfa_down_sql,
+		"000012_mfa.down.sql",
+	)
+}
+
+var __000012_mfa_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xd1\x41\x6b\xb3\x30\x1c\x06\xf0\xbb\x9f\xe2\x39\xb6\x50\x5e\x5e\xc6\xe8\xa5\xa7\xa8\x71\x93\xa5\x3a\x34\x42\x7b\x0a\x99\xfe\xa5\x82\x9d\x2e\xea\xd8\x28\xfd\xee\xa3\xb1\xdb\xba\x96\x95\xdd\x12\xf2\xcb\x9f\x27\x4f\xbc\x84\x33\xc9\x21\x99\x2b\x38\xc2\x00\x51\x2c\xc1\x57\x61\x2a\x53\xec\x76\xff\x5a\x43\x65\xf5\xb6\xdf\x0f\x1d\x19\xb5\x2d\x35\x26\x0e\x00\xd8\x6d\x55\x1c\x96\xf0\xee\x59\x32\xb9\x99\x4f\xf1\x98\x84\x4b\x96\xac\xf1\xc0\xd7\x33\xab\x3a\xca\x0d\xf5\xb0\x4a\xf2\x95\xb4\xb3\xa3\x4c\x88\xf1\x98\x9e\xf5\x53\x4d\xe3\x10\x37\x8e\x05\x67\xd1\x97\x80\xcf\x03\x96\x09\x89\x80\x89\x94\x8f\xbe\xd6\x5d\xaf\xba\x9e\x5a\x00\x6e\x78\x17\x46\xf2\x92\xff\x1f\x69\x6e\x48\xf7\xa4\x74\x7f\x49\x47\x30\xb4\xc5\x6f\xc0\x99\x2e\x1c\xe7\x6f\xad\x6c\x4b\xad\x0c\xe5\xcd\x2b\x99\x77\x95\x37\x05\x5d\xab\xe7\x67\x80\x83\x56\x1b\xdd\x6d\x3e\xc9\xfc\xf6\x9c\x9c\xf4\x89\xc9\x71\xe4\xec\xfb\xe2\xd4\x06\x65\x42\xf2\xe4\x98\xf3\x24\x99\x69\x6a\x02\xf3\x7d\x78\xb1\xc8\x96\xd1\xd9\x13\x0c\xbd\x0c\x95\x21\xfb\xa1\xd7\x9b\x5f\x38\x1f\x03\x00\x30\x24\x0b\xde\x20\x02\x00\x00")
+
+func _000012_mfa_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000012_mfa_up_sql,
+		"000012_mfa.up.sql",
+	)
+}
+
+var __000013_session_metadata_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xa8\xae\xd6\x2b\x28\x4a\x4d\xcb\xac\xa8\xad\xcd\x4c\xa9\x88\x2f\x4e\x2d\x2e\xce\xcc\xcf\x8b\x2f\x2d\x4e\x2d\x8a\xcf\x4c\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x45\x56\x0c\x55\xa8\x00\x36\xd4\xd9\xdf\x27\xd4\xd7\x0f\xc9\xd4\x9c\xc4\xe2\x92\xf8\xc4\xe4\x92\xcc\xb2\xcc\x92\xca\xf8\xc4\x12\x6b\xf2\x8c\xc9\x2c\x88\x4f\x4c\x49\x29\x4a\x2d\x2e\x26\xd3\x00\xb0\x37\x12\xd3\x53\xf3\xc8\x75\x41\x71\x7e\x69\x51\x72\xaa\x35\x17\x60\x00\xac\x37\x54\x5f\x3c\x01\x00\x00")
+
+func _000013_session_metadata_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000013_session_metadata_down_sql,
+		"000013_session_metadata.down.sql",
+	)
+}
+
+var __000013_session_metadata_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xd0\xc1\x4a\x03\x31\x10\xc6\xf1\x7b\x9e\x62\x6e\x6d\x2f\x22\xd5\x7a\xd9\x53\xba\x49\x35\x10\xb3\x90\x66\xa5\xb7\x10\x9a\x28\x03\xd2\x96\x4c\x56\x2a\x65\xdf\x5d\x58\x64\x11\xf5\x54\xf6\x3c\xf0\xff\x0d\x1f\xd7\x4e\x5a\x70\x7c\xad\x25\x5c\x2e\x37\xa7\x9c\x5e\xf1\xdc\xf7\x94\x88\xf0\x78\x00\x2e\x04\xd4\x8d\x6e\x9f\x0d\xa8\x0d\x98\xc6\x81\xdc\xa9\xad\xdb\x02\x1d\xbb\xbc\x4f\xf0\xc2\x6d\xfd\xc4\xed\xfc\x6e\xb9\x18\xae\xa6\xd5\x1a\x84\xdc\xf0\x56\x3b\x98\xcd\x2a\x76\x6d\xbf\xa3\x94\x7d\x78\x4b\x87\x32\x1a\xcb\xd5\x6a\x62\x04\x4f\x3e\xc4\x98\x13\xd1\x88\x3c\xdc\x4f\x6c\xbc\x07\x2a\x3e\xec\x0b\x7e\x60\xf9\xf4\xa1\xc0\x5a\x3d\x2a\xe3\xfe\x22\xb7\x15\x63\xb5\x95\xdc\x49\x50\x46\xc8\xdd\xaf\xce\x0f\x13\xe3\xd9\x7f\xbb\x7e\xd8\x09\x23\x34\xe6\xbf\xaf\xe6\x1d\xa5\xec\x31\x2e\x2a\xf6\x35\x00\x52\x71\x00\xec\xe9\x01\x00\x00")
+
+func _000013_session_metadata_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000013_session_metadata_up_sql,
+		"000013_session_metadata.up.sql",
+	)
+}
+
+var __000014_login_attempt_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2f\x00\xd0\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x6c\x6f\x67\x69\x6e\x5f\x61\x74\x74\x65\x6d\x70\x74\x3b\x0a\x03\x00\xb3\xae\x19\x38\x2f\x00\x00\x00")
+
+func _000014_login_attempt_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000014_login_attempt_down_sql,
+		"000014_login_attempt.down.sql",
+	)
+}
+
+var __000014_login_attempt_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xd1\x41\x6b\xc2\x30\x1c\x05\xf0\x7b\x3e\xc5\x3b\xb6\x50\xc6\x70\x78\xf2\x14\x35\x6e\x61\x5d\x1c\x31\x0e\x3d\x85\xac\xc6\x91\xad\x6b\x8a\x4d\x41\x10\xbf\xfb\x98\xad\x52\x3a\x18\xcd\x2d\xf0\xcb\x9f\xbc\xf7\x9f\x49\x46\x15\x83\xa2\xd3\x94\x81\x2f\x20\x96\x0a\x6c\xc3\x57\x6a\x85\xd3\xe9\xae\x3c\xd8\xbd\x3b\x9e\xcf\xb9\xff\x70\x85\x36\x21\xd8\xef\x32\x20\x22\x00\x60\xb2\xe0\x7c\x81\xdb\x79\xa3\x72\xf6\x44\x65\xf4\x30\x8a\x2f\x53\xc4\x3a\x4d\x93\x8b\xac\x32\x5f\x5a\x0c\x92\xf5\xfb\xa7\xcd\xc2\x1f\x39\x1a\x8f\xfb\x74\x6f\x5c\x5e\x1f\x6c\x75\xa5\x5c\xa8\x9b\xc0\x9c\x2d\xe8\x3a\x55\xb8\x6f\x6c\x6e\xaa\xa0\xdb\x07\xda\x04\x4c\xf9\x63\x97\xb7\xc8\x67\x5f\x76\xa7\xeb\x22\xb8\xfc\xf7\xde\x43\xfd\x99\xaf\x92\xbf\x50\xb9\xc5\x33\xdb\x22\x6a\xba\x48\x9a\xa4\xc9\x35\x46\x4c\xe2\x09\x21\x6d\xc3\x5c\xcc\xd9\x66\x60\xc3\xba\xfb\x17\xed\x76\x47\x2c\xc5\x3f\xeb\xe8\xea\x78\x42\x7e\x06\x00\xa2\xc5\x90\x63\xd3\x01\x00\x00")
+
+func _000014_login_attempt_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000014_login_attempt_up_sql,
+		"000014_login_attempt.up.sql",
+	)
+}
+
+var __000015_rate_limit_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2c\x00\xd3\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x72\x61\x74\x65\x5f\x6c\x69\x6d\x69\x74\x3b\x0a\x03\x00\xdc\x81\xa3\x74\x2c\x00\x00\x00")
+
+func _000015_rate_limit_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000015_rate_limit_down_sql,
+		"000015_rate_limit.down.sql",
+	)
+}
+
+var __000015_rate_limit_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xcf\xc1\x4a\x03\x31\x14\x85\xe1\x7d\x9e\xe2\x2c\x3b\x20\x2e\x84\xae\xba\xca\x4c\xaf\x7a\x31\x26\x25\x93\x4a\xbb\x0a\x81\x44\x08\x1d\x6d\xa9\x11\x2b\xa5\xef\x2e\x9d\x51\x07\x04\xa1\x59\x65\xf1\x71\x0f\x7f\x63\x49\x3a\x82\x93\xb5\x22\xf0\x2d\xb4\x71\xa0\x15\xb7\xae\xc5\xf1\x78\xbd\xdb\xa7\xe7\x7c\x38\x9d\xf6\xa1\x24\xdf\xe5\x97\x5c\x30\x11\x00\xb0\x49\x9f\xf8\x79\x4f\xd2\x36\xf7\xd2\x4e\x6e\xa6\xd3\x0a\x0b\xcb\x8f\xd2\xae\xf1\x40\xeb\xab\x5e\x96\xed\x26\xbd\xbe\x0d\x72\x6e\x96\xe7\x99\x85\xa5\x86\x5b\x36\xba\x5f\xd3\x4b\xa5\x06\x1a\xba\x6e\xfb\x91\xe2\xf9\x8b\xda\x18\x45\xf2\xaf\x78\xdf\xc5\x50\x52\xf4\xa1\x00\x35\xdf\xb1\x76\xbf\x40\x54\x33\x21\xbe\x6b\x58\xcf\x69\x75\x49\x8d\x1f\x0f\xfa\x1c\x0f\x30\xfa\xbf\xea\x11\x56\x33\xf1\x35\x00\x08\xc0\x59\xad\x35\x01\x00\x00")
+
+func _000015_rate_limit_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000015_rate_limit_up_sql,
+		"000015_rate_limit.up.sql",
+	)
+}
+
+var __000016_audit_log_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2b\x00\xd4\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x3b\x0a\x03\x00\xad\xc3\x1b\xfe\x2b\x00\x00\x00")
+
+func _000016_audit_log_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000016_audit_log_down_sql,
+		"000016_audit_log.down.sql",
+	)
+}
+
+var __000016_audit_log_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\x51\x6b\xea\x30\x18\x86\xef\xfb\x2b\xbe\x3b\x2d\xa8\x70\x3c\x07\x6f\xbc\x8a\x36\x6a\x38\x5d\x2b\x69\x3a\xf4\xaa\x04\xf3\xcd\x05\x4a\xdb\xb5\x11\x14\xf1\xbf\x8f\xd6\x35\x73\xcc\x89\xb2\x5e\x15\xfa\xf4\x7d\xde\xe4\x9d\x72\x4a\x04\x05\x41\x26\x3e\x05\x36\x83\x20\x14\x40\x57\x2c\x12\x11\x1c\x8f\x83\xa2\xc4\x17\xbd\x3f\x9d\xe4\x4e\x69\x93\xa4\xf9\x16\xba\x0e\x00\x80\x56\xd0\x3e\xd3\x05\xe1\xdd\xe1\xc8\x85\x25\x67\x4f\x84\xaf\xe1\x3f\x5d\xf7\x1a\x68\x53\xa2\x34\x98\x48\x03\x30\x61\x73\x16\x88\x26\x3c\x88\x7d\xff\xfc\x5d\x6e\x8c\xce\xb3\xfa\x0d\x9e\x09\x6f\x72\xfe\x0e\xdd\xef\x50\x5e\x26\x5a\x5d\x40\xc3\xd1\x27\x04\x1e\x9d\x91\xd8\x17\xd0\xe9\x5c\xf2\xe6\x50\xa0\xe5\xff\x5c\xf0\x67\xc8\xc8\x72\x8b\xa6\x49\xbd\x2b\x54\x17\x89\x54\xaa\xc4\xaa\xb2\xa1\xa3\x7f\x37\xf8\x12\xdf\x76\x58\x35\x82\xbb\xf2\x15\x1a\xa9\xd3\xaa\xae\x26\xe8\x4a\x5c\x01\x8f\xa7\x8e\xe3\x8e\x1d\xe7\x63\x2e\x16\x78\x74\x75\xc7\x5c\x89\xdd\x20\xd1\x6a\x0f\x61\xf0\xc3\xa8\x16\x73\xc7\x0f\x2b\xda\x85\x6e\x1b\x5a\xaa\x07\xbf\x71\xd9\xe1\x6e\xcb\x2c\xf6\xd5\xe6\xf4\xfb\x20\x5e\x11\x9a\x3c\xa8\x0f\xae\x2b\x90\x45\x81\x99\xea\xe7\x59\x7a\x18\xb4\x7d\x42\x0e\x9c\x2e\x7d\x32\xa5\xc0\x63\x9f\x5e\xef\x92\xe5\xc9\xae\x50\xd2\x20\x90\xa8\xae\x12\x2f\xbd\xfa\x5f\x11\x5e\x2f\xe5\x85\xc0\x82\x48\x50\xe2\xd5\x37\xba\x60\xc1\x7c\xfc\xa8\x4e\x61\x8a\x56\xe7\x51\x9f\x3e\xa8\x7b\x1f\x00\xea\x8e\xcb\x4f\xec\x03\x00\x00")
+
+func _000016_audit_log_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000016_audit_log_up_sql,
+		"000016_audit_log.up.sql",
+	)
+}
+
+var __000017_token_hash_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8e\xc1\x0b\x82\x30\x14\x87\xef\xfb\x2b\xde\x31\x2f\x1d\x22\xbc\x78\x5a\xee\x49\xa3\xb9\xc9\x9c\xa1\x27\x09\x32\x94\x40\xa3\x75\x10\xc4\xff\x3d\x58\x87\x0c\x14\xa1\xf3\xef\x7d\xef\xfb\x98\x56\x09\x70\xc9\x30\x07\x1e\x01\xe6\x3c\x35\x29\x0c\xc3\xf6\xf1\xac\x6e\x4d\x3f\x8e\xb6\xb2\xb6\xe9\xda\xf2\xd5\xdd\xab\xb6\xac\x2f\xb6\x2e\x9b\x6b\x1f\x10\xc2\x50\xa0\x41\x88\xb4\x8a\x67\xee\x03\x42\x85\x41\x0d\x86\x1e\x04\xce\xec\xe0\xbc\xa1\x12\x59\x2c\x27\xe2\xaf\x65\xf5\x01\x65\x6c\xc2\x4b\x65\x7e\x7e\x40\x78\xa4\x7a\xb3\xf3\x3d\xb7\xc8\x4c\x88\xe5\x64\x07\x2c\xfa\xdc\xfa\x7f\xee\x07\x5f\x89\x3d\x53\xed\x7a\xfd\xbd\x07\x89\xe6\x31\xd5\x05\x9c\xb0\x08\xc8\x7b\x00\xaf\x0f\x3a\x58\x9e\x01\x00\x00")
+
+func _000017_token_hash_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000017_token_hash_down_sql,
+		"000017_token_hash.down.sql",
+	)
+}
+
+var __000017_token_hash_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x91\xcf\x6e\xd4\x30\x10\xc6\xef\x79\x8a\xef\x46\x2b\x91\x3d\x21\x0e\xe4\x14\x36\xae\x88\xc8\x26\x25\x75\xa4\xf6\xb4\x72\xeb\x21\xb1\x12\xec\x95\xc7\x4d\x37\xaa\xfa\xee\xc8\xa1\xa8\x40\xa9\x04\x3d\x4e\xe6\xfb\xf3\xcb\x38\x4d\x21\xdd\x48\x96\xa1\xac\x06\x13\xb3\x71\x71\xf0\x04\xeb\xee\xc0\xc1\x79\xd2\x50\x8c\x91\x16\xd2\x18\x14\x0f\xc4\x1b\xc8\x81\x70\x98\x94\xb1\x81\x8e\x01\xb3\x9a\x6e\x89\x93\x34\xc5\x8d\xb2\x6f\x02\xae\xe9\x87\x50\x63\x20\x4f\x60\x63\x6f\x08\x61\xa0\x18\x02\xc3\xe8\xc9\x92\x57\x81\x34\xae\x97\xf5\x3b\x93\x9f\xc9\xbf\x05\xbb\x38\x2e\xb1\x3e\xa6\x19\x3b\xab\xc9\xe8\x55\x69\x2c\x07\x52\xfa\x03\x26\x63\x47\x86\xb1\xa0\x6f\xca\x4c\x0c\x35\x79\x52\x7a\x01\x93\x0d\x91\xf7\x80\x3b\xe7\x47\x63\xfb\xf5\x8f\x6e\x99\xfc\x4a\x36\xa8\x99\x10\x1c\x26\xd7\x47\xb3\xea\x95\xb1\x9b\xa4\x10\x95\x90\x02\x67\x6d\xb3\xc3\xfd\xfd\xe6\xe0\xe9\xab\x39\x3e\x3c\x84\x78\x92\x2c\xc9\x2b\x29\x5a\xc8\xfc\x63\x25\x9e\x6d\x51\xb4\xcd\x39\xb6\x4d\xd5\xed\x6a\x94\x67\x10\x97\xe5\x85\xbc\xc0\xbf\x38\xf3\xa2\xf8\xc5\x58\x37\xf2\x37\xf3\x3e\x9e\x0e\xdb\x4f\x79\x7b\xf2\xfe\xdd\x29\xce\xdb\x72\x97\xb7\x57\xf8\x2c\xae\xb2\xe4\x25\xde\xc7\x77\x7b\xb1\xf7\x71\xff\x2a\xe6\x9f\xde\xff\xa2\x8e\xeb\xba\xab\xaa\x2c\x49\xb6\xad\xc8\xa5\x40\x57\x97\x5f\x3a\x81\xb2\x2e\xc4\xe5\x1f\x01\xcf\xcb\xf6\x4f\x99\x7b\xa3\x8f\x68\xea\xbf\x11\x9d\x3c\xa9\x4e\xb3\xe4\xfb\x00\x46\x8e\x0f\xce\xca\x02\x00\x00")
+
+func _000017_token_hash_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000017_token_hash_up_sql,
+		"000017_token_hash.up.sql",
+	)
+}
+
+var __000018_password_history_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x32\x00\xcd\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x70\x61\x73\x73\x77\x6f\x72\x64\x5f\x68\x69\x73\x74\x6f\x72\x79\x3b\x0a\x03\x00\x52\x87\x51\x03\x32\x00\x00\x00")
+
+func _000018_password_history_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000018_password_history_down_sql,
+		"000018_password_history.down.sql",
+	)
+}
+
+var __000018_password_history_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x8f\xc1\x4b\x87\x30\x1c\xc5\xef\xfb\x2b\xde\x51\x41\x82\x3c\x44\xe0\x69\xda\xaa\x91\xcd\x98\x2b\xf4\x34\xa4\x2d\xda\x49\xd9\x8c\x0c\xf1\x7f\x8f\x4c\x91\x3a\xfc\xf8\x7d\xaf\xdf\x0f\xef\xbd\x4f\x21\x19\x55\x0c\x8a\xe6\x25\x03\xbf\x85\xa8\x14\x58\xc3\x6b\x55\x63\x9e\x2f\x06\x6f\xdf\xdc\xb4\x2c\x43\x17\xc2\x67\xef\x8d\x7e\x77\x61\xec\xfd\x17\x22\x02\x00\xce\x60\xbf\xe2\x9e\xca\x28\xbd\x8a\xf1\x24\xf9\x23\x95\x2d\x1e\x58\x9b\xac\xd0\x47\xb0\x5e\x3b\xf3\x07\xfa\x69\x11\xcf\x65\xf9\x4b\xbc\x7a\xdb\x8d\x56\x77\x23\x90\xf3\x3b\x2e\xd4\xbf\xff\xde\x0e\xe0\x85\xca\x35\xe4\x32\xbd\x3e\x52\x48\x9c\x11\xb2\x89\x70\x71\xc3\x9a\xf3\x45\xf4\xb6\x4e\x3b\x33\xa1\x12\xa7\x9d\x37\x36\x39\x06\xc7\x19\xf9\x1e\x00\xbc\x77\x26\x7e\x41\x01\x00\x00")
+
+func _000018_password_history_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000018_password_history_up_sql,
+		"000018_password_history.up.sql",
+	)
+}
+
+var __000019_invitation_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x2c\x00\xd3\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x69\x6e\x76\x69\x74\x61\x74\x69\x6f\x6e\x3b\x0a\x03\x00\xfe\xce\xc9\xc3\x2c\x00\x00\x00")
+
+func _000019_invitation_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000019_invitation_down_sql,
+		"000019_invitation.down.sql",
+	)
+}
+
+var __000019_invitation_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x5f\x4b\xc3\x30\x14\xc5\xdf\xf3\x29\xce\xdb\x56\x10\xc1\x3e\x0c\x61\x4f\xd9\x96\x69\xb0\x66\x92\x65\xd2\x21\x12\x82\xbd\xc2\x85\xee\x0f\x59\x91\xc2\xd8\x77\x17\xb3\xd2\x82\x20\x98\xa7\xc0\xfd\x9d\x73\xcf\x3d\x73\xab\xa4\x53\x70\x72\x56\x28\xe8\x25\xcc\xca\x41\x95\x7a\xed\xd6\x38\x9f\x6f\x8f\x91\x3e\xb9\xbd\x5c\x78\xff\xc5\x4d\x68\xf8\xb0\xc7\x58\x00\x00\x57\xe8\xdf\xfc\x51\xda\x71\x3e\xc9\xf0\x62\xf5\xb3\xb4\x5b\x3c\xa9\xed\x4d\xa2\x68\x17\xb8\xee\xa8\x57\x69\x13\x78\x97\xdf\x67\x69\x8b\xd9\x14\xc5\x15\x8b\x87\x9a\x3c\x57\xa7\x9f\xbf\x53\xa5\xeb\xc7\x58\xa8\xa5\xdc\x14\x0e\xa3\xb7\xf7\xd1\x95\x4d\x49\x28\x7a\xae\x06\xcb\x7c\x32\x38\x0e\x92\x4e\xf0\x11\x29\x34\xe4\x43\x03\x60\xa6\x1f\xb4\x19\xec\xbb\x90\xed\x91\x23\x9d\x12\xf1\x0b\x10\xd9\x54\x88\xae\x21\x6d\x16\xaa\xfc\x4f\x43\x3e\x5d\xed\xb9\x6a\xb1\x32\x7f\x95\x48\xbb\xc0\x75\x36\x15\xdf\x03\x00\xa9\xdc\x4d\xee\x7f\x01\x00\x00")
+
+func _000019_invitation_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000019_invitation_up_sql,
+		"000019_invitation.up.sql",
+	)
+}
+
+var __000020_user_pending_email_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x73\x65\x72\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x65\x6e\x64\x69\x6e\x67\x5f\x65\x6d\x61\x69\x6c\x3b\x0a\x03\x00\xb0\xfb\x0e\xdd\x41\x00\x00\x00")
+
+func _000020_user_pending_email_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000020_user_pending_email_down_sql,
+		"000020_user_pending_email.down.sql",
+	)
+}
+
+var __000020_user_pending_email_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x65\x00\x9a\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x75\x73\x65\x72\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x70\x65\x6e\x64\x69\x6e\x67\x5f\x65\x6d\x61\x69\x6c\x20\x56\x41\x52\x43\x48\x41\x52\x28\x31\x32\x38\x29\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x27\x3b\x0a\x03\x00\x2a\xb2\x28\x51\x65\x00\x00\x00")
+
+func _000020_user_pending_email_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000020_user_pending_email_up_sql,
+		"000020_user_pending_email.up.sql",
+	)
+}
+
+var __000021_cycle_down_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x7b\x7b\x2e\x70\x72\x65\x66\x69\x78\x7d\x7d\x63\x79\x63\x6c\x65\x3b\x0a\x03\x00\x86\xf2\xf3\xaf\x27\x00\x00\x00")
+
+func _000021_cycle_down_sql() ([]byte, error) {
+	return bindata_read(
+		__000021_cycle_down_sql,
+		"000021_cycle.down.sql",
+	)
+}
+
+var __000021_cycle_up_sql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xd1\x41\x6b\xc2\x30\x1c\x05\xf0\x7b\x3f\xc5\xbb\x69\x41\xc6\x56\xa6\x0c\x3c\x45\x8d\x2e\xac\x8b\x23\xc6\xa1\xa7\x90\x35\x91\x85\x95\x19\x52\x07\x0e\xf1\xbb\x0f\x5b\xad\x8a\x03\xcd\xf9\xf7\x7f\x81\xf7\xfa\x82\x12\x49\x21\x49\x2f\xa5\x60\x43\xf0\xb1\x04\x9d\xb1\x89\x9c\x60\xb3\xb9\xf3\xc1\x2e\xdc\x7a\xbb\xcd\x7e\xb3\xdc\xa2\x19\x01\x80\x33\xa8\x5f\xff\x99\x88\x66\xd2\x89\xf1\x26\xd8\x2b\x11\x73\xbc\xd0\x79\xab\x54\xc1\xfa\xe5\x41\xbd\x13\x51\xc2\xce\x63\x5c\xe6\xf3\x69\x9a\x56\xea\x23\xe8\xef\xec\xf3\x5c\x25\xed\xf6\x05\xfb\x71\xb9\xc1\x39\x7b\x48\x9e\x8e\x0c\x03\x3a\x24\xd3\x54\xa2\xd1\xa8\x2e\xbc\x2e\x0a\xbb\x3f\x61\x5c\xd2\x11\x15\x97\xf8\xbe\xb2\x0b\xed\xf2\x5b\x6d\xf1\xe5\xbc\xb7\xe6\x26\x9b\x05\xab\x57\xcb\xa0\x9c\x39\x29\xea\x80\x4f\x88\x55\x7a\x05\xa0\xc7\x46\x8c\xcb\x3a\x2d\x8a\xbb\x51\xb4\x5f\x87\xf1\x01\x9d\x5d\x59\x47\xed\x1a\x57\x75\xa0\x72\x66\x8d\x31\xff\x67\xc4\x9d\x6b\x1d\x7f\x8e\xbb\xd1\xdf\x00\x9d\x41\xcb\xba\x04\x02\x00\x00")
+
+func _000021_cycle_up_sql() ([]byte, error) {
+	return bindata_read(
+		__000021_cycle_up_sql,
+		"000021_cycle.up.sql",
+	)
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -87,6 +429,44 @@ var _bindata = map[string]func() ([]byte, error){
 	"000001_auth.up.sql": _000001_auth_up_sql,
 	"000002_cycle.down.sql": _000002_cycle_down_sql,
 	"000002_cycle.up.sql": _000002_cycle_up_sql,
+	"000003_user_locale.down.sql": _000003_user_locale_down_sql,
+	"000003_user_locale.up.sql": _000003_user_locale_up_sql,
+	"000004_notification_preferences.down.sql": _000004_notification_preferences_down_sql,
+	"000004_notification_preferences.up.sql": _000004_notification_preferences_up_sql,
+	"000005_api_key.down.sql": _000005_api_key_down_sql,
+	"000005_api_key.up.sql": _000005_api_key_up_sql,
+	"000006_personal_access_token.down.sql": _000006_personal_access_token_down_sql,
+	"000006_personal_access_token.up.sql": _000006_personal_access_token_up_sql,
+	"000007_role_permission.down.sql": _000007_role_permission_down_sql,
+	"000007_role_permission.up.sql": _000007_role_permission_up_sql,
+	"000008_user_auth_info.down.sql": _000008_user_auth_info_down_sql,
+	"000008_user_auth_info.up.sql": _000008_user_auth_info_up_sql,
+	"000009_oidc.down.sql": _000009_oidc_down_sql,
+	"000009_oidc.up.sql": _000009_oidc_up_sql,
+	"000010_oauthstate_provider.down.sql": _000010_oauthstate_provider_down_sql,
+	"000010_oauthstate_provider.up.sql": _000010_oauthstate_provider_up_sql,
+	"000011_github_access.down.sql": _000011_github_access_down_sql,
+	"000011_github_access.up.sql": _000011_github_access_up_sql,
+	"000012_mfa.down.sql": _000012_mfa_down_sql,
+	"000012_mfa.up.sql": _000012_mfa_up_sql,
+	"000013_session_metadata.down.sql": _000013_session_metadata_down_sql,
+	"000013_session_metadata.up.sql": _000013_session_metadata_up_sql,
+	"000014_login_attempt.down.sql": _000014_login_attempt_down_sql,
+	"000014_login_attempt.up.sql": _000014_login_attempt_up_sql,
+	"000015_rate_limit.down.sql": _000015_rate_limit_down_sql,
+	"000015_rate_limit.up.sql": _000015_rate_limit_up_sql,
+	"000016_audit_log.down.sql": _000016_audit_log_down_sql,
+	"000016_audit_log.up.sql": _000016_audit_log_up_sql,
+	"000017_token_hash.down.sql": _000017_token_hash_down_sql,
+	"000017_token_hash.up.sql": _000017_token_hash_up_sql,
+	"000018_password_history.down.sql": _000018_password_history_down_sql,
+	"000018_password_history.up.sql": _000018_password_history_up_sql,
+	"000019_invitation.down.sql": _000019_invitation_down_sql,
+	"000019_invitation.up.sql": _000019_invitation_up_sql,
+	"000020_user_pending_email.down.sql": _000020_user_pending_email_down_sql,
+	"000020_user_pending_email.up.sql": _000020_user_pending_email_up_sql,
+	"000021_cycle.down.sql": _000021_cycle_down_sql,
+	"000021_cycle.up.sql": _000021_cycle_up_sql,
 }
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
@@ -136,4 +516,80 @@ var _bintree = &_bintree_t{nil, map[string]*_bintree_t{
 	}},
 	"000002_cycle.up.sql": &_bintree_t{_000002_cycle_up_sql, map[string]*_bintree_t{
 	}},
+	"000003_user_locale.down.sql": &_bintree_t{_000003_user_locale_down_sql, map[string]*_bintree_t{
+	}},
+	"000003_user_locale.up.sql": &_bintree_t{_000003_user_locale_up_sql, map[string]*_bintree_t{
+	}},
+	"000004_notification_preferences.down.sql": &_bintree_t{_000004_notification_preferences_down_sql, map[string]*_bintree_t{
+	}},
+	"000004_notification_preferences.up.sql": &_bintree_t{_000004_notification_preferences_up_sql, map[string]*_bintree_t{
+	}},
+	"000005_api_key.down.sql": &_bintree_t{_000005_api_key_down_sql, map[string]*_bintree_t{
+	}},
+	"000005_api_key.up.sql": &_bintree_t{_000005_api_key_up_sql, map[string]*_bintree_t{
+	}},
+	"000006_personal_access_token.down.sql": &_bintree_t{_000006_personal_access_token_down_sql, map[string]*_bintree_t{
+	}},
+	"000006_personal_access_token.up.sql": &_bintree_t{_000006_personal_access_token_up_sql, map[string]*_bintree_t{
+	}},
+	"000007_role_permission.down.sql": &_bintree_t{_000007_role_permission_down_sql, map[string]*_bintree_t{
+	}},
+	"000007_role_permission.up.sql": &_bintree_t{_000007_role_permission_up_sql, map[string]*_bintree_t{
+	}},
+	"000008_user_auth_info.down.sql": &_bintree_t{_000008_user_auth_info_down_sql, map[string]*_bintree_t{
+	}},
+	"000008_user_auth_info.up.sql": &_bintree_t{_000008_user_auth_info_up_sql, map[string]*_bintree_t{
+	}},
+	"000009_oidc.down.sql": &_bintree_t{_000009_oidc_down_sql, map[string]*_bintree_t{
+	}},
+	"000009_oidc.up.sql": &_bintree_t{_000009_oidc_up_sql, map[string]*_bintree_t{
+	}},
+	"000010_oauthstate_provider.down.sql": &_bintree_t{_000010_oauthstate_provider_down_sql, map[string]*_bintree_t{
+	}},
+	"000010_oauthstate_provider.up.sql": &_bintree_t{_000010_oauthstate_provider_up_sql, map[string]*_bintree_t{
+	}},
+	"000011_github_access.down.sql": &_bintree_t{_000011_github_access_down_sql, map[string]*_bintree_t{
+	}},
+	"000011_github_access.up.sql": &_bintree_t{_000011_github_access_up_sql, map[string]*_bintree_t{
+	}},
+	"000012_mfa.down.sql": &_bintree_t{_000012_mfa_down_sql, map[string]*_bintree_t{
+	}},
+	"000012_mfa.up.sql": &_bintree_t{_000012_mfa_up_sql, map[string]*_bintree_t{
+	}},
+	"000013_session_metadata.down.sql": &_bintree_t{_000013_session_metadata_down_sql, map[string]*_bintree_t{
+	}},
+	"000013_session_metadata.up.sql": &_bintree_t{_000013_session_metadata_up_sql, map[string]*_bintree_t{
+	}},
+	"000014_login_attempt.down.sql": &_bintree_t{_000014_login_attempt_down_sql, map[string]*_bintree_t{
+	}},
+	"000014_login_attempt.up.sql": &_bintree_t{_000014_login_attempt_up_sql, map[string]*_bintree_t{
+	}},
+	"000015_rate_limit.down.sql": &_bintree_t{_000015_rate_limit_down_sql, map[string]*_bintree_t{
+	}},
+	"000015_rate_limit.up.sql": &_bintree_t{_000015_rate_limit_up_sql, map[string]*_bintree_t{
+	}},
+	"000016_audit_log.down.sql": &_bintree_t{_000016_audit_log_down_sql, map[string]*_bintree_t{
+	}},
+	"000016_audit_log.up.sql": &_bintree_t{_000016_audit_log_up_sql, map[string]*_bintree_t{
+	}},
+	"000017_token_hash.down.sql": &_bintree_t{_000017_token_hash_down_sql, map[string]*_bintree_t{
+	}},
+	"000017_token_hash.up.sql": &_bintree_t{_000017_token_hash_up_sql, map[string]*_bintree_t{
+	}},
+	"000018_password_history.down.sql": &_bintree_t{_000018_password_history_down_sql, map[string]*_bintree_t{
+	}},
+	"000018_password_history.up.sql": &_bintree_t{_000018_password_history_up_sql, map[string]*_bintree_t{
+	}},
+	"000019_invitation.down.sql": &_bintree_t{_000019_invitation_down_sql, map[string]*_bintree_t{
+	}},
+	"000019_invitation.up.sql": &_bintree_t{_000019_invitation_up_sql, map[string]*_bintree_t{
+	}},
+	"000020_user_pending_email.down.sql": &_bintree_t{_000020_user_pending_email_down_sql, map[string]*_bintree_t{
+	}},
+	"000020_user_pending_email.up.sql": &_bintree_t{_000020_user_pending_email_up_sql, map[string]*_bintree_t{
+	}},
+	"000021_cycle.down.sql": &_bintree_t{_000021_cycle_down_sql, map[string]*_bintree_t{
+	}},
+	"000021_cycle.up.sql": &_bintree_t{_000021_cycle_up_sql, map[string]*_bintree_t{
+	}},
 }}
@@ -0,0 +1 @@
+ALTER TABLE {{.prefix}}user DROP COLUMN IF EXISTS locale;
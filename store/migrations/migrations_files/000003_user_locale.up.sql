@@ -0,0 +1 @@
+ALTER TABLE {{.prefix}}user ADD COLUMN IF NOT EXISTS locale VARCHAR(32) NOT NULL DEFAULT 'en';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}notification_preferences (
+    user_id     CHAR(26) PRIMARY KEY,
+    email       BOOLEAN NOT NULL DEFAULT TRUE,
+    in_app      BOOLEAN NOT NULL DEFAULT TRUE,
+    events      TEXT NOT NULL DEFAULT '[]',
+    repos       TEXT NOT NULL DEFAULT '[]',
+    branches    TEXT NOT NULL DEFAULT '[]',
+    update_at   BIGINT NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}api_key (
+    id              CHAR(26) PRIMARY KEY,
+    name            VARCHAR(64) NOT NULL,
+    scope           VARCHAR(32) NOT NULL,
+    key_hash        CHAR(64) NOT NULL UNIQUE,
+    creator_id      CHAR(26) NOT NULL,
+    create_at       BIGINT NOT NULL,
+    expires_at      BIGINT NOT NULL DEFAULT 0,
+    last_used_at    BIGINT NOT NULL DEFAULT 0,
+    last_used_ip    VARCHAR(64) NOT NULL DEFAULT '',
+    delete_at       BIGINT NOT NULL DEFAULT 0
+);
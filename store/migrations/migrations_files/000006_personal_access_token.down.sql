@@ -0,0 +1 @@
+DROP TABLE IF EXISTS {{.prefix}}personal_access_token;
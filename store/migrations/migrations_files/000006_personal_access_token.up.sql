@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}personal_access_token (
+    id              CHAR(26) PRIMARY KEY,
+    user_id         CHAR(26) NOT NULL,
+    name            VARCHAR(64) NOT NULL,
+    scope           VARCHAR(32) NOT NULL,
+    token_hash      CHAR(64) NOT NULL UNIQUE,
+    create_at       BIGINT NOT NULL,
+    expires_at      BIGINT NOT NULL DEFAULT 0,
+    last_used_at    BIGINT NOT NULL DEFAULT 0,
+    delete_at       BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}personal_access_token_user_id_idx ON {{.prefix}}personal_access_token (user_id);
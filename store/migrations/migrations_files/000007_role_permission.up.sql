@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}role_permission (
+    role_id     CHAR(26) NOT NULL,
+    permission  VARCHAR(64) NOT NULL,
+    create_at   BIGINT NOT NULL DEFAULT (extract(epoch from now()) * 1000),
+
+    PRIMARY KEY (role_id, permission)
+);
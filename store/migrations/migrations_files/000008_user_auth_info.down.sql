@@ -0,0 +1,3 @@
+ALTER TABLE {{.prefix}}oauthstate DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS {{.prefix}}user_auth_info;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}user_auth_info (
+    user_id             CHAR(26) NOT NULL,
+    oauth_provider      VARCHAR(32) NOT NULL,
+    provider_user_id    VARCHAR(64) NOT NULL,
+    token               TEXT NOT NULL,
+    username            VARCHAR(128) NOT NULL DEFAULT '',
+    email               VARCHAR(128) NOT NULL DEFAULT '',
+    name                VARCHAR(128) NOT NULL DEFAULT '',
+    avatar_url          TEXT NOT NULL DEFAULT '',
+    create_at           BIGINT NOT NULL,
+    update_at           BIGINT NOT NULL,
+    delete_at           BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (user_id, oauth_provider),
+    UNIQUE (oauth_provider, provider_user_id)
+);
+
+ALTER TABLE {{.prefix}}oauthstate ADD COLUMN IF NOT EXISTS user_id VARCHAR(26) NOT NULL DEFAULT '';
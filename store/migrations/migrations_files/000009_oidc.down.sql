@@ -0,0 +1,4 @@
+ALTER TABLE {{.prefix}}user_auth_info ALTER COLUMN provider_user_id TYPE VARCHAR(64);
+
+ALTER TABLE {{.prefix}}oauthstate DROP COLUMN IF EXISTS code_verifier;
+ALTER TABLE {{.prefix}}oauthstate DROP COLUMN IF EXISTS nonce;
@@ -0,0 +1,4 @@
+ALTER TABLE {{.prefix}}oauthstate ADD COLUMN IF NOT EXISTS nonce VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE {{.prefix}}oauthstate ADD COLUMN IF NOT EXISTS code_verifier VARCHAR(128) NOT NULL DEFAULT '';
+
+ALTER TABLE {{.prefix}}user_auth_info ALTER COLUMN provider_user_id TYPE VARCHAR(255);
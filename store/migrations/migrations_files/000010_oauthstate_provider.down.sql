@@ -0,0 +1 @@
+ALTER TABLE {{.prefix}}oauthstate DROP COLUMN IF EXISTS provider;
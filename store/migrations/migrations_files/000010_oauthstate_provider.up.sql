@@ -0,0 +1 @@
+ALTER TABLE {{.prefix}}oauthstate ADD COLUMN IF NOT EXISTS provider VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{.prefix}}github_membership;
+DROP TABLE IF EXISTS {{.prefix}}github_access_rule;
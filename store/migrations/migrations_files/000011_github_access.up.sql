@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}github_access_rule (
+    id          CHAR(26) PRIMARY KEY,
+    org         VARCHAR(64) NOT NULL,
+    team        VARCHAR(128) NOT NULL DEFAULT '',
+    role_id     VARCHAR(26) NOT NULL DEFAULT '',
+    repos       TEXT NOT NULL DEFAULT '[]',
+    create_at   BIGINT NOT NULL,
+    update_at   BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {{.prefix}}github_membership (
+    user_id     CHAR(26) PRIMARY KEY,
+    member_of   TEXT NOT NULL DEFAULT '[]',
+    allowed     BOOLEAN NOT NULL DEFAULT TRUE,
+    repos       TEXT NOT NULL DEFAULT '[]',
+    sync_at     BIGINT NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE {{.prefix}}role DROP COLUMN IF EXISTS require_mfa;
+
+DROP TABLE IF EXISTS {{.prefix}}mfa_recovery_code;
+
+DROP TABLE IF EXISTS {{.prefix}}user_mfa;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}user_mfa (
+    user_id     CHAR(26) PRIMARY KEY,
+    secret      TEXT NOT NULL,
+    enabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step   BIGINT NOT NULL DEFAULT 0,
+    create_at   BIGINT NOT NULL,
+    update_at   BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {{.prefix}}mfa_recovery_code (
+    user_id     CHAR(26) NOT NULL,
+    code_hash   CHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+ALTER TABLE {{.prefix}}role ADD COLUMN IF NOT EXISTS require_mfa BOOLEAN NOT NULL DEFAULT FALSE;
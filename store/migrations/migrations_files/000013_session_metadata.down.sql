@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS {{.prefix}}session_user_id_idx;
+
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS last_activity_at;
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS source;
@@ -0,0 +1,6 @@
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS source VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS last_activity_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}session_user_id_idx ON {{.prefix}}session (user_id);
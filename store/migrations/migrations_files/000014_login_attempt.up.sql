@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}login_attempt (
+    action          VARCHAR(32) NOT NULL,
+    scope           VARCHAR(32) NOT NULL,
+    subject         VARCHAR(255) NOT NULL,
+    failures        INT NOT NULL DEFAULT 0,
+    last_failure_at BIGINT NOT NULL,
+    locked_until    BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (action, scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}login_attempt_locked_until_idx ON {{.prefix}}login_attempt (locked_until);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}rate_limit (
+    key         VARCHAR(255) PRIMARY KEY,
+    tokens      DOUBLE PRECISION NOT NULL,
+    allowed     BOOLEAN NOT NULL,
+    updated_at  BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}rate_limit_updated_at_idx ON {{.prefix}}rate_limit (updated_at);
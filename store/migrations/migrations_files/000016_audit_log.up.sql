@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}audit_log (
+    id         CHAR(26) PRIMARY KEY,
+    create_at  BIGINT NOT NULL,
+    action     VARCHAR(32) NOT NULL,
+    actor_id   VARCHAR(26) NOT NULL DEFAULT '',
+    actor_type VARCHAR(16) NOT NULL,
+    target_id  VARCHAR(26) NOT NULL DEFAULT '',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(26) NOT NULL DEFAULT '',
+    details    TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}audit_log_create_at_idx ON {{.prefix}}audit_log (create_at);
+CREATE INDEX IF NOT EXISTS {{.prefix}}audit_log_actor_id_idx ON {{.prefix}}audit_log (actor_id, create_at);
+CREATE INDEX IF NOT EXISTS {{.prefix}}audit_log_target_id_idx ON {{.prefix}}audit_log (target_id, create_at);
+
+-- The audit log is append-only.
+CREATE OR REPLACE RULE {{.prefix}}audit_log_no_update AS ON UPDATE TO {{.prefix}}audit_log DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE {{.prefix}}audit_log_no_delete AS ON DELETE TO {{.prefix}}audit_log DO INSTEAD NOTHING;
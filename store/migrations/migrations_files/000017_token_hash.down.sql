@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS {{.prefix}}session_token_hash_idx;
+
+DELETE FROM {{.prefix}}session;
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS token_hash;
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS token CHAR(26) NOT NULL;
+
+DELETE FROM {{.prefix}}token;
+ALTER TABLE {{.prefix}}token DROP COLUMN IF EXISTS token_hash;
+ALTER TABLE {{.prefix}}token ADD COLUMN IF NOT EXISTS token VARCHAR(64) PRIMARY KEY;
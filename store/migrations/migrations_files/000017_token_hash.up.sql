@@ -0,0 +1,13 @@
+-- Tokens and sessions are now stored as keyed hashes. The plaintext values
+-- can't be hashed here since the key is generated by the server, so they are
+-- invalidated instead: links in emails already sent stop working and users
+-- have to log in again.
+DELETE FROM {{.prefix}}token;
+ALTER TABLE {{.prefix}}token DROP COLUMN IF EXISTS token;
+ALTER TABLE {{.prefix}}token ADD COLUMN IF NOT EXISTS token_hash CHAR(64) PRIMARY KEY;
+
+DELETE FROM {{.prefix}}session;
+ALTER TABLE {{.prefix}}session DROP COLUMN IF EXISTS token;
+ALTER TABLE {{.prefix}}session ADD COLUMN IF NOT EXISTS token_hash CHAR(64) NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.prefix}}session_token_hash_idx ON {{.prefix}}session (token_hash);
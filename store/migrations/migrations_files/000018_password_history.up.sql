@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}password_history (
+    id         CHAR(26) PRIMARY KEY,
+    user_id    CHAR(26) NOT NULL,
+    create_at  BIGINT NOT NULL,
+    password   VARCHAR(128) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}password_history_user_id_idx ON {{.prefix}}password_history (user_id, create_at);
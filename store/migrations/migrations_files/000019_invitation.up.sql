@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}invitation (
+    id          CHAR(26) PRIMARY KEY,
+    email       VARCHAR(128) NOT NULL,
+    role_ids    TEXT NOT NULL DEFAULT '[]',
+    inviter_id  VARCHAR(26) NOT NULL DEFAULT '',
+    create_at   BIGINT NOT NULL,
+    expires_at  BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}invitation_email_idx ON {{.prefix}}invitation (email);
@@ -0,0 +1 @@
+ALTER TABLE {{.prefix}}user ADD COLUMN IF NOT EXISTS pending_email VARCHAR(128) NOT NULL DEFAULT '';
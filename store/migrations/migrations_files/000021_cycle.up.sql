@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {{.prefix}}cycle (
+    id          CHAR(26) PRIMARY KEY,
+    repo        VARCHAR(64) NOT NULL,
+    branch      VARCHAR(255) NOT NULL,
+    build       VARCHAR(128) NOT NULL DEFAULT '',
+    passed      INTEGER NOT NULL DEFAULT 0,
+    failed      INTEGER NOT NULL DEFAULT 0,
+    skipped     INTEGER NOT NULL DEFAULT 0,
+    creator_id  CHAR(26) NOT NULL,
+    create_at   BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.prefix}}cycle_repo_create_at_idx ON {{.prefix}}cycle (repo, create_at);
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlNotificationPreferencesStore struct {
+	*SqlStore
+}
+
+func newSqlNotificationPreferencesStore(sqlStore *SqlStore) NotificationPreferencesStore {
+	s := &SqlNotificationPreferencesStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) NotificationPreferences() NotificationPreferencesStore {
+	return s.stores.notificationPreferences
+}
+
+var notificationPreferencesSelect sq.SelectBuilder
+
+func init() {
+	notificationPreferencesSelect = sq.
+		Select(
+			"user_id",
+			"email",
+			"in_app",
+			"events",
+			"repos",
+			"branches",
+			"update_at",
+		)
+}
+
+func (s *SqlNotificationPreferencesStore) getNotificationPreferencesTable() string {
+	return s.tablePrefix + "notification_preferences"
+}
+
+// GetNotificationPreferences fetches the notification preferences of the given
+// user, or nil if the user never saved any.
+func (s *SqlNotificationPreferencesStore) GetNotificationPreferences(userID string) (*model.NotificationPreferences, error) {
+	var preferences model.NotificationPreferences
+	err := s.getBuilder(
+		s.db,
+		&preferences,
+		notificationPreferencesSelect.From(s.getNotificationPreferencesTable()).Where("user_id = ?", userID),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get notification preferences")
+	}
+
+	return &preferences, nil
+}
+
+// SaveNotificationPreferences inserts or updates the notification preferences
+// of a user.
+func (s *SqlNotificationPreferencesStore) SaveNotificationPreferences(preferences *model.NotificationPreferences) error {
+	preferences.PreSave()
+
+	if err := preferences.IsValid(); err != nil {
+		return err
+	}
+
+	_, err := s.execBuilder(
+		s.db,
+		sq.Insert(s.getNotificationPreferencesTable()).
+			SetMap(map[string]interface{}{
+				"user_id":   preferences.UserID,
+				"email":     preferences.Email,
+				"in_app":    preferences.InApp,
+				"events":    preferences.Events,
+				"repos":     preferences.Repos,
+				"branches":  preferences.Branches,
+				"update_at": preferences.UpdateAt,
+			}).
+			Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+				email = EXCLUDED.email,
+				in_app = EXCLUDED.in_app,
+				events = EXCLUDED.events,
+				repos = EXCLUDED.repos,
+				branches = EXCLUDED.branches,
+				update_at = EXCLUDED.update_at`),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to save notification preferences")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown preferences", func(t *testing.T) {
+		preferences, err := th.SqlStore.NotificationPreferences().GetNotificationPreferences(model.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, preferences)
+	})
+
+	t.Run("save invalid preferences", func(t *testing.T) {
+		err := th.SqlStore.NotificationPreferences().SaveNotificationPreferences(&model.NotificationPreferences{})
+		require.Error(t, err)
+
+		preferences := model.NewNotificationPreferences(model.NewID())
+		preferences.Events = model.StringList{"junk"}
+		err = th.SqlStore.NotificationPreferences().SaveNotificationPreferences(preferences)
+		require.Error(t, err)
+	})
+
+	t.Run("save and update preferences", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+
+		preferences := model.NewNotificationPreferences(user.ID)
+		err := th.SqlStore.NotificationPreferences().SaveNotificationPreferences(preferences)
+		require.NoError(t, err)
+
+		saved, err := th.SqlStore.NotificationPreferences().GetNotificationPreferences(user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		assert.Equal(t, preferences, saved)
+
+		preferences.Email = false
+		preferences.Events = nil
+		preferences.Repos = model.StringList{"mattermost-server", "mattermost-webapp"}
+		err = th.SqlStore.NotificationPreferences().SaveNotificationPreferences(preferences)
+		require.NoError(t, err)
+
+		saved, err = th.SqlStore.NotificationPreferences().GetNotificationPreferences(user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		assert.False(t, saved.Email)
+		assert.True(t, saved.InApp)
+		assert.Equal(t, model.StringList{}, saved.Events)
+		assert.Equal(t, model.StringList{"mattermost-server", "mattermost-webapp"}, saved.Repos)
+	})
+}
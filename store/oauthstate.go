@@ -32,6 +32,10 @@ func init() {
 		Select(
 			"id",
 			"token",
+			"provider",
+			"user_id",
+			"nonce",
+			"code_verifier",
 			"create_at",
 			"expires_at",
 		)
@@ -41,18 +45,23 @@ func (s *SqlOAuthStateStore) getOAuthStateTable() string {
 	return s.tablePrefix + "oauthstate"
 }
 
-// CreateOAuthState inserts a new OAuth state.
-func (s *SqlOAuthStateStore) CreateOAuthState() (*model.OAuthState, error) {
-	oauthState := &model.OAuthState{}
+// CreateOAuthState inserts a new OAuth state for the given provider. The user
+// id is only set when a logged in user is linking their account.
+func (s *SqlOAuthStateStore) CreateOAuthState(provider, userID string) (*model.OAuthState, error) {
+	oauthState := &model.OAuthState{Provider: provider, UserID: userID}
 	oauthState.PreSave()
 
 	_, err := s.execBuilder(s.db, sq.
 		Insert(s.getOAuthStateTable()).
 		SetMap(map[string]interface{}{
-			"id":         oauthState.ID,
-			"token":      oauthState.Token,
-			"create_at":  oauthState.CreateAt,
-			"expires_at": oauthState.ExpiresAt,
+			"id":            oauthState.ID,
+			"token":         oauthState.Token,
+			"provider":      oauthState.Provider,
+			"user_id":       oauthState.UserID,
+			"nonce":         oauthState.Nonce,
+			"code_verifier": oauthState.CodeVerifier,
+			"create_at":     oauthState.CreateAt,
+			"expires_at":    oauthState.ExpiresAt,
 		}),
 	)
 	if err != nil {
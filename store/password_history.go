@@ -0,0 +1,106 @@
+package store
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlPasswordHistoryStore struct {
+	*SqlStore
+}
+
+func newSqlPasswordHistoryStore(sqlStore *SqlStore) PasswordHistoryStore {
+	s := &SqlPasswordHistoryStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) PasswordHistory() PasswordHistoryStore {
+	return s.stores.passwordHistory
+}
+
+func (s *SqlPasswordHistoryStore) getPasswordHistoryTable() string {
+	return s.tablePrefix + "password_history"
+}
+
+// AddPasswordHistory records a previous password hash of a user, keeping only
+// the given number of their most recent ones.
+func (s *SqlPasswordHistoryStore) AddPasswordHistory(userID, passwordHash string, keep int) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if keep > 0 {
+		// Passwords changed within the same millisecond still get ordered.
+		createAt := sq.Expr(
+			"GREATEST(?, (SELECT COALESCE(MAX(create_at), 0) + 1 FROM "+s.getPasswordHistoryTable()+" WHERE user_id = ?))",
+			model.GetMillis(), userID,
+		)
+		_, err = s.execBuilder(tx, sq.
+			Insert(s.getPasswordHistoryTable()).
+			SetMap(map[string]interface{}{
+				"id":        model.NewID(),
+				"user_id":   userID,
+				"create_at": createAt,
+				"password":  passwordHash,
+			}),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to create password history")
+		}
+	}
+
+	kept := sq.
+		Select("id").
+		From(s.getPasswordHistoryTable()).
+		Where("user_id = ?", userID).
+		OrderBy("create_at DESC", "id DESC").
+		Limit(uint64(keep))
+	keptSQL, keptArgs, err := kept.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "failed to build password history query")
+	}
+
+	_, err = s.execBuilder(tx, sq.
+		Delete(s.getPasswordHistoryTable()).
+		Where("user_id = ?", userID).
+		Where(sq.Expr("id NOT IN ("+keptSQL+")", keptArgs...)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to prune password history")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetPasswordHistory fetches the given number of the most recent previous
+// password hashes of a user.
+func (s *SqlPasswordHistoryStore) GetPasswordHistory(userID string, limit int) ([]string, error) {
+	hashes := []string{}
+	if limit <= 0 {
+		return hashes, nil
+	}
+
+	err := s.selectBuilder(s.db, &hashes, sq.
+		Select("password").
+		From(s.getPasswordHistoryTable()).
+		Where("user_id = ?", userID).
+		OrderBy("create_at DESC", "id DESC").
+		Limit(uint64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get password history of user %s", userID)
+	}
+
+	return hashes, nil
+}
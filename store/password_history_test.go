@@ -0,0 +1,47 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestPasswordHistory(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	userID := model.NewID()
+
+	hashes, err := th.SqlStore.PasswordHistory().GetPasswordHistory(userID, 5)
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	for _, hash := range []string{"hash1", "hash2", "hash3"} {
+		err = th.SqlStore.PasswordHistory().AddPasswordHistory(userID, hash, 2)
+		require.NoError(t, err)
+	}
+	err = th.SqlStore.PasswordHistory().AddPasswordHistory(model.NewID(), "other", 2)
+	require.NoError(t, err)
+
+	hashes, err = th.SqlStore.PasswordHistory().GetPasswordHistory(userID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash3", "hash2"}, hashes)
+
+	hashes, err = th.SqlStore.PasswordHistory().GetPasswordHistory(userID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash3"}, hashes)
+
+	hashes, err = th.SqlStore.PasswordHistory().GetPasswordHistory(userID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	// Keeping nothing clears the history.
+	err = th.SqlStore.PasswordHistory().AddPasswordHistory(userID, "hash4", 0)
+	require.NoError(t, err)
+	hashes, err = th.SqlStore.PasswordHistory().GetPasswordHistory(userID, 5)
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+type SqlPersonalAccessTokenStore struct {
+	*SqlStore
+}
+
+func newSqlPersonalAccessTokenStore(sqlStore *SqlStore) PersonalAccessTokenStore {
+	s := &SqlPersonalAccessTokenStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) PersonalAccessToken() PersonalAccessTokenStore {
+	return s.stores.personalAccessToken
+}
+
+var personalAccessTokenSelect sq.SelectBuilder
+
+func init() {
+	personalAccessTokenSelect = sq.
+		Select(
+			"id",
+			"user_id",
+			"name",
+			"scope",
+			"token_hash",
+			"create_at",
+			"expires_at",
+			"last_used_at",
+			"delete_at",
+		)
+}
+
+func (s *SqlPersonalAccessTokenStore) getPersonalAccessTokenTable() string {
+	return s.tablePrefix + "personal_access_token"
+}
+
+// CreatePersonalAccessToken inserts a new personal access token. Its PreSave
+// must have been called to generate the token.
+func (s *SqlPersonalAccessTokenStore) CreatePersonalAccessToken(token *model.PersonalAccessToken) (*model.PersonalAccessToken, error) {
+	if err := token.IsValid(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getPersonalAccessTokenTable()).
+		SetMap(map[string]interface{}{
+			"id":           token.ID,
+			"user_id":      token.UserID,
+			"name":         token.Name,
+			"scope":        token.Scope,
+			"token_hash":   token.TokenHash,
+			"create_at":    token.CreateAt,
+			"expires_at":   token.ExpiresAt,
+			"last_used_at": token.LastUsedAt,
+			"delete_at":    token.DeleteAt,
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create personal access token")
+	}
+
+	return token, nil
+}
+
+// GetPersonalAccessToken fetches the given personal access token by id.
+func (s *SqlPersonalAccessTokenStore) GetPersonalAccessToken(id string) (*model.PersonalAccessToken, error) {
+	return s.getPersonalAccessToken(sq.Eq{"id": id})
+}
+
+// GetPersonalAccessTokenByHash fetches the personal access token with the given
+// hash.
+func (s *SqlPersonalAccessTokenStore) GetPersonalAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error) {
+	return s.getPersonalAccessToken(sq.Eq{"token_hash": tokenHash})
+}
+
+func (s *SqlPersonalAccessTokenStore) getPersonalAccessToken(where sq.Eq) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	err := s.getBuilder(
+		s.db,
+		&token,
+		personalAccessTokenSelect.From(s.getPersonalAccessTokenTable()).Where(where),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get personal access token")
+	}
+
+	return &token, nil
+}
+
+// GetPersonalAccessTokensForUser fetches the personal access tokens of a user
+// that were not revoked, newest first.
+func (s *SqlPersonalAccessTokenStore) GetPersonalAccessTokensForUser(userID string) ([]*model.PersonalAccessToken, error) {
+	tokens := []*model.PersonalAccessToken{}
+	err := s.selectBuilder(
+		s.db,
+		&tokens,
+		personalAccessTokenSelect.From(s.getPersonalAccessTokenTable()).
+			Where("user_id = ?", userID).
+			Where("delete_at = 0").
+			OrderBy("create_at DESC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get personal access tokens")
+	}
+
+	return tokens, nil
+}
+
+// UpdatePersonalAccessTokenLastUsed records when a personal access token was
+// last used.
+func (s *SqlPersonalAccessTokenStore) UpdatePersonalAccessTokenLastUsed(id string, lastUsedAt int64) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getPersonalAccessTokenTable()).
+			Where("id = ?", id).
+			Set("last_used_at", lastUsedAt),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update personal access token usage")
+	}
+
+	return nil
+}
+
+// DeletePersonalAccessToken revokes a personal access token.
+func (s *SqlPersonalAccessTokenStore) DeletePersonalAccessToken(id string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getPersonalAccessTokenTable()).
+			Where("id = ?", id).
+			Where("delete_at = 0").
+			Set("delete_at", model.GetMillis()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete personal access token")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalAccessTokens(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown token", func(t *testing.T) {
+		token, err := th.SqlStore.PersonalAccessToken().GetPersonalAccessToken(model.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+
+		token, err = th.SqlStore.PersonalAccessToken().GetPersonalAccessTokenByHash(model.HashToken("junk"))
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	t.Run("create invalid token", func(t *testing.T) {
+		token := &model.PersonalAccessToken{UserID: model.NewID(), Name: "script", Scope: "junk"}
+		token.PreSave()
+
+		_, err := th.SqlStore.PersonalAccessToken().CreatePersonalAccessToken(token)
+		require.Error(t, err)
+	})
+
+	t.Run("create, use and delete token", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+
+		token := &model.PersonalAccessToken{UserID: user.ID, Name: "script", Scope: model.PersonalAccessTokenScopeRead}
+		value := token.PreSave()
+
+		_, err := th.SqlStore.PersonalAccessToken().CreatePersonalAccessToken(token)
+		require.NoError(t, err)
+
+		saved, err := th.SqlStore.PersonalAccessToken().GetPersonalAccessTokenByHash(model.HashToken(value))
+		require.NoError(t, err)
+		assert.Equal(t, token, saved)
+
+		err = th.SqlStore.PersonalAccessToken().UpdatePersonalAccessTokenLastUsed(token.ID, 42)
+		require.NoError(t, err)
+
+		tokens, err := th.SqlStore.PersonalAccessToken().GetPersonalAccessTokensForUser(user.ID)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, int64(42), tokens[0].LastUsedAt)
+
+		err = th.SqlStore.PersonalAccessToken().DeletePersonalAccessToken(token.ID)
+		require.NoError(t, err)
+
+		tokens, err = th.SqlStore.PersonalAccessToken().GetPersonalAccessTokensForUser(user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+
+		saved, err = th.SqlStore.PersonalAccessToken().GetPersonalAccessToken(token.ID)
+		require.NoError(t, err)
+		assert.True(t, saved.IsRevoked())
+	})
+}
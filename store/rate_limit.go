@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+type SqlRateLimitStore struct {
+	*SqlStore
+}
+
+func newSqlRateLimitStore(sqlStore *SqlStore) RateLimitStore {
+	s := &SqlRateLimitStore{
+		SqlStore: sqlStore,
+	}
+
+	return s
+}
+
+func (s *SqlStore) RateLimit() RateLimitStore {
+	return s.stores.rateLimit
+}
+
+func (s *SqlRateLimitStore) getRateLimitTable() string {
+	return s.tablePrefix + "rate_limit"
+}
+
+// rateLimitBucket is the state of a bucket after taking a token.
+type rateLimitBucket struct {
+	Tokens  float64
+	Allowed bool
+}
+
+// TakeRateLimitToken refills the bucket of the given key as of the given time
+// and takes a token from it if it holds at least one, returning the tokens
+// left and whether one was taken. The bucket is updated in a single statement
+// so that concurrent servers share it.
+func (s *SqlRateLimitStore) TakeRateLimitToken(key string, capacity, refillPerMilli float64, now int64) (float64, bool, error) {
+	table := s.getRateLimitTable()
+	refilled := fmt.Sprintf(
+		"LEAST(%[2]s, %[1]s.tokens + GREATEST(EXCLUDED.updated_at - %[1]s.updated_at, 0) * %[3]s)",
+		table, formatFloat(capacity), formatFloat(refillPerMilli),
+	)
+
+	var bucket rateLimitBucket
+	err := s.getBuilder(s.db, &bucket, sq.
+		Insert(table).
+		SetMap(map[string]interface{}{
+			"key":        key,
+			"tokens":     capacity - 1,
+			"allowed":    true,
+			"updated_at": now,
+		}).
+		Suffix(fmt.Sprintf(`ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END,
+			allowed = %[1]s >= 1,
+			updated_at = GREATEST(EXCLUDED.updated_at, %[2]s.updated_at)
+			RETURNING tokens, allowed`, refilled, table)),
+	)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to take rate limit token")
+	}
+
+	return bucket.Tokens, bucket.Allowed, nil
+}
+
+// formatFloat formats a float as an SQL literal.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64) + "::DOUBLE PRECISION"
+}
+
+// DeleteRateLimitsBefore removes the buckets last used before the given time.
+func (s *SqlRateLimitStore) DeleteRateLimitsBefore(before int64) error {
+	_, err := s.execBuilder(s.db, sq.
+		Delete(s.getRateLimitTable()).
+		Where(sq.Lt{"updated_at": before}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete rate limits")
+	}
+
+	return nil
+}
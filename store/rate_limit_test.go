@@ -0,0 +1,66 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestRateLimits(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("take tokens until the bucket is empty", func(t *testing.T) {
+		key := model.NewID()
+
+		tokens, allowed, err := th.SqlStore.RateLimit().TakeRateLimitToken(key, 2, 0.001, 1000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, float64(1), tokens)
+
+		tokens, allowed, err = th.SqlStore.RateLimit().TakeRateLimitToken(key, 2, 0.001, 1000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, float64(0), tokens)
+
+		tokens, allowed, err = th.SqlStore.RateLimit().TakeRateLimitToken(key, 2, 0.001, 1500)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.InDelta(t, 0.5, tokens, 0.0001)
+
+		tokens, allowed, err = th.SqlStore.RateLimit().TakeRateLimitToken(key, 2, 0.001, 2000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.InDelta(t, 0, tokens, 0.0001)
+
+		// The bucket doesn't refill past its capacity.
+		tokens, allowed, err = th.SqlStore.RateLimit().TakeRateLimitToken(key, 2, 0.001, 100000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.InDelta(t, 1, tokens, 0.0001)
+	})
+
+	t.Run("delete idle buckets", func(t *testing.T) {
+		idle := model.NewID()
+		active := model.NewID()
+
+		_, _, err := th.SqlStore.RateLimit().TakeRateLimitToken(idle, 1, 0.001, 1000)
+		require.NoError(t, err)
+		_, _, err = th.SqlStore.RateLimit().TakeRateLimitToken(active, 1, 0.001, 5000)
+		require.NoError(t, err)
+
+		err = th.SqlStore.RateLimit().DeleteRateLimitsBefore(2000)
+		require.NoError(t, err)
+
+		// The idle bucket starts full again.
+		_, allowed, err := th.SqlStore.RateLimit().TakeRateLimitToken(idle, 1, 0.001, 1000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		_, allowed, err = th.SqlStore.RateLimit().TakeRateLimitToken(active, 1, 0.001, 5000)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
@@ -25,8 +25,9 @@ func (s *SqlStore) Role() RoleStore {
 }
 
 var (
-	roleSelect     sq.SelectBuilder
-	userRoleSelect sq.SelectBuilder
+	roleSelect           sq.SelectBuilder
+	userRoleSelect       sq.SelectBuilder
+	rolePermissionSelect sq.SelectBuilder
 )
 
 func init() {
@@ -36,6 +37,7 @@ func init() {
 			"name",
 			"create_at",
 			"update_at",
+			"require_mfa",
 		)
 	userRoleSelect = sq.
 		Select(
@@ -44,6 +46,12 @@ func init() {
 			"create_at",
 			"update_at",
 		)
+	rolePermissionSelect = sq.
+		Select(
+			"role_id",
+			"permission",
+			"create_at",
+		)
 }
 
 func (s *SqlRoleStore) getRoleTable() string {
@@ -54,6 +62,10 @@ func (s *SqlRoleStore) getUserRoleTable() string {
 	return s.tablePrefix + "user_role"
 }
 
+func (s *SqlRoleStore) getRolePermissionTable() string {
+	return s.tablePrefix + "role_permission"
+}
+
 // CreateRole inserts a new role.
 func (s *SqlRoleStore) CreateRole(role *model.Role) (*model.Role, error) {
 	role.CreatePreSave()
@@ -78,7 +90,32 @@ func (s *SqlRoleStore) CreateRole(role *model.Role) (*model.Role, error) {
 	return role, nil
 }
 
-// GetRoleByName returns the role entity for the provided name.
+// GetRole returns the role entity for the provided id along with its
+// permissions.
+func (s *SqlRoleStore) GetRole(id string) (*model.Role, error) {
+	var role model.Role
+	err := s.getBuilder(
+		s.db,
+		&role,
+		roleSelect.From(s.getRoleTable()).
+			Where("id = ?", id),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to get role %s", id)
+	}
+
+	role.Permissions, err = s.GetRolePermissions(role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetRoleByName returns the role entity for the provided name along with its
+// permissions.
 func (s *SqlRoleStore) GetRoleByName(name string) (*model.Role, error) {
 	var role model.Role
 	err := s.getBuilder(
@@ -93,9 +130,190 @@ func (s *SqlRoleStore) GetRoleByName(name string) (*model.Role, error) {
 		return nil, errors.Wrapf(err, "failed to get role by name %s", name)
 	}
 
+	role.Permissions, err = s.GetRolePermissions(role.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &role, nil
 }
 
+// GetRoles returns every role ordered by name along with their permissions.
+func (s *SqlRoleStore) GetRoles() ([]*model.Role, error) {
+	var roles []*model.Role
+	err := s.selectBuilder(
+		s.db,
+		&roles,
+		roleSelect.From(s.getRoleTable()).
+			OrderBy("name ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get roles")
+	}
+
+	var rolePermissions []*model.RolePermission
+	err = s.selectBuilder(
+		s.db,
+		&rolePermissions,
+		rolePermissionSelect.From(s.getRolePermissionTable()).
+			OrderBy("permission ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get role permissions")
+	}
+
+	permissionsByRole := make(map[string]model.StringList)
+	for _, rolePermission := range rolePermissions {
+		permissionsByRole[rolePermission.RoleID] = append(permissionsByRole[rolePermission.RoleID], rolePermission.Permission)
+	}
+	for _, role := range roles {
+		role.Permissions = permissionsByRole[role.ID]
+		if role.Permissions == nil {
+			role.Permissions = model.StringList{}
+		}
+	}
+
+	return roles, nil
+}
+
+// SetRoleRequireMFA sets whether users with the given role must log in with
+// two-factor authentication.
+func (s *SqlRoleStore) SetRoleRequireMFA(roleID string, requireMFA bool) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update(s.getRoleTable()).
+			Set("require_mfa", requireMFA).
+			Set("update_at", model.GetMillis()).
+			Where("id = ?", roleID),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set whether role %s requires MFA", roleID)
+	}
+
+	return nil
+}
+
+// GetRolePermissions returns the permissions granted to the given role.
+func (s *SqlRoleStore) GetRolePermissions(roleID string) (model.StringList, error) {
+	permissions := []string{}
+	err := s.selectBuilder(
+		s.db,
+		&permissions,
+		sq.Select("permission").
+			From(s.getRolePermissionTable()).
+			Where("role_id = ?", roleID).
+			OrderBy("permission ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get permissions of role %s", roleID)
+	}
+
+	return model.StringList(permissions), nil
+}
+
+// AddRolePermission grants a permission to the given role. Granting a
+// permission the role already has is a no-op.
+func (s *SqlRoleStore) AddRolePermission(roleID, permission string) error {
+	_, err := s.execBuilder(s.db, sq.
+		Insert(s.getRolePermissionTable()).
+		SetMap(map[string]interface{}{
+			"role_id":    roleID,
+			"permission": permission,
+			"create_at":  model.GetMillis(),
+		}).
+		Suffix("ON CONFLICT DO NOTHING"),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add permission %s to role %s", permission, roleID)
+	}
+
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of the given role.
+func (s *SqlRoleStore) SetRolePermissions(roleID string, permissions []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = s.execBuilder(tx, sq.
+		Delete(s.getRolePermissionTable()).
+		Where("role_id = ?", roleID),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete permissions of role %s", roleID)
+	}
+
+	now := model.GetMillis()
+	for _, permission := range permissions {
+		_, err = s.execBuilder(tx, sq.
+			Insert(s.getRolePermissionTable()).
+			SetMap(map[string]interface{}{
+				"role_id":    roleID,
+				"permission": permission,
+				"create_at":  now,
+			}).
+			Suffix("ON CONFLICT DO NOTHING"),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add permission %s to role %s", permission, roleID)
+		}
+	}
+
+	_, err = s.execBuilder(tx, sq.
+		Update(s.getRoleTable()).
+		Set("update_at", now).
+		Where("id = ?", roleID),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update role %s", roleID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// UserHasPermission returns true if any of the roles of the given user grants
+// the permission.
+func (s *SqlRoleStore) UserHasPermission(userID, permission string) (bool, error) {
+	var hasPermission bool
+	err := s.getBuilder(s.db, &hasPermission, sq.
+		Select("COUNT(*) > 0").
+		From(s.getUserRoleTable()+" ur").
+		Join(s.getRolePermissionTable()+" rp ON ur.role_id = rp.role_id").
+		Where("ur.user_id = ? AND rp.permission = ?", userID, permission),
+	)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check permission %s of user %s", permission, userID)
+	}
+
+	return hasPermission, nil
+}
+
+// GetRolesForUser returns the roles of the given user ordered by name.
+func (s *SqlRoleStore) GetRolesForUser(userID string) ([]*model.Role, error) {
+	roles := []*model.Role{}
+	err := s.selectBuilder(
+		s.db,
+		&roles,
+		sq.Select("r.id", "r.name", "r.create_at", "r.update_at", "r.require_mfa").
+			From(s.getRoleTable()+" r").
+			Join(s.getUserRoleTable()+" ur ON ur.role_id = r.id").
+			Where("ur.user_id = ?", userID).
+			OrderBy("r.name ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get roles of user %s", userID)
+	}
+
+	return roles, nil
+}
+
 func (s *SqlRoleStore) UserHasRole(userID, roleID string) (bool, error) {
 	var userRole model.UserRole
 	err := s.getBuilder(
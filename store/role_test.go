@@ -55,6 +55,79 @@ func TestRoles(t *testing.T) {
 	})
 }
 
+func TestRolePermissions(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("should seed the default roles with permissions", func(t *testing.T) {
+		role, err := th.SqlStore.Role().GetRoleByName(model.AdminRoleName)
+		require.NoError(t, err)
+		require.NotNil(t, role)
+		assert.ElementsMatch(t, model.Permissions, role.Permissions)
+
+		role, err = th.SqlStore.Role().GetRoleByName(model.UserRoleName)
+		require.NoError(t, err)
+		require.NotNil(t, role)
+		assert.ElementsMatch(t, model.DefaultUserRolePermissions, role.Permissions)
+	})
+
+	t.Run("should set the permissions of a role", func(t *testing.T) {
+		role, err := th.SqlStore.Role().CreateRole(&model.Role{Name: "role" + model.NewID()})
+		require.NoError(t, err)
+
+		err = th.SqlStore.Role().AddRolePermission(role.ID, model.PermissionCyclesRead)
+		require.NoError(t, err)
+		err = th.SqlStore.Role().AddRolePermission(role.ID, model.PermissionCyclesRead)
+		require.NoError(t, err)
+
+		permissions, err := th.SqlStore.Role().GetRolePermissions(role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.StringList{model.PermissionCyclesRead}, permissions)
+
+		err = th.SqlStore.Role().SetRolePermissions(role.ID, []string{model.PermissionCyclesWrite, model.PermissionUsersManage})
+		require.NoError(t, err)
+
+		role, err = th.SqlStore.Role().GetRole(role.ID)
+		require.NoError(t, err)
+		require.NotNil(t, role)
+		assert.ElementsMatch(t, []string{model.PermissionCyclesWrite, model.PermissionUsersManage}, role.Permissions)
+
+		roles, err := th.SqlStore.Role().GetRoles()
+		require.NoError(t, err)
+		found := false
+		for _, r := range roles {
+			if r.ID == role.ID {
+				found = true
+				assert.ElementsMatch(t, role.Permissions, r.Permissions)
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("should check the permissions of a user through their roles", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+
+		hasPermission, err := th.SqlStore.Role().UserHasPermission(user.ID, model.PermissionCyclesRead)
+		require.NoError(t, err)
+		assert.False(t, hasPermission)
+
+		role, err := th.SqlStore.Role().CreateRole(&model.Role{Name: "role" + model.NewID()})
+		require.NoError(t, err)
+		err = th.SqlStore.Role().SetRolePermissions(role.ID, []string{model.PermissionCyclesWrite})
+		require.NoError(t, err)
+		err = th.SqlStore.Role().AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		hasPermission, err = th.SqlStore.Role().UserHasPermission(user.ID, model.PermissionCyclesWrite)
+		require.NoError(t, err)
+		assert.True(t, hasPermission)
+
+		hasPermission, err = th.SqlStore.Role().UserHasPermission(user.ID, model.PermissionUsersManage)
+		require.NoError(t, err)
+		assert.False(t, hasPermission)
+	})
+}
+
 func TestUserRoles(t *testing.T) {
 	th := SetupStoreTestHelper(t)
 	defer th.TearDown(t)
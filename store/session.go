@@ -31,11 +31,14 @@ func init() {
 	sessionSelect = sq.
 		Select(
 			"id",
-			"token",
 			"create_at",
 			"expires_at",
 			"user_id",
 			"csrf_token",
+			"source",
+			"user_agent",
+			"ip_address",
+			"last_activity_at",
 		)
 }
 
@@ -43,19 +46,23 @@ func (s *SqlSessionStore) getSessionTable() string {
 	return s.tablePrefix + "session"
 }
 
-// CreateSession inserts a new session.
+// CreateSession inserts a new session. Only the hash of its token is stored.
 func (s *SqlSessionStore) CreateSession(session *model.Session) (*model.Session, error) {
 	session.PreSave()
 
 	_, err := s.execBuilder(s.db, sq.
 		Insert(s.getSessionTable()).
 		SetMap(map[string]interface{}{
-			"id":         session.ID,
-			"token":      session.Token,
-			"create_at":  session.CreateAt,
-			"expires_at": session.ExpiresAt,
-			"user_id":    session.UserID,
-			"csrf_token": session.CSRFToken,
+			"id":               session.ID,
+			"token_hash":       s.hashToken(session.Token),
+			"create_at":        session.CreateAt,
+			"expires_at":       session.ExpiresAt,
+			"user_id":          session.UserID,
+			"csrf_token":       session.CSRFToken,
+			"source":           session.Source,
+			"user_agent":       session.UserAgent,
+			"ip_address":       session.IPAddress,
+			"last_activity_at": session.LastActivityAt,
 		}),
 	)
 	if err != nil {
@@ -65,21 +72,45 @@ func (s *SqlSessionStore) CreateSession(session *model.Session) (*model.Session,
 	return session, nil
 }
 
-// GetSession fetches the given session by id or token. Does not return expired sessions.
-func (s *SqlSessionStore) GetSession(idOrToken string) (*model.Session, error) {
+// GetSession fetches the given session by id. Does not return expired sessions.
+func (s *SqlSessionStore) GetSession(id string) (*model.Session, error) {
+	session, err := s.getSession(sq.Eq{"id": id})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get session by id")
+	}
+
+	return session, nil
+}
+
+// GetSessionByToken fetches the session authenticated by the given token.
+// Does not return expired sessions.
+func (s *SqlSessionStore) GetSessionByToken(token string) (*model.Session, error) {
+	session, err := s.getSession(sq.Eq{"token_hash": s.hashToken(token)})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get session by token")
+	}
+	if session != nil {
+		session.Token = token
+	}
+
+	return session, nil
+}
+
+// getSession fetches the session matching the given condition, deleting it
+// if it expired.
+func (s *SqlSessionStore) getSession(where sq.Eq) (*model.Session, error) {
 	sessionTable := s.getSessionTable()
 
 	var session model.Session
 	err := s.getBuilder(
 		s.db,
 		&session,
-		sessionSelect.From(sessionTable).
-			Where(sq.Or{sq.Eq{"id": idOrToken}, sq.Eq{"token": idOrToken}}),
+		sessionSelect.From(sessionTable).Where(where),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
-		return nil, errors.Wrap(err, "failed to get session by id or token")
+		return nil, err
 	}
 
 	if session.IsExpired() {
@@ -100,6 +131,42 @@ func (s *SqlSessionStore) GetSession(idOrToken string) (*model.Session, error) {
 	return &session, nil
 }
 
+// GetSessionsForUser fetches the unexpired sessions of the given user, the
+// most recently active first.
+func (s *SqlSessionStore) GetSessionsForUser(userID string) ([]*model.Session, error) {
+	sessions := []*model.Session{}
+	err := s.selectBuilder(
+		s.db,
+		&sessions,
+		sessionSelect.From(s.getSessionTable()).
+			Where("user_id = ?", userID).
+			Where("expires_at > ?", model.GetMillis()).
+			OrderBy("last_activity_at DESC", "id ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get sessions of user %s", userID)
+	}
+
+	return sessions, nil
+}
+
+// UpdateSessionActivity records the last time the given session was used and
+// when it now expires.
+func (s *SqlSessionStore) UpdateSessionActivity(id string, lastActivityAt, expiresAt int64) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update(s.getSessionTable()).
+			Set("last_activity_at", lastActivityAt).
+			Set("expires_at", expiresAt).
+			Where("id = ?", id),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update activity of session %s", id)
+	}
+
+	return nil
+}
+
 // DeleteSession deletes a session by ID.
 func (s *SqlSessionStore) DeleteSession(id string) error {
 	_, err := s.execBuilder(
@@ -136,3 +203,24 @@ func (s *SqlSessionStore) DeleteSessionsForUser(userID string) error {
 
 	return nil
 }
+
+// DeleteOtherSessionsForUser deletes all the sessions of a user but the given
+// one.
+func (s *SqlSessionStore) DeleteOtherSessionsForUser(userID, sessionID string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Delete(s.getSessionTable()).
+			Where("user_id = ?", userID).
+			Where("id <> ?", sessionID),
+	)
+	if err != nil {
+		s.logger.
+			WithField("type", "session").
+			WithField("user_id", userID).
+			WithError(err).
+			Error("unable to delete other user sessions")
+		return err
+	}
+
+	return nil
+}
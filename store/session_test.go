@@ -36,17 +36,32 @@ func TestSessions(t *testing.T) {
 		session, err = th.SqlStore.Session().GetSession(session.ID)
 		require.NoError(t, err)
 		require.NotNil(t, session)
-		assert.Equal(t, token, session.Token)
+		assert.Empty(t, session.Token)
 
-		session, err = th.SqlStore.Session().GetSession(session.Token)
+		session, err = th.SqlStore.Session().GetSessionByToken(token)
 		require.NoError(t, err)
 		require.NotNil(t, session)
 		assert.Equal(t, id, session.ID)
+		assert.Equal(t, token, session.Token)
+
+		// Sessions are looked up by the hash of their token.
+		session, err = th.SqlStore.Session().GetSessionByToken(id)
+		require.NoError(t, err)
+		require.Nil(t, session)
+		session, err = th.SqlStore.Session().GetSession(token)
+		require.NoError(t, err)
+		require.Nil(t, session)
+
+		var tokenHash string
+		err = th.SqlStore.get(th.SqlStore.db, &tokenHash, "SELECT token_hash FROM "+th.SqlStore.tablePrefix+"session WHERE id = ?", id)
+		require.NoError(t, err)
+		assert.Len(t, tokenHash, 64)
+		assert.NotContains(t, tokenHash, token)
 
-		err = th.SqlStore.Session().DeleteSession(session.ID)
+		err = th.SqlStore.Session().DeleteSession(id)
 		assert.NoError(t, err)
 
-		session, err = th.SqlStore.Session().GetSession(session.ID)
+		session, err = th.SqlStore.Session().GetSession(id)
 		assert.NoError(t, err)
 		assert.Nil(t, session)
 	})
@@ -86,4 +101,47 @@ func TestSessions(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Nil(t, session2)
 	})
+
+	t.Run("list, touch and delete other sessions of a user", func(t *testing.T) {
+		userID := model.NewID()
+
+		session1, err := th.SqlStore.Session().CreateSession(&model.Session{
+			UserID:    userID,
+			Source:    model.SessionSourcePassword,
+			UserAgent: "agent",
+			IPAddress: "10.0.0.1",
+		})
+		require.NoError(t, err)
+		session2, err := th.SqlStore.Session().CreateSession(&model.Session{UserID: userID, Source: model.SessionSourceOAuth})
+		require.NoError(t, err)
+		other, err := th.SqlStore.Session().CreateSession(&model.Session{UserID: model.NewID()})
+		require.NoError(t, err)
+
+		err = th.SqlStore.Session().UpdateSessionActivity(session1.ID, session2.LastActivityAt+1000, session2.ExpiresAt+1000)
+		require.NoError(t, err)
+
+		sessions, err := th.SqlStore.Session().GetSessionsForUser(userID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, session1.ID, sessions[0].ID)
+		assert.Equal(t, session2.LastActivityAt+1000, sessions[0].LastActivityAt)
+		assert.Equal(t, session2.ExpiresAt+1000, sessions[0].ExpiresAt)
+		assert.Equal(t, model.SessionSourcePassword, sessions[0].Source)
+		assert.Equal(t, "agent", sessions[0].UserAgent)
+		assert.Equal(t, "10.0.0.1", sessions[0].IPAddress)
+		assert.Equal(t, session2.ID, sessions[1].ID)
+
+		err = th.SqlStore.Session().DeleteOtherSessionsForUser(userID, session2.ID)
+		require.NoError(t, err)
+
+		session, err := th.SqlStore.Session().GetSession(session1.ID)
+		require.NoError(t, err)
+		assert.Nil(t, session)
+		session, err = th.SqlStore.Session().GetSession(session2.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, session)
+		session, err = th.SqlStore.Session().GetSession(other.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, session)
+	})
 }
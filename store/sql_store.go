@@ -9,17 +9,30 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
+
 	// enable the pq driver
 	_ "github.com/lib/pq"
 )
 
 type SqlStoreStores struct {
-	oauthState     OAuthStateStore
-	role           RoleStore
-	session        SessionStore
-	token          TokenStore
-	user           UserStore
-	user_auth_info UserAuthInfoStore
+	apiKey                  APIKeyStore
+	auditLog                AuditLogStore
+	cycle                   CycleStore
+	githubAccess            GithubAccessStore
+	invitation              InvitationStore
+	loginAttempt            LoginAttemptStore
+	mfa                     MFAStore
+	notificationPreferences NotificationPreferencesStore
+	oauthState              OAuthStateStore
+	passwordHistory         PasswordHistoryStore
+	personalAccessToken     PersonalAccessTokenStore
+	rateLimit               RateLimitStore
+	role                    RoleStore
+	session                 SessionStore
+	token                   TokenStore
+	user                    UserStore
+	user_auth_info          UserAuthInfoStore
 }
 
 type SqlStore struct {
@@ -27,10 +40,15 @@ type SqlStore struct {
 	tablePrefix string
 	logger      logrus.FieldLogger
 	stores      SqlStoreStores
+	keyring     *encryption.Keyring
+	// tokenHashKey is the key one-time tokens and session tokens are
+	// hashed with, loaded when the store is initialized.
+	tokenHashKey []byte
 }
 
-// New constructs a new instance of SqlStore.
-func New(dsn string, tablePrefix string, logger logrus.FieldLogger) (*SqlStore, error) {
+// New constructs a new instance of SqlStore. The keyring encrypts the secrets
+// stored at rest, such as the key tokens are hashed with, and is required.
+func New(dsn string, tablePrefix string, keyring *encryption.Keyring, logger logrus.FieldLogger) (*SqlStore, error) {
 	url, err := url.Parse(dsn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse dsn as url")
@@ -70,12 +88,24 @@ func New(dsn string, tablePrefix string, logger logrus.FieldLogger) (*SqlStore,
 
 	var stores SqlStoreStores
 	store := &SqlStore{
-		db,
-		tablePrefix,
-		logger,
-		stores,
+		db:          db,
+		tablePrefix: tablePrefix,
+		logger:      logger,
+		stores:      stores,
+		keyring:     keyring,
 	}
+	store.stores.apiKey = newSqlAPIKeyStore(store)
+	store.stores.auditLog = newSqlAuditLogStore(store)
+	store.stores.cycle = newSqlCycleStore(store)
+	store.stores.githubAccess = newSqlGithubAccessStore(store)
+	store.stores.invitation = newSqlInvitationStore(store)
+	store.stores.loginAttempt = newSqlLoginAttemptStore(store)
+	store.stores.mfa = newSqlMFAStore(store)
+	store.stores.notificationPreferences = newSqlNotificationPreferencesStore(store)
 	store.stores.oauthState = newSqlOAuthStateStore(store)
+	store.stores.passwordHistory = newSqlPasswordHistoryStore(store)
+	store.stores.personalAccessToken = newSqlPersonalAccessTokenStore(store)
+	store.stores.rateLimit = newSqlRateLimitStore(store)
 	store.stores.role = newSqlRoleStore(store)
 	store.stores.session = newSqlSessionStore(store)
 	store.stores.token = newSqlTokenStore(store)
@@ -3,7 +3,18 @@ package store
 import "github.com/saturninoabril/dashboard-server/model"
 
 type Store interface {
+	APIKey() APIKeyStore
+	AuditLog() AuditLogStore
+	Cycle() CycleStore
+	GithubAccess() GithubAccessStore
+	Invitation() InvitationStore
+	LoginAttempt() LoginAttemptStore
+	MFA() MFAStore
+	NotificationPreferences() NotificationPreferencesStore
 	OAuthState() OAuthStateStore
+	PasswordHistory() PasswordHistoryStore
+	PersonalAccessToken() PersonalAccessTokenStore
+	RateLimit() RateLimitStore
 	Role() RoleStore
 	Session() SessionStore
 	Token() TokenStore
@@ -11,15 +22,105 @@ type Store interface {
 	UserAuthInfo() UserAuthInfoStore
 }
 
+type APIKeyStore interface {
+	CreateAPIKey(apiKey *model.APIKey) (*model.APIKey, error)
+	GetAPIKey(id string) (*model.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (*model.APIKey, error)
+	GetAPIKeys() ([]*model.APIKey, error)
+	UpdateAPIKeyUsage(id string, lastUsedAt int64, lastUsedIP string) error
+	DeleteAPIKey(id string) error
+}
+
+type AuditLogStore interface {
+	CreateAuditLog(entry *model.AuditLog) (*model.AuditLog, error)
+	GetAuditLogs(filter *model.AuditLogFilter) ([]*model.AuditLog, error)
+}
+
+type CycleStore interface {
+	CreateCycle(cycle *model.Cycle) (*model.Cycle, error)
+	GetCycle(id string) (*model.Cycle, error)
+	GetCycles(filter *model.CycleFilter) ([]*model.Cycle, error)
+}
+
+type GithubAccessStore interface {
+	CreateGithubAccessRule(rule *model.GithubAccessRule) (*model.GithubAccessRule, error)
+	GetGithubAccessRule(id string) (*model.GithubAccessRule, error)
+	GetGithubAccessRules() ([]*model.GithubAccessRule, error)
+	UpdateGithubAccessRule(rule *model.GithubAccessRule) error
+	DeleteGithubAccessRule(id string) error
+	GetGithubMembership(userID string) (*model.GithubMembership, error)
+	SaveGithubMembership(membership *model.GithubMembership) error
+	DeleteGithubMembership(userID string) error
+}
+
+type InvitationStore interface {
+	CreateInvitation(invitation *model.Invitation) (*model.Invitation, error)
+	GetInvitation(id string) (*model.Invitation, error)
+	GetInvitationsByEmail(email string) ([]*model.Invitation, error)
+	GetInvitations(now int64) ([]*model.Invitation, error)
+	DeleteInvitation(id string) error
+}
+
+type LoginAttemptStore interface {
+	GetLoginAttempts(action, scope, subject string) (*model.LoginAttempts, error)
+	RecordLoginFailure(action, scope, subject string, now, window int64) (*model.LoginAttempts, error)
+	LockLoginAttempts(action, scope, subject string, lockedUntil int64) error
+	GetLockedLoginAttempts(now int64) ([]*model.LoginAttempts, error)
+	DeleteLoginAttempts(action, scope, subject string) error
+	DeleteLoginAttemptsForSubject(scope, subject string) error
+}
+
+type MFAStore interface {
+	GetUserMFA(userID string) (*model.UserMFA, error)
+	SaveUserMFA(mfa *model.UserMFA) error
+	UseMFAStep(userID string, step int64) (bool, error)
+	DeleteUserMFA(userID string) error
+	SetMFARecoveryCodes(userID string, codeHashes []string) error
+	UseMFARecoveryCode(userID, codeHash string) (bool, error)
+	CountMFARecoveryCodes(userID string) (int, error)
+}
+
+type NotificationPreferencesStore interface {
+	GetNotificationPreferences(userID string) (*model.NotificationPreferences, error)
+	SaveNotificationPreferences(preferences *model.NotificationPreferences) error
+}
+
 type OAuthStateStore interface {
-	CreateOAuthState() (*model.OAuthState, error)
+	CreateOAuthState(provider, userID string) (*model.OAuthState, error)
 	GetOAuthState(idOrToken string) (*model.OAuthState, error)
 	DeleteOAuthState(id string) error
 }
 
+type PasswordHistoryStore interface {
+	AddPasswordHistory(userID, passwordHash string, keep int) error
+	GetPasswordHistory(userID string, limit int) ([]string, error)
+}
+
+type PersonalAccessTokenStore interface {
+	CreatePersonalAccessToken(token *model.PersonalAccessToken) (*model.PersonalAccessToken, error)
+	GetPersonalAccessToken(id string) (*model.PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error)
+	GetPersonalAccessTokensForUser(userID string) ([]*model.PersonalAccessToken, error)
+	UpdatePersonalAccessTokenLastUsed(id string, lastUsedAt int64) error
+	DeletePersonalAccessToken(id string) error
+}
+
+type RateLimitStore interface {
+	TakeRateLimitToken(key string, capacity, refillPerMilli float64, now int64) (float64, bool, error)
+	DeleteRateLimitsBefore(before int64) error
+}
+
 type RoleStore interface {
 	CreateRole(role *model.Role) (*model.Role, error)
+	GetRole(id string) (*model.Role, error)
 	GetRoleByName(name string) (*model.Role, error)
+	GetRoles() ([]*model.Role, error)
+	GetRolesForUser(userID string) ([]*model.Role, error)
+	GetRolePermissions(roleID string) (model.StringList, error)
+	AddRolePermission(roleID, permission string) error
+	SetRolePermissions(roleID string, permissions []string) error
+	SetRoleRequireMFA(roleID string, requireMFA bool) error
+	UserHasPermission(userID, permission string) (bool, error)
 	UserHasRole(userID, roleID string) (bool, error)
 	UserHasRoleByName(userID, roleName string) (bool, error)
 	AddUserRole(userID, roleID string) error
@@ -28,33 +129,48 @@ type RoleStore interface {
 
 type SessionStore interface {
 	CreateSession(session *model.Session) (*model.Session, error)
-	GetSession(idOrToken string) (*model.Session, error)
+	GetSession(id string) (*model.Session, error)
+	GetSessionByToken(token string) (*model.Session, error)
+	GetSessionsForUser(userID string) ([]*model.Session, error)
+	UpdateSessionActivity(id string, lastActivityAt, expiresAt int64) error
 	DeleteSession(id string) error
 	DeleteSessionsForUser(userID string) error
+	DeleteOtherSessionsForUser(userID, sessionID string) error
 }
 
 type TokenStore interface {
 	CreateToken(token *model.Token) (*model.Token, error)
+	CreateDerivedToken(tokenType, extra string) (*model.Token, error)
 	GetToken(tokenValue string) (*model.Token, error)
 	GetTokensByEmail(email, tokenType string) ([]*model.Token, error)
+	GetTokensByExtra(extra, tokenType string) ([]*model.Token, error)
 	DeleteToken(tokenValue string) error
 	DeleteTokensByEmail(email, tokenType string) error
+	DeleteTokensByExtra(extra, tokenType string) error
 	CleanupTokenStore(expiryTimeMillis int64)
 }
 
 type UserAuthInfoStore interface {
 	CreateUserAuthInfo(user *model.UserAuthInfo) (*model.UserAuthInfo, error)
 	GetUserAuthInfo(userID, oauthProvider string) (*model.UserAuthInfo, error)
-	UpdateUserAuthInfoToken(user *model.UserAuthInfo) error
+	GetUserAuthInfoByProviderUserID(oauthProvider, providerUserID string) (*model.UserAuthInfo, error)
+	GetUserAuthInfosByProvider(oauthProvider string) ([]*model.UserAuthInfo, error)
+	UpdateUserAuthInfo(user *model.UserAuthInfo) error
+	DeleteUserAuthInfo(userID, oauthProvider string) error
 }
 
 type UserStore interface {
 	CreateUser(user *model.User) (*model.User, error)
 	GetUser(id string) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
+	GetUsers(filter *model.UserFilter) ([]*model.User, error)
 	VerifyEmail(id, email string) error
 	UnverifyEmail(id, email string) error
-	UpdatePassword(id, password string) error
+	SetPendingEmail(id, pendingEmail string) error
+	ConfirmPendingEmail(id, pendingEmail string) (bool, error)
+	UpdatePassword(id, passwordHash string) error
+	UpdatePasswordHash(id, oldHash, newHash string) error
 	UpdateUser(user *model.User) error
 	UpdateUserState(userID string, state string) error
+	DeleteUser(id string) error
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/testlib"
 	log "github.com/sirupsen/logrus"
@@ -12,6 +13,9 @@ import (
 
 const (
 	testPassword = "Password12"
+	// testEncryptionKeys is the keyring of the test stores. It is fixed since
+	// the test database, and the secrets encrypted in it, outlive test runs.
+	testEncryptionKeys = "test:dGVzdC1lbmNyeXB0aW9uLWtleS0wMDAwMDAwMDAwMDA="
 )
 
 type StoreTestHelper struct {
@@ -34,7 +38,9 @@ func (th *StoreTestHelper) TearDown(t *testing.T) {
 func MakeTestStore(tb testing.TB, logger log.FieldLogger) *SqlStore {
 	dsn := os.Getenv("DASHBOARD_DATABASE_TEST")
 	tablePrefix := os.Getenv("DASHBOARD_TABLE_PREFIX")
-	sqlStore, err := New(dsn, tablePrefix, logger)
+	keyring, err := encryption.ParseKeyring(testEncryptionKeys)
+	require.NoError(tb, err)
+	sqlStore, err := New(dsn, tablePrefix, keyring, logger)
 	require.NoError(tb, err)
 
 	return sqlStore
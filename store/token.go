@@ -28,7 +28,6 @@ var tokenSelect sq.SelectBuilder
 
 func init() {
 	tokenSelect = sq.Select(
-		"token",
 		"create_at",
 		"type",
 		"extra",
@@ -39,7 +38,7 @@ func (s *SqlTokenStore) getTokenTable() string {
 	return s.tablePrefix + "token"
 }
 
-// CreateToken inserts a new token.
+// CreateToken inserts a new token. Only the hash of its value is stored.
 func (s *SqlTokenStore) CreateToken(token *model.Token) (*model.Token, error) {
 	err := token.IsValid()
 	if err != nil {
@@ -50,10 +49,10 @@ func (s *SqlTokenStore) CreateToken(token *model.Token) (*model.Token, error) {
 		s.db,
 		sq.Insert(s.getTokenTable()).
 			SetMap(map[string]interface{}{
-				"token":     token.Token,
-				"create_at": token.CreateAt,
-				"type":      token.Type,
-				"extra":     token.Extra,
+				"token_hash": s.hashToken(token.Token),
+				"create_at":  token.CreateAt,
+				"type":       token.Type,
+				"extra":      token.Extra,
 			}),
 	)
 	if err != nil {
@@ -63,41 +62,84 @@ func (s *SqlTokenStore) CreateToken(token *model.Token) (*model.Token, error) {
 	return token, nil
 }
 
+// CreateDerivedToken returns the token of the given type and extra value,
+// creating it the first time. Its value is derived from the type and extra
+// value with the token hash key instead of being random, so the same token is
+// returned every time while only its hash is stored.
+func (s *SqlTokenStore) CreateDerivedToken(tokenType, extra string) (*model.Token, error) {
+	token := &model.Token{
+		Token:    s.deriveToken(tokenType, extra),
+		CreateAt: model.GetMillis(),
+		Type:     tokenType,
+		Extra:    extra,
+	}
+	err := token.IsValid()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+
+	_, err = s.execBuilder(
+		s.db,
+		sq.Insert(s.getTokenTable()).
+			SetMap(map[string]interface{}{
+				"token_hash": s.hashToken(token.Token),
+				"create_at":  token.CreateAt,
+				"type":       token.Type,
+				"extra":      token.Extra,
+			}).
+			Suffix("ON CONFLICT (token_hash) DO NOTHING"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create derived token")
+	}
+
+	return s.GetToken(token.Token)
+}
+
 // GetToken fetches the given token by token value.
 func (s *SqlTokenStore) GetToken(tokenValue string) (*model.Token, error) {
 	var token model.Token
 	err := s.getBuilder(
 		s.db,
 		&token,
-		tokenSelect.From(s.getTokenTable()).Where("token = ?", tokenValue),
+		tokenSelect.From(s.getTokenTable()).Where("token_hash = ?", s.hashToken(tokenValue)),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
 		return nil, errors.Wrap(err, "failed to get token by token value")
 	}
+	token.Token = tokenValue
 
 	return &token, nil
 }
 
-// GetTokensByEmail fetches the tokens for the passed email
+// GetTokensByEmail fetches the tokens for the passed email. Their values are
+// not returned since only their hashes are stored.
 func (s *SqlTokenStore) GetTokensByEmail(email, tokenType string) ([]*model.Token, error) {
-	var tokens []*model.Token
 	extraField, err := model.CreateTokenTypeResetPasswordExtra(email)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get token by email")
 	}
-	err = s.selectBuilder(
+
+	return s.GetTokensByExtra(extraField, tokenType)
+}
+
+// GetTokensByExtra fetches the tokens of one type with the passed extra value.
+// Their values are not returned since only their hashes are stored.
+func (s *SqlTokenStore) GetTokensByExtra(extra, tokenType string) ([]*model.Token, error) {
+	var tokens []*model.Token
+	err := s.selectBuilder(
 		s.db,
 		&tokens,
 		tokenSelect.From(s.getTokenTable()).
-			Where("extra = ?", extraField).
+			Where("extra = ?", extra).
 			Where("type = ?", tokenType),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
-		return nil, errors.Wrap(err, "failed to get token by token value")
+		return nil, errors.Wrap(err, "failed to get tokens by extra value")
 	}
 
 	return tokens, nil
@@ -107,7 +149,7 @@ func (s *SqlTokenStore) GetTokensByEmail(email, tokenType string) ([]*model.Toke
 func (s *SqlTokenStore) DeleteToken(tokenValue string) error {
 	_, err := s.execBuilder(
 		s.db,
-		sq.Delete("").From(s.getTokenTable()).Where("token = ?", tokenValue),
+		sq.Delete("").From(s.getTokenTable()).Where("token_hash = ?", s.hashToken(tokenValue)),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete token")
@@ -119,16 +161,27 @@ func (s *SqlTokenStore) DeleteToken(tokenValue string) error {
 // DeleteTokensByEmail deletes all the tokes, of one type, belonging to the
 // passed email
 func (s *SqlTokenStore) DeleteTokensByEmail(email, tokenType string) error {
-	tokens, err := s.GetTokensByEmail(email, tokenType)
+	extra, err := model.CreateTokenTypeResetPasswordExtra(email)
 	if err != nil {
 		return errors.Wrapf(err, "error deleting tokens for email %s", email)
 	}
-	for _, token := range tokens {
-		err := s.DeleteToken(token.Token)
-		if err != nil {
-			return errors.Wrapf(err, "error deleting token %s for email %s", token.Token, email)
-		}
+
+	return errors.Wrapf(s.DeleteTokensByExtra(extra, tokenType), "error deleting tokens for email %s", email)
+}
+
+// DeleteTokensByExtra deletes all the tokens of one type with the passed
+// extra value.
+func (s *SqlTokenStore) DeleteTokensByExtra(extra, tokenType string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Delete("").From(s.getTokenTable()).
+			Where("extra = ?", extra).
+			Where("type = ?", tokenType),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete tokens by extra value")
 	}
+
 	return nil
 }
 
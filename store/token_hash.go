@@ -0,0 +1,131 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
+)
+
+const (
+	// systemTokenHashKey is the system key holding the key one-time tokens
+	// and session tokens are hashed with.
+	systemTokenHashKey = "TokenHashKey"
+	tokenHashKeySize   = 32
+)
+
+// initializeTokenHashKey loads the key tokens are hashed with, generating it
+// the first time the store is initialized. Concurrent servers initializing a
+// new database agree on the key of the first one storing it. The key is
+// stored encrypted with the keyring, so that reading the database is not
+// enough to hash guessed tokens, and re-encrypted whenever the primary key of
+// the keyring changes.
+func (s *SqlStore) initializeTokenHashKey() error {
+	if s.keyring == nil {
+		return errors.New("an encryption key is required to hash tokens")
+	}
+
+	value, err := s.getSystemValue(s.db, systemTokenHashKey)
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		key := make([]byte, tokenHashKeySize)
+		if _, err = rand.Read(key); err != nil {
+			return errors.Wrap(err, "failed to generate token hash key")
+		}
+		encrypted, err := s.keyring.Encrypt(key)
+		if err != nil {
+			return errors.Wrap(err, "failed to encrypt token hash key")
+		}
+
+		_, err = s.execBuilder(
+			s.db,
+			sq.Insert(s.getSystemTable()).
+				Columns("key", "value").
+				Values(systemTokenHashKey, encrypted).
+				Suffix("ON CONFLICT (key) DO NOTHING"),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to store token hash key")
+		}
+
+		value, err = s.getSystemValue(s.db, systemTokenHashKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	var key []byte
+	if encryption.IsEncrypted(value) {
+		key, err = s.keyring.Decrypt(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to decrypt token hash key, the encryption key it was stored with is missing")
+		}
+	} else {
+		// Keys stored before they were encrypted are base64 encoded.
+		key, err = base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return errors.New("invalid token hash key")
+		}
+	}
+	if len(key) != tokenHashKeySize {
+		return errors.New("invalid token hash key")
+	}
+
+	if s.keyring.NeedsRotation(value) {
+		if err = s.reencryptTokenHashKey(value, key); err != nil {
+			return err
+		}
+	}
+	s.tokenHashKey = key
+
+	return nil
+}
+
+// reencryptTokenHashKey stores the token hash key encrypted with the primary
+// key of the keyring, unless another server did it in the meantime.
+func (s *SqlStore) reencryptTokenHashKey(previousValue string, key []byte) error {
+	encrypted, err := s.keyring.Encrypt(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt token hash key")
+	}
+
+	_, err = s.execBuilder(
+		s.db,
+		sq.Update(s.getSystemTable()).
+			Set("value", encrypted).
+			Where(sq.Eq{"key": systemTokenHashKey, "value": previousValue}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to store token hash key")
+	}
+
+	return nil
+}
+
+// deriveToken returns the value of the token of the given type and extra
+// value, which can only be computed with the token hash key.
+func (s *SqlStore) deriveToken(tokenType, extra string) string {
+	mac := hmac.New(sha256.New, s.tokenHashKey)
+	mac.Write([]byte("derived:" + tokenType + ":" + extra))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashToken returns the keyed hash a one-time token or session token is
+// stored and looked up by, so that the values can't be used by someone able
+// to read the tables.
+func (s *SqlStore) hashToken(token string) string {
+	mac := hmac.New(sha256.New, s.tokenHashKey)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,9 +2,11 @@ package store
 
 import (
 	"math/rand"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/saturninoabril/dashboard-server/internal/encryption"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/testlib"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,15 @@ func TestTokens(t *testing.T) {
 	th := SetupStoreTestHelper(t)
 	defer th.TearDown(t)
 
+	t.Run("hash key is encrypted", func(t *testing.T) {
+		value, err := th.SqlStore.getSystemValue(th.SqlStore.db, systemTokenHashKey)
+		require.NoError(t, err)
+		assert.True(t, encryption.IsEncrypted(value))
+
+		_, err = New(os.Getenv("DASHBOARD_DATABASE_TEST"), th.SqlStore.tablePrefix, nil, testlib.MakeLogger(t))
+		require.Error(t, err, "the store requires a keyring")
+	})
+
 	t.Run("get unknown token", func(t *testing.T) {
 		token, err := th.SqlStore.Token().GetToken("")
 		assert.NoError(t, err)
@@ -35,7 +46,8 @@ func TestTokens(t *testing.T) {
 
 	t.Run("create and get token", func(t *testing.T) {
 		var err error
-		originalToken := model.NewToken(model.TokenTypeVerifyEmail, "extra")
+		extra := model.NewID()
+		originalToken := model.NewToken(model.TokenTypeVerifyEmail, extra)
 		require.NoError(t, originalToken.IsValid())
 
 		token, err := th.SqlStore.Token().CreateToken(originalToken)
@@ -45,6 +57,17 @@ func TestTokens(t *testing.T) {
 		token, err = th.SqlStore.Token().GetToken(token.Token)
 		require.NoError(t, err)
 		require.Equal(t, originalToken, token)
+
+		// Only the hash of the value is stored.
+		var tokenHash string
+		err = th.SqlStore.get(th.SqlStore.db, &tokenHash, "SELECT token_hash FROM "+th.SqlStore.tablePrefix+"token WHERE extra = ?", extra)
+		require.NoError(t, err)
+		assert.Equal(t, th.SqlStore.hashToken(token.Token), tokenHash)
+
+		tokens, err := th.SqlStore.Token().GetTokensByExtra(extra, model.TokenTypeVerifyEmail)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Empty(t, tokens[0].Token)
 	})
 
 	t.Run("delete token", func(t *testing.T) {
@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
@@ -39,6 +40,8 @@ func init() {
 			"first_name",
 			"last_name",
 			"State",
+			"locale",
+			"pending_email",
 		)
 }
 
@@ -65,6 +68,7 @@ func (s *SqlUserStore) CreateUser(user *model.User) (*model.User, error) {
 			"password":       user.Password,
 			"first_name":     user.FirstName,
 			"last_name":      user.LastName,
+			"locale":         user.Locale,
 		}),
 	)
 	if err != nil {
@@ -111,6 +115,35 @@ func (s *SqlUserStore) GetUserByEmail(email string) (*model.User, error) {
 	return &user, nil
 }
 
+// GetUsers fetches a page of users matching the given filter, ordered by
+// email.
+func (s *SqlUserStore) GetUsers(filter *model.UserFilter) ([]*model.User, error) {
+	if err := filter.IsValid(); err != nil {
+		return nil, err
+	}
+
+	query := userSelect.From(s.getUserTable()).
+		OrderBy("email ASC").
+		Limit(uint64(filter.PerPage)).
+		Offset(uint64(filter.Page * filter.PerPage))
+	if filter.Term != "" {
+		term := "%" + escapeLikeTerm(strings.ToLower(filter.Term)) + "%"
+		query = query.Where(sq.Or{
+			sq.Like{"email": term},
+			sq.ILike{"first_name": term},
+			sq.ILike{"last_name": term},
+		})
+	}
+
+	users := []*model.User{}
+	err := s.selectBuilder(s.db, &users, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get users")
+	}
+
+	return users, nil
+}
+
 // VerifyEmail updates a user's email and marks it as verified.
 func (s *SqlUserStore) VerifyEmail(id, email string) error {
 	currentTime := model.GetMillis()
@@ -146,17 +179,62 @@ func (s *SqlUserStore) UnverifyEmail(id, email string) error {
 	return nil
 }
 
-// UpdatePassword accepts a plaintext password value, hashes it, and saves it
-// as the new password for a given user.
-func (s *SqlUserStore) UpdatePassword(id, password string) error {
-	hashedPassword := model.HashPassword(password)
+// SetPendingEmail sets the email a user asked to change theirs to, waiting
+// for its confirmation. An empty email cancels the change.
+func (s *SqlUserStore) SetPendingEmail(id, pendingEmail string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getUserTable()).
+			Where("id = ?", id).
+			Set("update_at", model.GetMillis()).
+			Set("pending_email", pendingEmail),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to set user pending email")
+	}
+
+	return nil
+}
+
+// ConfirmPendingEmail replaces the email of a user with their pending one and
+// marks it as verified. It returns false if the pending email of the user is
+// no longer the given one.
+func (s *SqlUserStore) ConfirmPendingEmail(id, pendingEmail string) (bool, error) {
+	result, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getUserTable()).
+			Where("id = ?", id).
+			Where("pending_email = ?", pendingEmail).
+			Where("pending_email <> ''").
+			Set("update_at", model.GetMillis()).
+			Set("email", pendingEmail).
+			Set("email_verified", true).
+			Set("pending_email", ""),
+	)
+	if err != nil {
+		if isUniqueConstraintError(err, []string{"Email", "email"}) {
+			return false, errors.New("email exists")
+		}
+		return false, errors.Wrap(err, "failed to confirm user pending email")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to confirm user pending email")
+	}
+
+	return rows == 1, nil
+}
+
+// UpdatePassword saves the hash of the new password of a given user.
+func (s *SqlUserStore) UpdatePassword(id, passwordHash string) error {
 	currentTime := model.GetMillis()
 	_, err := s.execBuilder(
 		s.db,
 		sq.Update("").Table(s.getUserTable()).
 			Where("id = ?", id).
 			Set("update_at", currentTime).
-			Set("password", hashedPassword),
+			Set("password", passwordHash),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to set user email as verified")
@@ -165,6 +243,23 @@ func (s *SqlUserStore) UpdatePassword(id, password string) error {
 	return nil
 }
 
+// UpdatePasswordHash replaces the password hash of a user with a new hash of
+// the same password, unless the password changed since the old hash was read.
+func (s *SqlUserStore) UpdatePasswordHash(id, oldHash, newHash string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Update("").Table(s.getUserTable()).
+			Where("id = ?", id).
+			Where("password = ?", oldHash).
+			Set("password", newHash),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update user password hash")
+	}
+
+	return nil
+}
+
 // UpdateUser updates the given user.
 func (s *SqlUserStore) UpdateUser(user *model.User) error {
 	if err := user.IsValid(); err != nil {
@@ -178,6 +273,7 @@ func (s *SqlUserStore) UpdateUser(user *model.User) error {
 				"email":      user.Email,
 				"first_name": user.FirstName,
 				"last_name":  user.LastName,
+				"locale":     user.Locale,
 			}),
 	)
 	if err != nil {
@@ -205,3 +301,49 @@ func (s *SqlUserStore) UpdateUserState(userID string, state string) error {
 
 	return nil
 }
+
+// DeleteUser deletes the given user along with their roles, sessions,
+// personal access tokens, notification preferences, linked accounts and
+// password history. API
+// keys created by the user are revoked.
+func (s *SqlUserStore) DeleteUser(id string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"user_role", "session", "personal_access_token", "notification_preferences", "user_auth_info", "github_membership", "user_mfa", "mfa_recovery_code", "password_history"} {
+		_, err = s.execBuilder(tx, sq.
+			Delete(s.tablePrefix+table).
+			Where("user_id = ?", id),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete %s of user %s", table, id)
+		}
+	}
+
+	_, err = s.execBuilder(tx, sq.
+		Update(s.tablePrefix+"api_key").
+		Set("delete_at", model.GetMillis()).
+		Where("creator_id = ?", id).
+		Where("delete_at = 0"),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke API keys of user %s", id)
+	}
+
+	_, err = s.execBuilder(tx, sq.
+		Delete(s.getUserTable()).
+		Where("id = ?", id),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete user %s", id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
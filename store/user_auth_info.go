@@ -2,9 +2,11 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
 
 	"github.com/saturninoabril/dashboard-server/model"
 )
@@ -27,11 +29,19 @@ func (s *SqlStore) UserAuthInfo() UserAuthInfoStore {
 
 var userAuthInfoSelect sq.SelectBuilder
 
+// userAuthInfoRow is a row of the user_auth_info table, holding the encrypted
+// token.
+type userAuthInfoRow struct {
+	model.UserAuthInfo
+	EncryptedToken string `db:"token"`
+}
+
 func init() {
 	userAuthInfoSelect = sq.
 		Select(
 			"user_id",
 			"oauth_provider",
+			"provider_user_id",
 			"token",
 			"username",
 			"email",
@@ -47,76 +57,179 @@ func (s *SqlUserAuthInfoStore) getUserAuthInfoTable() string {
 	return s.tablePrefix + "user_auth_info"
 }
 
-// CreateUserAuthInfo inserts a new user.
+// encryptToken encrypts the OAuth token to be stored at rest.
+func (s *SqlUserAuthInfoStore) encryptToken(token oauth2.Token) (string, error) {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal oauth token")
+	}
+
+	encrypted, err := s.encryptSecret(b)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encrypt oauth token")
+	}
+
+	return encrypted, nil
+}
+
+// decryptToken decrypts an OAuth token stored at rest.
+func (s *SqlUserAuthInfoStore) decryptToken(encrypted string) (oauth2.Token, error) {
+	var token oauth2.Token
+
+	b, err := s.decryptSecret(encrypted)
+	if err != nil {
+		return token, errors.Wrap(err, "failed to decrypt oauth token")
+	}
+
+	if err = json.Unmarshal(b, &token); err != nil {
+		return token, errors.Wrap(err, "failed to unmarshal oauth token")
+	}
+
+	return token, nil
+}
+
+// CreateUserAuthInfo links a user to their account on an OAuth provider.
 func (s *SqlUserAuthInfoStore) CreateUserAuthInfo(userAuthInfo *model.UserAuthInfo) (*model.UserAuthInfo, error) {
 	userAuthInfo.PreSave()
 
+	token, err := s.encryptToken(userAuthInfo.Token)
+	if err != nil {
+		return nil, err
+	}
+
 	userAuthInfoTable := s.getUserAuthInfoTable()
-	_, err := s.execBuilder(
+	_, err = s.execBuilder(
 		s.db,
 		sq.Insert(userAuthInfoTable).
 			SetMap(map[string]interface{}{
-				"user_id":        userAuthInfo.UserID,
-				"oauth_provider": userAuthInfo.OAuthProvider,
-				"token":          userAuthInfo.Token,
-				"username":       userAuthInfo.Username,
-				"email":          userAuthInfo.Email,
-				"name":           userAuthInfo.Name,
-				"avatar_url":     userAuthInfo.AvatarURL,
-				"create_at":      userAuthInfo.CreateAt,
-				"update_at":      userAuthInfo.UpdateAt,
-				"delete_at":      userAuthInfo.DeleteAt,
+				"user_id":          userAuthInfo.UserID,
+				"oauth_provider":   userAuthInfo.OAuthProvider,
+				"provider_user_id": userAuthInfo.ProviderUserID,
+				"token":            token,
+				"username":         userAuthInfo.Username,
+				"email":            userAuthInfo.Email,
+				"name":             userAuthInfo.Name,
+				"avatar_url":       userAuthInfo.AvatarURL,
+				"create_at":        userAuthInfo.CreateAt,
+				"update_at":        userAuthInfo.UpdateAt,
+				"delete_at":        userAuthInfo.DeleteAt,
 			}),
 	)
 	if err != nil {
-		if isUniqueConstraintError(
-			err,
-			[]string{
-				"UserID",
-				userAuthInfoTable + "_user_id_key",
-				"OAuthProvider",
-				userAuthInfoTable + "_oauth_provider_key",
-			}) {
+		if isUniqueConstraintError(err, []string{userAuthInfoTable + "_pkey"}) {
 			return nil, errors.New("user_id with given oauth_provider exists")
 		}
-		return nil, errors.Wrap(err, "failed to create user")
+		if isUniqueConstraintError(err, []string{userAuthInfoTable + "_oauth_provider_provider_user_id_key"}) {
+			return nil, errors.New("account is linked to another user")
+		}
+		return nil, errors.Wrap(err, "failed to create user auth info")
 	}
 
 	return userAuthInfo, nil
 }
 
-// GetUser fetches the given user by id.
+// GetUserAuthInfo fetches the account of the given user on an OAuth provider.
 func (s *SqlUserAuthInfoStore) GetUserAuthInfo(userID, oauthProvider string) (*model.UserAuthInfo, error) {
-	var userAuthInfo model.UserAuthInfo
+	return s.getUserAuthInfo(sq.Eq{"user_id": userID, "oauth_provider": oauthProvider})
+}
+
+// GetUserAuthInfoByProviderUserID fetches the link of the given account on an
+// OAuth provider.
+func (s *SqlUserAuthInfoStore) GetUserAuthInfoByProviderUserID(oauthProvider, providerUserID string) (*model.UserAuthInfo, error) {
+	return s.getUserAuthInfo(sq.Eq{"oauth_provider": oauthProvider, "provider_user_id": providerUserID})
+}
+
+// GetUserAuthInfosByProvider fetches every account linked on the given OAuth
+// provider.
+func (s *SqlUserAuthInfoStore) GetUserAuthInfosByProvider(oauthProvider string) ([]*model.UserAuthInfo, error) {
+	var rows []userAuthInfoRow
+	err := s.selectBuilder(
+		s.db,
+		&rows,
+		userAuthInfoSelect.
+			From(s.getUserAuthInfoTable()).
+			Where(sq.Eq{"oauth_provider": oauthProvider}).
+			OrderBy("user_id ASC"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user auth infos")
+	}
+
+	userAuthInfos := make([]*model.UserAuthInfo, 0, len(rows))
+	for _, row := range rows {
+		userAuthInfo := row.UserAuthInfo
+		userAuthInfo.Token, err = s.decryptToken(row.EncryptedToken)
+		if err != nil {
+			return nil, err
+		}
+		userAuthInfos = append(userAuthInfos, &userAuthInfo)
+	}
+
+	return userAuthInfos, nil
+}
+
+func (s *SqlUserAuthInfoStore) getUserAuthInfo(where sq.Eq) (*model.UserAuthInfo, error) {
+	var row userAuthInfoRow
 	err := s.getBuilder(
 		s.db,
-		&userAuthInfo,
+		&row,
 		userAuthInfoSelect.
 			From(s.getUserAuthInfoTable()).
-			Where("user_id = $1 AND oauth_provider = $2", userID, oauthProvider),
+			Where(where),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	} else if err != nil {
-		return nil, errors.Wrap(err, "failed to get user by id")
+		return nil, errors.Wrap(err, "failed to get user auth info")
+	}
+
+	userAuthInfo := row.UserAuthInfo
+	userAuthInfo.Token, err = s.decryptToken(row.EncryptedToken)
+	if err != nil {
+		return nil, err
 	}
 
 	return &userAuthInfo, nil
 }
 
-// UpdateUserAuthInfoToken updates the given user.
-func (s *SqlUserAuthInfoStore) UpdateUserAuthInfoToken(userAuthInfo *model.UserAuthInfo) error {
-	_, err := s.execBuilder(
+// UpdateUserAuthInfo updates the token and profile of a linked account.
+func (s *SqlUserAuthInfoStore) UpdateUserAuthInfo(userAuthInfo *model.UserAuthInfo) error {
+	token, err := s.encryptToken(userAuthInfo.Token)
+	if err != nil {
+		return err
+	}
+
+	userAuthInfo.UpdateAt = model.GetMillis()
+	_, err = s.execBuilder(
 		s.db,
 		sq.Update(s.getUserAuthInfoTable()).
-			Where("user_id = $1 AND oauth_provider = $2", userAuthInfo.UserID, userAuthInfo.OAuthProvider).
+			Where(sq.Eq{"user_id": userAuthInfo.UserID, "oauth_provider": userAuthInfo.OAuthProvider}).
 			SetMap(map[string]interface{}{
-				"token":     userAuthInfo.Token,
-				"update_at": model.GetMillis(),
+				"token":      token,
+				"username":   userAuthInfo.Username,
+				"email":      userAuthInfo.Email,
+				"name":       userAuthInfo.Name,
+				"avatar_url": userAuthInfo.AvatarURL,
+				"update_at":  userAuthInfo.UpdateAt,
 			}),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to update user token")
+		return errors.Wrap(err, "failed to update user auth info")
+	}
+
+	return nil
+}
+
+// DeleteUserAuthInfo unlinks the account of the given user on an OAuth
+// provider.
+func (s *SqlUserAuthInfoStore) DeleteUserAuthInfo(userID, oauthProvider string) error {
+	_, err := s.execBuilder(
+		s.db,
+		sq.Delete(s.getUserAuthInfoTable()).
+			Where(sq.Eq{"user_id": userID, "oauth_provider": oauthProvider}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete user auth info")
 	}
 
 	return nil
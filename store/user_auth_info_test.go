@@ -0,0 +1,73 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/saturninoabril/dashboard-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestUserAuthInfo(t *testing.T) {
+	th := SetupStoreTestHelper(t)
+	defer th.TearDown(t)
+
+	t.Run("get unknown user auth info", func(t *testing.T) {
+		userAuthInfo, err := th.SqlStore.UserAuthInfo().GetUserAuthInfo(model.NewID(), model.OAuthProviderGithub)
+		require.NoError(t, err)
+		assert.Nil(t, userAuthInfo)
+
+		userAuthInfo, err = th.SqlStore.UserAuthInfo().GetUserAuthInfoByProviderUserID(model.OAuthProviderGithub, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, userAuthInfo)
+	})
+
+	t.Run("create, update and delete user auth info", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+		providerUserID := model.NewID()
+
+		userAuthInfo, err := th.SqlStore.UserAuthInfo().CreateUserAuthInfo(&model.UserAuthInfo{
+			UserID:         user.ID,
+			OAuthProvider:  model.OAuthProviderGithub,
+			ProviderUserID: providerUserID,
+			Token:          oauth2.Token{AccessToken: "access", TokenType: "bearer"},
+			Username:       "octocat",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, userAuthInfo)
+
+		_, err = th.SqlStore.UserAuthInfo().CreateUserAuthInfo(&model.UserAuthInfo{
+			UserID:         createTestUser(t, th.SqlStore).ID,
+			OAuthProvider:  model.OAuthProviderGithub,
+			ProviderUserID: providerUserID,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "linked to another user")
+
+		fetched, err := th.SqlStore.UserAuthInfo().GetUserAuthInfoByProviderUserID(model.OAuthProviderGithub, providerUserID)
+		require.NoError(t, err)
+		require.NotNil(t, fetched)
+		assert.Equal(t, user.ID, fetched.UserID)
+		assert.Equal(t, "access", fetched.Token.AccessToken)
+		assert.Equal(t, "octocat", fetched.Username)
+
+		fetched.Token.AccessToken = "refreshed"
+		fetched.Username = "octodog"
+		err = th.SqlStore.UserAuthInfo().UpdateUserAuthInfo(fetched)
+		require.NoError(t, err)
+
+		fetched, err = th.SqlStore.UserAuthInfo().GetUserAuthInfo(user.ID, model.OAuthProviderGithub)
+		require.NoError(t, err)
+		require.NotNil(t, fetched)
+		assert.Equal(t, "refreshed", fetched.Token.AccessToken)
+		assert.Equal(t, "octodog", fetched.Username)
+
+		err = th.SqlStore.UserAuthInfo().DeleteUserAuthInfo(user.ID, model.OAuthProviderGithub)
+		require.NoError(t, err)
+
+		fetched, err = th.SqlStore.UserAuthInfo().GetUserAuthInfo(user.ID, model.OAuthProviderGithub)
+		require.NoError(t, err)
+		assert.Nil(t, fetched)
+	})
+}
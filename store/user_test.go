@@ -1,8 +1,10 @@
 package store
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/saturninoabril/dashboard-server/internal/i18n"
 	"github.com/saturninoabril/dashboard-server/model"
 	"github.com/saturninoabril/dashboard-server/testlib"
 	"github.com/stretchr/testify/assert"
@@ -51,6 +53,7 @@ func TestUsers(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, user)
 		assert.Equal(t, email, user.Email)
+		assert.Equal(t, i18n.DefaultLocale, user.Locale)
 
 		user, err = th.SqlStore.User().GetUserByEmail(user.Email)
 		require.NoError(t, err)
@@ -113,29 +116,84 @@ func TestUsers(t *testing.T) {
 		require.NotEqual(t, user.CreateAt, user.UpdateAt)
 	})
 
+	t.Run("pending email", func(t *testing.T) {
+		user, err := th.SqlStore.User().CreateUser(&model.User{
+			Email:    testlib.GetTestEmail(),
+			Password: testPassword,
+		})
+		require.NoError(t, err)
+		previousEmail := user.Email
+		pendingEmail := testlib.GetTestEmail()
+
+		confirmed, err := th.SqlStore.User().ConfirmPendingEmail(user.ID, "")
+		require.NoError(t, err)
+		assert.False(t, confirmed, "there is no pending email")
+
+		err = th.SqlStore.User().SetPendingEmail(user.ID, pendingEmail)
+		require.NoError(t, err)
+
+		user, err = th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, previousEmail, user.Email)
+		assert.Equal(t, pendingEmail, user.PendingEmail)
+
+		confirmed, err = th.SqlStore.User().ConfirmPendingEmail(user.ID, testlib.GetTestEmail())
+		require.NoError(t, err)
+		assert.False(t, confirmed, "another email is pending")
+
+		confirmed, err = th.SqlStore.User().ConfirmPendingEmail(user.ID, pendingEmail)
+		require.NoError(t, err)
+		assert.True(t, confirmed)
+
+		user, err = th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, pendingEmail, user.Email)
+		assert.Empty(t, user.PendingEmail)
+		assert.True(t, user.EmailVerified)
+	})
+
 	t.Run("update password", func(t *testing.T) {
 		var err error
-		password := "Password1"
 		user := &model.User{
 			Email:    testlib.GetTestEmail(),
-			Password: password,
+			Password: "hash1",
 		}
 
 		user, err = th.SqlStore.User().CreateUser(user)
 		require.NoError(t, err)
 		require.NotNil(t, user)
 		require.Equal(t, user.CreateAt, user.UpdateAt)
-		require.True(t, model.ComparePassword(user.Password, password))
 
-		newPassword := "Password2"
-		err = th.SqlStore.User().UpdatePassword(user.ID, newPassword)
+		err = th.SqlStore.User().UpdatePassword(user.ID, "hash2")
 		require.NoError(t, err)
 
 		user, err = th.SqlStore.User().GetUser(user.ID)
 		require.NoError(t, err)
 		require.NotNil(t, user)
 		require.NotEqual(t, user.CreateAt, user.UpdateAt)
-		require.True(t, model.ComparePassword(user.Password, newPassword))
+		require.Equal(t, "hash2", user.Password)
+	})
+
+	t.Run("update password hash", func(t *testing.T) {
+		user, err := th.SqlStore.User().CreateUser(&model.User{
+			Email:    testlib.GetTestEmail(),
+			Password: "hash1",
+		})
+		require.NoError(t, err)
+		oldHash := user.Password
+
+		// A hash read before the password changed doesn't replace it.
+		err = th.SqlStore.User().UpdatePasswordHash(user.ID, "stale", "hash2")
+		require.NoError(t, err)
+		user, err = th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		require.Equal(t, oldHash, user.Password)
+
+		err = th.SqlStore.User().UpdatePasswordHash(user.ID, oldHash, "hash3")
+		require.NoError(t, err)
+		user, err = th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		require.Equal(t, "hash3", user.Password)
 	})
 
 	t.Run("update user", func(t *testing.T) {
@@ -156,6 +214,7 @@ func TestUsers(t *testing.T) {
 		user.Email = newEmail
 		user.FirstName = newFirstname
 		user.LastName = newLastname
+		user.Locale = "es"
 
 		err = th.SqlStore.User().UpdateUser(user)
 		require.NoError(t, err)
@@ -166,6 +225,7 @@ func TestUsers(t *testing.T) {
 		assert.Equal(t, newEmail, user.Email)
 		assert.Equal(t, newFirstname, user.FirstName)
 		assert.Equal(t, newLastname, user.LastName)
+		assert.Equal(t, "es", user.Locale)
 
 		var newCreateAt int64 = 7
 		user.CreateAt = newCreateAt
@@ -220,4 +280,62 @@ func TestUsers(t *testing.T) {
 		require.NotNil(t, user2)
 		assert.Equal(t, user2.State, model.UserStateLocked)
 	})
+
+	t.Run("get users", func(t *testing.T) {
+		_, err := th.SqlStore.User().GetUsers(&model.UserFilter{PerPage: 0})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid per page")
+
+		term := model.NewID()[:10]
+		user1, err := th.SqlStore.User().CreateUser(&model.User{Email: strings.ToLower(term) + "@example.com", Password: testPassword})
+		require.NoError(t, err)
+		user2, err := th.SqlStore.User().CreateUser(&model.User{Email: testlib.GetTestEmail(), Password: testPassword, FirstName: "First" + strings.ToUpper(term)})
+		require.NoError(t, err)
+
+		users, err := th.SqlStore.User().GetUsers(&model.UserFilter{Term: term, PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+
+		users, err = th.SqlStore.User().GetUsers(&model.UserFilter{Term: term, PerPage: 1})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		firstID := users[0].ID
+
+		users, err = th.SqlStore.User().GetUsers(&model.UserFilter{Term: term, Page: 1, PerPage: 1})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.NotEqual(t, firstID, users[0].ID)
+		assert.ElementsMatch(t, []string{user1.ID, user2.ID}, []string{firstID, users[0].ID})
+
+		users, err = th.SqlStore.User().GetUsers(&model.UserFilter{Term: "%", PerPage: 10})
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		user := createTestUser(t, th.SqlStore)
+
+		role, err := th.SqlStore.Role().GetRoleByName(model.UserRoleName)
+		require.NoError(t, err)
+		err = th.SqlStore.Role().AddUserRole(user.ID, role.ID)
+		require.NoError(t, err)
+
+		session, err := th.SqlStore.Session().CreateSession(&model.Session{UserID: user.ID})
+		require.NoError(t, err)
+
+		err = th.SqlStore.User().DeleteUser(user.ID)
+		require.NoError(t, err)
+
+		deleted, err := th.SqlStore.User().GetUser(user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, deleted)
+
+		hasRole, err := th.SqlStore.Role().UserHasRole(user.ID, role.ID)
+		require.NoError(t, err)
+		assert.False(t, hasRole)
+
+		session, err = th.SqlStore.Session().GetSession(session.ID)
+		require.NoError(t, err)
+		assert.Nil(t, session)
+	})
 }
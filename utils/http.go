@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/saturninoabril/dashboard-server/model"
 )
 
@@ -20,12 +24,17 @@ func IsSecure(r *http.Request) bool {
 	return GetProtocol(r) == "https"
 }
 
-// AttachSessionCookies will attach the required cookies to a session.
+// AttachSessionCookies will attach the required cookies to a session. The
+// cookies expire with the session, so they are attached again whenever the
+// session is extended.
 func AttachSessionCookies(w http.ResponseWriter, r *http.Request, session *model.Session) {
 	secure := IsSecure(r)
 
-	maxAge := model.SessionLengthMilliseconds / 1000
-	expiresAt := time.Unix(model.GetMillis()/1000+int64(maxAge), 0)
+	maxAge := int((session.ExpiresAt - model.GetMillis()) / 1000)
+	if maxAge < 1 {
+		maxAge = 1
+	}
+	expiresAt := time.Unix(session.ExpiresAt/1000, 0)
 	sessionCookie := &http.Cookie{
 		Name:     model.SessionCookieToken,
 		Path:     "/",
@@ -97,3 +106,79 @@ func DeleteSessionCookies(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, csrfCookie)
 	http.SetCookie(w, userCookie)
 }
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy %s", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %s", proxy)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// isTrustedProxy returns true if the given IP address belongs to one of the
+// trusted proxies.
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetIPAddress returns the IP address of the client of an HTTP request. The
+// X-Forwarded-For and X-Real-IP headers are only honoured when the request
+// comes from one of the given trusted proxies, in which case the client is the
+// last address of X-Forwarded-For that isn't a trusted proxy itself.
+func GetIPAddress(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteAddr = r.RemoteAddr
+	}
+	if !isTrustedProxy(remoteAddr, trustedProxies) {
+		return remoteAddr
+	}
+
+	if forwardedFor := r.Header.Get(model.HeaderForwardedFor); forwardedFor != "" {
+		addresses := strings.Split(forwardedFor, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			address := strings.TrimSpace(addresses[i])
+			if address == "" {
+				continue
+			}
+			if i == 0 || !isTrustedProxy(address, trustedProxies) {
+				return address
+			}
+		}
+	}
+	if realIP := r.Header.Get(model.HeaderRealIP); realIP != "" {
+		return strings.TrimSpace(realIP)
+	}
+
+	return remoteAddr
+}
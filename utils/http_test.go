@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/saturninoabril/dashboard-server/model"
+)
+
+func TestGetIPAddress(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	_, err = ParseTrustedProxies([]string{"junk"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	testCases := []struct {
+		description  string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		expected     string
+	}{
+		{"no proxy headers", "203.0.113.1:1234", "", "", "203.0.113.1"},
+		{"headers from untrusted clients are ignored", "203.0.113.1:1234", "198.51.100.1", "198.51.100.2", "203.0.113.1"},
+		{"forwarded for by a trusted proxy", "10.1.2.3:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed addresses before the client are ignored", "10.1.2.3:1234", "1.2.3.4, 198.51.100.1, 192.168.1.1", "", "198.51.100.1"},
+		{"only trusted proxies", "10.1.2.3:1234", "10.0.0.1, 10.0.0.2", "", "10.0.0.1"},
+		{"real IP from a trusted proxy", "192.168.1.1:1234", "", "198.51.100.2", "198.51.100.2"},
+		{"trusted proxy without headers", "192.168.1.1:1234", "", "", "192.168.1.1"},
+	}
+
+	t.Run("no trusted proxies", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.1.2.3:1234"
+		r.Header.Set(model.HeaderForwardedFor, "198.51.100.1")
+		assert.Equal(t, "10.1.2.3", GetIPAddress(r, nil))
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				r.Header.Set(model.HeaderForwardedFor, tc.forwardedFor)
+			}
+			if tc.realIP != "" {
+				r.Header.Set(model.HeaderRealIP, tc.realIP)
+			}
+			assert.Equal(t, tc.expected, GetIPAddress(r, proxies))
+		})
+	}
+}
@@ -23,6 +23,17 @@ func GetTemplateDirectory() (string, bool) {
 	return FindDir(templatesDir)
 }
 
+// GetTranslationsDirectory returns the directory containing the translation
+// files.
+func GetTranslationsDirectory() (string, bool) {
+	translationsDir := "internal/i18n/translations"
+	if serverPath := os.Getenv("SERVER_PATH"); serverPath != "" {
+		translationsDir = filepath.Join(serverPath, translationsDir)
+	}
+
+	return FindDir(translationsDir)
+}
+
 // FindDir looks for the given directory in nearby ancestors relative to the current working
 // directory as well as the directory of the executable, falling back to `./` if not found.
 func FindDir(dir string) (string, bool) {